@@ -0,0 +1,275 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/config"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/routes"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/blocklist"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/geoip"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/health"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/redact"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/security"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/slo"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/traefik"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/useragent"
+)
+
+// buildFeatures builds the optional parts of the agent that the
+// configuration enables
+func buildFeatures(cfg *config.Config) (routes.Features, error) {
+	var f routes.Features
+	var err error
+
+	if f.Redaction, err = loadRedaction(cfg.Redaction, cfg.RedactionFile, cfg.RedactionKey); err != nil {
+		return f, fmt.Errorf("invalid redaction configuration: %w", err)
+	}
+
+	if cfg.GeoIPEnabled {
+		if f.GeoIP, err = openGeoIP(cfg.GeoIPCityDB, cfg.GeoIPASNDB, cfg.GeoIPCacheSize); err != nil {
+			return f, fmt.Errorf("invalid GeoIP configuration: %w", err)
+		}
+	}
+
+	if cfg.UserAgentEnabled {
+		if f.UserAgents, err = loadUserAgentRules(cfg.UserAgentRulesFile, cfg.UserAgentCacheSize); err != nil {
+			return f, fmt.Errorf("invalid user agent rules: %w", err)
+		}
+	}
+
+	if cfg.SecurityEnabled {
+		if f.Security, err = loadSecurity(cfg.Security, cfg.SecurityFile); err != nil {
+			return f, fmt.Errorf("invalid security configuration: %w", err)
+		}
+	}
+
+	if cfg.HealthEnabled {
+		if f.Health, err = loadHealth(cfg); err != nil {
+			return f, fmt.Errorf("invalid backend health configuration: %w", err)
+		}
+	}
+
+	if f.SLO, err = loadSLO(cfg.SLO, cfg.SLOFile); err != nil {
+		return f, fmt.Errorf("invalid SLO configuration: %w", err)
+	}
+
+	if cfg.BlocklistEnabled {
+		if f.Blocklist, err = loadBlocklist(cfg); err != nil {
+			return f, fmt.Errorf("invalid blocklist configuration: %w", err)
+		}
+	}
+
+	if cfg.TraefikAPIURL != "" {
+		f.Traefik, err = traefik.New(traefik.Options{
+			URL:      cfg.TraefikAPIURL,
+			Username: cfg.TraefikAPIUsername,
+			Password: cfg.TraefikAPIPassword,
+			Interval: cfg.TraefikAPIPollInterval,
+			Timeout:  cfg.TraefikAPITimeout,
+		})
+		if err != nil {
+			return f, fmt.Errorf("invalid Traefik API configuration: %w", err)
+		}
+	}
+
+	return f, nil
+}
+
+// readInline returns inline JSON, or the content of file when there is none
+func readInline(inline, file string) ([]byte, error) {
+	if inline == "" && file != "" {
+		return os.ReadFile(file)
+	}
+	return []byte(inline), nil
+}
+
+// loadRedaction compiles redaction profiles from a JSON string or a JSON
+// file. key, when set, replaces the hash key of the config. It returns nil
+// when neither is set.
+func loadRedaction(inline, file, key string) (*redact.Policy, error) {
+	data, err := readInline(inline, file)
+	if err != nil || len(data) == 0 {
+		return nil, err
+	}
+
+	cfg, err := redact.ParseConfig(data)
+	if err != nil {
+		return nil, err
+	}
+	if key != "" {
+		cfg.HashKey = key
+	}
+	return redact.NewPolicy(cfg)
+}
+
+// loadSecurity builds the detector from inline JSON or a JSON file, using
+// the default thresholds when neither is set
+func loadSecurity(inline, file string) (*security.Detector, error) {
+	data, err := readInline(inline, file)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := security.DefaultConfig()
+	if len(data) > 0 {
+		if cfg, err = security.ParseConfig(data); err != nil {
+			return nil, err
+		}
+	}
+	return security.New(cfg)
+}
+
+// loadHealth builds the backend health tracker, using the defaults for
+// anything left unset
+func loadHealth(c *config.Config) (*health.Tracker, error) {
+	cfg := health.DefaultConfig()
+	if c.HealthWindows != "" {
+		cfg.Windows = nil
+		for _, value := range strings.Split(c.HealthWindows, ",") {
+			if value = strings.TrimSpace(value); value == "" {
+				continue
+			}
+			w, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, err
+			}
+			cfg.Windows = append(cfg.Windows, w)
+		}
+		if len(cfg.Windows) > 0 {
+			// Default to the middle window
+			cfg.StatusWindow = cfg.Windows[len(cfg.Windows)/2]
+		}
+	}
+	if c.HealthStatusWindow != "" {
+		w, err := time.ParseDuration(c.HealthStatusWindow)
+		if err != nil {
+			return nil, err
+		}
+		cfg.StatusWindow = w
+	}
+	if c.HealthMinRequests > 0 {
+		cfg.MinRequests = c.HealthMinRequests
+	}
+	if c.HealthDownErrorPercent > 0 {
+		cfg.DownErrorRate = float64(c.HealthDownErrorPercent) / 100
+	}
+	if c.HealthDegradedErrorPercent > 0 {
+		cfg.DegradedErrorRate = float64(c.HealthDegradedErrorPercent) / 100
+	}
+	if c.HealthDegradedP95MS > 0 {
+		cfg.DegradedP95 = time.Duration(c.HealthDegradedP95MS) * time.Millisecond
+	}
+	return health.New(cfg)
+}
+
+// loadSLO builds the SLO tracker from inline JSON or a JSON file. It returns
+// nil when neither is set.
+func loadSLO(inline, file string) (*slo.Tracker, error) {
+	data, err := readInline(inline, file)
+	if err != nil || len(data) == 0 {
+		return nil, err
+	}
+
+	cfg, err := slo.ParseConfig(data)
+	if err != nil {
+		return nil, err
+	}
+	return slo.New(cfg)
+}
+
+// loadBlocklist checks the detections that add entries and opens the
+// blocklist
+func loadBlocklist(cfg *config.Config) (*blocklist.Manager, error) {
+	for _, t := range cfg.BlocklistAutoTypes {
+		if !security.ValidType(t) {
+			return nil, fmt.Errorf("unknown event type %q", t)
+		}
+	}
+	if !security.ValidSeverity(cfg.BlocklistAutoSeverity) {
+		return nil, fmt.Errorf("unknown severity %q", cfg.BlocklistAutoSeverity)
+	}
+
+	format := cfg.BlocklistFormat
+	if format == "" {
+		format = formatFromPath(cfg.BlocklistPath)
+	}
+	mode := cfg.BlocklistMode
+	if mode == "" {
+		mode = blocklist.ModeAllowList
+	}
+	return blocklist.New(blocklist.Options{
+		Render: blocklist.RenderOptions{
+			Format:          format,
+			Mode:            mode,
+			Middleware:      cfg.BlocklistMiddleware,
+			TraefikVersion:  cfg.BlocklistTraefikVersion,
+			IPStrategyDepth: cfg.BlocklistIPStrategyDepth,
+			Plugin:          cfg.BlocklistPlugin,
+			PluginField:     cfg.BlocklistPluginField,
+		},
+		OutputPath: cfg.BlocklistPath,
+		StatePath:  cfg.BlocklistStateFile,
+		DryRun:     cfg.BlocklistDryRun,
+	})
+}
+
+// MaxMind's file names, looked for in the working directory when no
+// database is configured
+var (
+	defaultLocationFiles = []string{"GeoLite2-City.mmdb", "GeoLite2-Country.mmdb"}
+	defaultASNFiles      = []string{"GeoLite2-ASN.mmdb"}
+)
+
+// openGeoIP opens the configured GeoIP databases, falling back to MaxMind's
+// default file names in the working directory. It returns nil when no
+// database is found.
+func openGeoIP(locationPath, asnPath string, cacheSize int) (*geoip.DB, error) {
+	if locationPath == "" {
+		locationPath = firstExisting(defaultLocationFiles)
+	}
+	if asnPath == "" {
+		asnPath = firstExisting(defaultASNFiles)
+	}
+	if locationPath == "" && asnPath == "" {
+		return nil, nil
+	}
+	return geoip.Open(geoip.Options{
+		LocationPath: locationPath,
+		ASNPath:      asnPath,
+		CacheSize:    cacheSize,
+	})
+}
+
+// loadUserAgentRules compiles the rules file, or the embedded rules when no
+// file is given
+func loadUserAgentRules(path string, cacheSize int) (*useragent.Classifier, error) {
+	rules := useragent.DefaultRules()
+	if path != "" {
+		var err error
+		if rules, err = useragent.LoadRules(path); err != nil {
+			return nil, err
+		}
+	}
+	return useragent.New(rules, cacheSize)
+}
+
+// formatFromPath picks the rendered format from the output file extension
+func formatFromPath(path string) string {
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		return blocklist.FormatTOML
+	}
+	return blocklist.FormatYAML
+}
+
+func firstExisting(paths []string) string {
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
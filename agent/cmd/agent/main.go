@@ -1,10 +1,12 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
 	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/auth"
@@ -13,6 +15,7 @@ import (
 	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/routes"
 	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/state"
 	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logger"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
 )
 
 func main() {
@@ -22,22 +25,52 @@ func main() {
 	logger.Log.Printf("Starting Traefik Log Dashboard Agent...")
 	logger.Log.Printf("Access Log Path: %s", cfg.AccessPath)
 	logger.Log.Printf("Error Log Path: %s", cfg.ErrorPath)
+	for _, src := range cfg.LogSources() {
+		logger.Log.Printf("Log Source: %s (%s) %s", src.Name, src.Type, src.Path)
+	}
+	logger.Log.Printf("File Watch Mode: %s", cfg.WatchMode)
 	logger.Log.Printf("System Monitoring: %v", cfg.SystemMonitoring)
 	logger.Log.Printf("Port: %s", cfg.Port)
 
 	// Initialize authentication
-	authenticator := auth.NewAuthenticator(cfg.AuthToken)
+	authenticator := auth.NewAuthenticator("")
+	authenticator.AddToken(cfg.AuthToken, cfg.AuthTokenScope)
+	for token, scope := range cfg.AuthTokens {
+		authenticator.AddToken(token, scope)
+	}
 	if authenticator.IsEnabled() {
 		logger.Log.Printf("Authentication: Enabled")
 	} else {
 		logger.Log.Printf("Authentication: Disabled (no token configured)")
 	}
 
+	// Build the optional features the configuration enables
+	features, err := buildFeatures(cfg)
+	if err != nil {
+		logger.Log.Fatalf("%v", err)
+	}
+	if features.Redaction != nil {
+		logger.Log.Printf("Redaction: Enabled")
+	}
+	if features.GeoIP != nil {
+		logger.Log.Printf("GeoIP Enrichment: Enabled")
+		defer features.GeoIP.Close()
+	}
+	if features.UserAgents != nil {
+		logger.Log.Printf("User Agent Classification: Enabled (rules %s)", features.UserAgents.Version())
+	}
+
+	// Configure checkpoint indexes for compressed archives
+	logs.ConfigureCompressedIndex(cfg.CompressedIndexDir, int64(cfg.CompressedCheckpointBytes))
+	if cfg.CompressedIndexDir != "" {
+		logger.Log.Printf("Compressed Index Directory: %s", cfg.CompressedIndexDir)
+	}
+
 	// Initialize state manager
 	stateManager := state.NewStateManager(cfg)
 
 	// Initialize route handler
-	handler := routes.NewHandler(cfg, stateManager)
+	handler := routes.NewHandler(cfg, stateManager, features)
 
 	// Create middleware chain
 	chain := middleware.Chain(
@@ -46,6 +79,9 @@ func main() {
 		middleware.CORS(middleware.DefaultCORSConfig()),
 	)
 
+	// Bulk log endpoints also negotiate gzip/zstd compression
+	logChain := middleware.Chain(chain, middleware.Compress())
+
 	// Set up HTTP routes with middleware
 	mux := http.NewServeMux()
 
@@ -53,9 +89,20 @@ func main() {
 	mux.HandleFunc("/api/logs/status", middleware.Apply(chain, handler.HandleStatus))
 
 	// Log endpoints (with auth)
-	mux.HandleFunc("/api/logs/access", middleware.Apply(chain, authenticator.Middleware(handler.HandleAccessLogs)))
-	mux.HandleFunc("/api/logs/error", middleware.Apply(chain, authenticator.Middleware(handler.HandleErrorLogs)))
-	mux.HandleFunc("/api/logs/get", middleware.Apply(chain, authenticator.Middleware(handler.HandleGetLog)))
+	mux.HandleFunc("/api/logs/access", middleware.Apply(logChain, authenticator.Middleware(handler.HandleAccessLogs)))
+	mux.HandleFunc("/api/logs/error", middleware.Apply(logChain, authenticator.Middleware(handler.HandleErrorLogs)))
+	mux.HandleFunc("/api/logs/get", middleware.Apply(logChain, authenticator.Middleware(handler.HandleGetLog)))
+	mux.HandleFunc("/api/logs/files", middleware.Apply(logChain, authenticator.Middleware(handler.HandleListFiles)))
+	mux.HandleFunc("/api/logs/files/download", middleware.Apply(logChain, authenticator.Middleware(handler.HandleDownloadFile)))
+	mux.HandleFunc("/api/logs/export", middleware.Apply(logChain, authenticator.Middleware(handler.HandleExport)))
+	mux.HandleFunc("/api/stats", middleware.Apply(logChain, authenticator.Middleware(handler.HandleStats)))
+	mux.HandleFunc("/api/latency", middleware.Apply(logChain, authenticator.Middleware(handler.HandleLatency)))
+	mux.HandleFunc("/api/security/events", middleware.Apply(logChain, authenticator.Middleware(handler.HandleSecurityEvents)))
+	mux.HandleFunc("/api/backends", middleware.Apply(logChain, authenticator.Middleware(handler.HandleBackends)))
+	mux.HandleFunc("/api/slo", middleware.Apply(logChain, authenticator.Middleware(handler.HandleSLO)))
+	mux.HandleFunc("/api/traefik/topology", middleware.Apply(logChain, authenticator.Middleware(handler.HandleTraefikTopology)))
+	mux.HandleFunc("/api/blocklist", middleware.Apply(chain, authenticator.Middleware(handler.HandleBlocklist)))
+	mux.HandleFunc("/api/blocklist/config", middleware.Apply(chain, authenticator.Middleware(handler.HandleBlocklistConfig)))
 	mux.HandleFunc("/api/logs/stream", middleware.Apply(chain, authenticator.Middleware(handler.HandleStreamAccessLogs)))
 
 	// System endpoints (with auth)
@@ -72,6 +119,31 @@ func main() {
 		fmt.Fprintf(w, `{"status":"ok","service":"traefik-log-dashboard-agent","version":"2.0.0"}`)
 	}))
 
+	// Background work: log analysis, blocklist upkeep, Traefik API polling
+	runCtx, stopRunners := context.WithCancel(context.Background())
+	if features.Security != nil {
+		logger.Log.Printf("Security Detection: Enabled")
+	}
+	if features.Health != nil {
+		logger.Log.Printf("Backend Health: Enabled (windows %s)", strings.Join(features.Health.WindowNames(), ", "))
+	}
+	if features.SLO != nil {
+		logger.Log.Printf("SLOs: %d objectives", len(features.SLO.Config().Objectives))
+	}
+	go handler.RunAnalysis(runCtx)
+	if features.Blocklist != nil {
+		if features.Blocklist.DryRun() {
+			logger.Log.Printf("Blocklist: Enabled (dry run)")
+		} else {
+			logger.Log.Printf("Blocklist: Enabled (%s)", features.Blocklist.OutputPath())
+		}
+		go handler.RunBlocklist(runCtx)
+	}
+	if features.Traefik != nil {
+		logger.Log.Printf("Traefik API: %s", features.Traefik.URL())
+		go features.Traefik.Run(runCtx)
+	}
+
 	// Create HTTP server
 	server := &http.Server{
 		Addr:    ":" + cfg.Port,
@@ -95,6 +167,10 @@ func main() {
 	if err := server.Close(); err != nil {
 		logger.Log.Fatalf("Server forced to shutdown: %v", err)
 	}
+	stopRunners()
+	if err := handler.Close(); err != nil {
+		logger.Log.Printf("Error stopping file watcher: %v", err)
+	}
 
 	logger.Log.Printf("Server exited")
 }
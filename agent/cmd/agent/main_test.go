@@ -58,7 +58,7 @@ func TestStatusEndpoint(t *testing.T) {
 	}
 
 	sm := state.NewStateManager(cfg)
-	handler := routes.NewHandler(cfg, sm)
+	handler := routes.NewHandler(cfg, sm, routes.Features{})
 	req := httptest.NewRequest(http.MethodGet, "/api/logs/status", nil)
 	w := httptest.NewRecorder()
 
@@ -152,7 +152,7 @@ func TestCORSHeaders(t *testing.T) {
 	}
 
 	sm := state.NewStateManager(cfg)
-	handler := routes.NewHandler(cfg, sm)
+	handler := routes.NewHandler(cfg, sm, routes.Features{})
 	req := httptest.NewRequest(http.MethodOptions, "/api/logs/status", nil)
 	w := httptest.NewRecorder()
 
@@ -175,7 +175,7 @@ func TestSystemResourcesEndpoint(t *testing.T) {
 	}
 
 	sm := state.NewStateManager(cfg)
-	handler := routes.NewHandler(cfg, sm)
+	handler := routes.NewHandler(cfg, sm, routes.Features{})
 	req := httptest.NewRequest(http.MethodGet, "/api/system/resources", nil)
 	w := httptest.NewRecorder()
 
@@ -215,7 +215,7 @@ func TestSystemResourcesDisabled(t *testing.T) {
 	}
 
 	sm := state.NewStateManager(cfg)
-	handler := routes.NewHandler(cfg, sm)
+	handler := routes.NewHandler(cfg, sm, routes.Features{})
 	req := httptest.NewRequest(http.MethodGet, "/api/system/resources", nil)
 	w := httptest.NewRecorder()
 
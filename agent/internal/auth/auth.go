@@ -1,27 +1,49 @@
 package auth
 
 import (
+	"context"
+	"crypto/subtle"
 	"net/http"
 	"strings"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/redact"
 )
 
+type scopeKey struct{}
+
 // Authenticator handles authentication for the agent
 type Authenticator struct {
-	token string
+	// tokens maps each accepted token to its scope
+	tokens map[string]string
 }
 
 // NewAuthenticator creates a new authenticator with the given token
 func NewAuthenticator(token string) *Authenticator {
-	return &Authenticator{
-		token: token,
+	a := &Authenticator{
+		tokens: make(map[string]string),
 	}
+	a.AddToken(token, redact.DefaultScope)
+	return a
 }
 
-// Middleware returns an HTTP middleware that validates Bearer tokens
+// AddToken accepts another token, granting it the given scope. Adding a
+// known token changes its scope.
+func (a *Authenticator) AddToken(token, scope string) {
+	if token == "" {
+		return
+	}
+	if scope == "" {
+		scope = redact.DefaultScope
+	}
+	a.tokens[token] = scope
+}
+
+// Middleware returns an HTTP middleware that validates Bearer tokens and
+// records the scope of the token in the request context
 func (a *Authenticator) Middleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// If no token is configured, skip authentication
-		if a.token == "" {
+		if len(a.tokens) == 0 {
 			next(w, r)
 			return
 		}
@@ -41,27 +63,49 @@ func (a *Authenticator) Middleware(next http.HandlerFunc) http.HandlerFunc {
 		}
 
 		// Validate token
-		token := parts[1]
-		if token != a.token {
+		scope, ok := a.lookup(parts[1])
+		if !ok {
 			http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
 			return
 		}
 
 		// Token is valid, proceed to next handler
-		next(w, r)
+		next(w, r.WithContext(context.WithValue(r.Context(), scopeKey{}, scope)))
+	}
+}
+
+// lookup returns the scope of a token. Every token is compared so the time
+// taken does not reveal which one nearly matched.
+func (a *Authenticator) lookup(token string) (string, bool) {
+	scope, found := "", false
+	for known, knownScope := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(known), []byte(token)) == 1 {
+			scope, found = knownScope, true
+		}
 	}
+	return scope, found
 }
 
-// ValidateToken checks if the provided token matches the configured token
+// ValidateToken checks if the provided token is accepted
 func (a *Authenticator) ValidateToken(token string) bool {
 	// If no token is configured, allow all requests
-	if a.token == "" {
+	if len(a.tokens) == 0 {
 		return true
 	}
-	return token == a.token
+	_, ok := a.lookup(token)
+	return ok
 }
 
 // IsEnabled returns true if authentication is enabled
 func (a *Authenticator) IsEnabled() bool {
-	return a.token != ""
-}
\ No newline at end of file
+	return len(a.tokens) > 0
+}
+
+// Scope returns the scope of the token that authenticated a request, or
+// redact.DefaultScope when authentication is disabled
+func Scope(ctx context.Context) string {
+	if scope, ok := ctx.Value(scopeKey{}).(string); ok {
+		return scope
+	}
+	return redact.DefaultScope
+}
@@ -2,10 +2,16 @@
 package config
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logger"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/redact"
 	"github.com/joho/godotenv"
 )
 
@@ -18,8 +24,80 @@ type Config struct {
 	AccessPath string
 	ErrorPath  string
 
-	// Authentication
-	AuthToken string
+	// Named log sources. When empty, one access and one error source are
+	// derived from AccessPath and ErrorPath.
+	Sources []logs.Source
+
+	// Authentication. AuthToken has AuthTokenScope; AuthTokens maps further
+	// tokens to their scopes.
+	AuthToken      string
+	AuthTokenScope string
+	AuthTokens     map[string]string
+
+	// Redaction profiles per token scope, as inline JSON or a JSON file;
+	// RedactionKey replaces their hash key. None leaves data untouched.
+	Redaction     string
+	RedactionFile string
+	RedactionKey  string
+
+	// GeoIP enrichment from MaxMind databases. Without a path, MaxMind's
+	// file names are looked for in the working directory.
+	GeoIPEnabled   bool
+	GeoIPCityDB    string
+	GeoIPASNDB     string
+	GeoIPCacheSize int
+
+	// User agent classification, with the embedded rules unless a rules
+	// file is given
+	UserAgentEnabled   bool
+	UserAgentRulesFile string
+	UserAgentCacheSize int
+
+	// Security event detection, with thresholds from inline JSON or a JSON
+	// file, or the defaults
+	SecurityEnabled bool
+	Security        string
+	SecurityFile    string
+
+	// Backend health tracking over comma separated windows. Zero
+	// thresholds keep the defaults.
+	HealthEnabled              bool
+	HealthWindows              string
+	HealthStatusWindow         string
+	HealthMinRequests          int
+	HealthDownErrorPercent     int
+	HealthDegradedErrorPercent int
+	HealthDegradedP95MS        int
+
+	// Service level objectives as inline JSON or a JSON file; none
+	// disables them
+	SLO     string
+	SLOFile string
+
+	// Blocklist rendered as Traefik dynamic configuration
+	BlocklistEnabled         bool
+	BlocklistPath            string
+	BlocklistFormat          string
+	BlocklistMode            string
+	BlocklistMiddleware      string
+	BlocklistTraefikVersion  int
+	BlocklistIPStrategyDepth int
+	BlocklistPlugin          string
+	BlocklistPluginField     string
+	BlocklistStateFile       string
+	BlocklistDryRun          bool
+	// Event types and minimum severity that add detection entries; no
+	// types means detections never block
+	BlocklistAutoTypes    []string
+	BlocklistAutoSeverity string
+	BlocklistAutoTTL      time.Duration
+
+	// Traefik API for the router and service topology; no URL disables it
+	TraefikAPIURL          string
+	TraefikAPIUsername     string
+	TraefikAPIPassword     string
+	TraefikAPIPollInterval time.Duration
+	TraefikAPITimeout      time.Duration
 
 	// System monitoring
 	SystemMonitoring bool
@@ -35,6 +113,20 @@ type Config struct {
 	StreamMaxDurationSec   int
 	StreamMaxBytesPerBatch int
 
+	// File browser
+	DownloadMaxBytes int
+
+	// Export
+	ExportMaxBytes int
+
+	// File watching
+	WatchMode           string
+	WatchPollIntervalMS int
+
+	// Compressed archives
+	CompressedIndexDir        string
+	CompressedCheckpointBytes int
+
 	// State persistence
 	PositionFile string
 }
@@ -47,21 +139,207 @@ func Load() *Config {
 		logger.Log.Println("No .env file found, using system environment variables")
 	}
 
-	return &Config{
-		Port:                   getEnv("PORT", "5000"),
-		AccessPath:             getEnv("TRAEFIK_LOG_DASHBOARD_ACCESS_PATH", "/var/log/traefik/access.log"),
-		ErrorPath:              getEnv("TRAEFIK_LOG_DASHBOARD_ERROR_PATH", "/var/log/traefik/traefik.log"),
-		AuthToken:              getEnv("TRAEFIK_LOG_DASHBOARD_AUTH_TOKEN", ""),
-		SystemMonitoring:       getEnvBool("TRAEFIK_LOG_DASHBOARD_SYSTEM_MONITORING", true),
-		MonitorInterval:        getEnvInt("TRAEFIK_LOG_DASHBOARD_MONITOR_INTERVAL", 2000),
-		LogFormat:              getEnv("TRAEFIK_LOG_DASHBOARD_LOG_FORMAT", "json"),
-		StreamBatchLines:       getEnvInt("TRAEFIK_LOG_DASHBOARD_STREAM_BATCH_LINES", 400),
-		StreamFlushIntervalMS:  getEnvInt("TRAEFIK_LOG_DASHBOARD_STREAM_FLUSH_INTERVAL_MS", 1000),
-		StreamMaxClients:       getEnvInt("TRAEFIK_LOG_DASHBOARD_STREAM_MAX_CLIENTS", 50),
-		StreamMaxDurationSec:   getEnvInt("TRAEFIK_LOG_DASHBOARD_STREAM_MAX_DURATION_SEC", 300),
-		StreamMaxBytesPerBatch: getEnvInt("TRAEFIK_LOG_DASHBOARD_STREAM_MAX_BYTES_PER_BATCH", 512*1024),
-		PositionFile:           getEnv("POSITION_FILE", "/data/.position"),
+	cfg := &Config{
+		Port:                       getEnv("PORT", "5000"),
+		AccessPath:                 getEnv("TRAEFIK_LOG_DASHBOARD_ACCESS_PATH", "/var/log/traefik/access.log"),
+		ErrorPath:                  getEnv("TRAEFIK_LOG_DASHBOARD_ERROR_PATH", "/var/log/traefik/traefik.log"),
+		AuthToken:                  getEnv("TRAEFIK_LOG_DASHBOARD_AUTH_TOKEN", ""),
+		AuthTokenScope:             getEnv("TRAEFIK_LOG_DASHBOARD_AUTH_TOKEN_SCOPE", redact.DefaultScope),
+		SystemMonitoring:           getEnvBool("TRAEFIK_LOG_DASHBOARD_SYSTEM_MONITORING", true),
+		MonitorInterval:            getEnvInt("TRAEFIK_LOG_DASHBOARD_MONITOR_INTERVAL", 2000),
+		LogFormat:                  getEnv("TRAEFIK_LOG_DASHBOARD_LOG_FORMAT", "json"),
+		StreamBatchLines:           getEnvInt("TRAEFIK_LOG_DASHBOARD_STREAM_BATCH_LINES", 400),
+		StreamFlushIntervalMS:      getEnvInt("TRAEFIK_LOG_DASHBOARD_STREAM_FLUSH_INTERVAL_MS", 1000),
+		StreamMaxClients:           getEnvInt("TRAEFIK_LOG_DASHBOARD_STREAM_MAX_CLIENTS", 50),
+		StreamMaxDurationSec:       getEnvInt("TRAEFIK_LOG_DASHBOARD_STREAM_MAX_DURATION_SEC", 300),
+		StreamMaxBytesPerBatch:     getEnvInt("TRAEFIK_LOG_DASHBOARD_STREAM_MAX_BYTES_PER_BATCH", 512*1024),
+		DownloadMaxBytes:           getEnvInt("TRAEFIK_LOG_DASHBOARD_DOWNLOAD_MAX_BYTES", 64*1024*1024),
+		ExportMaxBytes:             getEnvInt("TRAEFIK_LOG_DASHBOARD_EXPORT_MAX_BYTES", 256*1024*1024),
+		WatchMode:                  getEnv("TRAEFIK_LOG_DASHBOARD_WATCH_MODE", "auto"),
+		WatchPollIntervalMS:        getEnvInt("TRAEFIK_LOG_DASHBOARD_WATCH_POLL_INTERVAL_MS", 250),
+		CompressedIndexDir:         getEnv("TRAEFIK_LOG_DASHBOARD_COMPRESSED_INDEX_DIR", ""),
+		CompressedCheckpointBytes:  getEnvInt("TRAEFIK_LOG_DASHBOARD_COMPRESSED_CHECKPOINT_BYTES", 8*1024*1024),
+		PositionFile:               getEnv("POSITION_FILE", "/data/.position"),
+		Redaction:                  getEnv("TRAEFIK_LOG_DASHBOARD_REDACTION", ""),
+		RedactionFile:              getEnv("TRAEFIK_LOG_DASHBOARD_REDACTION_FILE", ""),
+		RedactionKey:               getEnv("TRAEFIK_LOG_DASHBOARD_REDACTION_KEY", ""),
+		GeoIPEnabled:               getEnvBool("TRAEFIK_LOG_DASHBOARD_GEOIP_ENABLED", false),
+		GeoIPCityDB:                getEnv("TRAEFIK_LOG_DASHBOARD_GEOIP_CITY_DB", getEnv("TRAEFIK_LOG_DASHBOARD_GEOIP_COUNTRY_DB", "")),
+		GeoIPASNDB:                 getEnv("TRAEFIK_LOG_DASHBOARD_GEOIP_ASN_DB", ""),
+		GeoIPCacheSize:             getEnvInt("TRAEFIK_LOG_DASHBOARD_GEOIP_CACHE_SIZE", 0),
+		UserAgentEnabled:           getEnvBool("TRAEFIK_LOG_DASHBOARD_USER_AGENT_ENABLED", false),
+		UserAgentRulesFile:         getEnv("TRAEFIK_LOG_DASHBOARD_USER_AGENT_RULES_FILE", ""),
+		UserAgentCacheSize:         getEnvInt("TRAEFIK_LOG_DASHBOARD_USER_AGENT_CACHE_SIZE", 0),
+		SecurityEnabled:            getEnvBool("TRAEFIK_LOG_DASHBOARD_SECURITY_ENABLED", false),
+		Security:                   getEnv("TRAEFIK_LOG_DASHBOARD_SECURITY", ""),
+		SecurityFile:               getEnv("TRAEFIK_LOG_DASHBOARD_SECURITY_FILE", ""),
+		HealthEnabled:              getEnvBool("TRAEFIK_LOG_DASHBOARD_HEALTH_ENABLED", false),
+		HealthWindows:              getEnv("TRAEFIK_LOG_DASHBOARD_HEALTH_WINDOWS", ""),
+		HealthStatusWindow:         getEnv("TRAEFIK_LOG_DASHBOARD_HEALTH_STATUS_WINDOW", ""),
+		HealthMinRequests:          getEnvInt("TRAEFIK_LOG_DASHBOARD_HEALTH_MIN_REQUESTS", 0),
+		HealthDownErrorPercent:     getEnvInt("TRAEFIK_LOG_DASHBOARD_HEALTH_DOWN_ERROR_PERCENT", 0),
+		HealthDegradedErrorPercent: getEnvInt("TRAEFIK_LOG_DASHBOARD_HEALTH_DEGRADED_ERROR_PERCENT", 0),
+		HealthDegradedP95MS:        getEnvInt("TRAEFIK_LOG_DASHBOARD_HEALTH_DEGRADED_P95_MS", 0),
+		SLO:                        getEnv("TRAEFIK_LOG_DASHBOARD_SLO", ""),
+		SLOFile:                    getEnv("TRAEFIK_LOG_DASHBOARD_SLO_FILE", ""),
+		BlocklistEnabled:           getEnvBool("TRAEFIK_LOG_DASHBOARD_BLOCKLIST_ENABLED", false),
+		BlocklistPath:              getEnv("TRAEFIK_LOG_DASHBOARD_BLOCKLIST_PATH", ""),
+		BlocklistFormat:            getEnv("TRAEFIK_LOG_DASHBOARD_BLOCKLIST_FORMAT", ""),
+		BlocklistMode:              getEnv("TRAEFIK_LOG_DASHBOARD_BLOCKLIST_MODE", ""),
+		BlocklistMiddleware:        getEnv("TRAEFIK_LOG_DASHBOARD_BLOCKLIST_MIDDLEWARE", ""),
+		BlocklistTraefikVersion:    getEnvInt("TRAEFIK_LOG_DASHBOARD_BLOCKLIST_TRAEFIK_VERSION", 3),
+		BlocklistIPStrategyDepth:   getEnvInt("TRAEFIK_LOG_DASHBOARD_BLOCKLIST_IP_STRATEGY_DEPTH", 0),
+		BlocklistPlugin:            getEnv("TRAEFIK_LOG_DASHBOARD_BLOCKLIST_PLUGIN", ""),
+		BlocklistPluginField:       getEnv("TRAEFIK_LOG_DASHBOARD_BLOCKLIST_PLUGIN_FIELD", ""),
+		BlocklistStateFile:         getEnv("TRAEFIK_LOG_DASHBOARD_BLOCKLIST_STATE_FILE", ""),
+		BlocklistDryRun:            getEnvBool("TRAEFIK_LOG_DASHBOARD_BLOCKLIST_DRY_RUN", false),
+		BlocklistAutoTypes:         splitList(getEnv("TRAEFIK_LOG_DASHBOARD_BLOCKLIST_AUTO_TYPES", "")),
+		BlocklistAutoSeverity:      getEnv("TRAEFIK_LOG_DASHBOARD_BLOCKLIST_AUTO_MIN_SEVERITY", "high"),
+		BlocklistAutoTTL:           time.Duration(getEnvInt("TRAEFIK_LOG_DASHBOARD_BLOCKLIST_AUTO_TTL_SEC", 3600)) * time.Second,
+		TraefikAPIURL:              getEnv("TRAEFIK_LOG_DASHBOARD_TRAEFIK_API_URL", ""),
+		TraefikAPIUsername:         getEnv("TRAEFIK_LOG_DASHBOARD_TRAEFIK_API_USERNAME", ""),
+		TraefikAPIPassword:         getEnv("TRAEFIK_LOG_DASHBOARD_TRAEFIK_API_PASSWORD", ""),
+		TraefikAPIPollInterval:     time.Duration(getEnvInt("TRAEFIK_LOG_DASHBOARD_TRAEFIK_API_POLL_INTERVAL_SEC", 30)) * time.Second,
+		TraefikAPITimeout:          time.Duration(getEnvInt("TRAEFIK_LOG_DASHBOARD_TRAEFIK_API_TIMEOUT_SEC", 5)) * time.Second,
+	}
+
+	sources, err := loadSources(
+		getEnv("TRAEFIK_LOG_DASHBOARD_SOURCES", ""),
+		getEnv("TRAEFIK_LOG_DASHBOARD_SOURCES_FILE", ""),
+		cfg.LogFormat,
+	)
+	if err != nil {
+		logger.Log.Fatalf("Invalid log source configuration: %v", err)
+	}
+	cfg.Sources = sources
+
+	tokens, err := parseScopedTokens(getEnv("TRAEFIK_LOG_DASHBOARD_AUTH_TOKENS", ""))
+	if err != nil {
+		logger.Log.Fatalf("Invalid token configuration: %v", err)
+	}
+	cfg.AuthTokens = tokens
+
+	return cfg
+}
+
+// AuthEnabled reports whether any token is configured
+func (c *Config) AuthEnabled() bool {
+	return c.AuthToken != "" || len(c.AuthTokens) > 0
+}
+
+// LogSources returns the configured log sources, falling back to sources
+// derived from AccessPath and ErrorPath when none are configured.
+func (c *Config) LogSources() []logs.Source {
+	if len(c.Sources) > 0 {
+		return c.Sources
+	}
+	return DefaultSources(c.AccessPath, c.ErrorPath, c.LogFormat)
+}
+
+// SourcesOfType returns the log sources of the given type
+func (c *Config) SourcesOfType(sourceType string) []logs.Source {
+	var sources []logs.Source
+	for _, src := range c.LogSources() {
+		if src.Type == sourceType {
+			sources = append(sources, src)
+		}
+	}
+	return sources
+}
+
+// FindSource returns the log source with the given name
+func (c *Config) FindSource(name string) (logs.Source, bool) {
+	for _, src := range c.LogSources() {
+		if src.Name == name {
+			return src, true
+		}
+	}
+	return logs.Source{}, false
+}
+
+// DefaultSources builds the legacy access and error sources. When a path is a
+// directory, files whose name contains "error" belong to the error source.
+func DefaultSources(accessPath, errorPath, format string) []logs.Source {
+	access := logs.Source{Name: "access", Path: accessPath, Type: logs.SourceTypeAccess, Format: format}
+	errorSrc := logs.Source{Name: "error", Path: errorPath, Type: logs.SourceTypeError}
+
+	if info, err := os.Stat(accessPath); err == nil && info.IsDir() {
+		access.Exclude = "*error*"
+	}
+	if info, err := os.Stat(errorPath); err == nil && info.IsDir() {
+		errorSrc.Include = "*error*"
+	}
+
+	return []logs.Source{access, errorSrc}
+}
+
+// loadSources parses source definitions from a JSON string or a JSON file.
+// It returns nil when neither is set.
+func loadSources(inline, file, defaultFormat string) ([]logs.Source, error) {
+	data := []byte(inline)
+	if inline == "" && file != "" {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		data = content
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var sources []logs.Source
+	if err := json.Unmarshal(data, &sources); err != nil {
+		return nil, fmt.Errorf("failed to parse sources: %w", err)
+	}
+
+	seen := make(map[string]bool, len(sources))
+	for i := range sources {
+		if sources[i].Type == "" {
+			sources[i].Type = logs.SourceTypeAccess
+		}
+		if sources[i].Format == "" && sources[i].Type == logs.SourceTypeAccess {
+			sources[i].Format = defaultFormat
+		}
+		if err := sources[i].Validate(); err != nil {
+			return nil, err
+		}
+		if seen[sources[i].Name] {
+			return nil, fmt.Errorf("duplicate source name %q", sources[i].Name)
+		}
+		seen[sources[i].Name] = true
+	}
+
+	return sources, nil
+}
+
+// parseScopedTokens parses a comma-separated list of scope:token pairs into
+// a map from token to scope
+func parseScopedTokens(value string) (map[string]string, error) {
+	tokens := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		scope, token, ok := strings.Cut(pair, ":")
+		if !ok || scope == "" || token == "" {
+			return nil, fmt.Errorf("expected scope:token, got %q", pair)
+		}
+		tokens[token] = scope
+	}
+	return tokens, nil
+}
+
+// splitList splits a comma separated value, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
+	return items
 }
 
 // getEnv retrieves an environment variable or returns a default value
@@ -0,0 +1,192 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Content encodings supported by Compress, in order of preference
+const (
+	EncodingZstd = "zstd"
+	EncodingGzip = "gzip"
+)
+
+var (
+	gzipWriters = sync.Pool{
+		New: func() any {
+			w, _ := gzip.NewWriterLevel(io.Discard, gzip.DefaultCompression)
+			return w
+		},
+	}
+	zstdWriters = sync.Pool{
+		New: func() any {
+			w, _ := zstd.NewWriter(io.Discard, zstd.WithEncoderConcurrency(1), zstd.WithEncoderLevel(zstd.SpeedDefault))
+			return w
+		},
+	}
+)
+
+// Compress returns a middleware that compresses JSON and text responses with
+// zstd or gzip when the client accepts it. Responses that already carry a
+// Content-Encoding, partial content and event streams are left alone.
+func Compress() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			encoding := NegotiateEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Add("Vary", "Accept-Encoding")
+			cw := &compressWriter{ResponseWriter: w, encoding: encoding}
+			defer cw.close()
+
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// NegotiateEncoding picks the preferred supported encoding from an
+// Accept-Encoding header, or "" when the response should not be compressed
+func NegotiateEncoding(header string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != EncodingZstd && name != EncodingGzip {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+
+		// Ties go to zstd, which is listed first in the preference order
+		if q > bestQ || (q == bestQ && q > 0 && name == EncodingZstd) {
+			best, bestQ = name, q
+		}
+	}
+	if bestQ <= 0 {
+		return ""
+	}
+	return best
+}
+
+// compressWriter decides whether to compress when the status is written and
+// then routes the body through the encoder
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+
+	decided bool
+	encoder io.WriteCloser
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if cw.decided {
+		return
+	}
+	cw.decided = true
+
+	h := cw.Header()
+	if compressible(code, h) {
+		h.Set("Content-Encoding", cw.encoding)
+		h.Del("Content-Length")
+		h.Del("Accept-Ranges")
+
+		switch cw.encoding {
+		case EncodingZstd:
+			enc := zstdWriters.Get().(*zstd.Encoder)
+			enc.Reset(cw.ResponseWriter)
+			cw.encoder = enc
+		default:
+			enc := gzipWriters.Get().(*gzip.Writer)
+			enc.Reset(cw.ResponseWriter)
+			cw.encoder = enc
+		}
+	}
+
+	cw.ResponseWriter.WriteHeader(code)
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if !cw.decided {
+		if cw.Header().Get("Content-Type") == "" {
+			cw.Header().Set("Content-Type", http.DetectContentType(p))
+		}
+		cw.WriteHeader(http.StatusOK)
+	}
+	if cw.encoder != nil {
+		return cw.encoder.Write(p)
+	}
+	return cw.ResponseWriter.Write(p)
+}
+
+// Flush pushes buffered compressed data to the client so streamed responses
+// are delivered incrementally
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if f, ok := cw.encoder.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+func (cw *compressWriter) close() {
+	if cw.encoder == nil {
+		return
+	}
+	cw.encoder.Close()
+
+	switch enc := cw.encoder.(type) {
+	case *zstd.Encoder:
+		enc.Reset(io.Discard)
+		zstdWriters.Put(enc)
+	case *gzip.Writer:
+		enc.Reset(io.Discard)
+		gzipWriters.Put(enc)
+	}
+	cw.encoder = nil
+}
+
+// compressible reports whether a response is worth compressing
+func compressible(code int, h http.Header) bool {
+	if code < http.StatusOK || code == http.StatusNoContent ||
+		code == http.StatusPartialContent || code == http.StatusNotModified {
+		return false
+	}
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	contentType := h.Get("Content-Type")
+	switch {
+	case strings.HasPrefix(contentType, "text/event-stream"):
+		// Event streams are flushed per event; keep them unencoded
+		return false
+	case strings.HasPrefix(contentType, "application/json"),
+		strings.HasPrefix(contentType, "application/x-ndjson"),
+		strings.HasPrefix(contentType, "text/"):
+		return true
+	}
+	return false
+}
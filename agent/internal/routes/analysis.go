@@ -0,0 +1,177 @@
+package routes
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/health"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logger"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/security"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/slo"
+)
+
+// analyzers receive the entries followed by RunAnalysis; any may be nil
+type analyzers struct {
+	security *security.Detector
+	health   *health.Tracker
+	slo      *slo.Tracker
+	// start is when the analysis began; SLOs count earlier entries from
+	// the log history instead
+	start time.Time
+}
+
+// RunAnalysis follows the active file of every access source from its
+// current end and passes new entries to security detection, backend health
+// tracking and SLOs until ctx is done. SLOs first read the history within
+// their windows.
+func (h *Handler) RunAnalysis(ctx context.Context) {
+	a := analyzers{
+		security: h.features.Security,
+		health:   h.features.Health,
+		slo:      h.features.SLO,
+		start:    time.Now(),
+	}
+	if a.security == nil && a.health == nil && a.slo == nil {
+		return
+	}
+
+	var sources []logs.Source
+	for _, src := range h.config.LogSources() {
+		if src.Type == logs.SourceTypeAccess {
+			sources = append(sources, src)
+		}
+	}
+
+	var wg sync.WaitGroup
+	if a.slo != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.loadSLOHistory(ctx, sources, a.slo, a.start)
+		}()
+	}
+	for _, src := range sources {
+		wg.Add(1)
+		go func(src logs.Source) {
+			defer wg.Done()
+			h.analyzeSource(ctx, src, a)
+		}(src)
+	}
+	wg.Wait()
+}
+
+// loadSLOHistory counts the entries logged before start within the longest
+// SLO window
+func (h *Handler) loadSLOHistory(ctx context.Context, sources []logs.Source, tracker *slo.Tracker, start time.Time) {
+	var longest time.Duration
+	for _, o := range tracker.Config().Objectives {
+		if w := time.Duration(o.Window); w > longest {
+			longest = w
+		}
+	}
+	since := start.Add(-longest)
+
+	for _, src := range sources {
+		err := logs.ScanSourceHistory(src, since, func(line string) error {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			entry, err := logs.ParseTraefikLog(line)
+			if err != nil || entry == nil || entry.StartUTC.IsZero() || !entry.StartUTC.Before(start) {
+				return nil
+			}
+			tracker.Observe(entry)
+			return nil
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			logger.Log.Printf("SLO history of %s: %v", src.Name, err)
+		}
+	}
+	tracker.SetLoaded()
+}
+
+func (h *Handler) analyzeSource(ctx context.Context, src logs.Source, a analyzers) {
+	interval := time.Duration(h.config.StreamFlushIntervalMS) * time.Millisecond
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// Wait for the source to have a file
+	var tailer *streamTailer
+	for tailer == nil {
+		if path, err := src.ActiveFile(); err == nil {
+			t, err := h.newStreamTailer(src, path)
+			if err != nil {
+				logger.Log.Printf("Log analysis for %s stopped: %v", src.Name, err)
+				return
+			}
+			// Only new entries are inspected, and the positions of log
+			// readers are left alone
+			t.position = 0
+			if t.info != nil {
+				t.position = t.info.Size()
+			}
+			tailer = t
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+	defer tailer.close()
+
+	enrichers := h.enrichers()
+	for {
+		if err := tailer.sync(h); err != nil {
+			logger.Log.Printf("analysis watch error: %v", err)
+		}
+		for {
+			lines, err := tailer.read(ctx, h.config.StreamBatchLines, h.config.StreamMaxBytesPerBatch)
+			if err != nil {
+				if ctx.Err() == nil {
+					logger.Log.Printf("analysis read error: %v", err)
+				}
+				break
+			}
+			if len(lines) == 0 {
+				break
+			}
+			for _, line := range lines {
+				entry, err := logs.ParseTraefikLog(line)
+				if err != nil || entry == nil {
+					continue
+				}
+				if a.health != nil {
+					a.health.Observe(entry)
+				}
+				if a.slo != nil && (entry.StartUTC.IsZero() || !entry.StartUTC.Before(a.start)) {
+					a.slo.Observe(entry)
+				}
+				if a.security == nil {
+					continue
+				}
+				logs.Enrich(entry, enrichers...)
+				for _, ev := range a.security.Observe(entry, line) {
+					logger.Log.Printf("Security event: %s (%s) from %s: %s", ev.Type, ev.Severity, ev.Client, ev.Summary)
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-tailer.fileSub.C:
+		case <-tailer.dirSub.C:
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/utils"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/blocklist"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/geoip"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logger"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/security"
+)
+
+// blocklistExpireInterval is how often expired entries are dropped
+const blocklistExpireInterval = time.Minute
+
+// blocklistRequest is the body of a POST to /api/blocklist
+type blocklistRequest struct {
+	// Value is an address or CIDR network
+	Value  string `json:"value"`
+	Reason string `json:"reason"`
+	// TTL is a Go duration such as "24h"; empty blocks for good
+	TTL string `json:"ttl"`
+}
+
+// HandleBlocklist lists (GET), adds (POST) and removes (DELETE) blocklist
+// entries. Changes require authentication and take effect when Traefik
+// reloads the rendered file. With dry_run=true a change is only previewed.
+func (h *Handler) HandleBlocklist(w http.ResponseWriter, r *http.Request) {
+	m := h.features.Blocklist
+	if m == nil {
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"status":  "disabled",
+			"message": "Blocklist is disabled",
+			"entries": []blocklist.Entry{},
+		})
+		return
+	}
+	// Entries hold client addresses, which redacted scopes must not see
+	if h.redactor(r) != nil {
+		utils.RespondError(w, http.StatusForbidden, "the blocklist is not available to this token scope")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		entries := m.Entries()
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"status":  "ok",
+			"path":    m.OutputPath(),
+			"dry_run": m.DryRun(),
+			"entries": entries,
+			"count":   len(entries),
+		})
+	case http.MethodPost:
+		if !h.config.AuthEnabled() {
+			utils.RespondError(w, http.StatusForbidden, "changing the blocklist requires an auth token")
+			return
+		}
+		h.addBlocklistEntry(w, r, m)
+	case http.MethodDelete:
+		if !h.config.AuthEnabled() {
+			utils.RespondError(w, http.StatusForbidden, "changing the blocklist requires an auth token")
+			return
+		}
+		h.removeBlocklistEntry(w, r, m)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		utils.RespondError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (h *Handler) addBlocklistEntry(w http.ResponseWriter, r *http.Request, m *blocklist.Manager) {
+	var req blocklistRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64*1024)).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	var ttl time.Duration
+	if req.TTL != "" {
+		var err error
+		if ttl, err = time.ParseDuration(req.TTL); err != nil || ttl < 0 {
+			utils.RespondError(w, http.StatusBadRequest, fmt.Sprintf("ttl: invalid duration %q", req.TTL))
+			return
+		}
+	}
+	if _, err := blocklist.ParsePrefix(req.Value); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "value: "+err.Error())
+		return
+	}
+
+	entry := blocklist.Entry{
+		Prefix: req.Value,
+		Reason: req.Reason,
+		Source: blocklist.SourceManual,
+	}
+	if utils.GetQueryParamBool(r, "dry_run", false) {
+		now := time.Now().UTC()
+		entry.CreatedAt = now
+		if ttl > 0 {
+			expires := now.Add(ttl)
+			entry.ExpiresAt = &expires
+		}
+		h.respondBlocklistPreview(w, r, m, []blocklist.Entry{entry}, nil)
+		return
+	}
+
+	entry, err := m.Add(entry, ttl)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	logger.Log.Printf("Blocklist: added %s (%s)", entry.Prefix, entry.Reason)
+	utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{
+		"status": "ok",
+		"entry":  entry,
+	})
+}
+
+func (h *Handler) removeBlocklistEntry(w http.ResponseWriter, r *http.Request, m *blocklist.Manager) {
+	value := utils.GetQueryParam(r, "value", "")
+	if _, err := blocklist.ParsePrefix(value); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "value: "+err.Error())
+		return
+	}
+	if utils.GetQueryParamBool(r, "dry_run", false) {
+		h.respondBlocklistPreview(w, r, m, nil, []string{value})
+		return
+	}
+
+	removed, err := m.Remove(value)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !removed {
+		utils.RespondError(w, http.StatusNotFound, fmt.Sprintf("%s is not blocked", value))
+		return
+	}
+	logger.Log.Printf("Blocklist: removed %s", value)
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"status":  "ok",
+		"removed": value,
+	})
+}
+
+// respondBlocklistPreview renders the configuration a change would produce
+func (h *Handler) respondBlocklistPreview(w http.ResponseWriter, r *http.Request, m *blocklist.Manager, add []blocklist.Entry, remove []string) {
+	format := strings.ToLower(utils.GetQueryParam(r, "format", ""))
+	data, err := m.Preview(format, add, remove)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"status":  "dry_run",
+		"config":  string(data),
+		"entries": add,
+		"removed": remove,
+	})
+}
+
+// HandleBlocklistConfig returns the dynamic configuration rendered from the
+// current entries, in the configured format or the one given by format
+func (h *Handler) HandleBlocklistConfig(w http.ResponseWriter, r *http.Request) {
+	m := h.features.Blocklist
+	if m == nil {
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"status":  "disabled",
+			"message": "Blocklist is disabled",
+		})
+		return
+	}
+	if h.redactor(r) != nil {
+		utils.RespondError(w, http.StatusForbidden, "the blocklist is not available to this token scope")
+		return
+	}
+
+	format := strings.ToLower(utils.GetQueryParam(r, "format", ""))
+	data, err := m.Preview(format, nil, nil)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// RunBlocklist drops expired entries and, when automatic blocking is
+// configured, blocks the clients of matching security events until ctx is
+// done
+func (h *Handler) RunBlocklist(ctx context.Context) {
+	m := h.features.Blocklist
+	if m == nil {
+		return
+	}
+
+	var events <-chan security.Event
+	if d := h.features.Security; d != nil && len(h.config.BlocklistAutoTypes) > 0 {
+		ch, cancel := d.Subscribe(64)
+		defer cancel()
+		events = ch
+	}
+
+	ticker := time.NewTicker(blocklistExpireInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n, err := m.Expire(); err != nil {
+				logger.Log.Printf("Blocklist expiry error: %v", err)
+			} else if n > 0 {
+				logger.Log.Printf("Blocklist: %d entries expired", n)
+			}
+		case ev := <-events:
+			if err := h.blockEvent(m, ev); err != nil {
+				logger.Log.Printf("Blocklist error for %s: %v", ev.Client, err)
+			}
+		}
+	}
+}
+
+// blockEvent adds a detection entry for an event matching the automatic
+// blocking rules. Private clients are never blocked, since they are usually
+// proxies or internal services.
+func (h *Handler) blockEvent(m *blocklist.Manager, ev security.Event) error {
+	if !containsString(h.config.BlocklistAutoTypes, ev.Type) ||
+		!security.SeverityAtLeast(ev.Severity, h.config.BlocklistAutoSeverity) {
+		return nil
+	}
+	ip := net.ParseIP(ev.Client)
+	if ip == nil || geoip.IsPrivate(ip) {
+		return nil
+	}
+
+	entry, err := m.Add(blocklist.Entry{
+		Prefix:  ev.Client,
+		Reason:  ev.Type + ": " + ev.Summary,
+		Source:  blocklist.SourceDetection,
+		EventID: ev.ID,
+	}, h.config.BlocklistAutoTTL)
+	if err != nil {
+		return err
+	}
+	if entry.EventID == ev.ID {
+		logger.Log.Printf("Blocklist: blocked %s after %s", entry.Prefix, ev.Type)
+	}
+	return nil
+}
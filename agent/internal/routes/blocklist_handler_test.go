@@ -0,0 +1,170 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/auth"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/config"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/state"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/blocklist"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/redact"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/security"
+)
+
+func newBlocklistHandler(t *testing.T, token string) (*Handler, string) {
+	t.Helper()
+	output := filepath.Join(t.TempDir(), "blocklist.yml")
+	manager, err := blocklist.New(blocklist.Options{OutputPath: output})
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy, err := redact.NewPolicy(redact.Config{Profiles: map[string]redact.Rules{
+		"support": {ClientIP: redact.IPTruncate},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{
+		AuthToken:             token,
+		AuthTokens:            map[string]string{"support-token": "support"},
+		BlocklistAutoTypes:    []string{security.TypeExploitProbe},
+		BlocklistAutoSeverity: security.SeverityHigh,
+		BlocklistAutoTTL:      time.Hour,
+	}
+	return NewHandler(cfg, state.NewStateManager(cfg), Features{Redaction: policy, Blocklist: manager}), output
+}
+
+func doBlocklist(t *testing.T, h *Handler, method, target, token, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	authenticator := auth.NewAuthenticator(h.config.AuthToken)
+	for tok, scope := range h.config.AuthTokens {
+		authenticator.AddToken(tok, scope)
+	}
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	authenticator.Middleware(h.HandleBlocklist)(w, req)
+	return w
+}
+
+func TestHandleBlocklist(t *testing.T) {
+	h, output := newBlocklistHandler(t, "admin")
+
+	w := doBlocklist(t, h, http.MethodPost, "/api/blocklist?dry_run=true", "admin", `{"value":"81.2.69.160","reason":"scanner"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("dry run: got %d: %s", w.Code, w.Body.String())
+	}
+	var preview struct {
+		Status string `json:"status"`
+		Config string `json:"config"`
+	}
+	json.NewDecoder(w.Body).Decode(&preview)
+	if preview.Status != "dry_run" || !strings.Contains(preview.Config, "81.2.69.160/32 manual: scanner") {
+		t.Errorf("unexpected preview %+v", preview)
+	}
+	if len(h.features.Blocklist.Entries()) != 0 {
+		t.Error("dry run changed the blocklist")
+	}
+
+	w = doBlocklist(t, h, http.MethodPost, "/api/blocklist", "admin", `{"value":"81.2.69.160","reason":"scanner","ttl":"24h"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("add: got %d: %s", w.Code, w.Body.String())
+	}
+	data, _ := os.ReadFile(output)
+	if !strings.Contains(string(data), "81.2.69.160/32 manual: scanner (until ") {
+		t.Errorf("output not updated:\n%s", data)
+	}
+
+	w = doBlocklist(t, h, http.MethodGet, "/api/blocklist", "admin", "")
+	var list struct {
+		Entries []blocklist.Entry `json:"entries"`
+	}
+	json.NewDecoder(w.Body).Decode(&list)
+	if len(list.Entries) != 1 || list.Entries[0].ExpiresAt == nil {
+		t.Errorf("unexpected entries %+v", list.Entries)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		token  string
+		body   string
+		status int
+	}{
+		{"redacted scope", http.MethodGet, "/api/blocklist", "support-token", "", http.StatusForbidden},
+		{"invalid value", http.MethodPost, "/api/blocklist", "admin", `{"value":"nope"}`, http.StatusBadRequest},
+		{"invalid ttl", http.MethodPost, "/api/blocklist", "admin", `{"value":"81.2.69.1","ttl":"soon"}`, http.StatusBadRequest},
+		{"catch-all network", http.MethodPost, "/api/blocklist", "admin", `{"value":"0.0.0.0/0"}`, http.StatusBadRequest},
+		{"unknown entry", http.MethodDelete, "/api/blocklist?value=81.2.69.1", "admin", "", http.StatusNotFound},
+		{"method", http.MethodPut, "/api/blocklist", "admin", "", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doBlocklist(t, h, tt.method, tt.target, tt.token, tt.body)
+			if w.Code != tt.status {
+				t.Errorf("got %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+		})
+	}
+
+	w = doBlocklist(t, h, http.MethodDelete, "/api/blocklist?value=81.2.69.160", "admin", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("remove: got %d: %s", w.Code, w.Body.String())
+	}
+	if len(h.features.Blocklist.Entries()) != 0 {
+		t.Error("entry not removed")
+	}
+}
+
+func TestHandleBlocklistRequiresAuth(t *testing.T) {
+	h, _ := newBlocklistHandler(t, "")
+	h.config.AuthTokens = nil
+
+	w := doBlocklist(t, h, http.MethodPost, "/api/blocklist", "", `{"value":"81.2.69.160"}`)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("got %d, want 403", w.Code)
+	}
+	w = doBlocklist(t, h, http.MethodGet, "/api/blocklist", "", "")
+	if w.Code != http.StatusOK {
+		t.Errorf("listing: got %d, want 200", w.Code)
+	}
+}
+
+func TestBlockEvent(t *testing.T) {
+	h, _ := newBlocklistHandler(t, "admin")
+	m := h.features.Blocklist
+
+	events := []security.Event{
+		{ID: "1", Type: security.TypeExploitProbe, Severity: security.SeverityHigh, Client: "81.2.69.160", Summary: "path traversal"},
+		{ID: "2", Type: security.TypeExploitProbe, Severity: security.SeverityMedium, Client: "81.2.69.161"},
+		{ID: "3", Type: security.TypePathScan, Severity: security.SeverityHigh, Client: "81.2.69.162"},
+		{ID: "4", Type: security.TypeExploitProbe, Severity: security.SeverityHigh, Client: "10.0.0.1"},
+	}
+	for _, ev := range events {
+		if err := h.blockEvent(m, ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries := m.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1: %+v", len(entries), entries)
+	}
+	e := entries[0]
+	if e.Prefix != "81.2.69.160/32" || e.Source != blocklist.SourceDetection || e.EventID != "1" || e.ExpiresAt == nil {
+		t.Errorf("unexpected entry %+v", e)
+	}
+	if e.Reason != "exploit_probe: path traversal" {
+		t.Errorf("unexpected reason %q", e.Reason)
+	}
+}
@@ -0,0 +1,136 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/utils"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+)
+
+// Response formats of the log endpoints
+const (
+	formatJSON   = "json"
+	formatNDJSON = "ndjson"
+
+	ndjsonContentType = "application/x-ndjson"
+)
+
+// ndjsonFlushLines is how many lines are written between flushes in NDJSON mode
+const ndjsonFlushLines = 256
+
+// parsedLogResult is a LogResult whose lines are JSON objects rather than strings
+type parsedLogResult struct {
+	Logs      []json.RawMessage  `json:"logs"`
+	Positions []logs.Position    `json:"positions"`
+	Sources   []logs.SourceRange `json:"sources,omitempty"`
+}
+
+// ndjsonMeta is the last record of an NDJSON response
+type ndjsonMeta struct {
+	Positions []logs.Position    `json:"positions"`
+	Sources   []logs.SourceRange `json:"sources,omitempty"`
+	Error     string             `json:"error,omitempty"`
+}
+
+// responseFormat picks the response format from the "format" query parameter,
+// falling back to the Accept header
+func responseFormat(r *http.Request) string {
+	switch utils.GetQueryParam(r, "format", "") {
+	case formatNDJSON:
+		return formatNDJSON
+	case formatJSON:
+		return formatJSON
+	}
+
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err == nil && (mediaType == ndjsonContentType || mediaType == "application/jsonl") {
+			return formatNDJSON
+		}
+	}
+	return formatJSON
+}
+
+// respondLogs writes a LogResult, converting its lines to objects when parsed is set
+func respondLogs(w http.ResponseWriter, result logs.LogResult, parsed bool) {
+	if !parsed {
+		utils.RespondJSON(w, http.StatusOK, result)
+		return
+	}
+
+	entries := make([]json.RawMessage, len(result.Logs))
+	for i, line := range result.Logs {
+		entries[i] = logs.ParsedLine(line)
+	}
+	utils.RespondJSON(w, http.StatusOK, parsedLogResult{
+		Logs:      entries,
+		Positions: result.Positions,
+		Sources:   result.Sources,
+	})
+}
+
+// ndjsonWriter streams log lines as newline-delimited JSON. Each line is a
+// JSON string, or an object when parsed is set; the final record is
+// {"_meta": {...}} with the positions to resume from.
+type ndjsonWriter struct {
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	parsed  bool
+	buf     bytes.Buffer
+	enc     *json.Encoder
+	pending int
+	count   int
+}
+
+func newNDJSONWriter(w http.ResponseWriter, parsed bool) *ndjsonWriter {
+	w.Header().Set("Content-Type", ndjsonContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+
+	n := &ndjsonWriter{
+		w:      w,
+		rc:     http.NewResponseController(w),
+		parsed: parsed,
+	}
+	n.enc = json.NewEncoder(&n.buf)
+	n.enc.SetEscapeHTML(false)
+	return n
+}
+
+// line writes one log line; it fails once the client has gone away
+func (n *ndjsonWriter) line(line string) error {
+	n.buf.Reset()
+	if n.parsed {
+		n.buf.Write(logs.ParsedLine(line))
+		n.buf.WriteByte('\n')
+	} else if err := n.enc.Encode(line); err != nil {
+		return err
+	}
+
+	if _, err := n.w.Write(n.buf.Bytes()); err != nil {
+		return err
+	}
+	n.count++
+
+	n.pending++
+	if n.pending >= ndjsonFlushLines {
+		n.pending = 0
+		n.rc.Flush()
+	}
+	return nil
+}
+
+// meta writes the closing record
+func (n *ndjsonWriter) meta(meta ndjsonMeta) {
+	if meta.Positions == nil {
+		meta.Positions = []logs.Position{}
+	}
+	n.buf.Reset()
+	n.enc.Encode(map[string]ndjsonMeta{"_meta": meta})
+	n.w.Write(n.buf.Bytes())
+	n.rc.Flush()
+}
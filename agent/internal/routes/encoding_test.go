@@ -0,0 +1,255 @@
+package routes
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/config"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/middleware"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/state"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+func writeAccessLines(t testing.TB, path string, n int) {
+	t.Helper()
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, `{"ClientHost":"10.0.0.%d","RequestMethod":"GET","RequestPath":"/api/items/%d?q=<x>","DownstreamStatus":200,"Duration":%d,"RouterName":"api@docker","ServiceName":"api","StartUTC":"2024-05-01T12:00:00Z","RequestUserAgent":"Mozilla/5.0 (X11; Linux x86_64)"}`+"\n", i%250, i, 1000000+i)
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+}
+
+func newFormatHandler(t testing.TB, lines int) http.Handler {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "access.log")
+	writeAccessLines(t, path, lines)
+
+	cfg := &config.Config{AccessPath: path}
+	h := NewHandler(cfg, state.NewStateManager(cfg), Features{})
+	return middleware.Apply(middleware.Compress(), h.HandleAccessLogs)
+}
+
+// decodeBody undoes the negotiated Content-Encoding
+func decodeBody(t testing.TB, resp *http.Response) []byte {
+	t.Helper()
+	var r io.Reader = resp.Body
+	switch resp.Header.Get("Content-Encoding") {
+	case "gzip":
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			t.Fatalf("gzip: %v", err)
+		}
+		r = gz
+	case "zstd":
+		dec, err := zstd.NewReader(resp.Body)
+		if err != nil {
+			t.Fatalf("zstd: %v", err)
+		}
+		defer dec.Close()
+		r = dec
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	return data
+}
+
+func TestLogResponseFormats(t *testing.T) {
+	handler := newFormatHandler(t, 20)
+
+	tests := []struct {
+		name     string
+		query    string
+		accept   string
+		encoding string
+		wantType string
+	}{
+		{"json", "", "", "", "application/json"},
+		{"json gzip", "", "", "gzip", "application/json"},
+		{"json zstd parsed", "&parsed=true", "", "zstd", "application/json"},
+		{"ndjson via accept", "", "application/x-ndjson", "", "application/x-ndjson"},
+		{"ndjson via query gzip", "&format=ndjson", "", "gzip", "application/x-ndjson"},
+		{"ndjson parsed zstd", "&format=ndjson&parsed=true", "", "zstd", "application/x-ndjson"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/logs/access?tail=true"+tt.query, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			if tt.encoding != "" {
+				req.Header.Set("Accept-Encoding", tt.encoding+", br")
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			resp := rr.Result()
+
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("expected 200, got %d", resp.StatusCode)
+			}
+			if got := resp.Header.Get("Content-Type"); !strings.HasPrefix(got, tt.wantType) {
+				t.Fatalf("expected content type %s, got %s", tt.wantType, got)
+			}
+			if got := resp.Header.Get("Content-Encoding"); got != tt.encoding {
+				t.Fatalf("expected encoding %q, got %q", tt.encoding, got)
+			}
+
+			parsed := strings.Contains(tt.query, "parsed=true")
+			var entries []json.RawMessage
+			body := decodeBody(t, resp)
+
+			if tt.wantType == "application/json" {
+				var result struct {
+					Logs []json.RawMessage `json:"logs"`
+				}
+				if err := json.Unmarshal(body, &result); err != nil {
+					t.Fatalf("decode: %v", err)
+				}
+				entries = result.Logs
+			} else {
+				scanner := bufio.NewScanner(strings.NewReader(string(body)))
+				var meta struct {
+					Meta *ndjsonMeta `json:"_meta"`
+				}
+				for scanner.Scan() {
+					line := scanner.Bytes()
+					if strings.HasPrefix(string(line), `{"_meta"`) {
+						if err := json.Unmarshal(line, &meta); err != nil {
+							t.Fatalf("decode meta: %v", err)
+						}
+						continue
+					}
+					entries = append(entries, append(json.RawMessage(nil), line...))
+				}
+				if meta.Meta == nil || len(meta.Meta.Positions) != 1 || meta.Meta.Sources[0].Count != 20 {
+					t.Fatalf("unexpected meta record: %+v", meta.Meta)
+				}
+			}
+
+			if len(entries) != 20 {
+				t.Fatalf("expected 20 entries, got %d", len(entries))
+			}
+			for _, entry := range entries {
+				if parsed {
+					var obj map[string]interface{}
+					if err := json.Unmarshal(entry, &obj); err != nil || obj["RouterName"] != "api@docker" {
+						t.Fatalf("expected parsed object, got %s", entry)
+					}
+				} else {
+					var line string
+					if err := json.Unmarshal(entry, &line); err != nil || !strings.Contains(line, "<x>") {
+						t.Fatalf("expected raw string line, got %s", entry)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestNDJSONResumesAfterLimit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	writeAccessLines(t, path, 10)
+
+	cfg := &config.Config{AccessPath: path}
+	h := NewHandler(cfg, state.NewStateManager(cfg), Features{})
+
+	var seen int
+	position := int64(0)
+	for page := 0; page < 5 && seen < 10; page++ {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/logs/access?format=ndjson&lines=3&position=%d", position), nil)
+		rr := httptest.NewRecorder()
+		h.HandleAccessLogs(rr, req)
+
+		lines := strings.Split(strings.TrimSpace(rr.Body.String()), "\n")
+		var meta struct {
+			Meta ndjsonMeta `json:"_meta"`
+		}
+		if err := json.Unmarshal([]byte(lines[len(lines)-1]), &meta); err != nil {
+			t.Fatalf("decode meta: %v", err)
+		}
+		if got := len(lines) - 1; got > 3 {
+			t.Fatalf("page %d returned %d lines, limit 3", page, got)
+		}
+		for _, line := range lines[:len(lines)-1] {
+			if !strings.Contains(line, fmt.Sprintf("/api/items/%d?", seen)) {
+				t.Fatalf("expected item %d, got %s", seen, line)
+			}
+			seen++
+		}
+		position = meta.Meta.Positions[0].Position
+	}
+
+	if seen != 10 {
+		t.Fatalf("expected to page through all 10 lines, saw %d", seen)
+	}
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := map[string]string{
+		"":                        "",
+		"identity":                "",
+		"gzip":                    "gzip",
+		"gzip, deflate, br, zstd": "zstd",
+		"zstd;q=0.5, gzip":        "gzip",
+		"gzip;q=0":                "",
+		"GZIP;q=0.8, zstd;q=0":    "gzip",
+	}
+	for header, want := range tests {
+		if got := middleware.NegotiateEncoding(header); got != want {
+			t.Errorf("NegotiateEncoding(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
+
+// BenchmarkLogFormats compares response size and cost of the log formats.
+// Run with: go test -bench LogFormats -benchmem ./internal/routes/
+func BenchmarkLogFormats(b *testing.B) {
+	handler := newFormatHandler(b, 1000)
+
+	modes := []struct {
+		name     string
+		query    string
+		encoding string
+	}{
+		{"json", "", ""},
+		{"json-gzip", "", "gzip"},
+		{"json-zstd", "", "zstd"},
+		{"json-parsed", "&parsed=true", ""},
+		{"json-parsed-zstd", "&parsed=true", "zstd"},
+		{"ndjson", "&format=ndjson", ""},
+		{"ndjson-gzip", "&format=ndjson", "gzip"},
+		{"ndjson-zstd", "&format=ndjson", "zstd"},
+		{"ndjson-parsed", "&format=ndjson&parsed=true", ""},
+		{"ndjson-parsed-zstd", "&format=ndjson&parsed=true", "zstd"},
+	}
+
+	for _, mode := range modes {
+		b.Run(mode.name, func(b *testing.B) {
+			b.ReportAllocs()
+			var size int
+			for i := 0; i < b.N; i++ {
+				req := httptest.NewRequest("GET", "/api/logs/access?tail=true&lines=1000"+mode.query, nil)
+				if mode.encoding != "" {
+					req.Header.Set("Accept-Encoding", mode.encoding)
+				}
+				rr := httptest.NewRecorder()
+				handler.ServeHTTP(rr, req)
+				size = rr.Body.Len()
+			}
+			b.ReportMetric(float64(size), "resp-bytes")
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/utils"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/export"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logger"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+)
+
+// exportFlushRows is how many rows are written between flushes of an export
+const exportFlushRows = 1000
+
+// Trailers sent after the body of an export
+const (
+	trailerExportRows      = "X-Export-Rows"
+	trailerExportTruncated = "X-Export-Truncated"
+	trailerExportError     = "X-Export-Error"
+)
+
+// errExportDone stops the scan once the export is complete
+var errExportDone = errors.New("export done")
+
+// HandleExport streams the access log entries that match the filters of the
+// request, oldest file first and archives included, as CSV, NDJSON or the
+// columnar format. The response is chunked and flushed as it is written. It
+// ends with a complete row once the row limit or the byte limit is reached;
+// the X-Export-Rows and X-Export-Truncated trailers report how it ended.
+// With tail, the last rows up to the row limit are exported instead of the
+// first ones; the last rows of each source are held in memory until the scan
+// ends, then merged by time.
+func (h *Handler) HandleExport(w http.ResponseWriter, r *http.Request) {
+	sources, err := h.selectSources(r, logs.SourceTypeAccess)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter, err := parseFilter(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	format := strings.ToLower(utils.GetQueryParam(r, "format", export.FormatCSV))
+	columns, err := export.Columns(utils.GetQueryParamList(r, "columns"))
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	limit := utils.GetQueryParamInt(r, "limit", 0)
+	tail := utils.GetQueryParamBool(r, "tail", false) && limit > 0
+	maxBytes := utils.GetQueryParamInt64(r, "max_bytes", 0)
+	if configured := int64(h.config.ExportMaxBytes); configured > 0 && (maxBytes <= 0 || maxBytes > configured) {
+		maxBytes = configured
+	}
+
+	counter := &logs.CountingWriter{W: w}
+	out, err := export.NewWriter(format, counter, columns)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = column.Name
+	}
+	filename := fmt.Sprintf("access-%s%s", time.Now().UTC().Format("20060102T150405Z"), export.Extension(format))
+
+	header := w.Header()
+	header.Set("Content-Type", export.ContentType(format))
+	header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Set("X-Export-Columns", strings.Join(names, ","))
+	header.Set("Trailer", strings.Join([]string{trailerExportRows, trailerExportTruncated, trailerExportError}, ", "))
+	w.WriteHeader(http.StatusOK)
+
+	p := h.pipeline(r, filter, true)
+	rc := http.NewResponseController(w)
+	ctx := r.Context()
+	rows := 0
+	truncated := false
+
+	emit := func(entry *logs.TraefikLog) error {
+		if err := out.Write(entry); err != nil {
+			return err
+		}
+		rows++
+
+		if rows%exportFlushRows == 0 {
+			if err := out.Flush(); err != nil {
+				return err
+			}
+			rc.Flush()
+		}
+
+		if limit > 0 && rows >= limit {
+			return errExportDone
+		}
+		if maxBytes > 0 && counter.N >= maxBytes {
+			truncated = true
+			return errExportDone
+		}
+		return nil
+	}
+
+	// The last rows of each source of a tail export
+	tails := make([]exportTail, len(sources))
+	current := 0
+	write := func(line string) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		entry, err := logs.ParseTraefikLog(line)
+		if err != nil || entry == nil {
+			return nil
+		}
+		p.entry(entry)
+		if !filter.Match(entry) {
+			return nil
+		}
+		if !tail {
+			return emit(entry)
+		}
+		tails[current].push(entry, limit)
+		return nil
+	}
+
+	var exportErr error
+	for i, src := range sources {
+		current = i
+		err := logs.ScanSourceHistory(src, filter.Since, write)
+		if errors.Is(err, errExportDone) {
+			break
+		}
+		if err != nil {
+			exportErr = fmt.Errorf("source %s: %v", src.Name, err)
+			break
+		}
+	}
+	if tail && exportErr == nil {
+		for _, entry := range newestRows(tails, limit) {
+			if err := emit(entry); err != nil {
+				if !errors.Is(err, errExportDone) {
+					exportErr = err
+				}
+				break
+			}
+		}
+	}
+
+	if err := out.Close(); err != nil && exportErr == nil {
+		exportErr = err
+	}
+	rc.Flush()
+
+	header.Set(trailerExportRows, strconv.Itoa(rows))
+	header.Set(trailerExportTruncated, strconv.FormatBool(truncated))
+	if exportErr != nil && ctx.Err() == nil {
+		logger.Log.Printf("Export failed after %d rows: %v", rows, exportErr)
+		header.Set(trailerExportError, exportErr.Error())
+	}
+}
+
+// exportTail keeps the last rows of one source, oldest first from start
+type exportTail struct {
+	rows  []*logs.TraefikLog
+	start int
+}
+
+// push adds entry, dropping the oldest row once limit rows are held
+func (t *exportTail) push(entry *logs.TraefikLog, limit int) {
+	if len(t.rows) < limit {
+		t.rows = append(t.rows, entry)
+		return
+	}
+	t.rows[t.start] = entry
+	t.start = (t.start + 1) % limit
+}
+
+// at returns the i-th oldest row
+func (t *exportTail) at(i int) *logs.TraefikLog {
+	return t.rows[(t.start+i)%len(t.rows)]
+}
+
+// newestRows merges the tails of the sources by StartUTC and returns the last
+// limit rows, oldest first. The rows of each source are taken in file order.
+func newestRows(tails []exportTail, limit int) []*logs.TraefikLog {
+	ends := make([]int, len(tails))
+	for i := range tails {
+		ends[i] = len(tails[i].rows)
+	}
+
+	rows := make([]*logs.TraefikLog, 0, limit)
+	for len(rows) < limit {
+		newest := -1
+		for i := range tails {
+			if ends[i] == 0 {
+				continue
+			}
+			if newest < 0 || tails[i].at(ends[i]-1).StartUTC.After(tails[newest].at(ends[newest]-1).StartUTC) {
+				newest = i
+			}
+		}
+		if newest < 0 {
+			break
+		}
+		ends[newest]--
+		rows = append(rows, tails[newest].at(ends[newest]))
+	}
+
+	for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+		rows[i], rows[j] = rows[j], rows[i]
+	}
+	return rows
+}
@@ -0,0 +1,288 @@
+package routes
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/config"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/state"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/export"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+	"github.com/klauspost/compress/gzip"
+)
+
+// newExportHandler writes an archive and an active file, 50 entries each,
+// one second apart; even entries are 200s from 10.0.0.x, odd ones 503s
+// from 192.168.1.x
+func newExportHandler(t *testing.T) *Handler {
+	t.Helper()
+	dir := t.TempDir()
+
+	entry := func(i int) string {
+		status, client := 200, fmt.Sprintf("10.0.0.%d", i%10)
+		if i%2 == 1 {
+			status, client = 503, fmt.Sprintf("192.168.1.%d", i%10)
+		}
+		return fmt.Sprintf(`{"ClientHost":%q,"RequestMethod":"GET","RequestPath":"/api/items/%d","DownstreamStatus":%d,"Duration":%d,"RouterName":"api@docker","StartUTC":"2024-05-01T12:%02d:%02dZ"}`+"\n",
+			client, i, status, i*1000000, i/60, i%60)
+	}
+
+	var archive, active strings.Builder
+	for i := 0; i < 50; i++ {
+		archive.WriteString(entry(i))
+		active.WriteString(entry(i + 50))
+	}
+
+	f, _ := os.Create(filepath.Join(dir, "access.log.1.gz"))
+	gz := gzip.NewWriter(f)
+	gz.Write([]byte(archive.String()))
+	gz.Close()
+	f.Close()
+	os.WriteFile(filepath.Join(dir, "access.log"), []byte(active.String()+"not a log line\n"), 0644)
+
+	// Make the archive the older file
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(filepath.Join(dir, "access.log.1.gz"), old, old)
+
+	cfg := &config.Config{AccessPath: dir, ExportMaxBytes: 1 << 20}
+	return NewHandler(cfg, state.NewStateManager(cfg), Features{})
+}
+
+func TestHandleExportCSV(t *testing.T) {
+	h := newExportHandler(t)
+
+	tests := []struct {
+		name  string
+		query string
+		paths []string
+	}{
+		{"everything", "", nil},
+		{"status class", "&status=5xx", nil},
+		{"time range and client", "&since=2024-05-01T12:00:40Z&until=2024-05-01T12:01:00Z&client=10.0.0.0/24", nil},
+		{"path and duration", "&path=/api/items/9&min_duration=90ms", []string{"/api/items/90", "/api/items/91", "/api/items/92", "/api/items/93", "/api/items/94", "/api/items/95", "/api/items/96", "/api/items/97", "/api/items/98", "/api/items/99"}},
+	}
+	counts := map[string]int{"everything": 100, "status class": 50, "time range and client": 10}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/logs/export?columns=StartUTC,RequestPath,DownstreamStatus,ClientHost"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			h.HandleExport(rr, req)
+
+			resp := rr.Result()
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", resp.StatusCode, rr.Body.String())
+			}
+			if !strings.Contains(resp.Header.Get("Content-Disposition"), ".csv") {
+				t.Fatalf("unexpected Content-Disposition %q", resp.Header.Get("Content-Disposition"))
+			}
+
+			records, err := csv.NewReader(resp.Body).ReadAll()
+			if err != nil {
+				t.Fatalf("read csv: %v", err)
+			}
+			if strings.Join(records[0], ",") != "StartUTC,RequestPath,DownstreamStatus,ClientHost" {
+				t.Fatalf("unexpected header %v", records[0])
+			}
+
+			rows := records[1:]
+			if tt.paths != nil {
+				if len(rows) != len(tt.paths) {
+					t.Fatalf("expected %d rows, got %d", len(tt.paths), len(rows))
+				}
+				for i, row := range rows {
+					if row[1] != tt.paths[i] {
+						t.Fatalf("row %d: expected %s, got %s", i, tt.paths[i], row[1])
+					}
+				}
+			} else if len(rows) != counts[tt.name] {
+				t.Fatalf("expected %d rows, got %d", counts[tt.name], len(rows))
+			}
+
+			// Archive first, then the active file
+			if tt.name == "everything" && (rows[0][1] != "/api/items/0" || rows[99][1] != "/api/items/99") {
+				t.Fatalf("unexpected order: first %v, last %v", rows[0], rows[99])
+			}
+			if got := resp.Trailer.Get(trailerExportRows); got != fmt.Sprint(len(rows)) {
+				t.Fatalf("expected rows trailer %d, got %q", len(rows), got)
+			}
+		})
+	}
+}
+
+func TestHandleExportColumnar(t *testing.T) {
+	h := newExportHandler(t)
+
+	req := httptest.NewRequest("GET", "/api/logs/export?format=columnar&columns=RequestPath,Duration&status=200&limit=7", nil)
+	rr := httptest.NewRecorder()
+	h.HandleExport(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	r, err := export.NewReader(rr.Body)
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	var rows [][]interface{}
+	for {
+		row, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("next: %v", err)
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) != 7 || rows[6][0] != "/api/items/12" || rows[6][1] != int64(12000000) {
+		t.Fatalf("unexpected rows %v", rows)
+	}
+}
+
+func TestHandleExportLimits(t *testing.T) {
+	h := newExportHandler(t)
+	h.config.ExportMaxBytes = 300
+
+	req := httptest.NewRequest("GET", "/api/logs/export?format=ndjson&max_bytes=100000", nil)
+	rr := httptest.NewRecorder()
+	h.HandleExport(rr, req)
+
+	resp := rr.Result()
+	if resp.Trailer.Get(trailerExportTruncated) != "true" {
+		t.Fatalf("expected a truncated export, trailers %v", resp.Trailer)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.HasSuffix(string(body), "}\n") {
+		t.Fatalf("expected the export to end on a complete row, got %q", body)
+	}
+	// The configured limit caps the requested one; output is buffered in
+	// chunks, so allow one chunk past it
+	if len(body) > 300+4096 {
+		t.Fatalf("export of %d bytes ignored the configured limit", len(body))
+	}
+}
+
+func TestHandleExportTail(t *testing.T) {
+	h := newExportHandler(t)
+
+	tests := []struct {
+		query string
+		first string
+		last  string
+		rows  int
+	}{
+		{"limit=3", "/api/items/0", "/api/items/2", 3},
+		{"limit=3&tail=true", "/api/items/97", "/api/items/99", 3},
+		{"limit=60&tail=true", "/api/items/40", "/api/items/99", 60},
+		{"limit=2&tail=true&status=5xx", "/api/items/97", "/api/items/99", 2},
+		{"limit=500&tail=true", "/api/items/0", "/api/items/99", 100},
+		// Without a limit there is nothing to leave out
+		{"tail=true", "/api/items/0", "/api/items/99", 100},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/api/logs/export?columns=RequestPath&"+tt.query, nil)
+		rr := httptest.NewRecorder()
+		h.HandleExport(rr, req)
+
+		records, err := csv.NewReader(rr.Result().Body).ReadAll()
+		if err != nil {
+			t.Fatalf("%s: read csv: %v", tt.query, err)
+		}
+		rows := records[1:]
+		if len(rows) != tt.rows || rows[0][0] != tt.first || rows[len(rows)-1][0] != tt.last {
+			t.Errorf("%s: %d rows from %v to %v, want %d from %s to %s", tt.query, len(rows), rows[0], rows[len(rows)-1], tt.rows, tt.first, tt.last)
+		}
+	}
+}
+
+func TestHandleExportTailSources(t *testing.T) {
+	// Two sources whose entries interleave in time: even seconds in edge,
+	// odd ones in internal
+	dir := t.TempDir()
+	var edge, internal strings.Builder
+	for i := 0; i < 20; i++ {
+		line := fmt.Sprintf(`{"RequestPath":"/t/%d","DownstreamStatus":200,"StartUTC":"2024-05-01T12:00:%02dZ"}`+"\n", i, i)
+		if i%2 == 0 {
+			edge.WriteString(line)
+		} else {
+			internal.WriteString(line)
+		}
+	}
+	os.WriteFile(filepath.Join(dir, "edge.log"), []byte(edge.String()), 0644)
+	os.WriteFile(filepath.Join(dir, "internal.log"), []byte(internal.String()), 0644)
+
+	cfg := &config.Config{
+		Sources: []logs.Source{
+			{Name: "edge", Path: filepath.Join(dir, "edge.log"), Type: logs.SourceTypeAccess},
+			{Name: "internal", Path: filepath.Join(dir, "internal.log"), Type: logs.SourceTypeAccess},
+		},
+		ExportMaxBytes: 1 << 20,
+	}
+	h := NewHandler(cfg, state.NewStateManager(cfg), Features{})
+
+	req := httptest.NewRequest("GET", "/api/logs/export?columns=RequestPath&limit=5&tail=true", nil)
+	rr := httptest.NewRecorder()
+	h.HandleExport(rr, req)
+
+	records, err := csv.NewReader(rr.Result().Body).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	var got []string
+	for _, row := range records[1:] {
+		got = append(got, row[0])
+	}
+	want := "/t/15 /t/16 /t/17 /t/18 /t/19"
+	if strings.Join(got, " ") != want {
+		t.Errorf("rows %v, want %s", got, want)
+	}
+}
+
+func TestHandleExportRejectsBadParams(t *testing.T) {
+	h := newExportHandler(t)
+
+	for _, query := range []string{
+		"format=xlsx",
+		"columns=Nope",
+		"status=abc",
+		"since=yesterday",
+		"since=2024-05-02T00:00:00Z&until=2024-05-01T00:00:00Z",
+		"client=10.0.0.0/99",
+		"min_duration=fast",
+	} {
+		req := httptest.NewRequest("GET", "/api/logs/export?"+query, nil)
+		rr := httptest.NewRecorder()
+		h.HandleExport(rr, req)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, rr.Code)
+		}
+	}
+}
+
+func TestAccessLogsFilter(t *testing.T) {
+	h := newExportHandler(t)
+
+	for _, format := range []string{"json", "ndjson"} {
+		req := httptest.NewRequest("GET", "/api/logs/access?tail=true&status=503&router=api@*&format="+format, nil)
+		rr := httptest.NewRecorder()
+		h.HandleAccessLogs(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", format, rr.Code)
+		}
+		if n := strings.Count(rr.Body.String(), `DownstreamStatus\":503`); n != 25 {
+			t.Fatalf("%s: expected 25 matching lines, got %d", format, n)
+		}
+		if strings.Contains(rr.Body.String(), `DownstreamStatus\":200`) {
+			t.Fatalf("%s: filtered lines leaked into the response", format)
+		}
+	}
+}
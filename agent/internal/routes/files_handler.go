@@ -0,0 +1,251 @@
+package routes
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/utils"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logger"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+)
+
+// sourceFiles lists the files of one source
+type sourceFiles struct {
+	Name   string             `json:"name"`
+	Type   string             `json:"type"`
+	Labels map[string]string  `json:"labels,omitempty"`
+	Files  []logs.LogFileInfo `json:"files"`
+}
+
+// HandleListFiles lists the files of every selected source with their size,
+// modification time, compression, line count and time span
+func (h *Handler) HandleListFiles(w http.ResponseWriter, r *http.Request) {
+	sources, err := h.selectSources(r, "")
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result := make([]sourceFiles, 0, len(sources))
+	for _, src := range sources {
+		files, err := src.ListFiles()
+		if err != nil {
+			// A missing path should not hide the other sources
+			logger.Log.Printf("Error listing files of source %s: %v", src.Name, err)
+			files = []logs.LogFileInfo{}
+		}
+		result = append(result, sourceFiles{
+			Name:   src.Name,
+			Type:   src.Type,
+			Labels: src.Labels,
+			Files:  files,
+		})
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"sources": result,
+	})
+}
+
+// HandleDownloadFile serves the raw bytes of one file of a source. A single
+// byte range may be requested with the Range header; without one the file must
+// fit within the download limit. Uncompressed files are gzip encoded for
+// clients that accept it. Scopes whose data is redacted cannot download.
+func (h *Handler) HandleDownloadFile(w http.ResponseWriter, r *http.Request) {
+	// Byte ranges of raw files cannot be redacted
+	if h.redactor(r) != nil {
+		utils.RespondError(w, http.StatusForbidden, "raw file downloads are not available to this token scope")
+		return
+	}
+
+	src, err := h.selectFileSource(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	path, ok := h.resolveFile(w, r, src)
+	if !ok {
+		return
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		utils.RespondError(w, http.StatusNotFound, logs.ErrFileNotFound.Error())
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	name := filepath.Base(path)
+	w.Header().Set("Content-Type", contentTypeOf(name))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	limit := int64(h.config.DownloadMaxBytes)
+
+	if rangeHeader := r.Header.Get("Range"); rangeHeader != "" {
+		start, end, err := parseByteRange(rangeHeader, info.Size())
+		if err != nil {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", info.Size()))
+			utils.RespondError(w, http.StatusRequestedRangeNotSatisfiable, err.Error())
+			return
+		}
+		// Oversized ranges are shortened; Content-Range tells the client
+		// what it received
+		if limit > 0 && end-start+1 > limit {
+			end = start + limit - 1
+		}
+		r.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+		http.ServeContent(w, r, name, info.ModTime(), f)
+		return
+	}
+
+	if limit > 0 && info.Size() > limit {
+		utils.RespondError(w, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("file is %d bytes, more than the download limit of %d bytes; request a byte range instead", info.Size(), limit))
+		return
+	}
+
+	if logs.IsCompressed(name) || !acceptsGzip(r) {
+		http.ServeContent(w, r, name, info.ModTime(), f)
+		return
+	}
+
+	w.Header().Set("Content-Encoding", "gzip")
+	w.Header().Add("Vary", "Accept-Encoding")
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	gz := gzip.NewWriter(w)
+	if _, err := io.Copy(gz, io.LimitReader(f, info.Size())); err != nil {
+		logger.Log.Printf("Error sending %s: %v", name, err)
+	}
+	gz.Close()
+}
+
+// selectFileSource returns the single source named by the "source" query
+// parameter, or the first access source when none is named
+func (h *Handler) selectFileSource(r *http.Request) (logs.Source, error) {
+	if len(utils.GetQueryParamList(r, "source")) == 0 {
+		return h.selectStreamSource(r)
+	}
+	sources, err := h.selectSources(r, "")
+	if err != nil {
+		return logs.Source{}, err
+	}
+	if len(sources) > 1 {
+		return logs.Source{}, fmt.Errorf("only one source may be selected")
+	}
+	return sources[0], nil
+}
+
+// resolveFile confines the "filename" query parameter to the source and
+// writes the error response when it cannot be served
+func (h *Handler) resolveFile(w http.ResponseWriter, r *http.Request, src logs.Source) (string, bool) {
+	filename := utils.GetQueryParam(r, "filename", "")
+	if filename == "" {
+		utils.RespondError(w, http.StatusBadRequest, "filename parameter is required")
+		return "", false
+	}
+
+	path, err := src.Resolve(filename)
+	switch {
+	case errors.Is(err, logs.ErrOutsideRoot):
+		logger.Log.Printf("Rejected file outside source %s: %q", src.Name, filename)
+		utils.RespondError(w, http.StatusForbidden, err.Error())
+		return "", false
+	case errors.Is(err, logs.ErrFileNotFound):
+		utils.RespondError(w, http.StatusNotFound, err.Error())
+		return "", false
+	case err != nil:
+		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		return "", false
+	}
+	return path, true
+}
+
+// parseByteRange parses a Range header holding a single byte range
+func parseByteRange(header string, size int64) (int64, int64, error) {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return 0, 0, fmt.Errorf("only a single byte range is supported")
+	}
+
+	first, last, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid range %q", spec)
+	}
+
+	var start, end int64
+	switch {
+	case first == "":
+		// Suffix range: the last n bytes
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n <= 0 {
+			return 0, 0, fmt.Errorf("invalid range %q", spec)
+		}
+		if n > size {
+			n = size
+		}
+		start, end = size-n, size-1
+	default:
+		var err error
+		start, err = strconv.ParseInt(first, 10, 64)
+		if err != nil || start < 0 {
+			return 0, 0, fmt.Errorf("invalid range %q", spec)
+		}
+		end = size - 1
+		if last != "" {
+			end, err = strconv.ParseInt(last, 10, 64)
+			if err != nil || end < start {
+				return 0, 0, fmt.Errorf("invalid range %q", spec)
+			}
+			if end >= size {
+				end = size - 1
+			}
+		}
+	}
+
+	if start >= size {
+		return 0, 0, fmt.Errorf("range starts beyond the end of the file")
+	}
+	return start, end, nil
+}
+
+func acceptsGzip(r *http.Request) bool {
+	for _, enc := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(enc), ";")
+		if strings.EqualFold(strings.TrimSpace(name), "gzip") && strings.TrimSpace(params) != "q=0" {
+			return true
+		}
+	}
+	return false
+}
+
+func contentTypeOf(name string) string {
+	switch logs.CompressionOf(name) {
+	case logs.CompressionGzip:
+		return "application/gzip"
+	case logs.CompressionZstd:
+		return "application/zstd"
+	case logs.CompressionBzip2:
+		return "application/x-bzip2"
+	default:
+		return "text/plain; charset=utf-8"
+	}
+}
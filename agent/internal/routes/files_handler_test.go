@@ -0,0 +1,207 @@
+package routes
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/config"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/state"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+)
+
+func newFilesHandler(t *testing.T) (*Handler, string) {
+	t.Helper()
+	base := t.TempDir()
+	root := filepath.Join(base, "traefik")
+	os.MkdirAll(root, 0755)
+	os.WriteFile(filepath.Join(root, "access.log"), []byte(strings.Repeat("0123456789\n", 100)), 0644)
+	os.WriteFile(filepath.Join(base, "secret.log"), []byte("secret\n"), 0644)
+	os.Symlink(filepath.Join(base, "secret.log"), filepath.Join(root, "leak.log"))
+
+	cfg := &config.Config{
+		AccessPath:       root,
+		ErrorPath:        root,
+		DownloadMaxBytes: 500,
+	}
+	return NewHandler(cfg, state.NewStateManager(cfg), Features{}), base
+}
+
+func TestFileEndpointsRejectEscapes(t *testing.T) {
+	h, base := newFilesHandler(t)
+
+	names := []string{
+		"../secret.log",
+		"..%2Fsecret.log",
+		filepath.Join(base, "secret.log"),
+		"leak.log",
+		"../../../../etc/passwd",
+	}
+	endpoints := map[string]http.HandlerFunc{
+		"get":      h.HandleGetLog,
+		"download": h.HandleDownloadFile,
+	}
+
+	for endpoint, handle := range endpoints {
+		for _, name := range names {
+			t.Run(endpoint+" "+name, func(t *testing.T) {
+				req := httptest.NewRequest("GET", "/?filename="+url.QueryEscape(name), nil)
+				rr := httptest.NewRecorder()
+				handle(rr, req)
+
+				if rr.Code != http.StatusForbidden && rr.Code != http.StatusNotFound {
+					t.Fatalf("expected 403 or 404, got %d: %s", rr.Code, rr.Body.String())
+				}
+				if strings.Contains(rr.Body.String(), "secret") {
+					t.Fatalf("leaked file contents: %s", rr.Body.String())
+				}
+			})
+		}
+	}
+}
+
+func TestHandleDownloadFile(t *testing.T) {
+	h, _ := newFilesHandler(t)
+	h.config.DownloadMaxBytes = 2000
+
+	tests := []struct {
+		name     string
+		header   map[string]string
+		status   int
+		encoding string
+		body     string
+	}{
+		{"plain", nil, 200, "", strings.Repeat("0123456789\n", 100)},
+		{"gzip", map[string]string{"Accept-Encoding": "gzip"}, 200, "gzip", strings.Repeat("0123456789\n", 100)},
+		{"range", map[string]string{"Range": "bytes=11-21"}, 206, "", "0123456789\n"},
+		{"suffix range", map[string]string{"Range": "bytes=-5"}, 206, "", "6789\n"},
+		{"unsatisfiable", map[string]string{"Range": "bytes=5000-"}, 416, "", ""},
+		{"multiple ranges", map[string]string{"Range": "bytes=0-1,5-6"}, 416, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/logs/files/download?source=access&filename=access.log", nil)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			rr := httptest.NewRecorder()
+			h.HandleDownloadFile(rr, req)
+
+			if rr.Code != tt.status {
+				t.Fatalf("expected %d, got %d: %s", tt.status, rr.Code, rr.Body.String())
+			}
+			if tt.body == "" {
+				return
+			}
+			if got := rr.Header().Get("Content-Encoding"); got != tt.encoding {
+				t.Fatalf("expected encoding %q, got %q", tt.encoding, got)
+			}
+
+			var body io.Reader = rr.Body
+			if tt.encoding == "gzip" {
+				gz, err := gzip.NewReader(rr.Body)
+				if err != nil {
+					t.Fatalf("gzip: %v", err)
+				}
+				body = gz
+			}
+			data, _ := io.ReadAll(body)
+			if string(data) != tt.body {
+				t.Fatalf("unexpected body %q", data)
+			}
+		})
+	}
+}
+
+func TestHandleDownloadFileLimit(t *testing.T) {
+	h, _ := newFilesHandler(t)
+
+	req := httptest.NewRequest("GET", "/api/logs/files/download?filename=access.log", nil)
+	rr := httptest.NewRecorder()
+	h.HandleDownloadFile(rr, req)
+	if rr.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 above the limit, got %d", rr.Code)
+	}
+
+	// Ranges are shortened to the limit
+	req = httptest.NewRequest("GET", "/api/logs/files/download?filename=access.log", nil)
+	req.Header.Set("Range", "bytes=0-")
+	rr = httptest.NewRecorder()
+	h.HandleDownloadFile(rr, req)
+	if rr.Code != http.StatusPartialContent || rr.Body.Len() != 500 {
+		t.Fatalf("expected 500 byte partial response, got %d with %d bytes", rr.Code, rr.Body.Len())
+	}
+	if got := rr.Header().Get("Content-Range"); got != "bytes 0-499/1100" {
+		t.Fatalf("unexpected Content-Range %q", got)
+	}
+}
+
+func TestHandleListFiles(t *testing.T) {
+	h, _ := newFilesHandler(t)
+
+	req := httptest.NewRequest("GET", "/api/logs/files?source=access", nil)
+	rr := httptest.NewRecorder()
+	h.HandleListFiles(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var result struct {
+		Sources []struct {
+			Name  string `json:"name"`
+			Files []struct {
+				Name       string `json:"name"`
+				Size       int64  `json:"size"`
+				Lines      int64  `json:"lines"`
+				LinesExact bool   `json:"lines_exact"`
+			} `json:"files"`
+		} `json:"sources"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(result.Sources) != 1 || len(result.Sources[0].Files) != 1 {
+		t.Fatalf("expected only access.log to be listed, got %+v", result)
+	}
+	file := result.Sources[0].Files[0]
+	if file.Name != "access.log" || file.Size != 1100 || file.Lines != 100 || !file.LinesExact {
+		t.Fatalf("unexpected file info %+v", file)
+	}
+}
+
+func TestHandleGetLogPages(t *testing.T) {
+	h, _ := newFilesHandler(t)
+
+	// Each page resumes where the previous one stopped
+	position := int64(0)
+	for _, want := range []int{30, 30, 30, 10, 0} {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/logs/file?filename=access.log&lines=30&position=%d", position), nil)
+		rr := httptest.NewRecorder()
+		h.HandleGetLog(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+
+		var result logs.LogResult
+		if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if len(result.Logs) != want || len(result.Positions) != 1 {
+			t.Fatalf("at %d: expected %d lines and one position, got %d and %d", position, want, len(result.Logs), len(result.Positions))
+		}
+		next := position + int64(11*want)
+		if result.Positions[0].Position != next {
+			t.Fatalf("at %d: expected next position %d, got %d", position, next, result.Positions[0].Position)
+		}
+		position = next
+	}
+}
@@ -0,0 +1,91 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/utils"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/useragent"
+)
+
+// parseFilter reads the time range and field filters shared by the access log
+// and export endpoints
+func parseFilter(r *http.Request) (logs.Filter, error) {
+	var filter logs.Filter
+	now := time.Now()
+
+	for key, bound := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		value := utils.GetQueryParam(r, key, "")
+		if value == "" {
+			continue
+		}
+		ts, err := logs.ParseTimeBound(value, now)
+		if err != nil {
+			return filter, fmt.Errorf("%s: %v", key, err)
+		}
+		*bound = ts
+	}
+	if !filter.Since.IsZero() && !filter.Until.IsZero() && !filter.Since.Before(filter.Until) {
+		return filter, fmt.Errorf("since must be before until")
+	}
+
+	for _, value := range utils.GetQueryParamList(r, "status") {
+		status, err := logs.ParseStatusRange(value)
+		if err != nil {
+			return filter, err
+		}
+		filter.Statuses = append(filter.Statuses, status)
+	}
+
+	for _, value := range utils.GetQueryParamList(r, "client") {
+		network, err := logs.ParseClientNetwork(value)
+		if err != nil {
+			return filter, err
+		}
+		filter.Clients = append(filter.Clients, network)
+	}
+
+	for key, bound := range map[string]*time.Duration{"min_duration": &filter.MinDuration, "max_duration": &filter.MaxDuration} {
+		value := utils.GetQueryParam(r, key, "")
+		if value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			return filter, fmt.Errorf("%s: invalid duration %q", key, value)
+		}
+		*bound = d
+	}
+
+	for _, method := range utils.GetQueryParamList(r, "method") {
+		filter.Methods = append(filter.Methods, strings.ToUpper(method))
+	}
+	filter.Routers = utils.GetQueryParamList(r, "router")
+	filter.Services = utils.GetQueryParamList(r, "service")
+	filter.Hosts = utils.GetQueryParamList(r, "host")
+	filter.Paths = utils.GetQueryParamList(r, "path")
+	for _, class := range utils.GetQueryParamList(r, "ua_class") {
+		if !containsString(useragent.Classes, strings.ToLower(class)) {
+			return filter, fmt.Errorf("ua_class: unknown class %q, use one of %s", class, strings.Join(useragent.Classes, ", "))
+		}
+		filter.UserAgentClasses = append(filter.UserAgentClasses, class)
+	}
+	filter.UserAgentNames = utils.GetQueryParamList(r, "ua_name")
+	filter.Browsers = utils.GetQueryParamList(r, "browser")
+	filter.OSes = utils.GetQueryParamList(r, "os")
+	filter.Devices = utils.GetQueryParamList(r, "device")
+
+	return filter, nil
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
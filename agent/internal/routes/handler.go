@@ -1,23 +1,82 @@
 package routes
 
 import (
+	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/config"
 	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/state"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/blocklist"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/geoip"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/health"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/redact"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/security"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/slo"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/traefik"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/useragent"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/watcher"
 )
 
+// Features are the optional parts of the agent, built from the
+// configuration at startup. A nil field is a disabled feature.
+type Features struct {
+	// Redaction profiles per token scope; nil leaves data untouched
+	Redaction *redact.Policy
+	// GeoIP enrichment; nil when no database is available
+	GeoIP *geoip.DB
+	// User agent classification
+	UserAgents *useragent.Classifier
+	// Security event detection
+	Security *security.Detector
+	// Backend health tracking
+	Health *health.Tracker
+	// Service level objectives; nil when none are declared
+	SLO *slo.Tracker
+	// Blocklist rendered as Traefik dynamic configuration
+	Blocklist *blocklist.Manager
+	// Traefik API client for the router and service topology
+	Traefik *traefik.Client
+}
+
 // Handler manages HTTP routes and dependencies
 type Handler struct {
 	config        *config.Config
+	features      Features
 	state         *state.StateManager
 	streamClients atomic.Int32
+
+	watcherOnce sync.Once
+	watcher     *watcher.Hub
 }
 
-// NewHandler creates a new Handler with the given configuration
-func NewHandler(cfg *config.Config, sm *state.StateManager) *Handler {
+// NewHandler creates a new Handler with the given configuration and
+// features
+func NewHandler(cfg *config.Config, sm *state.StateManager, features Features) *Handler {
 	return &Handler{
-		config: cfg,
-		state:  sm,
+		config:   cfg,
+		features: features,
+		state:    sm,
+	}
+}
+
+// fileWatcher returns the shared file watcher, starting it on first use
+func (h *Handler) fileWatcher() *watcher.Hub {
+	h.watcherOnce.Do(func() {
+		h.watcher = watcher.New(watcher.Options{
+			Mode:         h.config.WatchMode,
+			PollInterval: time.Duration(h.config.WatchPollIntervalMS) * time.Millisecond,
+		})
+	})
+	return h.watcher
+}
+
+// Close releases resources held by the handler
+func (h *Handler) Close() error {
+	var err error
+	h.watcherOnce.Do(func() {})
+	if h.watcher != nil {
+		err = h.watcher.Close()
 	}
+	return err
 }
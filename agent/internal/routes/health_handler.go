@@ -0,0 +1,75 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/utils"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/health"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+)
+
+// HandleBackends returns the health of every backend server seen within
+// the longest window, worst first. service (globs) and status narrow the
+// list.
+func (h *Handler) HandleBackends(w http.ResponseWriter, r *http.Request) {
+	tracker := h.features.Health
+	if tracker == nil {
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"status":   "disabled",
+			"message":  "Backend health tracking is disabled",
+			"backends": []health.Backend{},
+		})
+		return
+	}
+
+	services := utils.GetQueryParamList(r, "service")
+	statuses := utils.GetQueryParamList(r, "status")
+	for _, status := range statuses {
+		if !health.ValidStatus(status) {
+			utils.RespondError(w, http.StatusBadRequest, fmt.Sprintf("status: unknown status %q, use one of %s", status, strings.Join(health.Statuses, ", ")))
+			return
+		}
+	}
+
+	backends := make([]health.Backend, 0)
+	counts := make(map[string]int, len(health.Statuses))
+	for _, status := range health.Statuses {
+		counts[status] = 0
+	}
+	for _, b := range tracker.Backends() {
+		if !matchService(services, b.Service) {
+			continue
+		}
+		counts[b.Status]++
+		if len(statuses) > 0 && !containsString(statuses, b.Status) {
+			continue
+		}
+		backends = append(backends, b)
+	}
+
+	cfg := tracker.Config()
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"status":        "ok",
+		"windows":       tracker.WindowNames(),
+		"status_window": health.WindowName(cfg.StatusWindow),
+		"counts":        counts,
+		"backends":      backends,
+	})
+}
+
+// matchService reports whether a service name matches any of the globs, or
+// whether there are none
+func matchService(patterns []string, service string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	service = strings.ToLower(service)
+	for _, pattern := range patterns {
+		if logs.MatchGlob(strings.ToLower(pattern), service) {
+			return true
+		}
+	}
+	return false
+}
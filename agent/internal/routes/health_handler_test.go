@@ -0,0 +1,116 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/config"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/state"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/health"
+)
+
+func TestHandleBackends(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "access.log")
+	os.WriteFile(logPath, nil, 0644)
+
+	tracker, err := health.New(health.DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{
+		AccessPath:             logPath,
+		StreamBatchLines:       100,
+		StreamFlushIntervalMS:  50,
+		StreamMaxBytesPerBatch: 64 * 1024,
+		WatchMode:              "poll",
+		WatchPollIntervalMS:    20,
+	}
+	h := NewHandler(cfg, state.NewStateManager(cfg), Features{Health: tracker})
+	defer h.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		h.RunAnalysis(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// Give the analysis time to start at the end of the file
+	time.Sleep(200 * time.Millisecond)
+	var lines strings.Builder
+	now := time.Now().UTC()
+	for i := 0; i < 20; i++ {
+		ts := now.Add(-time.Duration(i) * time.Second).Format(time.RFC3339Nano)
+		fmt.Fprintf(&lines, `{"ServiceName":"api@docker","ServiceURL":"http://10.0.0.1:80","DownstreamStatus":200,"OriginDuration":20000000,"StartUTC":%q}`+"\n", ts)
+		fmt.Fprintf(&lines, `{"ServiceName":"api@docker","ServiceURL":"http://10.0.0.2:80","DownstreamStatus":502,"RetryAttempts":1,"StartUTC":%q}`+"\n", ts)
+		fmt.Fprintf(&lines, `{"ServiceName":"web@file","ServiceURL":"http://10.0.0.3:80","DownstreamStatus":200,"OriginDuration":5000000,"StartUTC":%q}`+"\n", ts)
+	}
+	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(lines.String())
+	f.Close()
+
+	type response struct {
+		Windows  []string         `json:"windows"`
+		Counts   map[string]int   `json:"counts"`
+		Backends []health.Backend `json:"backends"`
+	}
+	get := func(query string) (int, response) {
+		w := httptest.NewRecorder()
+		h.HandleBackends(w, httptest.NewRequest(http.MethodGet, "/api/backends"+query, nil))
+		var resp response
+		json.NewDecoder(w.Body).Decode(&resp)
+		return w.Code, resp
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	var resp response
+	for {
+		_, resp = get("")
+		if len(resp.Backends) == 3 && resp.Backends[0].Windows["1m"].Requests == 20 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("backends not tracked: %+v", resp)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	down := resp.Backends[0]
+	if down.URL != "http://10.0.0.2:80" || down.Status != health.StatusDown || down.Windows["1m"].BadGateway != 20 || down.Windows["1m"].Retries != 20 {
+		t.Errorf("unexpected first backend %+v", down)
+	}
+	if resp.Counts[health.StatusDown] != 1 || resp.Counts[health.StatusHealthy] != 2 {
+		t.Errorf("unexpected counts %v", resp.Counts)
+	}
+	if strings.Join(resp.Windows, ",") != "1m,5m,15m" {
+		t.Errorf("unexpected windows %v", resp.Windows)
+	}
+
+	_, resp = get("?service=api@*&status=healthy")
+	if len(resp.Backends) != 1 || resp.Backends[0].URL != "http://10.0.0.1:80" {
+		t.Errorf("filtered backends: %+v", resp.Backends)
+	}
+	if p95 := resp.Backends[0].Windows["5m"].P95MS; p95 < 15 || p95 > 25 {
+		t.Errorf("p95 = %v, want about 20", p95)
+	}
+
+	if code, _ := get("?status=broken"); code != http.StatusBadRequest {
+		t.Errorf("invalid status: got %d", code)
+	}
+}
@@ -0,0 +1,91 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/utils"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/latency"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+)
+
+// HandleLatency builds a latency heatmap from the access log entries that
+// match the filters of the request, archives included. step, metric
+// (duration, origin or overhead), group_by (router or service), top and
+// rows_per_doubling shape it.
+func (h *Handler) HandleLatency(w http.ResponseWriter, r *http.Request) {
+	sources, err := h.selectSources(r, logs.SourceTypeAccess)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter, err := parseFilter(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if filter.Since.IsZero() {
+		filter.Since = time.Now().Add(-defaultStatsWindow)
+	}
+	until := filter.Until
+	if until.IsZero() {
+		until = time.Now()
+	}
+
+	var step time.Duration
+	if value := utils.GetQueryParam(r, "step", ""); value != "" {
+		if step, err = time.ParseDuration(value); err != nil || step <= 0 {
+			utils.RespondError(w, http.StatusBadRequest, fmt.Sprintf("step: invalid duration %q", value))
+			return
+		}
+	}
+
+	top := utils.GetQueryParamInt(r, "top", latency.DefaultTop)
+	if top <= 0 || top > maxStatsTop {
+		utils.RespondError(w, http.StatusBadRequest, fmt.Sprintf("top must be between 1 and %d", maxStatsTop))
+		return
+	}
+
+	heatmap, err := latency.New(latency.Options{
+		Since:           filter.Since,
+		Until:           until,
+		Step:            step,
+		Metric:          utils.GetQueryParam(r, "metric", latency.MetricDuration),
+		GroupBy:         utils.GetQueryParam(r, "group_by", ""),
+		Top:             top,
+		RowsPerDoubling: utils.GetQueryParamInt(r, "rows_per_doubling", 4),
+	})
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	p := h.pipeline(r, filter, true)
+	ctx := r.Context()
+
+	add := func(line string) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		entry, err := logs.ParseTraefikLog(line)
+		if err != nil || entry == nil {
+			return nil
+		}
+		p.entry(entry)
+		if filter.Match(entry) {
+			heatmap.Add(entry)
+		}
+		return nil
+	}
+
+	for _, src := range sources {
+		if err := logs.ScanSourceHistory(src, filter.Since, add); err != nil {
+			utils.RespondError(w, http.StatusInternalServerError, fmt.Sprintf("source %s: %v", src.Name, err))
+			return
+		}
+	}
+
+	utils.RespondJSON(w, http.StatusOK, heatmap.Result())
+}
@@ -0,0 +1,54 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/latency"
+)
+
+func TestHandleLatency(t *testing.T) {
+	h := newExportHandler(t)
+
+	rr := httptest.NewRecorder()
+	h.HandleLatency(rr, httptest.NewRequest(http.MethodGet, "/api/latency?since=2024-05-01T12:00:00Z&until=2024-05-01T12:02:00Z&step=1m&group_by=router&status=2xx", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp latency.Result
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Overall.Requests != 50 || len(resp.Overall.Columns) != 2 || resp.Overall.Columns[0].Requests != 30 {
+		t.Fatalf("overall %+v", resp.Overall)
+	}
+	if len(resp.Groups) != 1 || resp.Groups[0].Key != "api@docker" {
+		t.Errorf("groups %+v", resp.Groups)
+	}
+	// Even entries take 0 to 98ms
+	if max := resp.Overall.Breakdown.Duration.MaxMS; max != 98 {
+		t.Errorf("max = %v, want 98", max)
+	}
+	var total uint64
+	for _, c := range resp.Overall.Columns {
+		for _, n := range c.Counts {
+			total += n
+		}
+		if len(c.Counts) != len(resp.Rows) {
+			t.Errorf("column has %d counts for %d rows", len(c.Counts), len(resp.Rows))
+		}
+	}
+	if total != 50 {
+		t.Errorf("counts add up to %d", total)
+	}
+
+	for _, query := range []string{"?step=soon", "?metric=ttfb", "?group_by=client", "?top=0", "?since=2024-05-01T00:00:00Z&until=2024-05-02T00:00:00Z&step=1s"} {
+		rr := httptest.NewRecorder()
+		h.HandleLatency(rr, httptest.NewRequest(http.MethodGet, "/api/latency"+query, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: got %d", query, rr.Code)
+		}
+	}
+}
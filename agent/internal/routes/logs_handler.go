@@ -2,8 +2,8 @@ package routes
 
 import (
 	"context"
+	"fmt"
 	"net/http"
-	"os"
 	"path/filepath"
 	"strings"
 	"time"
@@ -11,159 +11,215 @@ import (
 	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/utils"
 	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logger"
 	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/security"
 )
 
 // HandleAccessLogs handles requests for access logs
 func (h *Handler) HandleAccessLogs(w http.ResponseWriter, r *http.Request) {
+	h.handleSourceLogs(w, r, logs.SourceTypeAccess, 1000)
+}
+
+// HandleErrorLogs handles requests for error logs
+func (h *Handler) HandleErrorLogs(w http.ResponseWriter, r *http.Request) {
+	h.handleSourceLogs(w, r, logs.SourceTypeError, 100)
+}
+
+// handleSourceLogs reads new lines from the selected sources of a type and
+// merges them into one result. Each source's lines are contiguous and located
+// by the Sources ranges of the result.
+func (h *Handler) handleSourceLogs(w http.ResponseWriter, r *http.Request, sourceType string, defaultLines int) {
 	// Get query parameters
 	position := utils.GetQueryParamInt64(r, "position", -2) // -2 means use tracked position
-	lines := utils.GetQueryParamInt(r, "lines", 1000)
+	lines := utils.GetQueryParamInt(r, "lines", defaultLines)
 	tail := utils.GetQueryParamBool(r, "tail", false)
+	includeCompressed := utils.GetQueryParamBool(r, "include_compressed", false)
+	parsed := utils.GetQueryParamBool(r, "parsed", false)
 
-	// Check if path exists
-	fileInfo, err := os.Stat(h.config.AccessPath)
+	sources, err := h.selectSources(r, sourceType)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	var result logs.LogResult
-
-	if fileInfo.IsDir() {
-		// For directories, get logs from all files
-		if tail || position == -2 {
-			// First request or tail mode - get last N lines
-			positions := []logs.Position{}
-			result, err = logs.GetLogs(h.config.AccessPath, positions, false, false)
-		} else {
-			// Use provided position
-			positions := []logs.Position{{Position: position}}
-			result, err = logs.GetLogs(h.config.AccessPath, positions, false, false)
-		}
-	} else {
-		// Single file
-		trackedPos := h.state.GetFilePosition(h.config.AccessPath)
-
-		// Determine position to use
-		var usePosition int64
-		if position == -2 {
-			// Use tracked position
-			usePosition = trackedPos
-		} else if position == -1 || tail {
-			// Tail mode requested
-			usePosition = -1
-		} else {
-			// Use provided position
-			usePosition = position
+	// Field filters only apply to access logs
+	var filter logs.Filter
+	if sourceType == logs.SourceTypeAccess {
+		if filter, err = parseFilter(r); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+			return
 		}
+	}
 
-		positions := []logs.Position{{Position: usePosition}}
-		result, err = logs.GetLogs(h.config.AccessPath, positions, false, false)
+	p := h.pipeline(r, filter, parsed)
 
-		// Update tracked position if we got results
-		if err == nil && len(result.Positions) > 0 {
-			h.state.SetFilePosition(h.config.AccessPath, result.Positions[0].Position)
-		}
+	if responseFormat(r) == formatNDJSON {
+		h.streamSourceLogs(w, sources, position, lines, tail, includeCompressed, p)
+		return
 	}
 
-	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, err.Error())
-		return
+	merged := logs.LogResult{
+		Logs:      []string{},
+		Positions: []logs.Position{},
+		Sources:   make([]logs.SourceRange, 0, len(sources)),
 	}
 
-	// Limit the number of logs returned
-	if len(result.Logs) > lines {
-		// Keep only the most recent logs
-		startIdx := len(result.Logs) - lines
-		result.Logs = result.Logs[startIdx:]
+	for _, src := range sources {
+		result, err := h.readSource(src, position, tail, includeCompressed)
+		if err != nil {
+			utils.RespondError(w, http.StatusInternalServerError, fmt.Sprintf("source %s: %v", src.Name, err))
+			return
+		}
+
+		result.Logs = p.lines(src.Type, result.Logs)
+
+		// Limit the number of logs returned, keeping the most recent
+		if len(result.Logs) > lines {
+			result.Logs = result.Logs[len(result.Logs)-lines:]
+		}
+
+		merged.Sources = append(merged.Sources, sourceRange(src, len(merged.Logs), len(result.Logs)))
+		merged.Logs = append(merged.Logs, result.Logs...)
+		merged.Positions = appendSourcePositions(merged.Positions, src, result.Positions)
 	}
 
-	utils.RespondJSON(w, http.StatusOK, result)
+	respondLogs(w, merged, parsed)
 }
 
-// HandleErrorLogs handles requests for error logs
-func (h *Handler) HandleErrorLogs(w http.ResponseWriter, r *http.Request) {
-	position := utils.GetQueryParamInt64(r, "position", -2)
-	lines := utils.GetQueryParamInt(r, "lines", 100)
-	tail := utils.GetQueryParamBool(r, "tail", false)
-
-	fileInfo, err := os.Stat(h.config.ErrorPath)
-	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, err.Error())
-		return
+// streamSourceLogs writes the selected sources as NDJSON while they are read.
+// Unlike the JSON response, which keeps the most recent lines, reading stops
+// after the first lines of each source and the positions resume right after
+// them, so nothing is skipped. Lines rejected by the filter do not count
+// towards the limit.
+func (h *Handler) streamSourceLogs(w http.ResponseWriter, sources []logs.Source, position int64, lines int, tail, includeCompressed bool, p linePipeline) {
+	out := newNDJSONWriter(w, p.parsed)
+	meta := ndjsonMeta{
+		Positions: []logs.Position{},
+		Sources:   make([]logs.SourceRange, 0, len(sources)),
 	}
 
-	var result logs.LogResult
+	for _, src := range sources {
+		root, positions := h.sourcePositions(src, position, tail)
 
-	if fileInfo.IsDir() {
-		if tail || position == -2 {
-			positions := []logs.Position{}
-			result, err = logs.GetLogs(h.config.ErrorPath, positions, true, false)
-		} else {
-			positions := []logs.Position{{Position: position}}
-			result, err = logs.GetLogs(h.config.ErrorPath, positions, true, false)
-		}
-	} else {
-		trackedPos := h.state.GetFilePosition(h.config.ErrorPath)
-
-		var usePosition int64
-		if position == -2 {
-			usePosition = trackedPos
-		} else if position == -1 || tail {
-			usePosition = -1
-		} else {
-			usePosition = position
-		}
+		start := out.count
+		newPositions, err := logs.ScanSourceLogs(src, positions, includeCompressed, lines, p.wrap(src.Type, out.line))
+		h.state.SetDirectoryPositions(root, newPositions)
 
-		positions := []logs.Position{{Position: usePosition}}
-		result, err = logs.GetLogs(h.config.ErrorPath, positions, true, false)
+		meta.Sources = append(meta.Sources, sourceRange(src, start, out.count-start))
+		meta.Positions = appendSourcePositions(meta.Positions, src, newPositions)
 
-		if err == nil && len(result.Positions) > 0 {
-			h.state.SetFilePosition(h.config.ErrorPath, result.Positions[0].Position)
+		if err != nil {
+			meta.Error = fmt.Sprintf("source %s: %v", src.Name, err)
+			break
 		}
 	}
 
+	out.meta(meta)
+}
+
+// readSource reads one source and updates its tracked positions. For a
+// single-file source an explicit position overrides the tracked one; -1 or
+// tail requests the last lines of the newest file.
+func (h *Handler) readSource(src logs.Source, position int64, tail, includeCompressed bool) (logs.LogResult, error) {
+	root, positions := h.sourcePositions(src, position, tail)
+
+	result, err := logs.GetSourceLogs(src, positions, includeCompressed)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, err.Error())
-		return
+		return result, err
 	}
 
-	if len(result.Logs) > lines {
-		startIdx := len(result.Logs) - lines
-		result.Logs = result.Logs[startIdx:]
+	h.state.SetDirectoryPositions(root, result.Positions)
+	return result, nil
+}
+
+// sourcePositions returns the root of a source and the positions to read it from
+func (h *Handler) sourcePositions(src logs.Source, position int64, tail bool) (string, []logs.Position) {
+	root := src.Root()
+
+	switch {
+	case tail || position == -1:
+		return root, nil
+	case position >= 0 && !src.IsPattern() && root != filepath.Clean(src.Path):
+		// Single file with a caller-managed position
+		return root, []logs.Position{{Position: position, Filename: filepath.Base(src.Path)}}
+	default:
+		return root, h.state.GetDirectoryPositions(root)
+	}
+}
+
+func sourceRange(src logs.Source, start, count int) logs.SourceRange {
+	return logs.SourceRange{
+		Name:   src.Name,
+		Type:   src.Type,
+		Format: src.Format,
+		Labels: src.Labels,
+		Start:  start,
+		Count:  count,
 	}
+}
 
-	utils.RespondJSON(w, http.StatusOK, result)
+func appendSourcePositions(dst []logs.Position, src logs.Source, positions []logs.Position) []logs.Position {
+	for _, pos := range positions {
+		pos.Source = src.Name
+		dst = append(dst, pos)
+	}
+	return dst
 }
 
-// HandleGetLog handles requests for a specific log file
+// HandleGetLog handles requests for a specific log file. The file name is
+// relative to the root of the selected source and cannot leave it.
 func (h *Handler) HandleGetLog(w http.ResponseWriter, r *http.Request) {
-	filename := utils.GetQueryParam(r, "filename", "")
-	if filename == "" {
-		utils.RespondError(w, http.StatusBadRequest, "filename parameter is required")
+	src, err := h.selectFileSource(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	fullPath, ok := h.resolveFile(w, r, src)
+	if !ok {
 		return
 	}
 
 	position := utils.GetQueryParamInt64(r, "position", 0)
 	lines := utils.GetQueryParamInt(r, "lines", 100)
+	parsed := utils.GetQueryParamBool(r, "parsed", false)
+	p := h.pipeline(r, logs.Filter{}, parsed)
+	filePosition := logs.Position{
+		Filename: utils.GetQueryParam(r, "filename", ""),
+		Source:   src.Name,
+	}
 
-	fullPath := filepath.Join(h.config.AccessPath, filename)
+	if responseFormat(r) == formatNDJSON {
+		out := newNDJSONWriter(w, parsed)
+		next, err := logs.ScanLog(fullPath, position, lines, p.wrap(src.Type, out.line))
+		filePosition.Position = next.Position
+		meta := ndjsonMeta{Positions: []logs.Position{filePosition}}
+		if err != nil {
+			meta.Error = err.Error()
+		}
+		out.meta(meta)
+		return
+	}
 
-	positions := []logs.Position{{Position: position, Filename: filename}}
-	result, err := logs.GetLogs(fullPath, positions, false, false)
+	// Stop after lines lines so the returned position starts the next page
+	result := logs.LogResult{Logs: []string{}}
+	next, err := logs.ScanLog(fullPath, position, lines, p.wrap(src.Type, func(line string) error {
+		result.Logs = append(result.Logs, line)
+		return nil
+	}))
 	if err != nil {
 		utils.RespondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	filePosition.Position = next.Position
+	result.Positions = []logs.Position{filePosition}
 
-	if len(result.Logs) > lines {
-		result.Logs = result.Logs[:lines]
-	}
-
-	utils.RespondJSON(w, http.StatusOK, result)
+	respondLogs(w, result, parsed)
 }
 
 // HandleStreamAccessLogs streams access logs over SSE with light batching/backpressure.
+// New lines are pushed as soon as the file watcher reports a write; the flush
+// interval only paces keep-alives and acts as a safety net for missed events.
+// With security=true, new security events are sent as "security" events.
 func (h *Handler) HandleStreamAccessLogs(w http.ResponseWriter, r *http.Request) {
 	if h.streamClients.Load() >= int32(h.config.StreamMaxClients) {
 		utils.RespondError(w, http.StatusServiceUnavailable, "too many streaming clients")
@@ -176,6 +232,26 @@ func (h *Handler) HandleStreamAccessLogs(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	src, err := h.selectStreamSource(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	streamPath, err := src.ActiveFile()
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	p := h.pipeline(r, logs.Filter{}, false)
+	tailer, err := h.newStreamTailer(src, streamPath)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tailer.close()
+
 	h.streamClients.Add(1)
 	defer h.streamClients.Add(-1)
 
@@ -185,8 +261,14 @@ func (h *Handler) HandleStreamAccessLogs(w http.ResponseWriter, r *http.Request)
 	w.Header().Set("X-Accel-Buffering", "no")
 
 	ctx := r.Context()
-	startPos := h.state.GetFilePosition(h.config.AccessPath)
-	currentPos := startPos
+
+	// Security events are sent as their own event type when asked for
+	var securityEvents <-chan security.Event
+	if h.features.Security != nil && utils.GetQueryParamBool(r, "security", false) {
+		events, cancel := h.features.Security.Subscribe(16)
+		defer cancel()
+		securityEvents = events
+	}
 
 	flushInterval := time.Duration(h.config.StreamFlushIntervalMS) * time.Millisecond
 	ticker := time.NewTicker(flushInterval)
@@ -201,7 +283,13 @@ func (h *Handler) HandleStreamAccessLogs(w http.ResponseWriter, r *http.Request)
 		flusher.Flush()
 	}
 
+	// Deliver anything written before the client connected
+	if _, err := h.flushStream(ctx, w, flusher, tailer, p); err != nil {
+		return
+	}
+
 	for {
+		idle := false
 		select {
 		case <-ctx.Done():
 			return
@@ -209,47 +297,77 @@ func (h *Handler) HandleStreamAccessLogs(w http.ResponseWriter, r *http.Request)
 			_, _ = w.Write([]byte("event: end\ndata: stream timeout\n\n"))
 			flusher.Flush()
 			return
+		case <-tailer.fileSub.C:
+		case <-tailer.dirSub.C:
 		case <-ticker.C:
-			lines, nextPos, err := logs.StreamFromPosition(ctx, h.config.AccessPath, currentPos, h.config.StreamBatchLines, h.config.StreamMaxBytesPerBatch)
-			if err != nil && err != context.Canceled {
-				logger.Log.Printf("stream error: %v", err)
-				utils.RespondError(w, http.StatusInternalServerError, err.Error())
+			idle = true
+		case ev := <-securityEvents:
+			if err := writeSecurityEvent(w, p.event(ev)); err != nil {
 				return
 			}
+			flusher.Flush()
+		}
 
-			if len(lines) == 0 {
-				_, _ = w.Write([]byte(": keep-alive\n\n"))
-				flusher.Flush()
-				continue
-			}
+		sent, err := h.flushStream(ctx, w, flusher, tailer, p)
+		if err != nil {
+			return
+		}
 
-			var builder strings.Builder
-			bytesUsed := 0
-			maxBytes := h.config.StreamMaxBytesPerBatch
-			if maxBytes <= 0 {
-				maxBytes = 512 * 1024
-			}
+		if idle && !sent {
+			_, _ = w.Write([]byte(": keep-alive\n\n"))
+			flusher.Flush()
+		}
+	}
+}
 
-			for _, line := range lines {
-				entry := "data: " + line + "\n"
-				if bytesUsed+len(entry)+1 > maxBytes {
-					logger.Log.Printf("stream batch truncated at %d bytes", bytesUsed)
-					break
-				}
-				builder.WriteString(entry)
-				builder.WriteString("\n")
-				bytesUsed += len(entry) + 1
-			}
+// flushStream writes every line available to the tailer as SSE batches until
+// it catches up with the writer. It reports whether anything was sent; an
+// error means the stream must end.
+func (h *Handler) flushStream(ctx context.Context, w http.ResponseWriter, flusher http.Flusher, t *streamTailer, p linePipeline) (bool, error) {
+	if err := t.sync(h); err != nil {
+		logger.Log.Printf("stream watch error: %v", err)
+	}
 
-			if builder.Len() > 0 {
-				if _, err := w.Write([]byte(builder.String())); err != nil {
-					return
-				}
-				flusher.Flush()
+	maxBytes := h.config.StreamMaxBytesPerBatch
+	if maxBytes <= 0 {
+		maxBytes = 512 * 1024
+	}
+
+	sent := false
+	for {
+		lines, err := t.read(ctx, h.config.StreamBatchLines, h.config.StreamMaxBytesPerBatch)
+		if err != nil && err != context.Canceled {
+			logger.Log.Printf("stream error: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, err.Error())
+			return sent, err
+		}
+
+		if len(lines) == 0 {
+			return sent, nil
+		}
+
+		var builder strings.Builder
+		bytesUsed := 0
+
+		for _, line := range lines {
+			entry := "data: " + p.line(t.src.Type, line) + "\n"
+			if bytesUsed+len(entry)+1 > maxBytes {
+				logger.Log.Printf("stream batch truncated at %d bytes", bytesUsed)
+				break
 			}
+			builder.WriteString(entry)
+			builder.WriteString("\n")
+			bytesUsed += len(entry) + 1
+		}
 
-			currentPos = nextPos
-			h.state.SetFilePosition(h.config.AccessPath, currentPos)
+		if builder.Len() > 0 {
+			if _, err := w.Write([]byte(builder.String())); err != nil {
+				return sent, err
+			}
+			flusher.Flush()
+			sent = true
 		}
+
+		h.state.SetFilePosition(t.path, t.position)
 	}
 }
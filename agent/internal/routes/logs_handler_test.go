@@ -1,7 +1,11 @@
 package routes
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
@@ -11,6 +15,7 @@ import (
 
 	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/config"
 	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/state"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
 )
 
 func TestHandleStreamAccessLogs(t *testing.T) {
@@ -30,7 +35,7 @@ func TestHandleStreamAccessLogs(t *testing.T) {
 	}
 
 	st := state.NewStateManager(cfg)
-	h := NewHandler(cfg, st)
+	h := NewHandler(cfg, st, Features{})
 
 	req := httptest.NewRequest("GET", "/api/logs/stream", nil)
 	// Cancel after a short time to end the loop
@@ -46,3 +51,250 @@ func TestHandleStreamAccessLogs(t *testing.T) {
 		t.Fatalf("expected stream to contain first line, got: %s", body)
 	}
 }
+
+// TestStreamWriteLatency measures the time from a line being appended to the
+// log file to it arriving at an SSE client. The flush interval is far longer
+// than the allowed latency, so only the file watcher can deliver in time.
+func TestStreamWriteLatency(t *testing.T) {
+	modes := []struct {
+		mode       string
+		maxLatency time.Duration
+	}{
+		{"auto", 500 * time.Millisecond},
+		{"poll", time.Second},
+	}
+
+	for _, tt := range modes {
+		t.Run(tt.mode, func(t *testing.T) {
+			dir := t.TempDir()
+			logPath := filepath.Join(dir, "access.log")
+			if err := os.WriteFile(logPath, []byte("first\n"), 0644); err != nil {
+				t.Fatalf("write log: %v", err)
+			}
+
+			cfg := &config.Config{
+				AccessPath:             logPath,
+				StreamBatchLines:       10,
+				StreamFlushIntervalMS:  10000,
+				StreamMaxClients:       5,
+				StreamMaxDurationSec:   10,
+				StreamMaxBytesPerBatch: 1024,
+				WatchMode:              tt.mode,
+				WatchPollIntervalMS:    50,
+			}
+			h := NewHandler(cfg, state.NewStateManager(cfg), Features{})
+			defer h.Close()
+
+			srv := httptest.NewServer(http.HandlerFunc(h.HandleStreamAccessLogs))
+			defer srv.Close()
+
+			resp, err := http.Get(srv.URL)
+			if err != nil {
+				t.Fatalf("connect: %v", err)
+			}
+			defer resp.Body.Close()
+
+			lines := make(chan string, 16)
+			go func() {
+				scanner := bufio.NewScanner(resp.Body)
+				for scanner.Scan() {
+					if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+						lines <- data
+					}
+				}
+				close(lines)
+			}()
+
+			expect := func(want string, since time.Time) time.Duration {
+				t.Helper()
+				for {
+					select {
+					case line, ok := <-lines:
+						if !ok {
+							t.Fatalf("stream closed before %q", want)
+						}
+						if line == want {
+							return time.Since(since)
+						}
+					case <-time.After(tt.maxLatency):
+						t.Fatalf("%q not streamed within %v", want, tt.maxLatency)
+					}
+				}
+			}
+			expect("first", time.Now())
+
+			f, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			written := time.Now()
+			if _, err := f.WriteString("second\n"); err != nil {
+				t.Fatal(err)
+			}
+			f.Close()
+			t.Logf("write to SSE latency (%s): %v", tt.mode, expect("second", written))
+
+			// Rotate: move the file away and start a new one
+			if err := os.Rename(logPath, logPath+".1"); err != nil {
+				t.Fatal(err)
+			}
+			written = time.Now()
+			if err := os.WriteFile(logPath, []byte("rotated\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			t.Logf("rotation to SSE latency (%s): %v", tt.mode, expect("rotated", written))
+		})
+	}
+}
+
+// TestStreamBurst appends far more lines than fit in one batch at once;
+// every one of them has to reach the client
+func TestStreamBurst(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "access.log")
+	if err := os.WriteFile(logPath, nil, 0644); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+
+	cfg := &config.Config{
+		AccessPath:             logPath,
+		StreamBatchLines:       400,
+		StreamFlushIntervalMS:  50,
+		StreamMaxClients:       5,
+		StreamMaxDurationSec:   10,
+		StreamMaxBytesPerBatch: 512 * 1024,
+		WatchMode:              "poll",
+		WatchPollIntervalMS:    20,
+	}
+	h := NewHandler(cfg, state.NewStateManager(cfg), Features{})
+	defer h.Close()
+
+	srv := httptest.NewServer(http.HandlerFunc(h.HandleStreamAccessLogs))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var burst strings.Builder
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&burst, "line %04d\n", i)
+	}
+	if err := os.WriteFile(logPath, []byte(burst.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan int)
+	go func() {
+		n := 0
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+				if want := fmt.Sprintf("line %04d", n); data != want {
+					t.Errorf("line %d = %q, want %q", n, data, want)
+				}
+				if n++; n == 2000 {
+					break
+				}
+			}
+		}
+		done <- n
+	}()
+
+	select {
+	case n := <-done:
+		if n != 2000 {
+			t.Fatalf("streamed %d lines, want 2000", n)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("burst not streamed within 5s")
+	}
+}
+
+func TestHandleAccessLogsSources(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"edge/access.log":     "edge-1\nedge-2\n",
+		"internal/access.log": "internal-1\n",
+	} {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("write log: %v", err)
+		}
+	}
+
+	cfg := &config.Config{
+		Sources: []logs.Source{
+			{Name: "edge", Path: filepath.Join(dir, "edge", "*.log"), Type: logs.SourceTypeAccess, Labels: map[string]string{"instance": "edge"}},
+			{Name: "internal", Path: filepath.Join(dir, "internal", "access.log"), Type: logs.SourceTypeAccess},
+			{Name: "errors", Path: filepath.Join(dir, "traefik.log"), Type: logs.SourceTypeError},
+		},
+	}
+	h := NewHandler(cfg, state.NewStateManager(cfg), Features{})
+
+	tests := []struct {
+		name    string
+		query   string
+		status  int
+		sources []string
+		lines   int
+	}{
+		{"all access sources", "", 200, []string{"edge", "internal"}, 3},
+		{"single source", "?source=internal", 200, []string{"internal"}, 1},
+		{"comma separated", "?source=internal,edge", 200, []string{"internal", "edge"}, 3},
+		{"unknown source", "?source=nope", 400, nil, 0},
+		{"wrong type", "?source=errors", 400, nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/logs/access"+tt.query+sep(tt.query)+"tail=true", nil)
+			rr := httptest.NewRecorder()
+			h.HandleAccessLogs(rr, req)
+
+			if rr.Code != tt.status {
+				t.Fatalf("expected status %d, got %d: %s", tt.status, rr.Code, rr.Body.String())
+			}
+			if tt.status != 200 {
+				return
+			}
+
+			var result logs.LogResult
+			if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if len(result.Logs) != tt.lines {
+				t.Fatalf("expected %d lines, got %v", tt.lines, result.Logs)
+			}
+			if len(result.Sources) != len(tt.sources) {
+				t.Fatalf("expected sources %v, got %+v", tt.sources, result.Sources)
+			}
+			for i, name := range tt.sources {
+				rng := result.Sources[i]
+				if rng.Name != name {
+					t.Fatalf("expected source %s at %d, got %s", name, i, rng.Name)
+				}
+				for _, line := range result.Logs[rng.Start : rng.Start+rng.Count] {
+					if !strings.HasPrefix(line, name) {
+						t.Fatalf("line %q attributed to source %s", line, name)
+					}
+				}
+			}
+			for _, pos := range result.Positions {
+				if pos.Source == "" {
+					t.Fatalf("position without source: %+v", pos)
+				}
+			}
+		})
+	}
+}
+
+func sep(query string) string {
+	if query == "" {
+		return "?"
+	}
+	return "&"
+}
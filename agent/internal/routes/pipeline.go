@@ -0,0 +1,106 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/auth"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/redact"
+)
+
+// linePipeline prepares source lines for one request. Access entries are
+// enriched, redacted for the token scope and then filtered, so filters see
+// exactly what the client may see. Error log lines are only redacted.
+type linePipeline struct {
+	red       *redact.Redactor
+	enrichers []logs.Enricher
+	filter    logs.Filter
+	// parsed adds the derived fields to output lines
+	parsed bool
+}
+
+// pipeline builds the line pipeline of a request
+func (h *Handler) pipeline(r *http.Request, filter logs.Filter, parsed bool) linePipeline {
+	return linePipeline{
+		red:       h.redactor(r),
+		enrichers: h.enrichers(),
+		filter:    filter,
+		parsed:    parsed,
+	}
+}
+
+// redactor returns the redactor for the token scope of a request, or nil
+// when the scope sees raw data
+func (h *Handler) redactor(r *http.Request) *redact.Redactor {
+	return h.features.Redaction.For(auth.Scope(r.Context()))
+}
+
+// enrichers returns the configured enrichers
+func (h *Handler) enrichers() []logs.Enricher {
+	var enrichers []logs.Enricher
+	if h.features.GeoIP != nil {
+		enrichers = append(enrichers, h.features.GeoIP)
+	}
+	if h.features.UserAgents != nil {
+		enrichers = append(enrichers, h.features.UserAgents)
+	}
+	return enrichers
+}
+
+// entry enriches and redacts a parsed entry
+func (p linePipeline) entry(entry *logs.TraefikLog) {
+	logs.Enrich(entry, p.enrichers...)
+	p.red.Entry(entry)
+}
+
+// match reports whether a raw line of a source passes the filter
+func (p linePipeline) match(sourceType, line string) bool {
+	if p.filter.IsZero() || sourceType != logs.SourceTypeAccess {
+		return true
+	}
+	entry, err := logs.ParseTraefikLog(line)
+	if err != nil || entry == nil {
+		return false
+	}
+	p.entry(entry)
+	return p.filter.Match(entry)
+}
+
+// line prepares a raw line of a source for output
+func (p linePipeline) line(sourceType, line string) string {
+	if sourceType != logs.SourceTypeAccess {
+		if p.red == nil {
+			return line
+		}
+		return p.red.Text(line)
+	}
+	if p.parsed {
+		line = logs.EnrichLine(line, p.enrichers...)
+	}
+	if p.red == nil {
+		return line
+	}
+	return p.red.Line(line)
+}
+
+// lines filters and prepares lines in place
+func (p linePipeline) lines(sourceType string, lines []string) []string {
+	kept := lines[:0]
+	for _, line := range lines {
+		if p.match(sourceType, line) {
+			kept = append(kept, p.line(sourceType, line))
+		}
+	}
+	return kept
+}
+
+// wrap filters and prepares lines before passing them to fn. Rejected lines
+// return logs.ErrSkipLine so they do not count towards line limits.
+func (p linePipeline) wrap(sourceType string, fn logs.LineFunc) logs.LineFunc {
+	return func(line string) error {
+		if !p.match(sourceType, line) {
+			return logs.ErrSkipLine
+		}
+		return fn(p.line(sourceType, line))
+	}
+}
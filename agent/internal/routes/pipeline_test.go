@@ -0,0 +1,158 @@
+package routes
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/auth"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/config"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/state"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/redact"
+)
+
+const secretLine = `{"ClientHost":"203.0.113.77","ClientAddr":"203.0.113.77:4000","ClientUsername":"alice","RequestMethod":"GET","RequestPath":"/login?token=s3cret","DownstreamStatus":200,"StartUTC":"2024-05-01T12:00:00Z"}`
+
+// newRedactingHandler serves a log holding personal data to two tokens: the
+// admin token sees raw data, the viewer token redacted data
+func newRedactingHandler(t *testing.T) (*Handler, *auth.Authenticator) {
+	t.Helper()
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "access.log"), []byte(secretLine+"\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "error.log"), []byte(`level=error msg="dial from 203.0.113.77 failed"`+"\n"), 0644)
+
+	policy, err := redact.NewPolicy(redact.Config{Profiles: map[string]redact.Rules{
+		"admin": {},
+		redact.DefaultScope: {
+			ClientIP:       redact.IPTruncate,
+			QueryParams:    []string{"token"},
+			RemoveUsername: true,
+		},
+	}})
+	if err != nil {
+		t.Fatalf("policy: %v", err)
+	}
+
+	cfg := &config.Config{
+		AccessPath:       dir,
+		ErrorPath:        dir,
+		DownloadMaxBytes: 1 << 20,
+		ExportMaxBytes:   1 << 20,
+	}
+	authenticator := auth.NewAuthenticator("viewer-token")
+	authenticator.AddToken("admin-token", "admin")
+	return NewHandler(cfg, state.NewStateManager(cfg), Features{Redaction: policy}), authenticator
+}
+
+func TestRedactionPerScope(t *testing.T) {
+	h, authenticator := newRedactingHandler(t)
+
+	endpoints := map[string]struct {
+		handler http.HandlerFunc
+		query   string
+	}{
+		"access json":   {h.HandleAccessLogs, "?tail=true"},
+		"access ndjson": {h.HandleAccessLogs, "?tail=true&format=ndjson&parsed=true"},
+		"get":           {h.HandleGetLog, "?source=access&filename=access.log"},
+		"export csv":    {h.HandleExport, "?columns=all"},
+		"export ndjson": {h.HandleExport, "?format=ndjson&columns=all"},
+		"error":         {h.HandleErrorLogs, "?tail=true"},
+	}
+
+	for name, endpoint := range endpoints {
+		for _, token := range []string{"viewer-token", "admin-token"} {
+			t.Run(name+" "+token, func(t *testing.T) {
+				req := httptest.NewRequest("GET", "/"+endpoint.query, nil)
+				req.Header.Set("Authorization", "Bearer "+token)
+				rr := httptest.NewRecorder()
+				authenticator.Middleware(endpoint.handler)(rr, req)
+
+				if rr.Code != http.StatusOK {
+					t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+				}
+				body := rr.Body.String()
+				raw := strings.Contains(body, "203.0.113.77")
+				if token == "admin-token" && !raw {
+					t.Fatalf("admin should see raw data: %s", body)
+				}
+				if token == "viewer-token" {
+					if raw || strings.Contains(body, "s3cret") || strings.Contains(body, "alice") {
+						t.Fatalf("personal data leaked: %s", body)
+					}
+					if !strings.Contains(body, "203.0.113.0") {
+						t.Fatalf("expected the truncated address: %s", body)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestRedactionAppliesBeforeFilters(t *testing.T) {
+	h, authenticator := newRedactingHandler(t)
+
+	for token, want := range map[string]int{"viewer-token": 0, "admin-token": 1} {
+		req := httptest.NewRequest("GET", "/api/logs/export?client=203.0.113.77", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		authenticator.Middleware(h.HandleExport)(rr, req)
+
+		if got := rr.Result().Trailer.Get(trailerExportRows); got != map[int]string{0: "0", 1: "1"}[want] {
+			t.Errorf("%s: a filter on the raw address matched %s rows, want %d", token, got, want)
+		}
+	}
+}
+
+func TestRedactedScopeCannotDownload(t *testing.T) {
+	h, authenticator := newRedactingHandler(t)
+
+	for token, status := range map[string]int{"viewer-token": http.StatusForbidden, "admin-token": http.StatusOK} {
+		req := httptest.NewRequest("GET", "/api/logs/files/download?filename=access.log", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		authenticator.Middleware(h.HandleDownloadFile)(rr, req)
+		if rr.Code != status {
+			t.Errorf("%s: expected %d, got %d", token, status, rr.Code)
+		}
+	}
+}
+
+func TestRedactionInStream(t *testing.T) {
+	h, authenticator := newRedactingHandler(t)
+	h.config.StreamMaxClients = 1
+	h.config.StreamFlushIntervalMS = 50
+	h.config.StreamMaxDurationSec = 5
+	h.config.StreamBatchLines = 10
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	srv := httptest.NewServer(authenticator.Middleware(h.HandleStreamAccessLogs))
+	defer srv.Close()
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL, nil)
+	req.Header.Set("Authorization", "Bearer viewer-token")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		if strings.Contains(line, "203.0.113.77") || strings.Contains(line, "s3cret") {
+			t.Fatalf("personal data leaked into the stream: %s", line)
+		}
+		return
+	}
+	t.Fatal("no data event received")
+}
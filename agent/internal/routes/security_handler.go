@@ -0,0 +1,108 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/utils"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/security"
+)
+
+// maxSecurityEvents caps the events returned by one request
+const maxSecurityEvents = 1000
+
+// HandleSecurityEvents lists recent security events, most recent first
+func (h *Handler) HandleSecurityEvents(w http.ResponseWriter, r *http.Request) {
+	d := h.features.Security
+	if d == nil {
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"status":  "disabled",
+			"message": "Security detection is disabled",
+			"events":  []security.Event{},
+		})
+		return
+	}
+
+	q := security.Query{
+		Types:    utils.GetQueryParamList(r, "type"),
+		Severity: strings.ToLower(utils.GetQueryParam(r, "severity", "")),
+		Limit:    utils.GetQueryParamInt(r, "limit", 100),
+	}
+	for _, typ := range q.Types {
+		if !security.ValidType(typ) {
+			utils.RespondError(w, http.StatusBadRequest, fmt.Sprintf("type: unknown event type %q, use one of %s", typ, strings.Join(security.Types, ", ")))
+			return
+		}
+	}
+	if q.Severity != "" && !security.ValidSeverity(q.Severity) {
+		utils.RespondError(w, http.StatusBadRequest, fmt.Sprintf("severity: unknown severity %q", q.Severity))
+		return
+	}
+	if q.Limit <= 0 || q.Limit > maxSecurityEvents {
+		utils.RespondError(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxSecurityEvents))
+		return
+	}
+	if value := utils.GetQueryParam(r, "since", ""); value != "" {
+		since, err := logs.ParseTimeBound(value, time.Now())
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "since: "+err.Error())
+			return
+		}
+		q.Since = since
+	}
+
+	// Clients are matched after redaction, like log filters
+	client := utils.GetQueryParam(r, "client", "")
+	limit := q.Limit
+	if client != "" {
+		q.Limit = 0
+	}
+
+	p := h.pipeline(r, logs.Filter{}, false)
+	events := make([]security.Event, 0)
+	for _, ev := range d.Events(q) {
+		ev = p.event(ev)
+		if client != "" && ev.Client != client {
+			continue
+		}
+		events = append(events, ev)
+		if len(events) == limit {
+			break
+		}
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"status": "ok",
+		"events": events,
+		"count":  len(events),
+	})
+}
+
+// event redacts the client and evidence of a security event
+func (p linePipeline) event(ev security.Event) security.Event {
+	if p.red == nil {
+		return ev
+	}
+	ev.Client = p.red.IP(ev.Client)
+	// The evidence may be shared with other subscribers
+	evidence := make([]string, len(ev.Evidence))
+	for i, line := range ev.Evidence {
+		evidence[i] = p.red.Line(line)
+	}
+	ev.Evidence = evidence
+	return ev
+}
+
+// writeSecurityEvent sends a security event on an SSE stream
+func writeSecurityEvent(w http.ResponseWriter, ev security.Event) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: security\ndata: %s\n\n", data)
+	return err
+}
@@ -0,0 +1,191 @@
+package routes
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/auth"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/config"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/state"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/redact"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/security"
+)
+
+func TestSecurityDetection(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "access.log")
+	// Entries written before detection starts are not inspected
+	os.WriteFile(logPath, []byte(`{"ClientHost":"81.2.69.9","RequestPath":"/.env","DownstreamStatus":404}`+"\n"), 0644)
+
+	detector, err := security.New(security.DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy, err := redact.NewPolicy(redact.Config{Profiles: map[string]redact.Rules{
+		"support": {ClientIP: redact.IPTruncate},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{
+		AccessPath:             logPath,
+		StreamBatchLines:       10,
+		StreamFlushIntervalMS:  50,
+		StreamMaxClients:       5,
+		StreamMaxDurationSec:   10,
+		StreamMaxBytesPerBatch: 1024,
+		WatchMode:              "poll",
+		WatchPollIntervalMS:    20,
+	}
+	h := NewHandler(cfg, state.NewStateManager(cfg), Features{Security: detector, Redaction: policy})
+	defer h.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		h.RunAnalysis(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	authenticator := auth.NewAuthenticator("")
+	authenticator.AddToken("support-token", "support")
+	srv := httptest.NewServer(authenticator.Middleware(h.HandleStreamAccessLogs))
+	defer srv.Close()
+
+	req, _ := http.NewRequest("GET", srv.URL+"?security=true", nil)
+	req.Header.Set("Authorization", "Bearer support-token")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	events := make(chan string, 4)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		security := false
+		for scanner.Scan() {
+			line := scanner.Text()
+			if line == "event: security" {
+				security = true
+				continue
+			}
+			if data, ok := strings.CutPrefix(line, "data: "); ok && security {
+				events <- data
+			}
+			security = false
+		}
+		close(events)
+	}()
+
+	// Give the detector time to start at the end of the file
+	time.Sleep(200 * time.Millisecond)
+	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"ClientHost":"81.2.69.142","RequestPath":"/.git/config","DownstreamStatus":404,"StartUTC":"2024-05-01T12:00:00Z"}` + "\n")
+	f.Close()
+
+	select {
+	case data := <-events:
+		var ev security.Event
+		if err := json.Unmarshal([]byte(data), &ev); err != nil {
+			t.Fatalf("invalid event %q: %v", data, err)
+		}
+		if ev.Type != security.TypeExploitProbe || ev.Client != "81.2.69.0" || len(ev.Evidence) != 1 {
+			t.Errorf("unexpected event %+v", ev)
+		}
+		if strings.Contains(ev.Evidence[0], "81.2.69.142") {
+			t.Errorf("evidence not redacted: %s", ev.Evidence[0])
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no security event streamed")
+	}
+
+	// The API lists the same event; the older probe was never inspected
+	rr := httptest.NewRecorder()
+	h.HandleSecurityEvents(rr, httptest.NewRequest("GET", "/api/security/events?type=exploit_probe", nil))
+	var body struct {
+		Events []security.Event `json:"events"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Events) != 1 || body.Events[0].Client != "81.2.69.142" {
+		t.Errorf("events = %+v", body.Events)
+	}
+}
+
+func TestHandleSecurityEvents(t *testing.T) {
+	detector, _ := security.New(security.Config{})
+	cfg := &config.Config{}
+	h := NewHandler(cfg, state.NewStateManager(cfg), Features{Security: detector})
+
+	for _, line := range []string{
+		`{"ClientHost":"81.2.69.1","RequestPath":"/.env","DownstreamStatus":404,"StartUTC":"2024-05-01T12:00:00Z"}`,
+		`{"ClientHost":"81.2.69.2","RequestPath":"/backup.sql","DownstreamStatus":404,"StartUTC":"2024-05-01T12:01:00Z"}`,
+	} {
+		detector.Observe(mustParse(t, line), line)
+	}
+
+	tests := []struct {
+		query string
+		code  int
+		count int
+	}{
+		{"", http.StatusOK, 2},
+		{"?severity=high", http.StatusOK, 1},
+		{"?client=81.2.69.2", http.StatusOK, 1},
+		{"?since=2024-05-01T12:00:30Z", http.StatusOK, 1},
+		{"?limit=1", http.StatusOK, 1},
+		{"?type=path_scan", http.StatusOK, 0},
+		{"?type=ddos", http.StatusBadRequest, 0},
+		{"?severity=critical", http.StatusBadRequest, 0},
+		{"?limit=0", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		h.HandleSecurityEvents(rr, httptest.NewRequest("GET", "/api/security/events"+tt.query, nil))
+		if rr.Code != tt.code {
+			t.Errorf("%s: status %d, want %d", tt.query, rr.Code, tt.code)
+			continue
+		}
+		var body struct {
+			Count int `json:"count"`
+		}
+		json.Unmarshal(rr.Body.Bytes(), &body)
+		if body.Count != tt.count {
+			t.Errorf("%s: %d events, want %d", tt.query, body.Count, tt.count)
+		}
+	}
+
+	disabled := NewHandler(&config.Config{}, state.NewStateManager(&config.Config{}), Features{})
+	rr := httptest.NewRecorder()
+	disabled.HandleSecurityEvents(rr, httptest.NewRequest("GET", "/api/security/events", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"disabled"`) {
+		t.Errorf("disabled: %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func mustParse(t *testing.T, line string) *logs.TraefikLog {
+	t.Helper()
+	entry, err := logs.ParseTraefikLog(line)
+	if err != nil || entry == nil {
+		t.Fatalf("parse %q: %v", line, err)
+	}
+	return entry
+}
@@ -0,0 +1,45 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/utils"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/slo"
+)
+
+// HandleSLO returns the SLI, error budget and burn rate alerts of every
+// declared objective. name narrows the list.
+func (h *Handler) HandleSLO(w http.ResponseWriter, r *http.Request) {
+	tracker := h.features.SLO
+	if tracker == nil {
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"status":     "disabled",
+			"message":    "No SLOs are configured",
+			"objectives": []slo.Status{},
+		})
+		return
+	}
+
+	names := utils.GetQueryParamList(r, "name")
+	objectives := make([]slo.Status, 0)
+	firing := 0
+	for _, st := range tracker.Statuses() {
+		if len(names) > 0 && !containsString(names, st.Name) {
+			continue
+		}
+		for _, a := range st.Alerts {
+			if a.Firing {
+				firing++
+			}
+		}
+		objectives = append(objectives, st)
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"status": "ok",
+		// The history is still being read, so the windows are incomplete
+		"loading":    !tracker.Loaded(),
+		"firing":     firing,
+		"objectives": objectives,
+	})
+}
@@ -0,0 +1,128 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/config"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/state"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/slo"
+)
+
+func TestHandleSLO(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "access.log")
+
+	// History: 100 good requests an hour ago and one from before the window
+	var history strings.Builder
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&history, `{"RouterName":"api@docker","DownstreamStatus":200,"Duration":1000000,"StartUTC":%q}`+"\n", time.Now().Add(-time.Hour).UTC().Format(time.RFC3339Nano))
+	}
+	fmt.Fprintf(&history, `{"RouterName":"api@docker","DownstreamStatus":500,"StartUTC":%q}`+"\n", time.Now().Add(-48*time.Hour).UTC().Format(time.RFC3339Nano))
+	os.WriteFile(logPath, []byte(history.String()), 0644)
+
+	cfg, err := slo.ParseConfig([]byte(`{"objectives": [
+		{"name": "api", "routers": ["api@*"], "target": 99, "window": "1d"},
+		{"name": "web", "routers": ["web@*"], "target": 99.9, "window": "1d"}
+	], "alerts": [{"name": "page", "severity": "page", "long": "1h", "short": "5m", "burn_rate": 14.4}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracker, err := slo.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewHandler(&config.Config{
+		AccessPath:             logPath,
+		StreamBatchLines:       100,
+		StreamFlushIntervalMS:  50,
+		StreamMaxBytesPerBatch: 64 * 1024,
+		WatchMode:              "poll",
+		WatchPollIntervalMS:    20,
+	}, state.NewStateManager(&config.Config{}), Features{SLO: tracker})
+	defer h.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		h.RunAnalysis(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	type response struct {
+		Loading    bool         `json:"loading"`
+		Firing     int          `json:"firing"`
+		Objectives []slo.Status `json:"objectives"`
+	}
+	get := func(query string) response {
+		w := httptest.NewRecorder()
+		h.HandleSLO(w, httptest.NewRequest(http.MethodGet, "/api/slo"+query, nil))
+		var resp response
+		json.NewDecoder(w.Body).Decode(&resp)
+		return resp
+	}
+	waitFor := func(what string, ok func(response) bool) response {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			resp := get("")
+			if ok(resp) {
+				return resp
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("%s: %+v", what, resp)
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+
+	resp := waitFor("history not loaded", func(r response) bool { return !r.Loading })
+	if api := resp.Objectives[0]; api.Requests != 100 || api.State != slo.StateOK || api.SLI != 100 {
+		t.Errorf("after history: %+v", api)
+	}
+	if resp.Objectives[1].State != slo.StateNoData {
+		t.Errorf("web: %+v", resp.Objectives[1])
+	}
+
+	// Give the tail time to reach the end of the file, then fail
+	time.Sleep(200 * time.Millisecond)
+	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		fmt.Fprintf(f, `{"RouterName":"api@docker","DownstreamStatus":503,"StartUTC":%q}`+"\n", time.Now().UTC().Format(time.RFC3339Nano))
+	}
+	f.Close()
+
+	resp = waitFor("failures not counted", func(r response) bool { return r.Objectives[0].Requests == 120 })
+	api := resp.Objectives[0]
+	if api.State != slo.StateExhausted || resp.Firing != 1 || !api.Alerts[0].Firing {
+		t.Errorf("after failures: firing %d, %+v", resp.Firing, api)
+	}
+
+	if resp = get("?name=web"); len(resp.Objectives) != 1 || resp.Objectives[0].Name != "web" {
+		t.Errorf("filtered: %+v", resp.Objectives)
+	}
+}
+
+func TestHandleSLODisabled(t *testing.T) {
+	h := newExportHandler(t)
+	w := httptest.NewRecorder()
+	h.HandleSLO(w, httptest.NewRequest(http.MethodGet, "/api/slo", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"status":"disabled"`) {
+		t.Errorf("got %d %s", w.Code, w.Body.String())
+	}
+}
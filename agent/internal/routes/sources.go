@@ -0,0 +1,60 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/utils"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+)
+
+// selectSources returns the sources named by the "source" query parameter,
+// or every source of the given type when none is named. An empty type accepts
+// sources of any type. The parameter may be repeated or hold a comma-separated
+// list.
+func (h *Handler) selectSources(r *http.Request, sourceType string) ([]logs.Source, error) {
+	names := utils.GetQueryParamList(r, "source")
+	if len(names) == 0 {
+		if sourceType == "" {
+			return h.config.LogSources(), nil
+		}
+		sources := h.config.SourcesOfType(sourceType)
+		if len(sources) == 0 {
+			return nil, fmt.Errorf("no %s log sources configured", sourceType)
+		}
+		return sources, nil
+	}
+
+	sources := make([]logs.Source, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		src, ok := h.config.FindSource(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown source: %s", name)
+		}
+		if sourceType != "" && src.Type != sourceType {
+			return nil, fmt.Errorf("source %s is not an %s log source", name, sourceType)
+		}
+		sources = append(sources, src)
+	}
+
+	return sources, nil
+}
+
+// selectStreamSource returns the single access source named by the "source"
+// query parameter, or the first access source when none is named.
+func (h *Handler) selectStreamSource(r *http.Request) (logs.Source, error) {
+	sources, err := h.selectSources(r, logs.SourceTypeAccess)
+	if err != nil {
+		return logs.Source{}, err
+	}
+	if len(sources) > 1 && len(utils.GetQueryParamList(r, "source")) > 0 {
+		return logs.Source{}, fmt.Errorf("streaming supports a single source per connection")
+	}
+	return sources[0], nil
+}
@@ -0,0 +1,81 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/utils"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/stats"
+)
+
+// defaultStatsWindow is how far back stats look when no since is given
+const defaultStatsWindow = time.Hour
+
+// maxStatsTop caps the length of the top-N lists of a stats request
+const maxStatsTop = 100
+
+// statsResponse is the body of a stats request
+type statsResponse struct {
+	Since time.Time  `json:"since"`
+	Until *time.Time `json:"until,omitempty"`
+	stats.Summary
+}
+
+// HandleStats aggregates the access log entries that match the filters of
+// the request, archives included
+func (h *Handler) HandleStats(w http.ResponseWriter, r *http.Request) {
+	sources, err := h.selectSources(r, logs.SourceTypeAccess)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter, err := parseFilter(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if filter.Since.IsZero() {
+		filter.Since = time.Now().Add(-defaultStatsWindow)
+	}
+
+	top := utils.GetQueryParamInt(r, "top", stats.DefaultTop)
+	if top <= 0 || top > maxStatsTop {
+		utils.RespondError(w, http.StatusBadRequest, fmt.Sprintf("top must be between 1 and %d", maxStatsTop))
+		return
+	}
+
+	p := h.pipeline(r, filter, true)
+	agg := stats.New(top)
+	ctx := r.Context()
+
+	add := func(line string) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		entry, err := logs.ParseTraefikLog(line)
+		if err != nil || entry == nil {
+			return nil
+		}
+		p.entry(entry)
+		if filter.Match(entry) {
+			agg.Add(entry)
+		}
+		return nil
+	}
+
+	for _, src := range sources {
+		if err := logs.ScanSourceHistory(src, filter.Since, add); err != nil {
+			utils.RespondError(w, http.StatusInternalServerError, fmt.Sprintf("source %s: %v", src.Name, err))
+			return
+		}
+	}
+
+	resp := statsResponse{Since: filter.Since, Summary: agg.Summary()}
+	if !filter.Until.IsZero() {
+		resp.Until = &filter.Until
+	}
+	utils.RespondJSON(w, http.StatusOK, resp)
+}
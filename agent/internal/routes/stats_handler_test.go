@@ -0,0 +1,137 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/config"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/state"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/stats"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/useragent"
+)
+
+func TestHandleStats(t *testing.T) {
+	h := newExportHandler(t)
+
+	tests := []struct {
+		name     string
+		query    string
+		requests int64
+		classes  map[string]int64
+	}{
+		{"everything", "?since=2024-05-01T00:00:00Z", 100, map[string]int64{"2xx": 50, "5xx": 50}},
+		{"status class", "?since=2024-05-01T00:00:00Z&status=5xx", 50, map[string]int64{"5xx": 50}},
+		{"client network", "?since=2024-05-01T12:00:40Z&until=2024-05-01T12:01:00Z&client=10.0.0.0/24", 10, map[string]int64{"2xx": 10}},
+		{"default window", "", 0, map[string]int64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/stats"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			h.HandleStats(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("status %d: %s", rr.Code, rr.Body.String())
+			}
+			var resp struct {
+				stats.Summary
+			}
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Requests != tt.requests {
+				t.Errorf("requests = %d, want %d", resp.Requests, tt.requests)
+			}
+			for class, want := range tt.classes {
+				if got := resp.StatusClasses[class]; got != want {
+					t.Errorf("%s = %d, want %d", class, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleStatsTop(t *testing.T) {
+	h := newExportHandler(t)
+
+	req := httptest.NewRequest("GET", "/api/stats?since=2024-05-01T00:00:00Z&top=3", nil)
+	rr := httptest.NewRecorder()
+	h.HandleStats(rr, req)
+
+	var resp stats.Summary
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.TopClients) != 3 || resp.UniqueClients != 10 {
+		t.Errorf("top clients = %+v, unique = %d", resp.TopClients, resp.UniqueClients)
+	}
+	if len(resp.TopRouters) != 1 || resp.TopRouters[0].Count != 100 {
+		t.Errorf("top routers = %+v", resp.TopRouters)
+	}
+
+	for _, query := range []string{"top=0", "top=101", "status=abc", "source=missing"} {
+		rr := httptest.NewRecorder()
+		h.HandleStats(rr, httptest.NewRequest("GET", "/api/stats?"+query, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", query, rr.Code)
+		}
+	}
+}
+
+func TestHandleStatsUserAgents(t *testing.T) {
+	dir := t.TempDir()
+	agents := []string{
+		"Mozilla/5.0 (X11; Linux x86_64; rv:125.0) Gecko/20100101 Firefox/125.0",
+		"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+		"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+		"curl/8.5.0",
+		"sqlmap/1.8#stable (https://sqlmap.org)",
+	}
+	var lines strings.Builder
+	for _, ua := range agents {
+		lines.WriteString(`{"ClientHost":"10.0.0.1","RequestPath":"/","DownstreamStatus":200,"StartUTC":"2024-05-01T12:00:00Z","request_User-Agent":"` + ua + `"}` + "\n")
+	}
+	os.WriteFile(filepath.Join(dir, "access.log"), []byte(lines.String()), 0644)
+
+	classifier, err := useragent.New(useragent.DefaultRules(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{AccessPath: dir}
+	h := NewHandler(cfg, state.NewStateManager(cfg), Features{UserAgents: classifier})
+
+	get := func(query string) (int, stats.Summary) {
+		rr := httptest.NewRecorder()
+		h.HandleStats(rr, httptest.NewRequest("GET", "/api/stats?since=2024-05-01T00:00:00Z"+query, nil))
+		var resp stats.Summary
+		json.Unmarshal(rr.Body.Bytes(), &resp)
+		return rr.Code, resp
+	}
+
+	code, resp := get("")
+	if code != http.StatusOK || resp.Requests != 5 {
+		t.Fatalf("status %d, requests %d", code, resp.Requests)
+	}
+	if resp.TopUserAgentClasses[0] != (stats.Count{Key: "crawler", Count: 2}) || len(resp.TopUserAgentClasses) != 4 {
+		t.Errorf("classes = %+v", resp.TopUserAgentClasses)
+	}
+	if resp.TopBrowsers[0] != (stats.Count{Key: "Firefox", Count: 1}) {
+		t.Errorf("browsers = %+v", resp.TopBrowsers)
+	}
+
+	if _, resp := get("&ua_class=cli,scanner"); resp.Requests != 2 {
+		t.Errorf("ua_class filter matched %d requests, want 2", resp.Requests)
+	}
+	if _, resp := get("&ua_class=human&os=linux"); resp.Requests != 1 {
+		t.Errorf("human filter matched %d requests, want 1", resp.Requests)
+	}
+	if code, _ := get("&ua_class=robot"); code != http.StatusBadRequest {
+		t.Errorf("unknown class: status %d, want 400", code)
+	}
+}
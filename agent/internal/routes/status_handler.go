@@ -31,14 +31,29 @@ func (h *Handler) HandleStatus(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	sources := make([]map[string]interface{}, 0)
+	for _, src := range h.config.LogSources() {
+		files, err := src.Files(false)
+		sources = append(sources, map[string]interface{}{
+			"name":   src.Name,
+			"type":   src.Type,
+			"path":   src.Path,
+			"format": src.Format,
+			"labels": src.Labels,
+			"files":  len(files),
+			"exists": err == nil && len(files) > 0,
+		})
+	}
+
 	status := map[string]interface{}{
 		"status":             "ok",
 		"access_path":        h.config.AccessPath,
 		"access_path_exists": accessPathExists,
 		"error_path":         h.config.ErrorPath,
 		"error_path_exists":  errorPathExists,
+		"sources":            sources,
 		"system_monitoring":  h.config.SystemMonitoring,
-		"auth_enabled":       h.config.AuthToken != "",
+		"auth_enabled":       h.config.AuthEnabled(),
 	}
 
 	utils.RespondJSON(w, http.StatusOK, status)
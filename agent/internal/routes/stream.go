@@ -0,0 +1,165 @@
+package routes
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/watcher"
+)
+
+// streamTailer follows the active file of a source across rotations. It is
+// woken by the file watcher when the file is written, replaced or removed, and
+// when files appear in or vanish from its directory. It keeps the file open,
+// so lines written just before a rotation are still read from the old file.
+type streamTailer struct {
+	src      logs.Source
+	path     string
+	position int64
+	info     os.FileInfo
+	file     *os.File
+
+	// old is the file rotated away, read to its end before file
+	old         *os.File
+	oldPosition int64
+
+	fileSub *watcher.Subscription
+	dirSub  *watcher.Subscription
+}
+
+func (h *Handler) newStreamTailer(src logs.Source, path string) (*streamTailer, error) {
+	t := &streamTailer{
+		src:      src,
+		position: h.state.GetFilePosition(path),
+	}
+	if err := t.follow(h, path); err != nil {
+		return nil, err
+	}
+	t.open()
+	return t, nil
+}
+
+// open opens the file at path, if there is one yet
+func (t *streamTailer) open() {
+	file, err := os.Open(t.path)
+	if err != nil {
+		return
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return
+	}
+	t.file = file
+	t.info = info
+}
+
+// follow moves the watch subscriptions to path
+func (t *streamTailer) follow(h *Handler, path string) error {
+	hub := h.fileWatcher()
+
+	fileSub, err := hub.Subscribe(path)
+	if err != nil {
+		return err
+	}
+	dirSub, err := hub.Subscribe(filepath.Dir(path))
+	if err != nil {
+		fileSub.Close()
+		return err
+	}
+
+	t.unsubscribe()
+	t.path = path
+	t.fileSub = fileSub
+	t.dirSub = dirSub
+	return nil
+}
+
+// sync checks for rotation. When a newer file became active, or the file was
+// replaced in place, the old file is read to its end first and the new one
+// is then read from its beginning. A truncated file is read again from its
+// beginning.
+func (t *streamTailer) sync(h *Handler) error {
+	rotated := false
+	if active, err := t.src.ActiveFile(); err == nil && active != t.path {
+		if err := t.follow(h, active); err != nil {
+			return err
+		}
+		rotated = true
+	}
+
+	info, err := os.Stat(t.path)
+	if err != nil {
+		// Removed and not yet recreated; the directory watch wakes us
+		if rotated {
+			t.retire()
+		}
+		return nil
+	}
+	switch {
+	case rotated || (t.info != nil && !os.SameFile(t.info, info)):
+		t.retire()
+	case info.Size() < t.position:
+		t.position = 0
+	}
+
+	if t.file == nil {
+		t.open()
+	} else {
+		t.info = info
+	}
+	return nil
+}
+
+// retire keeps the open file to be read to its end and starts over with the
+// file now at path
+func (t *streamTailer) retire() {
+	if t.old != nil {
+		// Rotated twice before the first file was finished
+		t.old.Close()
+	}
+	t.old, t.oldPosition = t.file, t.position
+	t.file, t.info = nil, nil
+	t.position = 0
+}
+
+// read returns the next batch of lines, finishing a rotated file before
+// reading the current one. No lines and no error means it has caught up.
+func (t *streamTailer) read(ctx context.Context, batchLines, maxBytes int) ([]string, error) {
+	if t.old != nil {
+		lines, next, err := logs.StreamFromFile(ctx, t.old, t.oldPosition, batchLines, maxBytes)
+		t.oldPosition = next
+		if len(lines) > 0 || (err != nil && ctx.Err() != nil) {
+			return lines, err
+		}
+		t.old.Close()
+		t.old = nil
+	}
+	if t.file == nil {
+		return nil, nil
+	}
+
+	lines, next, err := logs.StreamFromFile(ctx, t.file, t.position, batchLines, maxBytes)
+	t.position = next
+	return lines, err
+}
+
+func (t *streamTailer) close() {
+	t.unsubscribe()
+	if t.file != nil {
+		t.file.Close()
+	}
+	if t.old != nil {
+		t.old.Close()
+	}
+}
+
+func (t *streamTailer) unsubscribe() {
+	if t.fileSub != nil {
+		t.fileSub.Close()
+	}
+	if t.dirSub != nil {
+		t.dirSub.Close()
+	}
+}
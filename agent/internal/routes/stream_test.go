@@ -0,0 +1,120 @@
+package routes
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/config"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/state"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+)
+
+func appendLog(t *testing.T, path, content string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStreamTailerRotation(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		active string
+		rotate func(t *testing.T, dir string)
+	}{
+		{
+			name:   "renamed and recreated",
+			path:   "access.log",
+			active: "access.log",
+			rotate: func(t *testing.T, dir string) {
+				path := filepath.Join(dir, "access.log")
+				appendLog(t, path, "b\n")
+				if err := os.Rename(path, path+".1"); err != nil {
+					t.Fatal(err)
+				}
+				appendLog(t, path, "c\n")
+			},
+		},
+		{
+			name:   "removed and recreated",
+			path:   "access.log",
+			active: "access.log",
+			rotate: func(t *testing.T, dir string) {
+				path := filepath.Join(dir, "access.log")
+				appendLog(t, path, "b\n")
+				if err := os.Remove(path); err != nil {
+					t.Fatal(err)
+				}
+				appendLog(t, path, "c\n")
+			},
+		},
+		{
+			name:   "newer file",
+			path:   "access-*.log",
+			active: "access-2.log",
+			rotate: func(t *testing.T, dir string) {
+				appendLog(t, filepath.Join(dir, "access-1.log"), "b\n")
+				appendLog(t, filepath.Join(dir, "access-2.log"), "c\n")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			first := filepath.Join(dir, strings.Replace(tt.path, "*", "1", 1))
+			appendLog(t, first, "a\n")
+
+			cfg := &config.Config{WatchMode: "poll", WatchPollIntervalMS: 20}
+			h := NewHandler(cfg, state.NewStateManager(cfg), Features{})
+			defer h.Close()
+
+			src := logs.Source{Name: "access", Path: filepath.Join(dir, tt.path), Type: logs.SourceTypeAccess}
+			tailer, err := h.newStreamTailer(src, first)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tailer.close()
+
+			read := func(want ...string) {
+				t.Helper()
+				if err := tailer.sync(h); err != nil {
+					t.Fatal(err)
+				}
+				var got []string
+				for {
+					lines, err := tailer.read(context.Background(), 10, 1024)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if len(lines) == 0 {
+						break
+					}
+					got = append(got, lines...)
+				}
+				if strings.Join(got, " ") != strings.Join(want, " ") {
+					t.Errorf("read %v, want %v", got, want)
+				}
+			}
+
+			read("a")
+			// Lines written just before the rotation come first
+			tt.rotate(t, dir)
+			read("b", "c")
+
+			appendLog(t, filepath.Join(dir, tt.active), "d\n")
+			read("d")
+			if tailer.path != filepath.Join(dir, tt.active) {
+				t.Errorf("following %s, want %s", tailer.path, tt.active)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/utils"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/traefik"
+)
+
+// topologyResponse is the body of a topology request
+type topologyResponse struct {
+	Status    string           `json:"status"`
+	FetchedAt time.Time        `json:"fetched_at"`
+	Error     string           `json:"error,omitempty"`
+	Since     time.Time        `json:"since"`
+	Until     *time.Time       `json:"until,omitempty"`
+	Overview  traefik.Overview `json:"overview"`
+	traefik.Report
+}
+
+// HandleTraefikTopology returns the routers, services and middlewares last
+// polled from the Traefik API, joined to the traffic of the access log
+// entries that match the filters of the request. When the last poll failed
+// the previous topology is returned along with the error.
+func (h *Handler) HandleTraefikTopology(w http.ResponseWriter, r *http.Request) {
+	client := h.features.Traefik
+	if client == nil {
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"status":  "disabled",
+			"message": "Traefik API polling is disabled",
+		})
+		return
+	}
+
+	topo, pollErr := client.Topology()
+	if topo == nil {
+		utils.RespondError(w, http.StatusServiceUnavailable, fmt.Sprintf("Traefik API at %s: %v", client.URL(), pollErr))
+		return
+	}
+
+	sources, err := h.selectSources(r, logs.SourceTypeAccess)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter, err := parseFilter(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if filter.Since.IsZero() {
+		filter.Since = time.Now().Add(-defaultStatsWindow)
+	}
+
+	p := h.pipeline(r, filter, true)
+	usage := traefik.NewUsage()
+	ctx := r.Context()
+
+	add := func(line string) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		entry, err := logs.ParseTraefikLog(line)
+		if err != nil || entry == nil {
+			return nil
+		}
+		p.entry(entry)
+		if filter.Match(entry) {
+			usage.Add(entry)
+		}
+		return nil
+	}
+
+	for _, src := range sources {
+		if err := logs.ScanSourceHistory(src, filter.Since, add); err != nil {
+			utils.RespondError(w, http.StatusInternalServerError, fmt.Sprintf("source %s: %v", src.Name, err))
+			return
+		}
+	}
+
+	resp := topologyResponse{
+		Status:    "ok",
+		FetchedAt: topo.FetchedAt,
+		Since:     filter.Since,
+		Overview:  topo.Overview,
+		Report:    traefik.Join(topo, usage),
+	}
+	if pollErr != nil {
+		resp.Status = "stale"
+		resp.Error = pollErr.Error()
+	}
+	if !filter.Until.IsZero() {
+		resp.Until = &filter.Until
+	}
+	utils.RespondJSON(w, http.StatusOK, resp)
+}
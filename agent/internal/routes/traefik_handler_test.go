@@ -0,0 +1,118 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/traefik"
+)
+
+func TestHandleTraefikTopology(t *testing.T) {
+	var down atomic.Bool
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			http.Error(w, "down", http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("X-Next-Page", "1")
+		switch r.URL.Path {
+		case "/api/overview":
+			w.Write([]byte(`{"http":{"routers":{"total":2,"warnings":0,"errors":0}}}`))
+		case "/api/http/routers":
+			w.Write([]byte(`[
+				{"name":"api@docker","provider":"docker","status":"enabled","rule":"Host(` + "`api.example.com`" + `)","entryPoints":["websecure"],"service":"api"},
+				{"name":"idle@docker","provider":"docker","status":"enabled","rule":"Host(` + "`idle.example.com`" + `)","entryPoints":["web"],"service":"idle"}
+			]`))
+		case "/api/http/services":
+			w.Write([]byte(`[{"name":"api@docker","provider":"docker","status":"enabled","type":"loadbalancer","loadBalancer":{"servers":[{"url":"http://10.0.0.2:80"}]}}]`))
+		case "/api/http/middlewares":
+			w.Write([]byte(`[]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer api.Close()
+
+	h := newExportHandler(t)
+	client, err := traefik.New(traefik.Options{URL: api.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.features.Traefik = client
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/traefik/topology"+query, nil)
+		w := httptest.NewRecorder()
+		h.HandleTraefikTopology(w, req)
+		return w
+	}
+
+	// Nothing polled yet
+	if w := get(""); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("got %d, want 503", w.Code)
+	}
+
+	if err := client.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var resp struct {
+		Status      string                 `json:"status"`
+		Error       string                 `json:"error"`
+		IdleRouters []string               `json:"idle_routers"`
+		Routers     []traefik.RouterReport `json:"routers"`
+	}
+	decode := func(w *httptest.ResponseRecorder) {
+		t.Helper()
+		if w.Code != http.StatusOK {
+			t.Fatalf("got %d: %s", w.Code, w.Body.String())
+		}
+		resp.Error = ""
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	decode(get("?since=2024-05-01T00:00:00Z"))
+	if resp.Status != "ok" || len(resp.Routers) != 2 {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+	traffic := resp.Routers[0].Traffic
+	if traffic.Requests != 100 || traffic.ServerErrors != 50 || traffic.ErrorRate != 0.5 {
+		t.Errorf("unexpected traffic %+v", traffic)
+	}
+	if !reflect.DeepEqual(resp.IdleRouters, []string{"idle@docker"}) {
+		t.Errorf("idle routers: got %v", resp.IdleRouters)
+	}
+
+	// Filters narrow the traffic that is joined
+	decode(get("?since=2024-05-01T00:00:00Z&status=200"))
+	if traffic := resp.Routers[0].Traffic; traffic.Requests != 50 || traffic.ServerErrors != 0 {
+		t.Errorf("filtered traffic: got %+v", traffic)
+	}
+
+	// A failed poll serves the previous topology as stale
+	down.Store(true)
+	client.Refresh(context.Background())
+	decode(get("?since=2024-05-01T00:00:00Z"))
+	if resp.Status != "stale" || resp.Error == "" || len(resp.Routers) != 2 {
+		t.Errorf("unexpected stale response %+v", resp)
+	}
+}
+
+func TestHandleTraefikTopologyDisabled(t *testing.T) {
+	h := newExportHandler(t)
+	w := httptest.NewRecorder()
+	h.HandleTraefikTopology(w, httptest.NewRequest(http.MethodGet, "/api/traefik/topology", nil))
+
+	var resp map[string]interface{}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if w.Code != http.StatusOK || resp["status"] != "disabled" {
+		t.Errorf("got %d %v", w.Code, resp)
+	}
+}
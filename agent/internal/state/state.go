@@ -4,10 +4,12 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 
 	"github.com/hhftechnology/traefik-log-dashboard/agent/internal/config"
 	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logger"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
 )
 
 // StateManager manages application state, specifically file positions
@@ -126,3 +128,46 @@ func (sm *StateManager) SetFilePosition(path string, position int64) {
 		}
 	}()
 }
+
+// GetDirectoryPositions returns the tracked positions of all files under dir,
+// with Filename set relative to dir
+func (sm *StateManager) GetDirectoryPositions(dir string) []logs.Position {
+	sm.positionMutex.RLock()
+	defer sm.positionMutex.RUnlock()
+
+	dir = filepath.Clean(dir)
+	positions := make([]logs.Position, 0)
+	for path, pos := range sm.positions {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		positions = append(positions, logs.Position{
+			Position: pos,
+			Filename: rel,
+		})
+	}
+	return positions
+}
+
+// SetDirectoryPositions updates the tracked positions for files under dir
+func (sm *StateManager) SetDirectoryPositions(dir string, positions []logs.Position) {
+	if len(positions) == 0 {
+		return
+	}
+
+	sm.positionMutex.Lock()
+	for _, pos := range positions {
+		if pos.Filename == "" {
+			continue
+		}
+		sm.positions[filepath.Join(dir, pos.Filename)] = pos.Position
+	}
+	sm.positionMutex.Unlock()
+
+	go func() {
+		if err := sm.SavePositions(); err != nil {
+			logger.Log.Printf("Error saving positions to file: %v", err)
+		}
+	}()
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
 )
 
 // RespondJSON sends a JSON response with the given status code
@@ -75,4 +76,18 @@ func GetQueryParamBool(r *http.Request, key string, defaultValue bool) bool {
 	}
 
 	return boolValue
-}
\ No newline at end of file
+}
+
+// GetQueryParamList retrieves a list query parameter from the request. The
+// parameter may be repeated and each value may hold a comma-separated list.
+func GetQueryParamList(r *http.Request, key string) []string {
+	var values []string
+	for _, raw := range r.URL.Query()[key] {
+		for _, value := range strings.Split(raw, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
@@ -0,0 +1,343 @@
+// Package blocklist maintains a list of blocked client addresses, from manual
+// entries and from security detection, and renders it as a Traefik file
+// provider dynamic configuration.
+package blocklist
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Sources of entries
+const (
+	SourceManual    = "manual"
+	SourceDetection = "detection"
+)
+
+// Entry is one blocked address or network
+type Entry struct {
+	// Prefix is a CIDR block; single addresses are /32 or /128
+	Prefix    string     `json:"prefix"`
+	Reason    string     `json:"reason,omitempty"`
+	Source    string     `json:"source"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// EventID is the security event behind a detection entry
+	EventID string `json:"event_id,omitempty"`
+}
+
+func (e Entry) expired(now time.Time) bool {
+	return e.ExpiresAt != nil && !now.Before(*e.ExpiresAt)
+}
+
+// Options configures a Manager
+type Options struct {
+	Render RenderOptions
+	// OutputPath is the dynamic configuration file Traefik watches
+	OutputPath string
+	// StatePath keeps the entries across restarts; empty keeps them in
+	// memory only
+	StatePath string
+	// DryRun renders the configuration without writing OutputPath
+	DryRun bool
+}
+
+// Manager holds the blocklist and keeps the rendered file up to date. It is
+// safe for concurrent use.
+type Manager struct {
+	opts Options
+	now  func() time.Time
+
+	mu       sync.Mutex
+	entries  map[netip.Prefix]Entry
+	rendered []byte
+}
+
+// New loads the saved entries and writes the initial configuration
+func New(opts Options) (*Manager, error) {
+	render, err := opts.Render.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+	opts.Render = render
+	if opts.OutputPath == "" && !opts.DryRun {
+		return nil, errors.New("an output path is required unless in dry-run mode")
+	}
+
+	m := &Manager{
+		opts:    opts,
+		now:     time.Now,
+		entries: make(map[netip.Prefix]Entry),
+	}
+	if err := m.load(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.expire()
+	if err := m.sync(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// DryRun reports whether the output file is left alone
+func (m *Manager) DryRun() bool {
+	return m.opts.DryRun
+}
+
+// OutputPath returns the path of the rendered file
+func (m *Manager) OutputPath() string {
+	return m.opts.OutputPath
+}
+
+// Add blocks an address or network for ttl, or for good when ttl is 0.
+// Manual entries replace detection entries for the same prefix; a detection
+// entry only extends an existing detection entry and never shortens or
+// replaces a manual one. It returns the entry now in effect.
+func (m *Manager) Add(e Entry, ttl time.Duration) (Entry, error) {
+	prefix, err := ParsePrefix(e.Prefix)
+	if err != nil {
+		return Entry{}, err
+	}
+	if e.Source == "" {
+		e.Source = SourceManual
+	}
+	if e.Source != SourceManual && e.Source != SourceDetection {
+		return Entry{}, fmt.Errorf("unknown source %q", e.Source)
+	}
+	if ttl < 0 {
+		return Entry{}, fmt.Errorf("invalid ttl %s", ttl)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now().UTC().Truncate(time.Second)
+	e.Prefix = prefix.String()
+	e.CreatedAt = now
+	e.ExpiresAt = nil
+	if ttl > 0 {
+		expires := now.Add(ttl)
+		e.ExpiresAt = &expires
+	}
+
+	if old, ok := m.entries[prefix]; ok && !old.expired(now) && e.Source == SourceDetection {
+		if old.Source == SourceManual || old.ExpiresAt == nil || (e.ExpiresAt != nil && !e.ExpiresAt.After(*old.ExpiresAt)) {
+			return old, nil
+		}
+		e.CreatedAt = old.CreatedAt
+	}
+
+	m.entries[prefix] = e
+	return e, m.sync()
+}
+
+// Remove unblocks an address or network. It reports whether it was listed.
+func (m *Manager) Remove(value string) (bool, error) {
+	prefix, err := ParsePrefix(value)
+	if err != nil {
+		return false, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.entries[prefix]; !ok {
+		return false, nil
+	}
+	delete(m.entries, prefix)
+	return true, m.sync()
+}
+
+// Expire drops expired entries and returns how many were dropped
+func (m *Manager) Expire() (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := m.expire()
+	if n == 0 {
+		return 0, nil
+	}
+	return n, m.sync()
+}
+
+func (m *Manager) expire() int {
+	now := m.now()
+	n := 0
+	for prefix, e := range m.entries {
+		if e.expired(now) {
+			delete(m.entries, prefix)
+			n++
+		}
+	}
+	return n
+}
+
+// Entries returns the active entries sorted by prefix
+func (m *Manager) Entries() []Entry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.active()
+}
+
+func (m *Manager) active() []Entry {
+	now := m.now()
+	entries := make([]Entry, 0, len(m.entries))
+	for _, e := range m.entries {
+		if !e.expired(now) {
+			entries = append(entries, e)
+		}
+	}
+	sortEntries(entries)
+	return entries
+}
+
+// Preview renders the configuration in a format, with optional entries
+// added and prefixes removed, without changing the list or the file
+func (m *Manager) Preview(format string, add []Entry, remove []string) ([]byte, error) {
+	opts := m.opts.Render
+	if format != "" {
+		opts.Format = format
+	}
+
+	skip := make(map[netip.Prefix]bool)
+	for _, value := range remove {
+		prefix, err := ParsePrefix(value)
+		if err != nil {
+			return nil, err
+		}
+		skip[prefix] = true
+	}
+
+	m.mu.Lock()
+	current := m.active()
+	m.mu.Unlock()
+
+	byPrefix := make(map[netip.Prefix]Entry, len(current)+len(add))
+	for _, e := range append(current, add...) {
+		prefix, err := ParsePrefix(e.Prefix)
+		if err != nil {
+			return nil, err
+		}
+		e.Prefix = prefix.String()
+		byPrefix[prefix] = e
+	}
+	entries := make([]Entry, 0, len(byPrefix))
+	for prefix, e := range byPrefix {
+		if !skip[prefix] {
+			entries = append(entries, e)
+		}
+	}
+	sortEntries(entries)
+	return Render(entries, opts)
+}
+
+// sync renders the active entries, writes the file when it changed and
+// saves the state. The caller holds mu.
+func (m *Manager) sync() error {
+	entries := m.active()
+	data, err := Render(entries, m.opts.Render)
+	if err != nil {
+		return err
+	}
+
+	if m.opts.StatePath != "" {
+		state, err := json.MarshalIndent(struct {
+			Entries []Entry `json:"entries"`
+		}{entries}, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := writeFileAtomic(m.opts.StatePath, append(state, '\n')); err != nil {
+			return fmt.Errorf("save blocklist state: %w", err)
+		}
+	}
+
+	if bytes.Equal(data, m.rendered) {
+		return nil
+	}
+	if !m.opts.DryRun {
+		if err := writeFileAtomic(m.opts.OutputPath, data); err != nil {
+			return fmt.Errorf("write blocklist: %w", err)
+		}
+	}
+	m.rendered = data
+	return nil
+}
+
+// load reads the saved entries, if any
+func (m *Manager) load() error {
+	if m.opts.StatePath == "" {
+		return nil
+	}
+	data, err := os.ReadFile(m.opts.StatePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var state struct {
+		Entries []Entry `json:"entries"`
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("parse %s: %w", m.opts.StatePath, err)
+	}
+	for _, e := range state.Entries {
+		prefix, err := ParsePrefix(e.Prefix)
+		if err != nil {
+			return fmt.Errorf("parse %s: %w", m.opts.StatePath, err)
+		}
+		e.Prefix = prefix.String()
+		m.entries[prefix] = e
+	}
+	return nil
+}
+
+func sortEntries(entries []Entry) {
+	sort.Slice(entries, func(i, j int) bool {
+		a, _ := ParsePrefix(entries[i].Prefix)
+		b, _ := ParsePrefix(entries[j].Prefix)
+		if a.Addr() != b.Addr() {
+			return a.Addr().Less(b.Addr())
+		}
+		return a.Bits() < b.Bits()
+	})
+}
+
+// writeFileAtomic replaces a file through a temporary file in the same
+// directory, so readers such as Traefik's file watcher never see it half
+// written
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
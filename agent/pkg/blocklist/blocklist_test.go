@@ -0,0 +1,259 @@
+package blocklist
+
+import (
+	"flag"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+var now = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func testEntries() []Entry {
+	expires := now.Add(time.Hour)
+	return []Entry{
+		{Prefix: "198.51.100.0/24", Source: SourceManual, Reason: "abuse report\n#42", CreatedAt: now},
+		{Prefix: "203.0.113.7/32", Source: SourceDetection, Reason: "brute_force", CreatedAt: now, ExpiresAt: &expires},
+		{Prefix: "2001:db8::/32", Source: SourceManual, CreatedAt: now},
+	}
+}
+
+func TestRenderGolden(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []Entry
+		opts    RenderOptions
+	}{
+		{"allowlist.yml", testEntries(), RenderOptions{}},
+		{"allowlist.toml", testEntries(), RenderOptions{Format: FormatTOML}},
+		{"whitelist-v2-depth.yml", testEntries(), RenderOptions{TraefikVersion: 2, IPStrategyDepth: 1, Middleware: "deny-abusers"}},
+		{"whitelist-v2-depth.toml", testEntries(), RenderOptions{Format: FormatTOML, TraefikVersion: 2, IPStrategyDepth: 1, Middleware: "deny-abusers"}},
+		{"plugin.yml", testEntries(), RenderOptions{Mode: ModePlugin}},
+		{"plugin.toml", testEntries(), RenderOptions{Format: FormatTOML, Mode: ModePlugin, Plugin: "fail2ban", PluginField: "denylist"}},
+		{"empty-allowlist.yml", nil, RenderOptions{}},
+		{"empty-plugin.toml", nil, RenderOptions{Format: FormatTOML, Mode: ModePlugin}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Render(tt.entries, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", tt.name)
+			if *update {
+				if err := os.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run go test -update to create it)", err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("rendered output differs from %s:\n%s", golden, got)
+			}
+		})
+	}
+}
+
+func TestRenderOptions(t *testing.T) {
+	for _, opts := range []RenderOptions{
+		{Format: "json"},
+		{Mode: "denylist"},
+		{TraefikVersion: 1},
+		{IPStrategyDepth: -1},
+		{Middleware: "bad name"},
+		{Plugin: "a.b"},
+	} {
+		if _, err := Render(nil, opts); err == nil {
+			t.Errorf("Render with %+v succeeded", opts)
+		}
+	}
+}
+
+func TestComplement(t *testing.T) {
+	mustPrefixes := func(values ...string) []netip.Prefix {
+		var out []netip.Prefix
+		for _, v := range values {
+			out = append(out, netip.MustParsePrefix(v))
+		}
+		return out
+	}
+	full := netip.MustParsePrefix("0.0.0.0/0")
+
+	if got := complement(full, nil); len(got) != 1 || got[0] != full {
+		t.Errorf("complement of nothing = %v", got)
+	}
+	if got := complement(full, mustPrefixes("0.0.0.0/1")); len(got) != 1 || got[0].String() != "128.0.0.0/1" {
+		t.Errorf("complement of the lower half = %v", got)
+	}
+
+	blocked := mustPrefixes("10.0.0.0/8", "10.1.0.0/16", "192.168.1.7/32")
+	got := complement(full, blocked)
+	if len(got) != 8+31-1 {
+		t.Errorf("complement has %d prefixes", len(got))
+	}
+	// Every address outside the blocked prefixes is covered exactly once
+	for _, addr := range []string{"0.0.0.0", "9.255.255.255", "10.0.0.1", "10.1.2.3", "11.0.0.0", "192.168.1.6", "192.168.1.7", "192.168.1.8", "255.255.255.255"} {
+		a := netip.MustParseAddr(addr)
+		isBlocked := false
+		for _, b := range blocked {
+			isBlocked = isBlocked || b.Contains(a)
+		}
+		covered := 0
+		for _, p := range got {
+			if p.Contains(a) {
+				covered++
+			}
+		}
+		if isBlocked && covered != 0 || !isBlocked && covered != 1 {
+			t.Errorf("%s: blocked %v, covered %d times", addr, isBlocked, covered)
+		}
+	}
+}
+
+func TestParsePrefix(t *testing.T) {
+	tests := map[string]string{
+		"203.0.113.7":        "203.0.113.7/32",
+		" 203.0.113.9/24 ":   "203.0.113.0/24",
+		"::ffff:203.0.113.7": "203.0.113.7/32",
+		"2001:db8::1":        "2001:db8::1/128",
+		"2001:db8::1/48":     "2001:db8::/48",
+	}
+	for in, want := range tests {
+		got, err := ParsePrefix(in)
+		if err != nil || got.String() != want {
+			t.Errorf("ParsePrefix(%q) = %v, %v; want %s", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "example.com", "10.0.0.0/33", "0.0.0.0/0", "::/0", "::ffff:10.0.0.0/104"} {
+		if _, err := ParsePrefix(bad); err == nil {
+			t.Errorf("ParsePrefix(%q) succeeded", bad)
+		}
+	}
+}
+
+func newManager(t *testing.T, opts Options) *Manager {
+	t.Helper()
+	m, err := New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.now = func() time.Time { return now }
+	return m
+}
+
+func TestManager(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "blocklist.yml")
+	statePath := filepath.Join(dir, "blocklist.json")
+	m := newManager(t, Options{OutputPath: output, StatePath: statePath})
+
+	read := func() string {
+		data, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	if !strings.Contains(read(), `"0.0.0.0/0"`) {
+		t.Fatalf("initial file should allow everything:\n%s", read())
+	}
+
+	if _, err := m.Add(Entry{Prefix: "203.0.113.7", Reason: "manual"}, 0); err != nil {
+		t.Fatal(err)
+	}
+	e, err := m.Add(Entry{Prefix: "198.51.100.1", Source: SourceDetection, EventID: "1-1"}, time.Hour)
+	if err != nil || e.ExpiresAt == nil || !e.ExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Fatalf("detection entry = %+v, %v", e, err)
+	}
+
+	// Detection does not override a manual entry and only extends its own
+	if e, _ := m.Add(Entry{Prefix: "203.0.113.7", Source: SourceDetection}, time.Hour); e.Source != SourceManual || e.ExpiresAt != nil {
+		t.Errorf("manual entry replaced by %+v", e)
+	}
+	if e, _ := m.Add(Entry{Prefix: "198.51.100.1", Source: SourceDetection}, time.Minute); !e.ExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("detection entry shortened to %v", e.ExpiresAt)
+	}
+	if e, _ := m.Add(Entry{Prefix: "198.51.100.1", Source: SourceDetection}, 2*time.Hour); !e.ExpiresAt.Equal(now.Add(2 * time.Hour)) {
+		t.Errorf("detection entry not extended: %v", e.ExpiresAt)
+	}
+
+	if got := m.Entries(); len(got) != 2 || got[0].Prefix != "198.51.100.1/32" || got[1].Prefix != "203.0.113.7/32" {
+		t.Errorf("entries = %+v", got)
+	}
+	content := read()
+	if strings.Contains(content, `"203.0.113.7/32"`) || !strings.Contains(content, `"203.0.113.6/32"`) || !strings.Contains(content, "# Blocked: 2") {
+		t.Errorf("blocked address still allowed:\n%s", content)
+	}
+
+	// A preview changes neither the list nor the file
+	preview, err := m.Preview(FormatTOML, []Entry{{Prefix: "192.0.2.1", Source: SourceManual}}, []string{"203.0.113.7"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(preview), "# Blocked: 2") || !strings.Contains(string(preview), "192.0.2.1/32 manual") || !strings.Contains(string(preview), "[http.middlewares.blocklist.ipAllowList]") {
+		t.Errorf("preview:\n%s", preview)
+	}
+	if len(m.Entries()) != 2 || read() != content {
+		t.Error("preview changed the blocklist")
+	}
+
+	m.now = func() time.Time { return now.Add(3 * time.Hour) }
+	if n, err := m.Expire(); n != 1 || err != nil {
+		t.Errorf("Expire() = %d, %v", n, err)
+	}
+	if strings.Contains(read(), "198.51.100.1/32 detection") {
+		t.Error("expired entry still rendered")
+	}
+
+	// Entries survive a restart
+	restarted := newManager(t, Options{OutputPath: output, StatePath: statePath})
+	if got := restarted.Entries(); len(got) != 1 || got[0].Reason != "manual" {
+		t.Errorf("entries after restart = %+v", got)
+	}
+
+	if ok, err := restarted.Remove("203.0.113.7/32"); !ok || err != nil {
+		t.Errorf("Remove() = %v, %v", ok, err)
+	}
+	if ok, _ := restarted.Remove("203.0.113.7"); ok {
+		t.Error("second Remove() found the entry")
+	}
+	if !strings.Contains(read(), "# Blocked: 0") {
+		t.Errorf("file not updated after remove:\n%s", read())
+	}
+
+	// No temporary files are left behind
+	files, _ := os.ReadDir(dir)
+	if len(files) != 2 {
+		t.Errorf("directory holds %d files", len(files))
+	}
+}
+
+func TestManagerDryRun(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "blocklist.yml")
+	m := newManager(t, Options{OutputPath: output, DryRun: true})
+	if _, err := m.Add(Entry{Prefix: "203.0.113.7"}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(output); !os.IsNotExist(err) {
+		t.Errorf("dry run wrote %s", output)
+	}
+	preview, err := m.Preview("", nil, nil)
+	if err != nil || !strings.Contains(string(preview), "203.0.113.7/32 manual") {
+		t.Errorf("preview = %s, %v", preview, err)
+	}
+
+	if _, err := New(Options{}); err == nil {
+		t.Error("expected an error without an output path")
+	}
+	if _, err := m.Add(Entry{Prefix: "203.0.113.7", Source: "robot"}, 0); err == nil {
+		t.Error("expected an error for an unknown source")
+	}
+}
@@ -0,0 +1,78 @@
+package blocklist
+
+import (
+	"fmt"
+	"net/netip"
+	"sort"
+	"strings"
+)
+
+// ParsePrefix parses an address or CIDR block into its masked prefix. A
+// single address becomes a /32 or /128.
+func ParsePrefix(value string) (netip.Prefix, error) {
+	value = strings.TrimSpace(value)
+	if strings.Contains(value, "/") {
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid CIDR %q", value)
+		}
+		prefix = prefix.Masked()
+		if prefix.Addr().Is4In6() {
+			return netip.Prefix{}, fmt.Errorf("invalid CIDR %q: use the IPv4 form", value)
+		}
+		if prefix.Bits() == 0 {
+			return netip.Prefix{}, fmt.Errorf("refusing to block every address (%s)", value)
+		}
+		return prefix, nil
+	}
+
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid IP address %q", value)
+	}
+	addr = addr.Unmap().WithZone("")
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// complement returns the smallest set of prefixes that covers the whole
+// address family of full except the blocked prefixes, sorted by address
+func complement(full netip.Prefix, blocked []netip.Prefix) []netip.Prefix {
+	var out []netip.Prefix
+	var walk func(p netip.Prefix, candidates []netip.Prefix)
+	walk = func(p netip.Prefix, candidates []netip.Prefix) {
+		var overlapping []netip.Prefix
+		for _, b := range candidates {
+			if b.Bits() <= p.Bits() && b.Contains(p.Addr()) {
+				// p lies inside a blocked prefix
+				return
+			}
+			if p.Overlaps(b) {
+				overlapping = append(overlapping, b)
+			}
+		}
+		if len(overlapping) == 0 {
+			out = append(out, p)
+			return
+		}
+		lower, upper := split(p)
+		walk(lower, overlapping)
+		walk(upper, overlapping)
+	}
+	walk(full, blocked)
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Addr().Less(out[j].Addr())
+	})
+	return out
+}
+
+// split halves a prefix
+func split(p netip.Prefix) (netip.Prefix, netip.Prefix) {
+	bits := p.Bits() + 1
+	lower := netip.PrefixFrom(p.Addr(), bits)
+
+	upper := p.Addr().AsSlice()
+	upper[p.Bits()/8] |= 0x80 >> (p.Bits() % 8)
+	addr, _ := netip.AddrFromSlice(upper)
+	return lower, netip.PrefixFrom(addr, bits)
+}
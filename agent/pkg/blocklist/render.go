@@ -0,0 +1,213 @@
+package blocklist
+
+import (
+	"bytes"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Output formats
+const (
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+)
+
+// Modes of the rendered middleware
+const (
+	// ModeAllowList allows every address except the blocked ones with
+	// Traefik's built-in ipAllowList (ipWhiteList before v3) middleware
+	ModeAllowList = "allowlist"
+	// ModePlugin lists the blocked addresses in a deny list plugin
+	ModePlugin = "plugin"
+)
+
+// RenderOptions shape the dynamic configuration. Zero fields take their
+// defaults.
+type RenderOptions struct {
+	Format string
+	Mode   string
+	// Middleware is the name to attach to routers, as <name>@file
+	Middleware string
+	// TraefikVersion 2 renders ipWhiteList instead of ipAllowList
+	TraefikVersion int
+	// IPStrategyDepth takes the client from X-Forwarded-For when Traefik
+	// sits behind other proxies; 0 uses the connection address
+	IPStrategyDepth int
+	// Plugin is the name the deny list plugin is declared under in the
+	// static configuration, and PluginField the option holding its list
+	Plugin      string
+	PluginField string
+}
+
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// withDefaults fills in defaults and validates the options
+func (o RenderOptions) withDefaults() (RenderOptions, error) {
+	if o.Format == "" {
+		o.Format = FormatYAML
+	}
+	if o.Mode == "" {
+		o.Mode = ModeAllowList
+	}
+	if o.Middleware == "" {
+		o.Middleware = "blocklist"
+	}
+	if o.TraefikVersion == 0 {
+		o.TraefikVersion = 3
+	}
+	if o.Plugin == "" {
+		o.Plugin = "denyip"
+	}
+	if o.PluginField == "" {
+		o.PluginField = "ipDenyList"
+	}
+
+	switch {
+	case o.Format != FormatYAML && o.Format != FormatTOML:
+		return o, fmt.Errorf("unknown format %q, use yaml or toml", o.Format)
+	case o.Mode != ModeAllowList && o.Mode != ModePlugin:
+		return o, fmt.Errorf("unknown mode %q, use allowlist or plugin", o.Mode)
+	case o.TraefikVersion != 2 && o.TraefikVersion != 3:
+		return o, fmt.Errorf("unsupported Traefik version %d, use 2 or 3", o.TraefikVersion)
+	case o.IPStrategyDepth < 0:
+		return o, fmt.Errorf("invalid ipStrategy depth %d", o.IPStrategyDepth)
+	}
+	for _, name := range []string{o.Middleware, o.Plugin, o.PluginField} {
+		if !namePattern.MatchString(name) {
+			return o, fmt.Errorf("invalid name %q: use letters, digits, - and _", name)
+		}
+	}
+	return o, nil
+}
+
+// Render renders entries as a Traefik file provider dynamic configuration
+// holding one middleware. The output only depends on the entries and the
+// options, so unchanged lists render identically.
+func Render(entries []Entry, opts RenderOptions) ([]byte, error) {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+
+	var blocked []netip.Prefix
+	for _, e := range entries {
+		prefix, err := ParsePrefix(e.Prefix)
+		if err != nil {
+			return nil, err
+		}
+		blocked = append(blocked, prefix)
+	}
+
+	var ranges []string
+	var key string
+	if opts.Mode == ModeAllowList {
+		key = "ipAllowList"
+		if opts.TraefikVersion == 2 {
+			key = "ipWhiteList"
+		}
+		var v4, v6 []netip.Prefix
+		for _, p := range blocked {
+			if p.Addr().Is4() {
+				v4 = append(v4, p)
+			} else {
+				v6 = append(v6, p)
+			}
+		}
+		for _, p := range complement(netip.MustParsePrefix("0.0.0.0/0"), v4) {
+			ranges = append(ranges, p.String())
+		}
+		for _, p := range complement(netip.MustParsePrefix("::/0"), v6) {
+			ranges = append(ranges, p.String())
+		}
+	} else {
+		for _, e := range entries {
+			ranges = append(ranges, e.Prefix)
+		}
+	}
+
+	var buf bytes.Buffer
+	writeHeader(&buf, entries)
+	if opts.Format == FormatYAML {
+		renderYAML(&buf, opts, key, ranges)
+	} else {
+		renderTOML(&buf, opts, key, ranges)
+	}
+	return buf.Bytes(), nil
+}
+
+func writeHeader(buf *bytes.Buffer, entries []Entry) {
+	buf.WriteString("# Generated by the Traefik Log Dashboard agent. Changes are overwritten.\n")
+	fmt.Fprintf(buf, "# Blocked: %d\n", len(entries))
+	for _, e := range entries {
+		fmt.Fprintf(buf, "#   %s %s", e.Prefix, e.Source)
+		if e.Reason != "" {
+			fmt.Fprintf(buf, ": %s", comment(e.Reason))
+		}
+		if e.ExpiresAt != nil {
+			fmt.Fprintf(buf, " (until %s)", e.ExpiresAt.UTC().Format(time.RFC3339))
+		}
+		buf.WriteString("\n")
+	}
+}
+
+// comment keeps free text on a single comment line
+func comment(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func renderYAML(buf *bytes.Buffer, opts RenderOptions, key string, ranges []string) {
+	buf.WriteString("http:\n  middlewares:\n")
+	fmt.Fprintf(buf, "    %s:\n", opts.Middleware)
+
+	if opts.Mode == ModePlugin {
+		fmt.Fprintf(buf, "      plugin:\n        %s:\n", opts.Plugin)
+		writeYAMLList(buf, "          ", opts.PluginField, ranges)
+		return
+	}
+
+	fmt.Fprintf(buf, "      %s:\n", key)
+	writeYAMLList(buf, "        ", "sourceRange", ranges)
+	if opts.IPStrategyDepth > 0 {
+		fmt.Fprintf(buf, "        ipStrategy:\n          depth: %d\n", opts.IPStrategyDepth)
+	}
+}
+
+func writeYAMLList(buf *bytes.Buffer, indent, name string, values []string) {
+	if len(values) == 0 {
+		fmt.Fprintf(buf, "%s%s: []\n", indent, name)
+		return
+	}
+	fmt.Fprintf(buf, "%s%s:\n", indent, name)
+	for _, v := range values {
+		fmt.Fprintf(buf, "%s  - %q\n", indent, v)
+	}
+}
+
+func renderTOML(buf *bytes.Buffer, opts RenderOptions, key string, ranges []string) {
+	if opts.Mode == ModePlugin {
+		fmt.Fprintf(buf, "[http.middlewares.%s.plugin.%s]\n", opts.Middleware, opts.Plugin)
+		writeTOMLList(buf, "  ", opts.PluginField, ranges)
+		return
+	}
+
+	fmt.Fprintf(buf, "[http.middlewares.%s.%s]\n", opts.Middleware, key)
+	writeTOMLList(buf, "  ", "sourceRange", ranges)
+	if opts.IPStrategyDepth > 0 {
+		fmt.Fprintf(buf, "\n  [http.middlewares.%s.%s.ipStrategy]\n    depth = %d\n", opts.Middleware, key, opts.IPStrategyDepth)
+	}
+}
+
+func writeTOMLList(buf *bytes.Buffer, indent, name string, values []string) {
+	if len(values) == 0 {
+		fmt.Fprintf(buf, "%s%s = []\n", indent, name)
+		return
+	}
+	fmt.Fprintf(buf, "%s%s = [\n", indent, name)
+	for _, v := range values {
+		fmt.Fprintf(buf, "%s  %q,\n", indent, v)
+	}
+	fmt.Fprintf(buf, "%s]\n", indent)
+}
@@ -0,0 +1,337 @@
+package export
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+)
+
+// The columnar format stores rows in groups, column by column, so repeated
+// values compress well and readers can skip columns they do not need.
+//
+//	file    = magic version columns group* end
+//	magic   = "TLDC"
+//	version = byte (1)
+//	columns = uvarint(n) { uvarint(len) name kind:byte }*n
+//	group   = uvarint(rows) block*columns
+//	end     = uvarint(0)
+//
+// A string block is a dictionary of the group's distinct values,
+// uvarint(size) { uvarint(len) bytes }*size, followed by one uvarint index
+// per row. Int blocks hold one zigzag varint per row with the difference to
+// the previous row. Time blocks do the same with Unix nanoseconds, where 0
+// is the zero time.
+const (
+	columnarMagic   = "TLDC"
+	columnarVersion = 1
+
+	// ColumnarGroupRows is the number of rows buffered per group
+	ColumnarGroupRows = 4096
+)
+
+// columnarWriter buffers up to ColumnarGroupRows rows and writes them as a group
+type columnarWriter struct {
+	w       *bufio.Writer
+	columns []Column
+	started bool
+	rows    int
+	strs    [][]string
+	ints    [][]int64
+	scratch []byte
+}
+
+func newColumnarWriter(w io.Writer, columns []Column) *columnarWriter {
+	return &columnarWriter{
+		w:       bufio.NewWriter(w),
+		columns: columns,
+		strs:    make([][]string, len(columns)),
+		ints:    make([][]int64, len(columns)),
+		scratch: make([]byte, binary.MaxVarintLen64),
+	}
+}
+
+func (c *columnarWriter) Write(entry *logs.TraefikLog) error {
+	for i, column := range c.columns {
+		switch column.Kind {
+		case KindString:
+			c.strs[i] = append(c.strs[i], column.stringValue(entry))
+		case KindInt:
+			c.ints[i] = append(c.ints[i], column.intValue(entry))
+		case KindTime:
+			var nanos int64
+			if ts := column.timeValue(entry); !ts.IsZero() {
+				nanos = ts.UnixNano()
+			}
+			c.ints[i] = append(c.ints[i], nanos)
+		}
+	}
+	c.rows++
+
+	if c.rows >= ColumnarGroupRows {
+		return c.writeGroup()
+	}
+	return nil
+}
+
+// Flush writes the buffered rows as a group, so a flush point always ends
+// on a complete group
+func (c *columnarWriter) Flush() error {
+	if err := c.writeGroup(); err != nil {
+		return err
+	}
+	return c.w.Flush()
+}
+
+func (c *columnarWriter) Close() error {
+	if err := c.writeGroup(); err != nil {
+		return err
+	}
+	c.uvarint(0)
+	return c.w.Flush()
+}
+
+func (c *columnarWriter) header() {
+	if c.started {
+		return
+	}
+	c.started = true
+	c.w.WriteString(columnarMagic)
+	c.w.WriteByte(columnarVersion)
+	c.uvarint(uint64(len(c.columns)))
+	for _, column := range c.columns {
+		c.str(column.Name)
+		c.w.WriteByte(byte(column.Kind))
+	}
+}
+
+func (c *columnarWriter) writeGroup() error {
+	c.header()
+	if c.rows == 0 {
+		return nil
+	}
+
+	c.uvarint(uint64(c.rows))
+	for i, column := range c.columns {
+		if column.Kind == KindString {
+			c.stringBlock(c.strs[i])
+			c.strs[i] = c.strs[i][:0]
+			continue
+		}
+		var prev int64
+		for _, v := range c.ints[i] {
+			c.varint(v - prev)
+			prev = v
+		}
+		c.ints[i] = c.ints[i][:0]
+	}
+	c.rows = 0
+
+	// bufio keeps the first write error
+	_, err := c.w.Write(nil)
+	return err
+}
+
+func (c *columnarWriter) stringBlock(values []string) {
+	dict := make(map[string]uint64)
+	order := make([]string, 0)
+	indexes := make([]uint64, len(values))
+	for i, v := range values {
+		index, ok := dict[v]
+		if !ok {
+			index = uint64(len(order))
+			dict[v] = index
+			order = append(order, v)
+		}
+		indexes[i] = index
+	}
+
+	c.uvarint(uint64(len(order)))
+	for _, v := range order {
+		c.str(v)
+	}
+	for _, index := range indexes {
+		c.uvarint(index)
+	}
+}
+
+func (c *columnarWriter) str(s string) {
+	c.uvarint(uint64(len(s)))
+	c.w.WriteString(s)
+}
+
+func (c *columnarWriter) uvarint(v uint64) {
+	n := binary.PutUvarint(c.scratch, v)
+	c.w.Write(c.scratch[:n])
+}
+
+func (c *columnarWriter) varint(v int64) {
+	n := binary.PutVarint(c.scratch, v)
+	c.w.Write(c.scratch[:n])
+}
+
+// ErrInvalidColumnar is returned for input that is not in the columnar format
+var ErrInvalidColumnar = errors.New("invalid columnar export")
+
+// maxColumnarString bounds string lengths and dictionary sizes when reading
+const maxColumnarString = 1 << 24
+
+// Reader decodes the columnar format row by row. Values are strings, int64s
+// and time.Times according to the column kinds.
+type Reader struct {
+	r       *bufio.Reader
+	columns []Column
+	group   [][]interface{}
+	row     int
+	done    bool
+}
+
+// NewReader reads the header of a columnar export
+func NewReader(r io.Reader) (*Reader, error) {
+	cr := &Reader{r: bufio.NewReader(r)}
+
+	magic := make([]byte, len(columnarMagic)+1)
+	if _, err := io.ReadFull(cr.r, magic); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidColumnar, err)
+	}
+	if string(magic[:len(columnarMagic)]) != columnarMagic {
+		return nil, fmt.Errorf("%w: bad magic", ErrInvalidColumnar)
+	}
+	if magic[len(columnarMagic)] != columnarVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidColumnar, magic[len(columnarMagic)])
+	}
+
+	n, err := cr.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < n; i++ {
+		name, err := cr.str()
+		if err != nil {
+			return nil, err
+		}
+		kind, err := cr.r.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidColumnar, err)
+		}
+		cr.columns = append(cr.columns, Column{Name: name, Kind: Kind(kind)})
+	}
+	return cr, nil
+}
+
+// Columns returns the columns in the header
+func (cr *Reader) Columns() []Column {
+	return cr.columns
+}
+
+// Next returns the next row, or io.EOF after the last one
+func (cr *Reader) Next() ([]interface{}, error) {
+	for len(cr.group) == 0 || cr.row >= len(cr.group[0]) {
+		if cr.done {
+			return nil, io.EOF
+		}
+		if err := cr.readGroup(); err != nil {
+			return nil, err
+		}
+	}
+
+	row := make([]interface{}, len(cr.columns))
+	for i := range cr.columns {
+		row[i] = cr.group[i][cr.row]
+	}
+	cr.row++
+	return row, nil
+}
+
+func (cr *Reader) readGroup() error {
+	rows, err := cr.uvarint()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		cr.done = true
+		return nil
+	}
+	if rows > maxColumnarString {
+		return fmt.Errorf("%w: group of %d rows", ErrInvalidColumnar, rows)
+	}
+
+	cr.group = make([][]interface{}, len(cr.columns))
+	cr.row = 0
+	for i, column := range cr.columns {
+		values := make([]interface{}, rows)
+		switch column.Kind {
+		case KindString:
+			size, err := cr.uvarint()
+			if err != nil {
+				return err
+			}
+			if size > rows {
+				return fmt.Errorf("%w: dictionary larger than group", ErrInvalidColumnar)
+			}
+			dict := make([]string, size)
+			for j := range dict {
+				if dict[j], err = cr.str(); err != nil {
+					return err
+				}
+			}
+			for j := range values {
+				index, err := cr.uvarint()
+				if err != nil {
+					return err
+				}
+				if index >= size {
+					return fmt.Errorf("%w: dictionary index out of range", ErrInvalidColumnar)
+				}
+				values[j] = dict[index]
+			}
+		case KindInt, KindTime:
+			var prev int64
+			for j := range values {
+				delta, err := binary.ReadVarint(cr.r)
+				if err != nil {
+					return fmt.Errorf("%w: %v", ErrInvalidColumnar, err)
+				}
+				prev += delta
+				if column.Kind == KindInt {
+					values[j] = prev
+				} else if prev == 0 {
+					values[j] = time.Time{}
+				} else {
+					values[j] = time.Unix(0, prev).UTC()
+				}
+			}
+		default:
+			return fmt.Errorf("%w: unknown column kind %d", ErrInvalidColumnar, column.Kind)
+		}
+		cr.group[i] = values
+	}
+	return nil
+}
+
+func (cr *Reader) uvarint() (uint64, error) {
+	v, err := binary.ReadUvarint(cr.r)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidColumnar, err)
+	}
+	return v, nil
+}
+
+func (cr *Reader) str() (string, error) {
+	n, err := cr.uvarint()
+	if err != nil {
+		return "", err
+	}
+	if n > maxColumnarString {
+		return "", fmt.Errorf("%w: string of %d bytes", ErrInvalidColumnar, n)
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(cr.r, buf); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidColumnar, err)
+	}
+	return string(buf), nil
+}
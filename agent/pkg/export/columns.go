@@ -0,0 +1,158 @@
+// Package export writes parsed access log entries as CSV, NDJSON or a compact
+// columnar binary format.
+package export
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+)
+
+// Kind is the value type of a column
+type Kind byte
+
+// Column kinds
+const (
+	KindString Kind = 1
+	KindInt    Kind = 2
+	KindTime   Kind = 3
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindString:
+		return "string"
+	case KindInt:
+		return "int"
+	case KindTime:
+		return "time"
+	}
+	return fmt.Sprintf("kind(%d)", byte(k))
+}
+
+// Column is one exportable field of a TraefikLog, named after its JSON key
+type Column struct {
+	Name  string
+	Kind  Kind
+	field int
+}
+
+// DefaultColumns are exported when no columns are requested
+var DefaultColumns = []string{
+	"StartUTC",
+	"ClientHost",
+	"RequestMethod",
+	"RequestHost",
+	"RequestPath",
+	"DownstreamStatus",
+	"Duration",
+	"RouterName",
+	"ServiceName",
+	"DownstreamContentSize",
+	"RequestUserAgent",
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// allColumns lists every TraefikLog field in declaration order
+var allColumns = func() []Column {
+	t := reflect.TypeOf(logs.TraefikLog{})
+	columns := make([]Column, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			name = field.Name
+		}
+
+		var kind Kind
+		switch {
+		case field.Type == timeType:
+			kind = KindTime
+		case field.Type.Kind() == reflect.String:
+			kind = KindString
+		case field.Type.Kind() == reflect.Int, field.Type.Kind() == reflect.Int64:
+			kind = KindInt
+		default:
+			continue
+		}
+		columns = append(columns, Column{Name: name, Kind: kind, field: i})
+	}
+	return columns
+}()
+
+// AllColumns returns every exportable column
+func AllColumns() []Column {
+	return append([]Column(nil), allColumns...)
+}
+
+// Columns resolves column names, case-insensitively. No names selects
+// DefaultColumns and "all" selects every column.
+func Columns(names []string) ([]Column, error) {
+	if len(names) == 0 {
+		names = DefaultColumns
+	}
+	if len(names) == 1 && strings.EqualFold(names[0], "all") {
+		return AllColumns(), nil
+	}
+
+	columns := make([]Column, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		column, ok := lookupColumn(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+		if seen[column.Name] {
+			continue
+		}
+		seen[column.Name] = true
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+func lookupColumn(name string) (Column, bool) {
+	for _, column := range allColumns {
+		if strings.EqualFold(column.Name, name) {
+			return column, true
+		}
+	}
+	return Column{}, false
+}
+
+func (c Column) value(entry *logs.TraefikLog) reflect.Value {
+	return reflect.ValueOf(entry).Elem().Field(c.field)
+}
+
+// stringValue returns the value of a string column
+func (c Column) stringValue(entry *logs.TraefikLog) string {
+	return c.value(entry).String()
+}
+
+// intValue returns the value of an int column
+func (c Column) intValue(entry *logs.TraefikLog) int64 {
+	return c.value(entry).Int()
+}
+
+// timeValue returns the value of a time column
+func (c Column) timeValue(entry *logs.TraefikLog) time.Time {
+	return c.value(entry).Interface().(time.Time)
+}
+
+// text formats any column as text; zero times are empty
+func (c Column) text(entry *logs.TraefikLog) string {
+	switch c.Kind {
+	case KindInt:
+		return fmt.Sprint(c.intValue(entry))
+	case KindTime:
+		if ts := c.timeValue(entry); !ts.IsZero() {
+			return ts.Format(time.RFC3339Nano)
+		}
+		return ""
+	}
+	return c.stringValue(entry)
+}
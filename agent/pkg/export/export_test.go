@@ -0,0 +1,175 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+)
+
+func testEntries(n int) []*logs.TraefikLog {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	entries := make([]*logs.TraefikLog, n)
+	for i := range entries {
+		entries[i] = &logs.TraefikLog{
+			StartUTC:         start.Add(time.Duration(i) * time.Millisecond),
+			ClientHost:       fmt.Sprintf("10.0.0.%d", i%7),
+			RequestMethod:    "GET",
+			RequestPath:      fmt.Sprintf("/items/%d?q=<x>", i),
+			DownstreamStatus: 200 + i%3,
+			Duration:         int64(1000000 - i),
+			RouterName:       "api@docker",
+		}
+	}
+	return entries
+}
+
+func writeAll(t *testing.T, format string, columns []Column, entries []*logs.TraefikLog) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(format, &buf, columns)
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+	for _, entry := range entries {
+		if err := w.Write(entry); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	return &buf
+}
+
+func TestColumns(t *testing.T) {
+	columns, err := Columns(nil)
+	if err != nil || len(columns) != len(DefaultColumns) {
+		t.Fatalf("expected default columns, got %v %v", columns, err)
+	}
+
+	columns, err = Columns([]string{"requestpath", "StartUTC", "RequestPath", "entryPointName"})
+	if err != nil {
+		t.Fatalf("Columns: %v", err)
+	}
+	if len(columns) != 3 || columns[0].Name != "RequestPath" || columns[1].Kind != KindTime || columns[2].Name != "entryPointName" {
+		t.Fatalf("unexpected columns %+v", columns)
+	}
+
+	if _, err := Columns([]string{"Nope"}); err == nil {
+		t.Fatal("expected unknown column error")
+	}
+	if all, _ := Columns([]string{"all"}); len(all) != len(AllColumns()) {
+		t.Fatalf("expected all columns, got %d", len(all))
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	columns, _ := Columns([]string{"StartUTC", "RequestPath", "DownstreamStatus"})
+	buf := writeAll(t, FormatCSV, columns, testEntries(3))
+
+	records, err := csv.NewReader(buf).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	if len(records) != 4 || records[0][1] != "RequestPath" {
+		t.Fatalf("unexpected records %v", records)
+	}
+	if records[2][0] != "2024-05-01T12:00:00.001Z" || records[2][1] != "/items/1?q=<x>" || records[2][2] != "201" {
+		t.Fatalf("unexpected row %v", records[2])
+	}
+
+	// An empty export still has a header
+	buf = writeAll(t, FormatCSV, columns, nil)
+	if buf.String() != "StartUTC,RequestPath,DownstreamStatus\n" {
+		t.Fatalf("unexpected empty export %q", buf.String())
+	}
+}
+
+func TestNDJSONWriter(t *testing.T) {
+	columns, _ := Columns([]string{"RequestPath", "Duration", "StartUTC"})
+	buf := writeAll(t, FormatNDJSON, columns, testEntries(2))
+
+	dec := json.NewDecoder(buf)
+	for i := 0; i < 2; i++ {
+		var row struct {
+			RequestPath string
+			Duration    int64
+			StartUTC    time.Time
+		}
+		if err := dec.Decode(&row); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if row.RequestPath != fmt.Sprintf("/items/%d?q=<x>", i) || row.Duration != int64(1000000-i) || row.StartUTC.Nanosecond() != i*1000000 {
+			t.Fatalf("unexpected row %+v", row)
+		}
+	}
+	if bytes.Contains(buf.Bytes(), []byte(`<`)) {
+		t.Fatal("expected unescaped HTML characters")
+	}
+}
+
+func TestColumnarRoundTrip(t *testing.T) {
+	columns, _ := Columns([]string{"StartUTC", "ClientHost", "DownstreamStatus", "Duration", "StartLocal"})
+	entries := testEntries(ColumnarGroupRows + 10)
+	buf := writeAll(t, FormatColumnar, columns, entries)
+
+	r, err := NewReader(buf)
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	if got := r.Columns(); len(got) != len(columns) || got[3].Name != "Duration" || got[3].Kind != KindInt {
+		t.Fatalf("unexpected columns %+v", got)
+	}
+
+	for i, entry := range entries {
+		row, err := r.Next()
+		if err != nil {
+			t.Fatalf("row %d: %v", i, err)
+		}
+		if !row[0].(time.Time).Equal(entry.StartUTC) || row[1] != entry.ClientHost ||
+			row[2] != int64(entry.DownstreamStatus) || row[3] != entry.Duration || !row[4].(time.Time).IsZero() {
+			t.Fatalf("row %d mismatch: %v", i, row)
+		}
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+}
+
+func TestColumnarIsCompact(t *testing.T) {
+	columns, _ := Columns(nil)
+	entries := testEntries(2000)
+	csvSize := writeAll(t, FormatCSV, columns, entries).Len()
+	columnarSize := writeAll(t, FormatColumnar, columns, entries).Len()
+	if columnarSize*2 > csvSize {
+		t.Fatalf("expected columnar (%d bytes) to be under half of CSV (%d bytes)", columnarSize, csvSize)
+	}
+}
+
+func TestColumnarRejectsGarbage(t *testing.T) {
+	if _, err := NewReader(bytes.NewReader([]byte("NOPE\x01"))); !errors.Is(err, ErrInvalidColumnar) {
+		t.Fatalf("expected ErrInvalidColumnar, got %v", err)
+	}
+
+	columns, _ := Columns([]string{"ClientHost"})
+	data := writeAll(t, FormatColumnar, columns, testEntries(5)).Bytes()
+	r, err := NewReader(bytes.NewReader(data[:len(data)-3]))
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	for {
+		if _, err = r.Next(); err != nil {
+			break
+		}
+	}
+	if !errors.Is(err, ErrInvalidColumnar) {
+		t.Fatalf("expected truncation error, got %v", err)
+	}
+}
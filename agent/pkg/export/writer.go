@@ -0,0 +1,164 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+)
+
+// Export formats
+const (
+	FormatCSV      = "csv"
+	FormatNDJSON   = "ndjson"
+	FormatColumnar = "columnar"
+)
+
+// Writer encodes entries in one export format. Output is buffered until
+// Flush or Close.
+type Writer interface {
+	Write(entry *logs.TraefikLog) error
+	Flush() error
+	Close() error
+}
+
+// NewWriter returns a writer for the given format
+func NewWriter(format string, w io.Writer, columns []Column) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, columns), nil
+	case FormatNDJSON:
+		return newNDJSONWriter(w, columns), nil
+	case FormatColumnar:
+		return newColumnarWriter(w, columns), nil
+	}
+	return nil, fmt.Errorf("unknown export format %q: use csv, ndjson or columnar", format)
+}
+
+// ContentType returns the media type of a format
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	}
+	return "application/octet-stream"
+}
+
+// Extension returns the file extension of a format
+func Extension(format string) string {
+	switch format {
+	case FormatCSV:
+		return ".csv"
+	case FormatNDJSON:
+		return ".ndjson"
+	}
+	return ".tldc"
+}
+
+// csvWriter writes a header row followed by one row per entry
+type csvWriter struct {
+	w       *csv.Writer
+	columns []Column
+	record  []string
+	started bool
+}
+
+func newCSVWriter(w io.Writer, columns []Column) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w), columns: columns, record: make([]string, len(columns))}
+}
+
+func (c *csvWriter) header() error {
+	if c.started {
+		return nil
+	}
+	c.started = true
+	for i, column := range c.columns {
+		c.record[i] = column.Name
+	}
+	return c.w.Write(c.record)
+}
+
+func (c *csvWriter) Write(entry *logs.TraefikLog) error {
+	if err := c.header(); err != nil {
+		return err
+	}
+	for i, column := range c.columns {
+		c.record[i] = column.text(entry)
+	}
+	return c.w.Write(c.record)
+}
+
+func (c *csvWriter) Flush() error {
+	if err := c.header(); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) Close() error {
+	return c.Flush()
+}
+
+// ndjsonWriter writes one object per entry holding the selected columns in order
+type ndjsonWriter struct {
+	w       *bufio.Writer
+	columns []Column
+	buf     []byte
+	str     bytes.Buffer
+	enc     *json.Encoder
+}
+
+func newNDJSONWriter(w io.Writer, columns []Column) *ndjsonWriter {
+	n := &ndjsonWriter{w: bufio.NewWriter(w), columns: columns}
+	n.enc = json.NewEncoder(&n.str)
+	n.enc.SetEscapeHTML(false)
+	return n
+}
+
+func (n *ndjsonWriter) Write(entry *logs.TraefikLog) error {
+	b := append(n.buf[:0], '{')
+	for i, column := range n.columns {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = strconv.AppendQuote(b, column.Name)
+		b = append(b, ':')
+
+		switch column.Kind {
+		case KindInt:
+			b = strconv.AppendInt(b, column.intValue(entry), 10)
+		case KindTime:
+			b = append(b, '"')
+			b = column.timeValue(entry).AppendFormat(b, time.RFC3339Nano)
+			b = append(b, '"')
+		default:
+			n.str.Reset()
+			if err := n.enc.Encode(column.stringValue(entry)); err != nil {
+				return err
+			}
+			b = append(b, bytes.TrimSuffix(n.str.Bytes(), []byte("\n"))...)
+		}
+	}
+	b = append(b, '}', '\n')
+	n.buf = b
+
+	_, err := n.w.Write(b)
+	return err
+}
+
+func (n *ndjsonWriter) Flush() error {
+	return n.w.Flush()
+}
+
+func (n *ndjsonWriter) Close() error {
+	return n.w.Flush()
+}
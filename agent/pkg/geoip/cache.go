@@ -0,0 +1,63 @@
+package geoip
+
+import (
+	"container/list"
+	"sync"
+)
+
+// cache is a fixed-size LRU cache of lookups keyed by address
+type cache struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List
+	items map[string]*list.Element
+}
+
+type cacheEntry struct {
+	addr string
+	info Info
+}
+
+func newCache(size int) *cache {
+	return &cache{
+		size:  size,
+		order: list.New(),
+		items: make(map[string]*list.Element, size),
+	}
+}
+
+func (c *cache) get(addr string) (Info, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[addr]
+	if !ok {
+		return Info{}, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*cacheEntry).info, true
+}
+
+func (c *cache) put(addr string, info Info) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[addr]; ok {
+		el.Value.(*cacheEntry).info = info
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.items[addr] = c.order.PushFront(&cacheEntry{addr: addr, info: info})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry).addr)
+	}
+}
+
+func (c *cache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
@@ -0,0 +1,185 @@
+// Package geoip resolves client addresses to a location and autonomous system
+// using local MaxMind-format (.mmdb) databases.
+package geoip
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// DefaultCacheSize is the number of addresses cached when Options.CacheSize is 0
+const DefaultCacheSize = 10000
+
+// Info is what is known about one address
+type Info struct {
+	CountryCode string `json:"country_code,omitempty"`
+	Country     string `json:"country,omitempty"`
+	City        string `json:"city,omitempty"`
+	ASN         int    `json:"asn,omitempty"`
+	ASOrg       string `json:"as_org,omitempty"`
+	// Private is set for loopback, private, link-local and shared (CGNAT)
+	// addresses, which are never looked up
+	Private bool `json:"private,omitempty"`
+}
+
+// Options configures a DB. Either path may be empty.
+type Options struct {
+	// LocationPath is a country or city database
+	LocationPath string
+	// ASNPath is an ASN database
+	ASNPath   string
+	CacheSize int
+}
+
+// DB looks up addresses in a location and an ASN database
+type DB struct {
+	location *maxminddb.Reader
+	asn      *maxminddb.Reader
+	cache    *cache
+}
+
+// record covers the fields read from country, city and ASN databases
+type record struct {
+	Country struct {
+		ISOCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	RegisteredCountry struct {
+		ISOCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"registered_country"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	AutonomousSystemNumber       uint   `maxminddb:"autonomous_system_number"`
+	AutonomousSystemOrganization string `maxminddb:"autonomous_system_organization"`
+}
+
+// Open opens the configured databases. It fails when neither is set.
+func Open(opts Options) (*DB, error) {
+	if opts.LocationPath == "" && opts.ASNPath == "" {
+		return nil, errors.New("no GeoIP database configured")
+	}
+
+	db := &DB{}
+	var err error
+	if opts.LocationPath != "" {
+		if db.location, err = maxminddb.Open(opts.LocationPath); err != nil {
+			return nil, fmt.Errorf("open %s: %w", opts.LocationPath, err)
+		}
+	}
+	if opts.ASNPath != "" {
+		if db.asn, err = maxminddb.Open(opts.ASNPath); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("open %s: %w", opts.ASNPath, err)
+		}
+	}
+
+	size := opts.CacheSize
+	if size <= 0 {
+		size = DefaultCacheSize
+	}
+	db.cache = newCache(size)
+	return db, nil
+}
+
+// Close closes the databases
+func (db *DB) Close() error {
+	if db == nil {
+		return nil
+	}
+	var err error
+	if db.location != nil {
+		err = db.location.Close()
+	}
+	if db.asn != nil {
+		if asnErr := db.asn.Close(); err == nil {
+			err = asnErr
+		}
+	}
+	return err
+}
+
+// Lookup resolves an address. Unknown and unparsable addresses return an
+// empty Info.
+func (db *DB) Lookup(addr string) Info {
+	if db == nil || addr == "" {
+		return Info{}
+	}
+	if info, ok := db.cache.get(addr); ok {
+		return info
+	}
+
+	info := db.lookup(addr)
+	db.cache.put(addr, info)
+	return info
+}
+
+func (db *DB) lookup(addr string) Info {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return Info{}
+	}
+	if IsPrivate(ip) {
+		return Info{Private: true}
+	}
+
+	var info Info
+	if db.location != nil {
+		var rec record
+		if err := db.location.Lookup(ip, &rec); err == nil {
+			country := rec.Country
+			if country.ISOCode == "" {
+				country = rec.RegisteredCountry
+			}
+			info.CountryCode = country.ISOCode
+			info.Country = country.Names["en"]
+			info.City = rec.City.Names["en"]
+			// Some location databases also carry the AS
+			info.ASN = int(rec.AutonomousSystemNumber)
+			info.ASOrg = rec.AutonomousSystemOrganization
+		}
+	}
+	if db.asn != nil {
+		var rec record
+		if err := db.asn.Lookup(ip, &rec); err == nil && rec.AutonomousSystemNumber != 0 {
+			info.ASN = int(rec.AutonomousSystemNumber)
+			info.ASOrg = rec.AutonomousSystemOrganization
+		}
+	}
+	return info
+}
+
+// Enrich sets the client location fields of an entry
+func (db *DB) Enrich(entry *logs.TraefikLog) {
+	if db == nil || entry == nil {
+		return
+	}
+	addr := entry.ClientHost
+	if addr == "" {
+		if host, _, err := net.SplitHostPort(entry.ClientAddr); err == nil {
+			addr = host
+		}
+	}
+
+	info := db.Lookup(addr)
+	entry.ClientCountryCode = info.CountryCode
+	entry.ClientCountry = info.Country
+	entry.ClientCity = info.City
+	entry.ClientASN = info.ASN
+	entry.ClientASOrg = info.ASOrg
+	entry.ClientPrivate = info.Private
+}
+
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0).To4(), Mask: net.CIDRMask(10, 32)}
+
+// IsPrivate reports whether an address is not publicly routable: loopback,
+// private (RFC 1918, RFC 4193), link-local, unspecified or shared (RFC 6598)
+func IsPrivate(ip net.IP) bool {
+	return ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsUnspecified() || sharedAddressSpace.Contains(ip)
+}
@@ -0,0 +1,171 @@
+package geoip
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+	"github.com/maxmind/mmdbwriter"
+	"github.com/maxmind/mmdbwriter/mmdbtype"
+)
+
+// writeDB writes a database of the given type holding the given networks
+func writeDB(t *testing.T, dbType string, networks map[string]mmdbtype.Map) string {
+	t.Helper()
+	tree, err := mmdbwriter.New(mmdbwriter.Options{DatabaseType: dbType, RecordSize: 24})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for cidr, data := range networks {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tree.Insert(network, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), dbType+".mmdb")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := tree.WriteTo(f); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func country(code, name string) mmdbtype.Map {
+	return mmdbtype.Map{
+		"iso_code": mmdbtype.String(code),
+		"names":    mmdbtype.Map{"en": mmdbtype.String(name)},
+	}
+}
+
+// openTestDB opens a city and an ASN fixture
+func openTestDB(t *testing.T, cacheSize int) *DB {
+	t.Helper()
+	city := writeDB(t, "GeoLite2-City", map[string]mmdbtype.Map{
+		"81.2.69.0/24": {
+			"country": country("GB", "United Kingdom"),
+			"city":    mmdbtype.Map{"names": mmdbtype.Map{"en": mmdbtype.String("London")}},
+		},
+		"2.125.160.0/24": {
+			"registered_country": country("DE", "Germany"),
+		},
+	})
+	asn := writeDB(t, "GeoLite2-ASN", map[string]mmdbtype.Map{
+		"81.2.69.0/24": {
+			"autonomous_system_number":       mmdbtype.Uint32(20712),
+			"autonomous_system_organization": mmdbtype.String("Andrews & Arnold Ltd"),
+		},
+	})
+
+	db, err := Open(Options{LocationPath: city, ASNPath: asn, CacheSize: cacheSize})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestLookup(t *testing.T) {
+	db := openTestDB(t, 0)
+
+	tests := []struct {
+		addr string
+		want Info
+	}{
+		{"81.2.69.142", Info{CountryCode: "GB", Country: "United Kingdom", City: "London", ASN: 20712, ASOrg: "Andrews & Arnold Ltd"}},
+		{"2.125.160.216", Info{CountryCode: "DE", Country: "Germany"}},
+		{"8.8.8.8", Info{}},
+		{"not an ip", Info{}},
+		{"", Info{}},
+		{"10.1.2.3", Info{Private: true}},
+		{"192.168.0.1", Info{Private: true}},
+		{"127.0.0.1", Info{Private: true}},
+		{"100.64.12.1", Info{Private: true}},
+		{"169.254.1.1", Info{Private: true}},
+		{"::1", Info{Private: true}},
+		{"fd00::1", Info{Private: true}},
+		{"fe80::1", Info{Private: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := db.Lookup(tt.addr); got != tt.want {
+				t.Errorf("Lookup(%q) = %+v, want %+v", tt.addr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnrich(t *testing.T) {
+	db := openTestDB(t, 0)
+
+	entry := &logs.TraefikLog{ClientAddr: "81.2.69.142:51234"}
+	db.Enrich(entry)
+	if entry.ClientCountryCode != "GB" || entry.ClientCity != "London" || entry.ClientASN != 20712 {
+		t.Errorf("entry not enriched from ClientAddr: %+v", entry)
+	}
+
+	entry = &logs.TraefikLog{ClientHost: "10.0.0.1", ClientAddr: "81.2.69.142:51234"}
+	db.Enrich(entry)
+	if !entry.ClientPrivate || entry.ClientCountryCode != "" {
+		t.Errorf("ClientHost should take precedence: %+v", entry)
+	}
+
+	var nilDB *DB
+	nilDB.Enrich(entry)
+	if info := nilDB.Lookup("81.2.69.142"); info != (Info{}) {
+		t.Errorf("nil DB lookup = %+v", info)
+	}
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	db := openTestDB(t, 2)
+
+	db.Lookup("81.2.69.1")
+	db.Lookup("81.2.69.2")
+	db.Lookup("81.2.69.1")
+	db.Lookup("81.2.69.3")
+
+	if n := db.cache.len(); n != 2 {
+		t.Fatalf("cache holds %d entries, want 2", n)
+	}
+	if _, ok := db.cache.get("81.2.69.2"); ok {
+		t.Error("least recently used address was not evicted")
+	}
+	for _, addr := range []string{"81.2.69.1", "81.2.69.3"} {
+		if info, ok := db.cache.get(addr); !ok || info.CountryCode != "GB" {
+			t.Errorf("cache.get(%q) = %+v, %v", addr, info, ok)
+		}
+	}
+}
+
+func TestOpen(t *testing.T) {
+	if _, err := Open(Options{}); err == nil {
+		t.Error("expected an error without databases")
+	}
+	if _, err := Open(Options{LocationPath: filepath.Join(t.TempDir(), "missing.mmdb")}); err == nil {
+		t.Error("expected an error for a missing database")
+	}
+
+	// A country database on its own
+	path := writeDB(t, "GeoLite2-Country", map[string]mmdbtype.Map{
+		"81.2.69.0/24": {"country": country("GB", "United Kingdom")},
+	})
+	db, err := Open(Options{LocationPath: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if info := db.Lookup("81.2.69.142"); info != (Info{CountryCode: "GB", Country: "United Kingdom"}) {
+		t.Errorf("Lookup = %+v", info)
+	}
+}
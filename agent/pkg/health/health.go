@@ -0,0 +1,399 @@
+// Package health scores the backend servers behind Traefik services from
+// the access log entries they served, over sliding time windows.
+package health
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/histogram"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+)
+
+// Backend statuses, from best to worst
+const (
+	StatusHealthy  = "healthy"
+	StatusIdle     = "idle"
+	StatusDegraded = "degraded"
+	StatusDown     = "down"
+)
+
+// Statuses lists the backend statuses, from best to worst
+var Statuses = []string{StatusHealthy, StatusIdle, StatusDegraded, StatusDown}
+
+// Resolution is the width of the time slices windows are built from
+const Resolution = 10 * time.Second
+
+// Config sets the windows and the thresholds of the statuses
+type Config struct {
+	// Windows are the sliding windows reported for every backend, shortest
+	// first
+	Windows []time.Duration
+	// StatusWindow is the window the score and degraded status are based on
+	StatusWindow time.Duration
+	// MinRequests is the least number of requests within the shortest
+	// window for a backend to be marked down
+	MinRequests int
+	// DownErrorRate marks a backend down when its 5xx rate within the
+	// shortest window reaches it
+	DownErrorRate float64
+	// DegradedErrorRate and DegradedP95 mark a backend degraded when its
+	// 5xx rate or 95th percentile latency within the status window
+	// reaches them
+	DegradedErrorRate float64
+	DegradedP95       time.Duration
+}
+
+// DefaultConfig returns the default windows and thresholds
+func DefaultConfig() Config {
+	return Config{
+		Windows:           []time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute},
+		StatusWindow:      5 * time.Minute,
+		MinRequests:       5,
+		DownErrorRate:     0.9,
+		DegradedErrorRate: 0.05,
+		DegradedP95:       time.Second,
+	}
+}
+
+func (c Config) validate() error {
+	if len(c.Windows) == 0 {
+		return errors.New("at least one window is required")
+	}
+	for i, w := range c.Windows {
+		if w < Resolution || w%Resolution != 0 {
+			return fmt.Errorf("window %s must be a multiple of %s", w, Resolution)
+		}
+		if i > 0 && w <= c.Windows[i-1] {
+			return errors.New("windows must be in increasing order")
+		}
+	}
+	found := false
+	for _, w := range c.Windows {
+		found = found || w == c.StatusWindow
+	}
+	if !found {
+		return fmt.Errorf("status window %s is not one of the windows", c.StatusWindow)
+	}
+	if c.DownErrorRate <= 0 || c.DownErrorRate > 1 || c.DegradedErrorRate <= 0 || c.DegradedErrorRate > 1 {
+		return errors.New("error rates must be within (0, 1]")
+	}
+	if c.MinRequests < 1 {
+		return errors.New("min requests must be at least 1")
+	}
+	return nil
+}
+
+// Window is the activity of a backend within one window
+type Window struct {
+	Requests     int64   `json:"requests"`
+	ServerErrors int64   `json:"server_errors"`
+	ErrorRate    float64 `json:"error_rate"`
+	BadGateway   int64   `json:"status_502"`
+	Timeouts     int64   `json:"status_504"`
+	Retries      int64   `json:"retries"`
+	AvgMS        float64 `json:"avg_ms"`
+	P50MS        float64 `json:"p50_ms"`
+	P95MS        float64 `json:"p95_ms"`
+	P99MS        float64 `json:"p99_ms"`
+}
+
+// Backend is the health of one server of a service
+type Backend struct {
+	Service string `json:"service"`
+	// URL is the server address as configured in Traefik
+	URL string `json:"url"`
+	// Status is healthy, idle, degraded or down
+	Status string `json:"status"`
+	// Reason explains a degraded or down status
+	Reason string `json:"reason,omitempty"`
+	// Score runs from 0 to 100 and falls with errors, latency and retries
+	// within the status window
+	Score     int               `json:"score"`
+	LastSeen  time.Time         `json:"last_seen"`
+	LastError *time.Time        `json:"last_error,omitempty"`
+	Windows   map[string]Window `json:"windows"`
+}
+
+// slice holds the entries of one Resolution-wide slice of time
+type slice struct {
+	index        int64 // Unix time / Resolution
+	requests     int64
+	serverErrors int64
+	badGateway   int64
+	timeouts     int64
+	retries      int64
+	latency      histogram.Histogram
+}
+
+// backend keeps the slices of the longest window in a ring
+type backend struct {
+	service   string
+	url       string
+	slices    []slice
+	lastSeen  time.Time
+	lastError time.Time
+}
+
+type key struct{ service, url string }
+
+// Tracker scores backends from the entries it observes. It is safe for
+// concurrent use.
+type Tracker struct {
+	cfg Config
+	now func() time.Time
+
+	mu       sync.Mutex
+	backends map[key]*backend
+}
+
+// New returns a Tracker, or an error when the configuration is invalid
+func New(cfg Config) (*Tracker, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return &Tracker{cfg: cfg, now: time.Now, backends: make(map[key]*backend)}, nil
+}
+
+// Config returns the configuration of the tracker
+func (t *Tracker) Config() Config {
+	return t.cfg
+}
+
+// WindowNames returns the windows as reported in Backend.Windows
+func (t *Tracker) WindowNames() []string {
+	names := make([]string, len(t.cfg.Windows))
+	for i, w := range t.cfg.Windows {
+		names[i] = WindowName(w)
+	}
+	return names
+}
+
+// Observe records an access log entry. Entries without a backend, such as
+// those answered by Traefik itself, are ignored.
+func (t *Tracker) Observe(entry *logs.TraefikLog) {
+	url := entry.ServiceURL
+	if url == "" {
+		url = entry.ServiceAddr
+	}
+	if url == "" {
+		return
+	}
+	ts := entry.StartUTC
+	if ts.IsZero() {
+		ts = t.now()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	k := key{entry.ServiceName, url}
+	b, ok := t.backends[k]
+	if !ok {
+		b = &backend{service: entry.ServiceName, url: url, slices: make([]slice, t.ringSize())}
+		t.backends[k] = b
+	}
+
+	index := ts.UnixNano() / int64(Resolution)
+	s := &b.slices[index%int64(len(b.slices))]
+	if s.index != index {
+		if s.index > index {
+			// Older than the ring
+			return
+		}
+		s.reset(index)
+	}
+
+	s.requests++
+	status := entry.DownstreamStatus
+	if status >= 500 {
+		s.serverErrors++
+		if ts.After(b.lastError) {
+			b.lastError = ts
+		}
+	}
+	switch status {
+	case 502:
+		s.badGateway++
+	case 504:
+		s.timeouts++
+	}
+	s.retries += int64(entry.RetryAttempts)
+
+	// Time spent in the backend, when Traefik reports it
+	duration := entry.OriginDuration
+	if duration <= 0 {
+		duration = entry.Duration
+	}
+	s.latency.Observe(float64(duration) / float64(time.Millisecond))
+
+	if ts.After(b.lastSeen) {
+		b.lastSeen = ts
+	}
+}
+
+func (s *slice) reset(index int64) {
+	latency := s.latency
+	latency.Reset()
+	*s = slice{index: index, latency: latency}
+}
+
+// ringSize covers the longest window plus the slice in progress
+func (t *Tracker) ringSize() int {
+	longest := t.cfg.Windows[len(t.cfg.Windows)-1]
+	return int(longest/Resolution) + 1
+}
+
+// Backends returns the health of every backend seen within the longest
+// window, worst first. Backends not seen for that long are forgotten.
+func (t *Tracker) Backends() []Backend {
+	now := t.now()
+	current := now.UnixNano() / int64(Resolution)
+	longest := t.cfg.Windows[len(t.cfg.Windows)-1]
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	out := make([]Backend, 0, len(t.backends))
+	for k, b := range t.backends {
+		if now.Sub(b.lastSeen) > longest {
+			delete(t.backends, k)
+			continue
+		}
+		out = append(out, t.report(b, current))
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		ri, rj := statusRank(out[i].Status), statusRank(out[j].Status)
+		if ri != rj {
+			return ri > rj
+		}
+		if out[i].Score != out[j].Score {
+			return out[i].Score < out[j].Score
+		}
+		if out[i].Service != out[j].Service {
+			return out[i].Service < out[j].Service
+		}
+		return out[i].URL < out[j].URL
+	})
+	return out
+}
+
+// report aggregates the windows of a backend and derives its status
+func (t *Tracker) report(b *backend, current int64) Backend {
+	r := Backend{
+		Service:  b.service,
+		URL:      b.url,
+		LastSeen: b.lastSeen,
+		Windows:  make(map[string]Window, len(t.cfg.Windows)),
+	}
+	if !b.lastError.IsZero() {
+		lastError := b.lastError
+		r.LastError = &lastError
+	}
+
+	windows := make([]Window, len(t.cfg.Windows))
+	var status Window
+	for i, w := range t.cfg.Windows {
+		// The oldest slice is partly outside the window, so a window covers
+		// up to one Resolution more than its length
+		windows[i] = aggregate(b.slices, current-int64(w/Resolution)-1, current)
+		r.Windows[WindowName(w)] = windows[i]
+		if w == t.cfg.StatusWindow {
+			status = windows[i]
+		}
+	}
+
+	r.Score = score(status, t.cfg.DegradedP95)
+	shortest := windows[0]
+	switch {
+	case shortest.Requests >= int64(t.cfg.MinRequests) && shortest.ErrorRate >= t.cfg.DownErrorRate:
+		r.Status = StatusDown
+		r.Reason = fmt.Sprintf("%.0f%% of requests failed in the last %s", shortest.ErrorRate*100, WindowName(t.cfg.Windows[0]))
+	case status.Requests > 0 && status.ErrorRate >= t.cfg.DegradedErrorRate:
+		r.Status = StatusDegraded
+		r.Reason = fmt.Sprintf("%.1f%% of requests failed in the last %s", status.ErrorRate*100, WindowName(t.cfg.StatusWindow))
+	case status.Requests > 0 && status.P95MS >= float64(t.cfg.DegradedP95)/float64(time.Millisecond):
+		r.Status = StatusDegraded
+		r.Reason = fmt.Sprintf("p95 latency %.0fms in the last %s", status.P95MS, WindowName(t.cfg.StatusWindow))
+	case shortest.Requests == 0:
+		r.Status = StatusIdle
+	default:
+		r.Status = StatusHealthy
+	}
+	return r
+}
+
+// aggregate sums the slices within (from, to]
+func aggregate(slices []slice, from, to int64) Window {
+	var w Window
+	var latency histogram.Histogram
+	for i := range slices {
+		s := &slices[i]
+		if s.index <= from || s.index > to || s.requests == 0 {
+			continue
+		}
+		w.Requests += s.requests
+		w.ServerErrors += s.serverErrors
+		w.BadGateway += s.badGateway
+		w.Timeouts += s.timeouts
+		w.Retries += s.retries
+		latency.Merge(&s.latency)
+	}
+	if w.Requests > 0 {
+		w.ErrorRate = float64(w.ServerErrors) / float64(w.Requests)
+	}
+	w.AvgMS = latency.Mean()
+	w.P50MS = latency.Quantile(0.5)
+	w.P95MS = latency.Quantile(0.95)
+	w.P99MS = latency.Quantile(0.99)
+	return w
+}
+
+// score starts at 100 and is scaled down by the success rate, by how far
+// the p95 latency exceeds the degraded threshold and by the retry rate
+func score(w Window, target time.Duration) int {
+	if w.Requests == 0 {
+		return 100
+	}
+	s := 1 - w.ErrorRate
+	targetMS := float64(target) / float64(time.Millisecond)
+	if targetMS > 0 && w.P95MS > targetMS {
+		s *= targetMS / w.P95MS
+	}
+	retryRate := float64(w.Retries) / float64(w.Requests)
+	if retryRate > 1 {
+		retryRate = 1
+	}
+	s *= 1 - retryRate/2
+	return int(s*100 + 0.5)
+}
+
+func statusRank(status string) int {
+	for i, s := range Statuses {
+		if s == status {
+			return i
+		}
+	}
+	return -1
+}
+
+// WindowName formats a window the way the configuration spells it, e.g. 5m
+func WindowName(w time.Duration) string {
+	switch {
+	case w%time.Hour == 0:
+		return fmt.Sprintf("%dh", w/time.Hour)
+	case w%time.Minute == 0:
+		return fmt.Sprintf("%dm", w/time.Minute)
+	default:
+		return fmt.Sprintf("%ds", w/time.Second)
+	}
+}
+
+// ValidStatus reports whether s is a known status
+func ValidStatus(s string) bool {
+	return statusRank(s) >= 0
+}
@@ -0,0 +1,171 @@
+package health
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+)
+
+var now = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func newTracker(t *testing.T) *Tracker {
+	t.Helper()
+	tr, err := New(DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr.now = func() time.Time { return now }
+	return tr
+}
+
+// observe records n entries for a backend, ago before now and a second apart
+func observe(tr *Tracker, url string, status int, origin time.Duration, ago time.Duration, n int) {
+	for i := 0; i < n; i++ {
+		tr.Observe(&logs.TraefikLog{
+			ServiceName:      "api@docker",
+			ServiceURL:       url,
+			DownstreamStatus: status,
+			OriginDuration:   int64(origin),
+			Duration:         int64(origin + time.Millisecond),
+			StartUTC:         now.Add(-ago - time.Duration(i)*time.Second),
+		})
+	}
+}
+
+func TestBackends(t *testing.T) {
+	tr := newTracker(t)
+
+	// Healthy: fast and successful
+	observe(tr, "http://10.0.0.1:80", 200, 20*time.Millisecond, 5*time.Second, 50)
+	// Down: only 502s within the last minute
+	observe(tr, "http://10.0.0.2:80", 200, 20*time.Millisecond, 4*time.Minute, 20)
+	observe(tr, "http://10.0.0.2:80", 502, 0, 5*time.Second, 10)
+	// Degraded: slow
+	observe(tr, "http://10.0.0.3:80", 200, 2*time.Second, 5*time.Second, 20)
+	// Idle: nothing in the last minute
+	observe(tr, "http://10.0.0.4:80", 200, 20*time.Millisecond, 10*time.Minute, 5)
+	// Forgotten: nothing in the last 15 minutes
+	observe(tr, "http://10.0.0.5:80", 200, 20*time.Millisecond, 20*time.Minute, 5)
+	// No backend
+	tr.Observe(&logs.TraefikLog{RouterName: "dashboard@internal", DownstreamStatus: 200, StartUTC: now})
+
+	backends := tr.Backends()
+	got := make(map[string]Backend)
+	var order []string
+	for _, b := range backends {
+		got[b.URL] = b
+		order = append(order, b.Status)
+	}
+	if len(backends) != 4 {
+		t.Fatalf("got %d backends, want 4: %v", len(backends), order)
+	}
+	wantOrder := []string{StatusDown, StatusDegraded, StatusIdle, StatusHealthy}
+	for i := range wantOrder {
+		if order[i] != wantOrder[i] {
+			t.Fatalf("got order %v, want %v", order, wantOrder)
+		}
+	}
+
+	healthy := got["http://10.0.0.1:80"]
+	if healthy.Score != 100 || healthy.Windows["1m"].Requests != 50 {
+		t.Errorf("healthy: %+v", healthy)
+	}
+	if p95 := healthy.Windows["1m"].P95MS; p95 < 15 || p95 > 25 {
+		t.Errorf("healthy p95 = %v, want about 20", p95)
+	}
+	if healthy.LastSeen != now.Add(-5*time.Second) || healthy.LastError != nil {
+		t.Errorf("healthy last seen %v, last error %v", healthy.LastSeen, healthy.LastError)
+	}
+
+	down := got["http://10.0.0.2:80"]
+	if w := down.Windows["1m"]; w.Requests != 10 || w.BadGateway != 10 || w.ErrorRate != 1 {
+		t.Errorf("down 1m window: %+v", w)
+	}
+	if w := down.Windows["5m"]; w.Requests != 30 || w.ServerErrors != 10 {
+		t.Errorf("down 5m window: %+v", w)
+	}
+	if down.LastError == nil || *down.LastError != now.Add(-5*time.Second) || down.Reason == "" {
+		t.Errorf("down: %+v", down)
+	}
+
+	degraded := got["http://10.0.0.3:80"]
+	if degraded.Score >= 100 || degraded.Score < 40 {
+		t.Errorf("degraded score %d", degraded.Score)
+	}
+
+	idle := got["http://10.0.0.4:80"]
+	if idle.Windows["1m"].Requests != 0 || idle.Windows["15m"].Requests != 5 {
+		t.Errorf("idle windows %+v", idle.Windows)
+	}
+}
+
+func TestBackendsSlide(t *testing.T) {
+	tr := newTracker(t)
+	observe(tr, "http://10.0.0.2:80", 504, time.Second, 5*time.Second, 10)
+	if b := tr.Backends()[0]; b.Status != StatusDown || b.Windows["1m"].Timeouts != 10 {
+		t.Fatalf("got %+v", b)
+	}
+
+	// Two minutes later the failures have left the shortest window but
+	// still degrade the status window
+	now = now.Add(2 * time.Minute)
+	defer func() { now = now.Add(-2 * time.Minute) }()
+	b := tr.Backends()[0]
+	if b.Status != StatusDegraded || b.Windows["1m"].Requests != 0 || b.Windows["5m"].Timeouts != 10 {
+		t.Errorf("got %+v", b)
+	}
+
+	// A slot reused for a later slice starts empty
+	observe(tr, "http://10.0.0.2:80", 200, 10*time.Millisecond, 0, 1)
+	b = tr.Backends()[0]
+	if w := b.Windows["15m"]; w.Requests != 11 || w.Timeouts != 10 {
+		t.Errorf("15m window: %+v", w)
+	}
+}
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name string
+		w    Window
+		want int
+	}{
+		{"no traffic", Window{}, 100},
+		{"clean", Window{Requests: 100, P95MS: 100}, 100},
+		{"errors", Window{Requests: 100, ErrorRate: 0.2, P95MS: 100}, 80},
+		{"slow", Window{Requests: 100, P95MS: 2000}, 50},
+		{"retries", Window{Requests: 100, Retries: 50, P95MS: 100}, 75},
+	}
+	for _, tt := range tests {
+		if got := score(tt.w, time.Second); got != tt.want {
+			t.Errorf("%s: score = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+	}{
+		{"no windows", func(c *Config) { c.Windows = nil }},
+		{"unaligned window", func(c *Config) { c.Windows = []time.Duration{15 * time.Second, 5 * time.Minute} }},
+		{"unordered windows", func(c *Config) { c.Windows = []time.Duration{5 * time.Minute, time.Minute} }},
+		{"unknown status window", func(c *Config) { c.StatusWindow = 2 * time.Minute }},
+		{"error rate", func(c *Config) { c.DownErrorRate = 1.5 }},
+		{"min requests", func(c *Config) { c.MinRequests = 0 }},
+	}
+	for _, tt := range tests {
+		cfg := DefaultConfig()
+		tt.modify(&cfg)
+		if _, err := New(cfg); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+
+	tr := newTracker(t)
+	names := tr.WindowNames()
+	if len(names) != 3 || names[0] != "1m" || names[2] != "15m" {
+		t.Errorf("got %v", names)
+	}
+}
@@ -0,0 +1,159 @@
+// Package histogram records latencies in fixed log-scale buckets, so that
+// histograms from different time slices can be merged and still give
+// percentiles within a few percent.
+package histogram
+
+import (
+	"math"
+	"sort"
+)
+
+// Bucket layout: the first bucket holds values up to MinBound, each next
+// bucket is growth times wider, and the last one holds everything above
+// MaxBound
+const (
+	MinBound = 0.1 // ms
+	MaxBound = 120000.0
+	// Four buckets per doubling keeps bounds within 19% of each other
+	growth = 1.189207115002721 // 2^(1/4)
+)
+
+// bounds are the upper bounds of all but the overflow bucket
+var bounds = func() []float64 {
+	var b []float64
+	for v := MinBound; v < MaxBound*growth; v *= growth {
+		b = append(b, v)
+	}
+	return b
+}()
+
+// Buckets is the number of buckets, the overflow bucket included
+var Buckets = len(bounds) + 1
+
+// Bounds returns the upper bound of every bucket, ending with +Inf for the
+// overflow bucket
+func Bounds() []float64 {
+	out := make([]float64, Buckets)
+	copy(out, bounds)
+	out[len(out)-1] = math.Inf(1)
+	return out
+}
+
+// Index returns the bucket of a value in milliseconds
+func Index(ms float64) int {
+	if ms <= MinBound {
+		return 0
+	}
+	return sort.SearchFloat64s(bounds, ms)
+}
+
+// Histogram counts values in milliseconds. The zero value is empty and
+// ready to use.
+type Histogram struct {
+	counts []uint32
+	total  uint64
+	sum    float64
+	max    float64
+}
+
+// Observe records one value in milliseconds
+func (h *Histogram) Observe(ms float64) {
+	if ms < 0 || math.IsNaN(ms) {
+		ms = 0
+	}
+	if h.counts == nil {
+		h.counts = make([]uint32, Buckets)
+	}
+	h.counts[Index(ms)]++
+	h.total++
+	h.sum += ms
+	if ms > h.max {
+		h.max = ms
+	}
+}
+
+// Merge adds the values of o
+func (h *Histogram) Merge(o *Histogram) {
+	if o == nil || o.total == 0 {
+		return
+	}
+	if h.counts == nil {
+		h.counts = make([]uint32, Buckets)
+	}
+	for i, c := range o.counts {
+		h.counts[i] += c
+	}
+	h.total += o.total
+	h.sum += o.sum
+	if o.max > h.max {
+		h.max = o.max
+	}
+}
+
+// Reset empties the histogram, keeping its storage
+func (h *Histogram) Reset() {
+	for i := range h.counts {
+		h.counts[i] = 0
+	}
+	h.total, h.sum, h.max = 0, 0, 0
+}
+
+// Count returns the number of values
+func (h *Histogram) Count() uint64 {
+	return h.total
+}
+
+// Mean returns the average value, or 0 when empty
+func (h *Histogram) Mean() float64 {
+	if h.total == 0 {
+		return 0
+	}
+	return h.sum / float64(h.total)
+}
+
+// Max returns the largest value, or 0 when empty
+func (h *Histogram) Max() float64 {
+	return h.max
+}
+
+// Counts returns a copy of the bucket counts, laid out like Bounds
+func (h *Histogram) Counts() []uint64 {
+	out := make([]uint64, Buckets)
+	for i, c := range h.counts {
+		out[i] = uint64(c)
+	}
+	return out
+}
+
+// Quantile estimates the value below which a fraction q of the values fall,
+// interpolating within the bucket. It returns 0 when empty.
+func (h *Histogram) Quantile(q float64) float64 {
+	if h.total == 0 {
+		return 0
+	}
+	q = math.Max(0, math.Min(1, q))
+	rank := q * float64(h.total)
+
+	var seen float64
+	for i, c := range h.counts {
+		if c == 0 {
+			continue
+		}
+		if seen+float64(c) >= rank {
+			lower := 0.0
+			if i > 0 {
+				lower = bounds[i-1]
+			}
+			upper := h.max
+			if i < len(bounds) && bounds[i] < upper {
+				upper = bounds[i]
+			}
+			if upper < lower {
+				return upper
+			}
+			return lower + (upper-lower)*(rank-seen)/float64(c)
+		}
+		seen += float64(c)
+	}
+	return h.max
+}
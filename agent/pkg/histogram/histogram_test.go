@@ -0,0 +1,97 @@
+package histogram
+
+import (
+	"math"
+	"testing"
+)
+
+func TestIndex(t *testing.T) {
+	tests := []struct {
+		ms   float64
+		want int
+	}{
+		{0, 0},
+		{MinBound, 0},
+		{MinBound * 1.1, 1},
+		{MinBound * growth * 1.01, 2},
+		{MaxBound * 10, Buckets - 1},
+	}
+	for _, tt := range tests {
+		if got := Index(tt.ms); got != tt.want {
+			t.Errorf("Index(%v) = %d, want %d", tt.ms, got, tt.want)
+		}
+	}
+
+	b := Bounds()
+	if len(b) != Buckets || !math.IsInf(b[len(b)-1], 1) {
+		t.Fatalf("unexpected bounds %v", b)
+	}
+	for i, ms := range []float64{1, 50, 999, 30000} {
+		j := Index(ms)
+		if ms > b[j] || (j > 0 && ms <= b[j-1]) {
+			t.Errorf("%d: %v not within bucket %d", i, ms, j)
+		}
+	}
+}
+
+func TestQuantile(t *testing.T) {
+	var h Histogram
+	if h.Quantile(0.5) != 0 || h.Mean() != 0 {
+		t.Error("empty histogram should report 0")
+	}
+
+	// 1..1000 ms, one of each
+	for i := 1; i <= 1000; i++ {
+		h.Observe(float64(i))
+	}
+	tests := []struct{ q, want float64 }{
+		{0.5, 500},
+		{0.95, 950},
+		{0.99, 990},
+		{1, 1000},
+	}
+	for _, tt := range tests {
+		got := h.Quantile(tt.q)
+		if math.Abs(got-tt.want)/tt.want > 0.1 {
+			t.Errorf("Quantile(%v) = %.1f, want about %v", tt.q, got, tt.want)
+		}
+	}
+	if h.Count() != 1000 || h.Max() != 1000 || h.Mean() != 500.5 {
+		t.Errorf("count %d max %v mean %v", h.Count(), h.Max(), h.Mean())
+	}
+	// Never above the largest value
+	if got := h.Quantile(1); got > 1000 {
+		t.Errorf("Quantile(1) = %v", got)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	var a, b, all Histogram
+	for i := 0; i < 100; i++ {
+		v := float64(i * i)
+		all.Observe(v)
+		if i%2 == 0 {
+			a.Observe(v)
+		} else {
+			b.Observe(v)
+		}
+	}
+	var merged Histogram
+	merged.Merge(&a)
+	merged.Merge(&b)
+	merged.Merge(nil)
+
+	if merged.Count() != all.Count() || merged.Max() != all.Max() || merged.Mean() != all.Mean() {
+		t.Errorf("merged %d/%v/%v, want %d/%v/%v", merged.Count(), merged.Max(), merged.Mean(), all.Count(), all.Max(), all.Mean())
+	}
+	for _, q := range []float64{0.5, 0.9, 0.99} {
+		if merged.Quantile(q) != all.Quantile(q) {
+			t.Errorf("Quantile(%v): merged %v, all %v", q, merged.Quantile(q), all.Quantile(q))
+		}
+	}
+
+	merged.Reset()
+	if merged.Count() != 0 || merged.Quantile(0.5) != 0 {
+		t.Error("reset histogram not empty")
+	}
+}
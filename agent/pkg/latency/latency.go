@@ -0,0 +1,364 @@
+// Package latency builds latency heatmaps from access log entries: a
+// histogram per time step for all traffic and per router or service, with
+// the time Traefik adds kept apart from the time spent in backends.
+package latency
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/histogram"
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+)
+
+// Metrics a heatmap can show
+const (
+	// MetricDuration is the total time Traefik took to answer
+	MetricDuration = "duration"
+	// MetricOrigin is the time spent waiting for the backend
+	MetricOrigin = "origin"
+	// MetricOverhead is the time Traefik itself added
+	MetricOverhead = "overhead"
+)
+
+// Groupings of the per-group heatmaps
+const (
+	GroupRouter  = "router"
+	GroupService = "service"
+)
+
+// Limits of a heatmap
+const (
+	DefaultColumns = 60
+	MaxColumns     = 1440
+	DefaultTop     = 10
+)
+
+// Options shape a heatmap
+type Options struct {
+	// Since and Until bound the entries; Until must be set
+	Since, Until time.Time
+	// Step is the width of a column; by default the range is split into
+	// DefaultColumns columns
+	Step time.Duration
+	// Metric is duration, origin or overhead
+	Metric string
+	// GroupBy adds a heatmap per router or service when set
+	GroupBy string
+	// Top caps the groups, busiest first
+	Top int
+	// RowsPerDoubling is 4, 2 or 1; fewer rows make a coarser heatmap
+	RowsPerDoubling int
+}
+
+// Summary sums up one latency distribution
+type Summary struct {
+	AvgMS float64 `json:"avg_ms"`
+	P50MS float64 `json:"p50_ms"`
+	P90MS float64 `json:"p90_ms"`
+	P95MS float64 `json:"p95_ms"`
+	P99MS float64 `json:"p99_ms"`
+	MaxMS float64 `json:"max_ms"`
+}
+
+// Breakdown separates the time Traefik added from the time spent in the
+// backend. Overhead and origin are as logged by Traefik, so they need not
+// add up to the duration percentiles.
+type Breakdown struct {
+	Duration Summary `json:"duration"`
+	Origin   Summary `json:"origin"`
+	Overhead Summary `json:"overhead"`
+}
+
+// Row is a latency range of the heatmap. UpperMS is omitted for the last,
+// unbounded row.
+type Row struct {
+	LowerMS float64 `json:"lower_ms"`
+	UpperMS float64 `json:"upper_ms,omitempty"`
+}
+
+// Column is one time step of a heatmap
+type Column struct {
+	Start    time.Time `json:"start"`
+	Requests uint64    `json:"requests"`
+	// Counts holds the requests of every row
+	Counts []uint64 `json:"counts"`
+	P50MS  float64  `json:"p50_ms"`
+	P95MS  float64  `json:"p95_ms"`
+	P99MS  float64  `json:"p99_ms"`
+	// OriginAvgMS and OverheadAvgMS split the average duration
+	OriginAvgMS   float64 `json:"origin_avg_ms"`
+	OverheadAvgMS float64 `json:"overhead_avg_ms"`
+}
+
+// Series is the heatmap of all traffic or of one group
+type Series struct {
+	Key       string `json:"key,omitempty"`
+	Requests  uint64 `json:"requests"`
+	Breakdown `json:"breakdown"`
+	Columns   []Column `json:"columns"`
+}
+
+// Result is a finished heatmap
+type Result struct {
+	Since   time.Time `json:"since"`
+	Until   time.Time `json:"until"`
+	Step    string    `json:"step"`
+	Metric  string    `json:"metric"`
+	GroupBy string    `json:"group_by,omitempty"`
+	// Rows are the latency ranges, fastest first, trimmed to those with
+	// requests
+	Rows    []Row    `json:"rows"`
+	Overall Series   `json:"overall"`
+	Groups  []Series `json:"groups,omitempty"`
+}
+
+// cell is one column of a series while it is built
+type cell struct {
+	latency  histogram.Histogram
+	origin   float64
+	overhead float64
+}
+
+type series struct {
+	key      string
+	columns  []cell
+	duration histogram.Histogram
+	origin   histogram.Histogram
+	overhead histogram.Histogram
+}
+
+func (s *series) add(column int, metric string, entry *logs.TraefikLog) {
+	duration, origin, overhead := ms(entry.Duration), ms(entry.OriginDuration), ms(entry.Overhead)
+	s.duration.Observe(duration)
+	s.origin.Observe(origin)
+	s.overhead.Observe(overhead)
+
+	c := &s.columns[column]
+	switch metric {
+	case MetricOrigin:
+		c.latency.Observe(origin)
+	case MetricOverhead:
+		c.latency.Observe(overhead)
+	default:
+		c.latency.Observe(duration)
+	}
+	c.origin += origin
+	c.overhead += overhead
+}
+
+// Heatmap builds heatmaps from the entries it is given. It is not safe for
+// concurrent use.
+type Heatmap struct {
+	opts    Options
+	columns int
+	overall *series
+	groups  map[string]*series
+}
+
+// New returns an empty Heatmap, or an error when the options are invalid
+func New(opts Options) (*Heatmap, error) {
+	if opts.Until.IsZero() || !opts.Until.After(opts.Since) {
+		return nil, errors.New("until must be after since")
+	}
+	if opts.Metric == "" {
+		opts.Metric = MetricDuration
+	}
+	switch opts.Metric {
+	case MetricDuration, MetricOrigin, MetricOverhead:
+	default:
+		return nil, fmt.Errorf("unknown metric %q, use duration, origin or overhead", opts.Metric)
+	}
+	switch opts.GroupBy {
+	case "", GroupRouter, GroupService:
+	default:
+		return nil, fmt.Errorf("unknown grouping %q, use router or service", opts.GroupBy)
+	}
+	if opts.Top <= 0 {
+		opts.Top = DefaultTop
+	}
+	if opts.RowsPerDoubling == 0 {
+		opts.RowsPerDoubling = 4
+	}
+	switch opts.RowsPerDoubling {
+	case 1, 2, 4:
+	default:
+		return nil, errors.New("rows per doubling must be 1, 2 or 4")
+	}
+
+	span := opts.Until.Sub(opts.Since)
+	if opts.Step <= 0 {
+		opts.Step = (span + DefaultColumns - 1) / DefaultColumns
+		if opts.Step < time.Second {
+			opts.Step = time.Second
+		}
+		opts.Step = opts.Step.Round(time.Second)
+	}
+	columns := int((span + opts.Step - 1) / opts.Step)
+	if columns > MaxColumns {
+		return nil, fmt.Errorf("%s steps make %d columns, at most %d are allowed", opts.Step, columns, MaxColumns)
+	}
+
+	h := &Heatmap{opts: opts, columns: columns, groups: make(map[string]*series)}
+	h.overall = h.newSeries("")
+	return h, nil
+}
+
+func (h *Heatmap) newSeries(key string) *series {
+	return &series{key: key, columns: make([]cell, h.columns)}
+}
+
+// Add records an entry; entries outside the range are ignored
+func (h *Heatmap) Add(entry *logs.TraefikLog) {
+	ts := entry.StartUTC
+	if ts.Before(h.opts.Since) || !ts.Before(h.opts.Until) {
+		return
+	}
+	column := int(ts.Sub(h.opts.Since) / h.opts.Step)
+	h.overall.add(column, h.opts.Metric, entry)
+
+	var key string
+	switch h.opts.GroupBy {
+	case GroupRouter:
+		key = entry.RouterName
+	case GroupService:
+		key = entry.ServiceName
+	default:
+		return
+	}
+	if key == "" {
+		return
+	}
+	s, ok := h.groups[key]
+	if !ok {
+		s = h.newSeries(key)
+		h.groups[key] = s
+	}
+	s.add(column, h.opts.Metric, entry)
+}
+
+// Result renders the heatmaps
+func (h *Heatmap) Result() Result {
+	groups := make([]*series, 0, len(h.groups))
+	for _, s := range h.groups {
+		groups = append(groups, s)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if ci, cj := groups[i].duration.Count(), groups[j].duration.Count(); ci != cj {
+			return ci > cj
+		}
+		return groups[i].key < groups[j].key
+	})
+	if len(groups) > h.opts.Top {
+		groups = groups[:h.opts.Top]
+	}
+
+	// Trim the rows to those with requests in any series shown
+	factor := 4 / h.opts.RowsPerDoubling
+	first, last := -1, -1
+	for _, s := range append([]*series{h.overall}, groups...) {
+		for _, c := range s.columns {
+			for i, n := range c.latency.Counts() {
+				if n == 0 {
+					continue
+				}
+				if row := i / factor; first < 0 || row < first {
+					first = row
+				}
+				if row := i / factor; row > last {
+					last = row
+				}
+			}
+		}
+	}
+
+	r := Result{
+		Since:   h.opts.Since,
+		Until:   h.opts.Until,
+		Step:    h.opts.Step.String(),
+		Metric:  h.opts.Metric,
+		GroupBy: h.opts.GroupBy,
+		Rows:    rows(first, last, factor),
+		Overall: h.series(h.overall, first, last, factor),
+	}
+	for _, s := range groups {
+		r.Groups = append(r.Groups, h.series(s, first, last, factor))
+	}
+	return r
+}
+
+// rows lists the latency ranges of rows first to last
+func rows(first, last, factor int) []Row {
+	out := make([]Row, 0)
+	if first < 0 {
+		return out
+	}
+	bounds := histogram.Bounds()
+	for row := first; row <= last; row++ {
+		var r Row
+		if lo := row*factor - 1; lo >= 0 {
+			r.LowerMS = bounds[lo]
+		}
+		if hi := (row+1)*factor - 1; hi < len(bounds)-1 {
+			r.UpperMS = bounds[hi]
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+func (h *Heatmap) series(s *series, first, last, factor int) Series {
+	out := Series{
+		Key:      s.key,
+		Requests: s.duration.Count(),
+		Breakdown: Breakdown{
+			Duration: summarize(&s.duration),
+			Origin:   summarize(&s.origin),
+			Overhead: summarize(&s.overhead),
+		},
+		Columns: make([]Column, len(s.columns)),
+	}
+	for i := range s.columns {
+		c := &s.columns[i]
+		col := Column{
+			Start:    h.opts.Since.Add(time.Duration(i) * h.opts.Step),
+			Requests: c.latency.Count(),
+			Counts:   make([]uint64, 0),
+		}
+		if first >= 0 {
+			col.Counts = make([]uint64, last-first+1)
+			for b, n := range c.latency.Counts() {
+				if row := b / factor; n > 0 && row >= first && row <= last {
+					col.Counts[row-first] += n
+				}
+			}
+		}
+		if col.Requests > 0 {
+			col.P50MS = c.latency.Quantile(0.5)
+			col.P95MS = c.latency.Quantile(0.95)
+			col.P99MS = c.latency.Quantile(0.99)
+			col.OriginAvgMS = c.origin / float64(col.Requests)
+			col.OverheadAvgMS = c.overhead / float64(col.Requests)
+		}
+		out.Columns[i] = col
+	}
+	return out
+}
+
+func summarize(h *histogram.Histogram) Summary {
+	return Summary{
+		AvgMS: h.Mean(),
+		P50MS: h.Quantile(0.5),
+		P90MS: h.Quantile(0.9),
+		P95MS: h.Quantile(0.95),
+		P99MS: h.Quantile(0.99),
+		MaxMS: h.Max(),
+	}
+}
+
+// ms converts nanoseconds to milliseconds
+func ms(ns int64) float64 {
+	return float64(ns) / float64(time.Millisecond)
+}
@@ -0,0 +1,138 @@
+package latency
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+)
+
+var since = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func entry(router string, at, origin, overhead time.Duration) *logs.TraefikLog {
+	return &logs.TraefikLog{
+		RouterName:     router,
+		ServiceName:    router,
+		StartUTC:       since.Add(at),
+		Duration:       int64(origin + overhead),
+		OriginDuration: int64(origin),
+		Overhead:       int64(overhead),
+	}
+}
+
+func within(got, want, tolerance float64) bool {
+	return math.Abs(got-want) <= want*tolerance
+}
+
+func TestHeatmap(t *testing.T) {
+	h, err := New(Options{Since: since, Until: since.Add(10 * time.Minute), Step: time.Minute, GroupBy: GroupRouter})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A bimodal router: half the requests take 10ms, half 1s
+	for i := 0; i < 100; i++ {
+		origin := 10 * time.Millisecond
+		if i%2 == 1 {
+			origin = time.Second
+		}
+		h.Add(entry("api@docker", time.Duration(i)*time.Second, origin, time.Millisecond))
+	}
+	// A fast router in the last minute, with a slow middleware
+	for i := 0; i < 10; i++ {
+		h.Add(entry("web@docker", 9*time.Minute, time.Millisecond, 20*time.Millisecond))
+	}
+	// Outside the range
+	h.Add(entry("api@docker", -time.Second, time.Second, 0))
+	h.Add(entry("api@docker", 10*time.Minute, time.Second, 0))
+
+	r := h.Result()
+	if r.Overall.Requests != 110 || len(r.Overall.Columns) != 10 || r.Step != "1m0s" {
+		t.Fatalf("overall %d requests in %d columns of %s", r.Overall.Requests, len(r.Overall.Columns), r.Step)
+	}
+	if len(r.Groups) != 2 || r.Groups[0].Key != "api@docker" || r.Groups[0].Requests != 100 {
+		t.Fatalf("groups %+v", r.Groups)
+	}
+
+	// Both modes are visible in the first column and nowhere in between
+	first := r.Groups[0].Columns[0]
+	if first.Requests != 60 || len(first.Counts) != len(r.Rows) {
+		t.Fatalf("first column %+v", first)
+	}
+	var modes []int
+	for i, n := range first.Counts {
+		if n > 0 {
+			modes = append(modes, i)
+		}
+	}
+	if len(modes) != 2 || first.Counts[modes[0]] != 30 || first.Counts[modes[1]] != 30 {
+		t.Errorf("counts %v", first.Counts)
+	}
+	if lo, hi := r.Rows[modes[0]], r.Rows[modes[1]]; lo.UpperMS < 11 || lo.LowerMS > 11 || hi.UpperMS < 1001 || hi.LowerMS > 1001 {
+		t.Errorf("modes in rows %+v and %+v", lo, hi)
+	}
+	if !within(first.OriginAvgMS, 505, 0.01) || !within(first.OverheadAvgMS, 1, 0.01) {
+		t.Errorf("split %v + %v", first.OriginAvgMS, first.OverheadAvgMS)
+	}
+
+	// Traefik's share stands apart from the backend's
+	web := r.Groups[1].Breakdown
+	if !within(web.Overhead.P50MS, 20, 0.2) || !within(web.Origin.P50MS, 1, 0.2) {
+		t.Errorf("web breakdown %+v", web)
+	}
+	if c := r.Groups[1].Columns[0]; c.Requests != 0 || c.P95MS != 0 {
+		t.Errorf("empty column %+v", c)
+	}
+}
+
+func TestHeatmapMetricAndRows(t *testing.T) {
+	h, err := New(Options{Since: since, Until: since.Add(time.Hour), Metric: MetricOverhead, RowsPerDoubling: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Add(entry("api@docker", time.Minute, time.Second, 3*time.Millisecond))
+	h.Add(entry("api@docker", time.Minute, time.Second, 300*time.Second))
+
+	r := h.Result()
+	if r.Step != "1m0s" || len(r.Overall.Columns) != DefaultColumns || r.Groups != nil {
+		t.Fatalf("step %s, %d columns, groups %v", r.Step, len(r.Overall.Columns), r.Groups)
+	}
+	// One row per doubling from 3ms up to the unbounded row
+	last := r.Rows[len(r.Rows)-1]
+	if r.Rows[0].UpperMS < 3 || r.Rows[0].LowerMS > 3 || last.UpperMS != 0 {
+		t.Errorf("rows %+v", r.Rows)
+	}
+	if upper := r.Rows[1].UpperMS; !within(upper, r.Rows[0].UpperMS*2, 0.01) {
+		t.Errorf("rows are not a doubling apart: %+v", r.Rows[:2])
+	}
+	if counts := r.Overall.Columns[1].Counts; counts[0] != 1 || counts[len(counts)-1] != 1 {
+		t.Errorf("counts %v", counts)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"no range", Options{Since: since, Until: since}},
+		{"metric", Options{Since: since, Until: since.Add(time.Hour), Metric: "ttfb"}},
+		{"grouping", Options{Since: since, Until: since.Add(time.Hour), GroupBy: "client"}},
+		{"rows", Options{Since: since, Until: since.Add(time.Hour), RowsPerDoubling: 3}},
+		{"columns", Options{Since: since, Until: since.Add(time.Hour), Step: time.Second}},
+	}
+	for _, tt := range tests {
+		if _, err := New(tt.opts); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+
+	h, err := New(Options{Since: since, Until: since.Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := h.Result(); len(r.Rows) != 0 || r.Overall.Columns[0].Counts == nil {
+		t.Errorf("empty heatmap %+v", r.Rows)
+	}
+}
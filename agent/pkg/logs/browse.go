@@ -0,0 +1,335 @@
+package logs
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// browseSampleBytes is how much of an uncompressed file is read from each end
+// to estimate its line count and find its first and last timestamps
+const browseSampleBytes = 64 * 1024
+
+var (
+	// ErrFileNotFound is returned when a file is not part of a source
+	ErrFileNotFound = errors.New("file not found in log source")
+	// ErrOutsideRoot is returned when a file name or symlink escapes the
+	// root directory of a source
+	ErrOutsideRoot = errors.New("path escapes the log source root")
+)
+
+var (
+	// archiveInfos caches the scan of immutable archives, keyed like archiveSizes
+	archiveInfos sync.Map
+
+	clfTimeRegex     = regexp.MustCompile(`\[(\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4})\]`)
+	logfmtTimeRegex  = regexp.MustCompile(`\btime="([^"]+)"`)
+	leadingTimeRegex = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}T\S+)`)
+)
+
+// LogFileInfo describes one file of a source for the file browser
+type LogFileInfo struct {
+	Name           string     `json:"name"`
+	Size           int64      `json:"size"`
+	ModTime        time.Time  `json:"mod_time"`
+	Compression    string     `json:"compression,omitempty"`
+	Lines          int64      `json:"lines"`
+	LinesExact     bool       `json:"lines_exact"`
+	FirstTimestamp *time.Time `json:"first_timestamp,omitempty"`
+	LastTimestamp  *time.Time `json:"last_timestamp,omitempty"`
+	Active         bool       `json:"active"`
+}
+
+// Resolve maps a file name relative to the source root to a path on disk.
+// Only files the source currently serves can be resolved, and a file whose
+// symlinks lead outside the root is rejected unless it is the file the source
+// is configured with.
+func (s Source) Resolve(name string) (string, error) {
+	if name == "" || filepath.IsAbs(name) || strings.ContainsRune(name, 0) {
+		return "", ErrOutsideRoot
+	}
+	clean := filepath.Clean(filepath.FromSlash(name))
+	if clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", ErrOutsideRoot
+	}
+
+	files, err := s.Files(true)
+	if err != nil {
+		return "", err
+	}
+	found := false
+	for _, file := range files {
+		if file == clean {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return "", ErrFileNotFound
+	}
+
+	return s.confine(clean)
+}
+
+// confine resolves symlinks of a file relative to the root and checks the
+// result is still inside the root
+func (s Source) confine(rel string) (string, error) {
+	root := s.Root()
+	full := filepath.Join(root, rel)
+
+	realFile, err := filepath.EvalSymlinks(full)
+	if err != nil {
+		return "", ErrFileNotFound
+	}
+
+	// The configured file of a single-file source is trusted as is
+	if !s.IsPattern() && full == filepath.Clean(s.Path) {
+		return realFile, nil
+	}
+
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", ErrFileNotFound
+	}
+	inside, err := filepath.Rel(realRoot, realFile)
+	if err != nil || inside == ".." || strings.HasPrefix(inside, ".."+string(filepath.Separator)) {
+		return "", ErrOutsideRoot
+	}
+	return realFile, nil
+}
+
+// ListFiles describes every file of a source, including compressed archives.
+// Line counts of uncompressed files are estimated from a sample because they
+// are still growing; archives are scanned once and cached.
+func (s Source) ListFiles() ([]LogFileInfo, error) {
+	files, err := s.Files(true)
+	if err != nil {
+		return nil, err
+	}
+
+	active, _ := s.ActiveFile()
+
+	infos := make([]LogFileInfo, 0, len(files))
+	for _, name := range files {
+		path, err := s.confine(name)
+		if err != nil {
+			// Symlinks escaping the root are not listed
+			continue
+		}
+
+		info, err := describeFile(path)
+		if err != nil {
+			continue
+		}
+		info.Name = filepath.ToSlash(name)
+		info.Active = filepath.Join(s.Root(), name) == active
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func describeFile(path string) (LogFileInfo, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return LogFileInfo{}, err
+	}
+
+	info := LogFileInfo{
+		Size:        stat.Size(),
+		ModTime:     stat.ModTime(),
+		Compression: CompressionOf(path),
+	}
+
+	if info.Compression != CompressionNone {
+		return describeArchive(path, info)
+	}
+	return describePlain(path, info)
+}
+
+// describePlain samples the head and tail of an uncompressed file
+func describePlain(path string, info LogFileInfo) (LogFileInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return info, err
+	}
+	defer f.Close()
+
+	head := make([]byte, browseSampleBytes)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return info, err
+	}
+	head = head[:n]
+
+	// Only count complete lines in the sample
+	complete := head
+	if int64(n) < info.Size || !bytes.HasSuffix(head, []byte("\n")) {
+		if i := bytes.LastIndexByte(head, '\n'); i >= 0 {
+			complete = head[:i+1]
+		} else {
+			complete = nil
+		}
+	}
+	sampleLines := int64(bytes.Count(complete, []byte("\n")))
+
+	if int64(n) >= info.Size {
+		info.Lines = int64(bytes.Count(head, []byte("\n")))
+		if len(head) > 0 && head[len(head)-1] != '\n' {
+			info.Lines++
+		}
+		info.LinesExact = true
+	} else if sampleLines > 0 {
+		info.Lines = info.Size * sampleLines / int64(len(complete))
+	}
+
+	info.FirstTimestamp = firstTimestamp(head)
+
+	tail := head
+	if int64(n) < info.Size {
+		offset := info.Size - browseSampleBytes
+		if offset < int64(n) {
+			offset = int64(n)
+		}
+		tail = make([]byte, info.Size-offset)
+		m, err := f.ReadAt(tail, offset)
+		if err != nil && err != io.EOF {
+			return info, err
+		}
+		tail = tail[:m]
+		// The first line of the tail sample is most likely partial
+		if i := bytes.IndexByte(tail, '\n'); i >= 0 {
+			tail = tail[i+1:]
+		}
+	}
+	info.LastTimestamp = lastTimestamp(tail)
+
+	return info, nil
+}
+
+// describeArchive scans a whole archive. Archives do not change once
+// rotated, so the result is cached per path, size and mtime.
+func describeArchive(path string, info LogFileInfo) (LogFileInfo, error) {
+	key, err := archiveKey(path)
+	if err != nil {
+		return info, err
+	}
+	if cached, ok := archiveInfos.Load(key); ok {
+		return cached.(LogFileInfo), nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return info, err
+	}
+	defer f.Close()
+
+	dec, err := newDecompressor(bufio.NewReader(f), info.Compression)
+	if err != nil {
+		return info, err
+	}
+	defer dec.Close()
+
+	reader := bufio.NewReaderSize(dec, 64*1024)
+	var total int64
+	var last string
+	for {
+		line, err := reader.ReadString('\n')
+		total += int64(len(line))
+		if len(line) > 0 {
+			info.Lines++
+			if info.FirstTimestamp == nil {
+				if ts, ok := lineTimestamp(line); ok {
+					info.FirstTimestamp = &ts
+				}
+			}
+			if strings.TrimSpace(line) != "" {
+				last = line
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return info, fmt.Errorf("failed to read archive: %w", err)
+		}
+	}
+
+	if ts, ok := lineTimestamp(last); ok {
+		info.LastTimestamp = &ts
+	}
+	info.LinesExact = true
+
+	archiveInfos.Store(key, info)
+	rememberArchiveSize(path, total)
+	return info, nil
+}
+
+func firstTimestamp(sample []byte) *time.Time {
+	for _, line := range strings.Split(string(sample), "\n") {
+		if ts, ok := lineTimestamp(line); ok {
+			return &ts
+		}
+	}
+	return nil
+}
+
+func lastTimestamp(sample []byte) *time.Time {
+	lines := strings.Split(string(sample), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if ts, ok := lineTimestamp(lines[i]); ok {
+			return &ts
+		}
+	}
+	return nil
+}
+
+// lineTimestamp extracts the time of a Traefik access or error log line in
+// JSON, common log format, logfmt or Traefik's plain text error format
+func lineTimestamp(line string) (time.Time, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return time.Time{}, false
+	}
+
+	if line[0] == '{' {
+		var fields struct {
+			StartUTC string `json:"StartUTC"`
+			Time     string `json:"time"`
+		}
+		if err := json.Unmarshal([]byte(line), &fields); err != nil {
+			return time.Time{}, false
+		}
+		for _, value := range []string{fields.StartUTC, fields.Time} {
+			if ts, err := time.Parse(time.RFC3339Nano, value); err == nil {
+				return ts, true
+			}
+		}
+		return time.Time{}, false
+	}
+
+	if m := clfTimeRegex.FindStringSubmatch(line); m != nil {
+		if ts, err := time.Parse("02/Jan/2006:15:04:05 -0700", m[1]); err == nil {
+			return ts, true
+		}
+	}
+	if m := logfmtTimeRegex.FindStringSubmatch(line); m != nil {
+		if ts, err := time.Parse(time.RFC3339Nano, m[1]); err == nil {
+			return ts, true
+		}
+	}
+	if m := leadingTimeRegex.FindStringSubmatch(line); m != nil {
+		if ts, err := time.Parse(time.RFC3339Nano, m[1]); err == nil {
+			return ts, true
+		}
+	}
+	return time.Time{}, false
+}
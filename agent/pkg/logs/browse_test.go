@@ -0,0 +1,159 @@
+package logs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func jsonLines(start time.Time, n int) []byte {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, `{"StartUTC":%q,"RequestPath":"/%d"}`+"\n", start.Add(time.Duration(i)*time.Second).Format(time.RFC3339Nano), i)
+	}
+	return []byte(b.String())
+}
+
+func TestSourceResolveConfinement(t *testing.T) {
+	base := t.TempDir()
+	root := filepath.Join(base, "logs")
+	os.MkdirAll(filepath.Join(root, "edge"), 0755)
+	os.WriteFile(filepath.Join(root, "access.log"), []byte("ok\n"), 0644)
+	os.WriteFile(filepath.Join(root, "edge", "access.log"), []byte("ok\n"), 0644)
+	os.WriteFile(filepath.Join(base, "secret.log"), []byte("secret\n"), 0644)
+
+	// A symlink inside the root pointing outside of it
+	if err := os.Symlink(filepath.Join(base, "secret.log"), filepath.Join(root, "leak.log")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	// A symlink that stays inside the root is fine
+	os.Symlink(filepath.Join(root, "access.log"), filepath.Join(root, "current.log"))
+
+	dirSource := Source{Name: "dir", Path: root, Type: SourceTypeAccess}
+	globSource := Source{Name: "glob", Path: filepath.Join(root, "*", "access.log"), Type: SourceTypeAccess}
+
+	tests := []struct {
+		name string
+		src  Source
+		file string
+		want error
+	}{
+		{"plain file", dirSource, "access.log", nil},
+		{"internal symlink", dirSource, "current.log", nil},
+		{"nested glob match", globSource, "edge/access.log", nil},
+		{"parent traversal", dirSource, "../secret.log", ErrOutsideRoot},
+		{"nested traversal", globSource, "edge/../../secret.log", ErrOutsideRoot},
+		{"absolute path", dirSource, filepath.Join(base, "secret.log"), ErrOutsideRoot},
+		{"escaping symlink", dirSource, "leak.log", ErrOutsideRoot},
+		{"not served", globSource, "access.log", ErrFileNotFound},
+		{"missing", dirSource, "nope.log", ErrFileNotFound},
+		{"empty", dirSource, "", ErrOutsideRoot},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := tt.src.Resolve(tt.file)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v (%s)", tt.want, err, path)
+			}
+			if err == nil && !strings.HasPrefix(path, root) {
+				resolvedRoot, _ := filepath.EvalSymlinks(root)
+				if !strings.HasPrefix(path, resolvedRoot) {
+					t.Fatalf("resolved %s outside %s", path, root)
+				}
+			}
+		})
+	}
+
+	// The configured file of a single-file source may itself be a symlink
+	single := Source{Name: "single", Path: filepath.Join(root, "leak.log"), Type: SourceTypeAccess}
+	if _, err := single.Resolve("leak.log"); err != nil {
+		t.Fatalf("configured symlink rejected: %v", err)
+	}
+
+	files, err := dirSource.ListFiles()
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	for _, f := range files {
+		if f.Name == "leak.log" {
+			t.Fatalf("escaping symlink listed: %+v", f)
+		}
+	}
+}
+
+func TestSourceListFiles(t *testing.T) {
+	ConfigureCompressedIndex("", 0)
+	dir := t.TempDir()
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	writeGzip(t, filepath.Join(dir, "access.log.1.gz"), jsonLines(start, 50))
+	// Large enough that the line count has to be estimated
+	os.WriteFile(filepath.Join(dir, "access.log"), jsonLines(start.Add(time.Hour), 5000), 0644)
+
+	src := Source{Name: "access", Path: dir, Type: SourceTypeAccess}
+	files, err := src.ListFiles()
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("expected 2 files, got %+v", files)
+	}
+
+	active, archive := files[0], files[1]
+	if active.Name != "access.log" || !active.Active || active.Compression != CompressionNone {
+		t.Fatalf("unexpected active file: %+v", active)
+	}
+	if active.LinesExact || active.Lines < 4500 || active.Lines > 5500 {
+		t.Fatalf("expected an estimate near 5000 lines, got %d (exact=%v)", active.Lines, active.LinesExact)
+	}
+	if active.FirstTimestamp == nil || !active.FirstTimestamp.Equal(start.Add(time.Hour)) {
+		t.Fatalf("unexpected first timestamp %v", active.FirstTimestamp)
+	}
+	if active.LastTimestamp == nil || !active.LastTimestamp.Equal(start.Add(time.Hour+4999*time.Second)) {
+		t.Fatalf("unexpected last timestamp %v", active.LastTimestamp)
+	}
+
+	if archive.Compression != CompressionGzip || archive.Active {
+		t.Fatalf("unexpected archive: %+v", archive)
+	}
+	if !archive.LinesExact || archive.Lines != 50 {
+		t.Fatalf("expected exactly 50 archive lines, got %d", archive.Lines)
+	}
+	if archive.FirstTimestamp == nil || !archive.FirstTimestamp.Equal(start) ||
+		archive.LastTimestamp == nil || !archive.LastTimestamp.Equal(start.Add(49*time.Second)) {
+		t.Fatalf("unexpected archive span %v - %v", archive.FirstTimestamp, archive.LastTimestamp)
+	}
+}
+
+func TestLineTimestamp(t *testing.T) {
+	want := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		line string
+	}{
+		{"json access", `{"StartUTC":"2024-05-01T12:00:00Z"}`},
+		{"json error", `{"level":"error","time":"2024-05-01T12:00:00Z"}`},
+		{"common log format", `1.2.3.4 - - [01/May/2024:12:00:00 +0000] "GET / HTTP/1.1" 200 1 "-" "-" 1 "r" "s" 1ms`},
+		{"logfmt", `time="2024-05-01T12:00:00Z" level=error msg="boom"`},
+		{"plain text", `2024-05-01T12:00:00Z ERR boom`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts, ok := lineTimestamp(tt.line)
+			if !ok || !ts.Equal(want) {
+				t.Fatalf("expected %v, got %v (%v)", want, ts, ok)
+			}
+		})
+	}
+
+	if _, ok := lineTimestamp("no time here"); ok {
+		t.Fatal("expected no timestamp")
+	}
+}
@@ -0,0 +1,292 @@
+package logs
+
+import (
+	"bufio"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// DefaultCompressedPageLines is the number of lines returned per call when
+// paging through a compressed archive without an explicit limit.
+const DefaultCompressedPageLines = 1000
+
+// Compression formats recognised for rotated log archives
+const (
+	CompressionNone  = ""
+	CompressionGzip  = "gzip"
+	CompressionZstd  = "zstd"
+	CompressionBzip2 = "bzip2"
+)
+
+var (
+	// archiveSizes remembers the uncompressed length of archives that have
+	// been read to the end, keyed by path, size and mtime. Rotated archives
+	// are immutable, so once an archive is exhausted later polls can return
+	// immediately instead of decompressing it again.
+	archiveSizes sync.Map
+)
+
+// CompressionOf returns the compression format implied by a file name
+func CompressionOf(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".gz":
+		return CompressionGzip
+	case ".zst", ".zstd":
+		return CompressionZstd
+	case ".bz2":
+		return CompressionBzip2
+	default:
+		return CompressionNone
+	}
+}
+
+// IsCompressed reports whether a file name refers to a supported compressed archive
+func IsCompressed(name string) bool {
+	return CompressionOf(name) != CompressionNone
+}
+
+// newDecompressor wraps r with a streaming decoder for the given format
+func newDecompressor(r io.Reader, format string) (io.ReadCloser, error) {
+	switch format {
+	case CompressionGzip:
+		return gzip.NewReader(r)
+	case CompressionZstd:
+		dec, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return dec.IOReadCloser(), nil
+	case CompressionBzip2:
+		return io.NopCloser(bzip2.NewReader(r)), nil
+	default:
+		return nil, fmt.Errorf("unsupported compression format: %q", format)
+	}
+}
+
+// compressedStream is a decompressed view of an archive starting at a known
+// uncompressed offset.
+type compressedStream struct {
+	io.Reader
+	start   int64
+	closers []io.Closer
+}
+
+func (s *compressedStream) Close() error {
+	var firstErr error
+	for i := len(s.closers) - 1; i >= 0; i-- {
+		if err := s.closers[i].Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// openCompressed opens an archive for reading at or before the requested
+// uncompressed offset. When a checkpoint index is configured the stream starts
+// at the nearest checkpoint; otherwise it starts at offset zero.
+func openCompressed(filePath string, position int64) (*compressedStream, error) {
+	if stream, err := openFromIndex(filePath, position); err != nil {
+		// The index is only an optimisation; fall back to the archive itself
+		logIndexError(filePath, err)
+	} else if stream != nil {
+		return stream, nil
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	dec, err := newDecompressor(bufio.NewReaderSize(file, 64*1024), CompressionOf(filePath))
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &compressedStream{
+		Reader:  dec,
+		start:   0,
+		closers: []io.Closer{file, dec},
+	}, nil
+}
+
+// archiveKey identifies a specific version of an archive on disk
+func archiveKey(filePath string) (string, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s|%d|%d", filePath, info.Size(), info.ModTime().UnixNano()), nil
+}
+
+// knownArchiveSize returns the uncompressed length of an archive if it has
+// already been read to the end or indexed.
+func knownArchiveSize(filePath string) (int64, bool) {
+	key, err := archiveKey(filePath)
+	if err != nil {
+		return 0, false
+	}
+	if size, ok := archiveSizes.Load(key); ok {
+		return size.(int64), true
+	}
+	return 0, false
+}
+
+func rememberArchiveSize(filePath string, size int64) {
+	if key, err := archiveKey(filePath); err == nil {
+		archiveSizes.Store(key, size)
+	}
+}
+
+// readCompressedLogFile reads up to maxLines lines from a compressed archive,
+// starting at the given uncompressed byte offset. The returned position is the
+// uncompressed offset of the next unread line, so callers can page through an
+// archive of any size with bounded memory. A negative position returns the
+// last maxLines lines of the archive.
+func readCompressedLogFile(filePath string, position int64, maxLines int) (LogResult, error) {
+	if maxLines <= 0 {
+		maxLines = DefaultCompressedPageLines
+	}
+
+	if position < 0 {
+		return tailCompressedLogFile(filePath, maxLines)
+	}
+
+	logs := make([]string, 0, min(maxLines, 1000))
+	offset, err := scanCompressedLogFile(filePath, position, maxLines, func(line string) error {
+		logs = append(logs, line)
+		return nil
+	})
+	if err != nil {
+		return LogResult{}, err
+	}
+
+	return LogResult{
+		Logs:      logs,
+		Positions: []Position{{Position: offset}},
+	}, nil
+}
+
+// scanCompressedLogFile passes up to maxLines lines of an archive, starting at
+// an uncompressed offset, to fn and returns the offset of the next unread line
+func scanCompressedLogFile(filePath string, position int64, maxLines int, fn LineFunc) (int64, error) {
+	if size, ok := knownArchiveSize(filePath); ok && position >= size {
+		return size, nil
+	}
+
+	stream, err := openCompressed(filePath, position)
+	if err != nil {
+		return position, err
+	}
+	defer stream.Close()
+
+	offset := stream.start
+	if skip := position - stream.start; skip > 0 {
+		n, err := io.CopyN(io.Discard, stream, skip)
+		offset += n
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				// Requested position is past the end of the archive
+				rememberArchiveSize(filePath, offset)
+				return offset, nil
+			}
+			return position, err
+		}
+	}
+
+	reader := bufio.NewReaderSize(stream, 64*1024)
+	for emitted := 0; emitted < maxLines; {
+		line, err := reader.ReadString('\n')
+		offset += int64(len(line))
+
+		if trimmed := strings.TrimRight(line, "\r\n"); strings.TrimSpace(trimmed) != "" {
+			switch err := fn(trimmed); {
+			case err == nil:
+				emitted++
+			case !errors.Is(err, ErrSkipLine):
+				// Leave the rejected line to be read again
+				return offset - int64(len(line)), err
+			}
+		}
+
+		if err != nil {
+			if err == io.EOF {
+				rememberArchiveSize(filePath, offset)
+				break
+			}
+			return offset, err
+		}
+	}
+
+	return offset, nil
+}
+
+// tailCompressedLogFile returns the last numLines lines of an archive. The
+// archive has to be decompressed in full, but only numLines lines are held in
+// memory at any time.
+func tailCompressedLogFile(filePath string, numLines int) (LogResult, error) {
+	stream, err := openCompressed(filePath, 0)
+	if err != nil {
+		return LogResult{}, err
+	}
+	defer stream.Close()
+
+	ring := make([]string, numLines)
+	count := 0
+	offset := stream.start
+
+	reader := bufio.NewReaderSize(stream, 64*1024)
+	for {
+		line, err := reader.ReadString('\n')
+		offset += int64(len(line))
+
+		if trimmed := strings.TrimRight(line, "\r\n"); strings.TrimSpace(trimmed) != "" {
+			ring[count%numLines] = trimmed
+			count++
+		}
+
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return LogResult{}, err
+		}
+	}
+
+	rememberArchiveSize(filePath, offset)
+
+	logs := make([]string, 0, min(count, numLines))
+	start := 0
+	if count > numLines {
+		start = count - numLines
+	}
+	for i := start; i < count; i++ {
+		logs = append(logs, ring[i%numLines])
+	}
+
+	return LogResult{
+		Logs:      logs,
+		Positions: []Position{{Position: offset}},
+	}, nil
+}
+
+// GetCompressedLog reads a page of at most maxLines lines from a compressed
+// archive starting at the given uncompressed offset.
+func GetCompressedLog(filePath string, position int64, maxLines int) (LogResult, error) {
+	if !IsCompressed(filePath) {
+		return LogResult{}, fmt.Errorf("not a compressed log file: %s", filePath)
+	}
+	if _, err := os.Stat(filePath); err != nil {
+		return LogResult{}, fmt.Errorf("file not found: %s", filePath)
+	}
+	return readCompressedLogFile(filePath, position, maxLines)
+}
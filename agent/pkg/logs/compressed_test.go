@@ -0,0 +1,328 @@
+package logs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// bzip2 of "line-1\n" .. "line-5\n" (the standard library has no bzip2 writer)
+const bzip2Fixture = "QlpoOTFBWSZTWR72HJAAAAzZAAAQAAI+AAIlIAAhFQMj1CAaaaK1vlWlpEieSJ8XckU4UJAe9hyQ"
+
+func numberedLines(n int) []byte {
+	var buf bytes.Buffer
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&buf, "line-%d\n", i)
+	}
+	return buf.Bytes()
+}
+
+func writeGzip(t *testing.T, path string, data []byte) {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write(data)
+	gz.Close()
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("write gzip: %v", err)
+	}
+}
+
+func writeZstd(t *testing.T, path string, data []byte) {
+	t.Helper()
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatalf("zstd writer: %v", err)
+	}
+	defer enc.Close()
+	if err := os.WriteFile(path, enc.EncodeAll(data, nil), 0644); err != nil {
+		t.Fatalf("write zstd: %v", err)
+	}
+}
+
+// readAllPages pages through an archive and returns every line read
+func readAllPages(t *testing.T, path string, pageSize int) []string {
+	t.Helper()
+	var all []string
+	var position int64
+	for i := 0; i < 1000; i++ {
+		result, err := GetCompressedLog(path, position, pageSize)
+		if err != nil {
+			t.Fatalf("page at %d: %v", position, err)
+		}
+		if len(result.Logs) > pageSize {
+			t.Fatalf("page returned %d lines, limit %d", len(result.Logs), pageSize)
+		}
+		all = append(all, result.Logs...)
+		next := result.Positions[0].Position
+		if len(result.Logs) == 0 {
+			if next != position {
+				t.Fatalf("empty page moved position from %d to %d", position, next)
+			}
+			return all
+		}
+		position = next
+	}
+	t.Fatalf("pagination did not terminate")
+	return nil
+}
+
+func TestCompressedPagination(t *testing.T) {
+	ConfigureCompressedIndex("", 0)
+	dir := t.TempDir()
+
+	gzPath := filepath.Join(dir, "access.log.1.gz")
+	writeGzip(t, gzPath, numberedLines(25))
+
+	zstPath := filepath.Join(dir, "access.log.2.zst")
+	writeZstd(t, zstPath, numberedLines(25))
+
+	bzData, _ := base64.StdEncoding.DecodeString(bzip2Fixture)
+	bzPath := filepath.Join(dir, "access.log.3.bz2")
+	if err := os.WriteFile(bzPath, bzData, 0644); err != nil {
+		t.Fatalf("write bzip2: %v", err)
+	}
+
+	tests := []struct {
+		path  string
+		lines int
+	}{
+		{gzPath, 25},
+		{zstPath, 25},
+		{bzPath, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(filepath.Ext(tt.path), func(t *testing.T) {
+			lines := readAllPages(t, tt.path, 4)
+			if len(lines) != tt.lines {
+				t.Fatalf("expected %d lines, got %d", tt.lines, len(lines))
+			}
+			for i, line := range lines {
+				if want := fmt.Sprintf("line-%d", i+1); line != want {
+					t.Fatalf("line %d: expected %q, got %q", i, want, line)
+				}
+			}
+		})
+	}
+}
+
+func TestCompressedPaginationWithIndex(t *testing.T) {
+	dir := t.TempDir()
+	ConfigureCompressedIndex(filepath.Join(dir, "index"), 64)
+	defer ConfigureCompressedIndex("", 0)
+
+	// One gzip member per 20 lines, as written by pigz --independent or by
+	// concatenating archives
+	gzPath := filepath.Join(dir, "access.log.1.gz")
+	var archive bytes.Buffer
+	data := numberedLines(200)
+	for i := 0; i < 200; i += 20 {
+		gz := gzip.NewWriter(&archive)
+		for j := i + 1; j <= i+20; j++ {
+			fmt.Fprintf(gz, "line-%d\n", j)
+		}
+		gz.Close()
+	}
+	if err := os.WriteFile(gzPath, archive.Bytes(), 0644); err != nil {
+		t.Fatalf("write gzip: %v", err)
+	}
+
+	lines := readAllPages(t, gzPath, 7)
+	if len(lines) != 200 {
+		t.Fatalf("expected 200 lines, got %d", len(lines))
+	}
+	for i, line := range lines {
+		if want := fmt.Sprintf("line-%d", i+1); line != want {
+			t.Fatalf("line %d: expected %q, got %q", i, want, line)
+		}
+	}
+
+	info, _ := os.Stat(gzPath)
+	idx, err := loadArchiveIndex(indexPath(filepath.Join(dir, "index"), gzPath), info)
+	if err != nil || idx == nil {
+		t.Fatalf("expected index to be written, err=%v", err)
+	}
+	if len(idx.Checkpoints) < 2 {
+		t.Fatalf("expected multiple checkpoints, got %d", len(idx.Checkpoints))
+	}
+	if idx.UncompressedSize != int64(len(data)) {
+		t.Fatalf("unexpected uncompressed size %d", idx.UncompressedSize)
+	}
+
+	// Each checkpoint starts a member that decodes to the lines at its offset
+	for _, cp := range idx.Checkpoints {
+		gz, err := gzip.NewReader(bytes.NewReader(archive.Bytes()[cp.Member:]))
+		if err != nil {
+			t.Fatalf("member at %d: %v", cp.Member, err)
+		}
+		want := data[cp.Offset : cp.Offset+8]
+		got := make([]byte, len(want))
+		if _, err := io.ReadFull(gz, got); err != nil || !bytes.Equal(got, want) {
+			t.Fatalf("checkpoint %+v: expected %q, got %q (%v)", cp, want, got, err)
+		}
+	}
+
+	// Only the index is stored, never a copy of the archive
+	entries, _ := os.ReadDir(filepath.Join(dir, "index"))
+	if len(entries) != 1 || filepath.Ext(entries[0].Name()) != ".idx" {
+		t.Fatalf("expected a single index file, got %v", entries)
+	}
+}
+
+func TestCompressedIndexSingleMember(t *testing.T) {
+	dir := t.TempDir()
+	indexDir := filepath.Join(dir, "index")
+	ConfigureCompressedIndex(indexDir, 64)
+	defer ConfigureCompressedIndex("", 0)
+
+	gzPath := filepath.Join(dir, "access.log.1.gz")
+	writeGzip(t, gzPath, numberedLines(100))
+
+	// An index of an earlier version, with its copy of the archive
+	idxPath := indexPath(indexDir, gzPath)
+	segPath := strings.TrimSuffix(idxPath, ".idx") + ".seg"
+	os.MkdirAll(indexDir, 0755)
+	os.WriteFile(idxPath, []byte(`{"checkpoints":[{"offset":0,"segment":0}]}`), 0644)
+	os.WriteFile(segPath, []byte("stale"), 0644)
+
+	lines := readAllPages(t, gzPath, 9)
+	if len(lines) != 100 || lines[99] != "line-100" {
+		t.Fatalf("unexpected lines: %d", len(lines))
+	}
+
+	info, _ := os.Stat(gzPath)
+	idx, err := loadArchiveIndex(idxPath, info)
+	if err != nil || idx == nil {
+		t.Fatalf("expected index to be rebuilt, err=%v", err)
+	}
+	if len(idx.Checkpoints) != 1 || idx.Checkpoints[0] != (Checkpoint{}) {
+		t.Fatalf("expected one checkpoint at the start, got %+v", idx.Checkpoints)
+	}
+	if idx.UncompressedSize != int64(len(numberedLines(100))) {
+		t.Fatalf("unexpected uncompressed size %d", idx.UncompressedSize)
+	}
+	if _, err := os.Stat(segPath); !os.IsNotExist(err) {
+		t.Fatalf("expected the old segment copy to be removed, err=%v", err)
+	}
+}
+
+func TestCompressedIndexBuildPerArchive(t *testing.T) {
+	dir := t.TempDir()
+	indexDir := filepath.Join(dir, "index")
+	ConfigureCompressedIndex(indexDir, 64)
+	defer ConfigureCompressedIndex("", 0)
+
+	slowPath := filepath.Join(dir, "access.log.1.gz")
+	otherPath := filepath.Join(dir, "access.log.2.gz")
+	writeGzip(t, slowPath, numberedLines(10))
+	writeGzip(t, otherPath, numberedLines(10))
+
+	// A build of the first archive that has not finished yet
+	slow := &indexBuild{done: make(chan struct{})}
+	indexMutex.Lock()
+	indexBuilds[indexPath(indexDir, slowPath)] = slow
+	indexMutex.Unlock()
+
+	waited := make(chan error, 1)
+	go func() {
+		_, err := openFromIndex(slowPath, 0)
+		waited <- err
+	}()
+
+	// Other archives are indexed meanwhile
+	done := make(chan error, 1)
+	go func() {
+		stream, err := openFromIndex(otherPath, 0)
+		if err == nil {
+			stream.Close()
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("indexing another archive waited for the pending build")
+	}
+
+	// Requests for the first archive share the pending build's result
+	select {
+	case err := <-waited:
+		t.Fatalf("request returned before the build finished, err=%v", err)
+	default:
+	}
+	slow.err = errors.New("build failed")
+	close(slow.done)
+	if err := <-waited; err != slow.err {
+		t.Fatalf("expected the shared build error, got %v", err)
+	}
+	indexMutex.Lock()
+	delete(indexBuilds, indexPath(indexDir, slowPath))
+	indexMutex.Unlock()
+}
+
+func TestCompressedTail(t *testing.T) {
+	ConfigureCompressedIndex("", 0)
+	gzPath := filepath.Join(t.TempDir(), "access.log.1.gz")
+	writeGzip(t, gzPath, numberedLines(50))
+
+	result, err := GetCompressedLog(gzPath, -1, 3)
+	if err != nil {
+		t.Fatalf("tail: %v", err)
+	}
+	if len(result.Logs) != 3 || result.Logs[0] != "line-48" || result.Logs[2] != "line-50" {
+		t.Fatalf("unexpected tail: %v", result.Logs)
+	}
+	if result.Positions[0].Position != int64(len(numberedLines(50))) {
+		t.Fatalf("tail position should be end of archive, got %d", result.Positions[0].Position)
+	}
+}
+
+func TestDirectoryLogsIncludeCompressed(t *testing.T) {
+	ConfigureCompressedIndex("", 0)
+	dir := t.TempDir()
+	writeGzip(t, filepath.Join(dir, "access.log.1.gz"), numberedLines(3))
+	if err := os.WriteFile(filepath.Join(dir, "access.log"), []byte("current\n"), 0644); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+
+	// First request tails the active file only
+	result, err := GetDirectoryLogs(dir, nil, false, true)
+	if err != nil {
+		t.Fatalf("tail: %v", err)
+	}
+	if len(result.Logs) != 1 || result.Positions[0].Filename != "access.log" {
+		t.Fatalf("unexpected tail result: %+v", result)
+	}
+
+	// Following request pages through the archive
+	result, err = GetDirectoryLogs(dir, result.Positions, false, true)
+	if err != nil {
+		t.Fatalf("page: %v", err)
+	}
+	if len(result.Logs) != 3 {
+		t.Fatalf("expected 3 archived lines, got %v", result.Logs)
+	}
+
+	// Once exhausted, nothing is returned again
+	result, err = GetDirectoryLogs(dir, result.Positions, false, true)
+	if err != nil {
+		t.Fatalf("page: %v", err)
+	}
+	if len(result.Logs) != 0 {
+		t.Fatalf("expected no new lines, got %v", result.Logs)
+	}
+}
@@ -0,0 +1,25 @@
+package logs
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// ParsedLine returns a log line as a JSON object. JSON lines are passed
+// through unchanged, common log format access lines are parsed into a
+// TraefikLog, and anything else is wrapped as {"raw": line}.
+func ParsedLine(line string) json.RawMessage {
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "{") && json.Valid([]byte(trimmed)) {
+		return json.RawMessage(trimmed)
+	}
+
+	if entry, err := parseCLFLog(trimmed); err == nil && entry != nil {
+		if data, err := json.Marshal(entry); err == nil {
+			return data
+		}
+	}
+
+	data, _ := json.Marshal(map[string]string{"raw": line})
+	return data
+}
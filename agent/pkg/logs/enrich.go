@@ -0,0 +1,90 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// Enricher adds derived fields to parsed entries
+type Enricher interface {
+	Enrich(entry *TraefikLog)
+}
+
+// DerivedFields are the TraefikLog fields set by enrichers rather than read
+// from logs
+var DerivedFields = []string{
+	"ClientCountryCode",
+	"ClientCountry",
+	"ClientCity",
+	"ClientASN",
+	"ClientASOrg",
+	"ClientPrivate",
+	"UserAgentClass",
+	"UserAgentName",
+	"UserAgentBrowser",
+	"UserAgentOS",
+	"UserAgentDevice",
+}
+
+// Enrich runs enrichers on an entry
+func Enrich(entry *TraefikLog, enrichers ...Enricher) {
+	for _, e := range enrichers {
+		e.Enrich(entry)
+	}
+}
+
+// EnrichLine runs enrichers on an access log line and returns it as JSON
+// with the derived fields added. JSON lines keep their other fields as
+// written; lines that cannot be parsed are returned unchanged.
+func EnrichLine(line string, enrichers ...Enricher) string {
+	if len(enrichers) == 0 {
+		return line
+	}
+	entry, err := ParseTraefikLog(line)
+	if err != nil || entry == nil {
+		return line
+	}
+	Enrich(entry, enrichers...)
+
+	trimmed := strings.TrimSpace(line)
+	if trimmed[0] != '{' {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return line
+		}
+		return string(data)
+	}
+
+	enriched, err := json.Marshal(entry)
+	if err != nil {
+		return line
+	}
+	var derived map[string]json.RawMessage
+	if err := json.Unmarshal(enriched, &derived); err != nil {
+		return line
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(trimmed), &fields); err != nil {
+		return line
+	}
+	added := false
+	for _, name := range DerivedFields {
+		if value, ok := derived[name]; ok {
+			fields[name] = value
+			added = true
+		}
+	}
+	if !added {
+		return line
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(fields); err != nil {
+		return line
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
@@ -0,0 +1,60 @@
+package logs
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type countryEnricher string
+
+func (c countryEnricher) Enrich(entry *TraefikLog) {
+	entry.ClientCountryCode = string(c)
+	entry.ClientASN = 64500
+}
+
+func TestEnrichLine(t *testing.T) {
+	enricher := countryEnricher("NL")
+
+	t.Run("json keeps fields", func(t *testing.T) {
+		line := `{"ClientHost":"81.2.69.142","RequestPath":"/a?b=<c>","custom":"kept","DownstreamStatus":200}`
+		got := EnrichLine(line, enricher)
+
+		var fields map[string]interface{}
+		if err := json.Unmarshal([]byte(got), &fields); err != nil {
+			t.Fatalf("invalid JSON %q: %v", got, err)
+		}
+		if fields["custom"] != "kept" || fields["RequestPath"] != "/a?b=<c>" {
+			t.Errorf("original fields changed: %s", got)
+		}
+		if fields["ClientCountryCode"] != "NL" || fields["ClientASN"] != float64(64500) {
+			t.Errorf("derived fields missing: %s", got)
+		}
+		if _, ok := fields["ClientCity"]; ok {
+			t.Errorf("empty derived fields should be omitted: %s", got)
+		}
+	})
+
+	t.Run("clf becomes json", func(t *testing.T) {
+		line := `81.2.69.142 - - [10/Oct/2024:13:55:36 +0000] "GET /index.html HTTP/1.1" 200 2326 "-" "curl/8.0" 1 "web@docker" "http://10.0.0.2:80" 5ms`
+		got := EnrichLine(line, enricher)
+
+		var entry TraefikLog
+		if err := json.Unmarshal([]byte(got), &entry); err != nil {
+			t.Fatalf("invalid JSON %q: %v", got, err)
+		}
+		if entry.ClientCountryCode != "NL" || entry.RequestPath != "/index.html" {
+			t.Errorf("unexpected entry %+v", entry)
+		}
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		for _, line := range []string{"not a log line", `{"ClientHost":"81.2.69.142"}`} {
+			if got := EnrichLine(line); got != line {
+				t.Errorf("EnrichLine without enrichers = %q", got)
+			}
+		}
+		if got := EnrichLine("not a log line", enricher); got != "not a log line" {
+			t.Errorf("unparsable line changed to %q", got)
+		}
+	})
+}
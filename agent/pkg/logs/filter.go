@@ -0,0 +1,275 @@
+package logs
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StatusRange is an inclusive range of HTTP status codes
+type StatusRange struct {
+	Min int
+	Max int
+}
+
+// Filter selects access log entries. Zero fields match everything; list
+// fields match when any of their values matches.
+type Filter struct {
+	Since time.Time
+	Until time.Time
+
+	Statuses []StatusRange
+	Methods  []string
+	// Routers, Services, Hosts and Paths are patterns in which * matches any
+	// run of characters, e.g. api@*. A path without * matches as a prefix.
+	Routers  []string
+	Services []string
+	Hosts    []string
+	Paths    []string
+	Clients  []*net.IPNet
+
+	MinDuration time.Duration
+	MaxDuration time.Duration
+
+	// User agent classification, compared without case. UserAgentNames are
+	// patterns like Routers.
+	UserAgentClasses []string
+	UserAgentNames   []string
+	Browsers         []string
+	OSes             []string
+	Devices          []string
+}
+
+// IsZero reports whether the filter matches every entry
+func (f Filter) IsZero() bool {
+	return f.Since.IsZero() && f.Until.IsZero() &&
+		len(f.Statuses) == 0 && len(f.Methods) == 0 && len(f.Routers) == 0 &&
+		len(f.Services) == 0 && len(f.Hosts) == 0 && len(f.Paths) == 0 &&
+		len(f.Clients) == 0 && f.MinDuration == 0 && f.MaxDuration == 0 &&
+		len(f.UserAgentClasses) == 0 && len(f.UserAgentNames) == 0 &&
+		len(f.Browsers) == 0 && len(f.OSes) == 0 && len(f.Devices) == 0
+}
+
+// Match reports whether an entry passes the filter
+func (f Filter) Match(entry *TraefikLog) bool {
+	if entry == nil {
+		return false
+	}
+
+	if !f.Since.IsZero() || !f.Until.IsZero() {
+		ts := entry.StartUTC
+		if ts.IsZero() {
+			return false
+		}
+		if !f.Since.IsZero() && ts.Before(f.Since) {
+			return false
+		}
+		if !f.Until.IsZero() && !ts.Before(f.Until) {
+			return false
+		}
+	}
+
+	if len(f.Statuses) > 0 {
+		matched := false
+		for _, r := range f.Statuses {
+			if entry.DownstreamStatus >= r.Min && entry.DownstreamStatus <= r.Max {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if len(f.Methods) > 0 && !containsFold(f.Methods, entry.RequestMethod) {
+		return false
+	}
+	if !matchAnyGlob(f.Routers, entry.RouterName, false) ||
+		!matchAnyGlob(f.Services, entry.ServiceName, false) ||
+		!matchAnyGlob(f.Hosts, entry.RequestHost, false) ||
+		!matchAnyGlob(f.Paths, entry.RequestPath, true) {
+		return false
+	}
+
+	if len(f.Clients) > 0 {
+		ip := net.ParseIP(clientIP(entry))
+		matched := false
+		for _, network := range f.Clients {
+			if ip != nil && network.Contains(ip) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	duration := time.Duration(entry.Duration)
+	if f.MinDuration > 0 && duration < f.MinDuration {
+		return false
+	}
+	if f.MaxDuration > 0 && duration > f.MaxDuration {
+		return false
+	}
+
+	if (len(f.UserAgentClasses) > 0 && !containsFold(f.UserAgentClasses, entry.UserAgentClass)) ||
+		!matchAnyGlob(f.UserAgentNames, entry.UserAgentName, false) ||
+		(len(f.Browsers) > 0 && !containsFold(f.Browsers, entry.UserAgentBrowser)) ||
+		(len(f.OSes) > 0 && !containsFold(f.OSes, entry.UserAgentOS)) ||
+		(len(f.Devices) > 0 && !containsFold(f.Devices, entry.UserAgentDevice)) {
+		return false
+	}
+
+	return true
+}
+
+// MatchLine parses a log line and reports whether it passes the filter
+func (f Filter) MatchLine(line string) bool {
+	if f.IsZero() {
+		return true
+	}
+	entry, err := ParseTraefikLog(line)
+	if err != nil {
+		return false
+	}
+	return f.Match(entry)
+}
+
+// ParseStatusRange parses a status code ("404"), a class ("5xx") or an
+// inclusive range ("400-499")
+func ParseStatusRange(value string) (StatusRange, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	if len(value) == 3 && strings.HasSuffix(value, "xx") && value[0] >= '1' && value[0] <= '5' {
+		class := int(value[0]-'0') * 100
+		return StatusRange{Min: class, Max: class + 99}, nil
+	}
+
+	if lo, hi, ok := strings.Cut(value, "-"); ok {
+		min, err1 := strconv.Atoi(lo)
+		max, err2 := strconv.Atoi(hi)
+		if err1 != nil || err2 != nil || min > max {
+			return StatusRange{}, fmt.Errorf("invalid status range %q", value)
+		}
+		return StatusRange{Min: min, Max: max}, nil
+	}
+
+	code, err := strconv.Atoi(value)
+	if err != nil {
+		return StatusRange{}, fmt.Errorf("invalid status %q", value)
+	}
+	return StatusRange{Min: code, Max: code}, nil
+}
+
+// ParseClientNetwork parses an IP address or CIDR block
+func ParseClientNetwork(value string) (*net.IPNet, error) {
+	value = strings.TrimSpace(value)
+	if strings.Contains(value, "/") {
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q", value)
+		}
+		return network, nil
+	}
+
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP address %q", value)
+	}
+	bits := 128
+	if ip.To4() != nil {
+		ip = ip.To4()
+		bits = 32
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+// ParseTimeBound parses an RFC 3339 timestamp, a Unix timestamp in seconds or
+// a duration relative to now ("15m" means 15 minutes ago)
+func ParseTimeBound(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if ts, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return ts, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		if d < 0 {
+			d = -d
+		}
+		return now.Add(-d), nil
+	}
+	if secs, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(secs, 0).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: use RFC 3339, Unix seconds or a duration such as 15m", value)
+}
+
+// clientIP returns the client address of an entry without its port
+func clientIP(entry *TraefikLog) string {
+	if entry.ClientHost != "" {
+		return entry.ClientHost
+	}
+	if host, _, err := net.SplitHostPort(entry.ClientAddr); err == nil {
+		return host
+	}
+	return entry.ClientAddr
+}
+
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchAnyGlob(patterns []string, s string, prefix bool) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	s = strings.ToLower(s)
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if prefix && !strings.ContainsAny(pattern, "*?") {
+			if strings.HasPrefix(s, pattern) {
+				return true
+			}
+			continue
+		}
+		if MatchGlob(pattern, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchGlob matches s against a pattern where * matches any run of
+// characters, including slashes, and ? matches a single character
+func MatchGlob(pattern, s string) bool {
+	p, i := 0, 0
+	star, mark := -1, 0
+	for i < len(s) {
+		switch {
+		case p < len(pattern) && (pattern[p] == '?' || pattern[p] == s[i]):
+			p++
+			i++
+		case p < len(pattern) && pattern[p] == '*':
+			star, mark = p, i
+			p++
+		case star >= 0:
+			p = star + 1
+			mark++
+			i = mark
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
@@ -0,0 +1,123 @@
+package logs
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestParseStatusRange(t *testing.T) {
+	tests := map[string]StatusRange{
+		"404":     {404, 404},
+		"5xx":     {500, 599},
+		"2XX":     {200, 299},
+		"400-499": {400, 499},
+	}
+	for value, want := range tests {
+		got, err := ParseStatusRange(value)
+		if err != nil || got != want {
+			t.Errorf("ParseStatusRange(%q) = %v, %v; want %v", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"", "abc", "6xx", "500-400", "4-"} {
+		if _, err := ParseStatusRange(value); err == nil {
+			t.Errorf("ParseStatusRange(%q) should fail", value)
+		}
+	}
+}
+
+func TestParseTimeBound(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := map[string]time.Time{
+		"2024-05-01T10:00:00Z": time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		"15m":                  now.Add(-15 * time.Minute),
+		"-1h":                  now.Add(-time.Hour),
+		"1714564800":           time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+	for value, want := range tests {
+		got, err := ParseTimeBound(value, now)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("ParseTimeBound(%q) = %v, %v; want %v", value, got, err, want)
+		}
+	}
+	if _, err := ParseTimeBound("yesterday", now); err == nil {
+		t.Error("expected an error for an unknown time")
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"api@*", "api@docker", true},
+		{"*@file", "api@docker", false},
+		{"/api/*/items", "/api/v1/v2/items", true},
+		{"h?st", "host", true},
+		{"h?st", "hoost", false},
+		{"*", "", true},
+		{"", "x", false},
+	}
+	for _, tt := range tests {
+		if got := MatchGlob(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("MatchGlob(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	entry := &TraefikLog{
+		StartUTC:         time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		ClientAddr:       "10.1.2.3:5555",
+		RequestMethod:    "POST",
+		RequestHost:      "Example.com",
+		RequestPath:      "/api/users/42",
+		DownstreamStatus: 502,
+		Duration:         int64(250 * time.Millisecond),
+		RouterName:       "api@docker",
+		ServiceName:      "users@docker",
+		UserAgentClass:   "crawler",
+		UserAgentName:    "Googlebot",
+		UserAgentOS:      "Android",
+		UserAgentDevice:  "mobile",
+	}
+	client, _ := ParseClientNetwork("10.1.0.0/16")
+	other, _ := ParseClientNetwork("10.2.0.1")
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{"zero", Filter{}, true},
+		{"since", Filter{Since: entry.StartUTC}, true},
+		{"until is exclusive", Filter{Until: entry.StartUTC}, false},
+		{"status class", Filter{Statuses: []StatusRange{{400, 499}, {500, 599}}}, true},
+		{"status miss", Filter{Statuses: []StatusRange{{200, 299}}}, false},
+		{"method", Filter{Methods: []string{"get", "post"}}, true},
+		{"router glob", Filter{Routers: []string{"*@file", "api@*"}}, true},
+		{"service miss", Filter{Services: []string{"orders@*"}}, false},
+		{"host case", Filter{Hosts: []string{"example.COM"}}, true},
+		{"path prefix", Filter{Paths: []string{"/api/users"}}, true},
+		{"path glob", Filter{Paths: []string{"/api/*/42"}}, true},
+		{"path miss", Filter{Paths: []string{"/admin"}}, false},
+		{"client from addr", Filter{Clients: []*net.IPNet{client}}, true},
+		{"client miss", Filter{Clients: []*net.IPNet{other}}, false},
+		{"min duration", Filter{MinDuration: 200 * time.Millisecond}, true},
+		{"max duration", Filter{MaxDuration: 200 * time.Millisecond}, false},
+		{"user agent class", Filter{UserAgentClasses: []string{"human", "Crawler"}}, true},
+		{"user agent class miss", Filter{UserAgentClasses: []string{"human"}}, false},
+		{"user agent name glob", Filter{UserAgentNames: []string{"google*"}}, true},
+		{"os and device", Filter{OSes: []string{"android"}, Devices: []string{"mobile", "tablet"}}, true},
+		{"browser miss", Filter{Browsers: []string{"Chrome"}}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.filter.Match(entry); got != tt.want {
+			t.Errorf("%s: Match = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if (Filter{Since: entry.StartUTC}).Match(&TraefikLog{}) {
+		t.Error("entries without a timestamp should not match a time range")
+	}
+}
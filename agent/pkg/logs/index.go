@@ -0,0 +1,322 @@
+package logs
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logger"
+)
+
+// DefaultCheckpointInterval is the default number of uncompressed bytes
+// between two checkpoints in an archive index.
+const DefaultCheckpointInterval int64 = 8 * 1024 * 1024
+
+// archiveIndexVersion tells apart the indexes written by earlier versions,
+// which are rebuilt
+const archiveIndexVersion = 2
+
+// Checkpoint maps an uncompressed offset in an archive to the compressed
+// offset of the gzip member that starts there.
+type Checkpoint struct {
+	Offset int64 `json:"offset"`
+	Member int64 `json:"member"`
+}
+
+// ArchiveIndex describes the checkpoints built for one compressed archive.
+// The members of a gzip archive decode on their own, so a read can start at
+// any checkpoint without decompressing the archive from the beginning.
+// Archives of a single member, as gzip writes them by default, and zstd or
+// bzip2 archives have one checkpoint at the start; the index then only
+// spares measuring their length. Nothing but the index is stored.
+type ArchiveIndex struct {
+	Version          int          `json:"version"`
+	Source           string       `json:"source"`
+	SourceSize       int64        `json:"source_size"`
+	SourceModTime    int64        `json:"source_mod_time"`
+	UncompressedSize int64        `json:"uncompressed_size"`
+	Interval         int64        `json:"interval"`
+	Checkpoints      []Checkpoint `json:"checkpoints"`
+}
+
+var (
+	indexDir      string
+	indexInterval = DefaultCheckpointInterval
+	indexMutex    sync.Mutex
+
+	// indexBuilds holds the index builds in progress by index path, so
+	// requests for one archive wait for a single build without holding up
+	// other archives
+	indexBuilds = map[string]*indexBuild{}
+)
+
+// indexBuild is an index build shared by the requests that wait for it
+type indexBuild struct {
+	done chan struct{}
+	idx  *ArchiveIndex
+	err  error
+}
+
+// ConfigureCompressedIndex enables on-disk checkpoint indexes for compressed
+// archives. An empty directory disables indexing.
+func ConfigureCompressedIndex(dir string, interval int64) {
+	indexMutex.Lock()
+	defer indexMutex.Unlock()
+
+	indexDir = dir
+	if interval > 0 {
+		indexInterval = interval
+	} else {
+		indexInterval = DefaultCheckpointInterval
+	}
+}
+
+// indexPath returns the index file path for an archive
+func indexPath(dir, filePath string) string {
+	abs, err := filepath.Abs(filePath)
+	if err != nil {
+		abs = filePath
+	}
+	sum := sha1.Sum([]byte(abs))
+	return filepath.Join(dir, hex.EncodeToString(sum[:])+".idx")
+}
+
+// openFromIndex opens an archive at the checkpoint nearest to position. It
+// returns a nil stream when indexing is disabled.
+func openFromIndex(filePath string, position int64) (*compressedStream, error) {
+	indexMutex.Lock()
+	dir, interval := indexDir, indexInterval
+	indexMutex.Unlock()
+
+	if dir == "" {
+		return nil, nil
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	idxPath := indexPath(dir, filePath)
+	idx, err := loadArchiveIndex(idxPath, info)
+	if err != nil || idx == nil {
+		idx, err = buildArchiveIndex(filePath, idxPath, info, interval)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rememberArchiveSize(filePath, idx.UncompressedSize)
+
+	if len(idx.Checkpoints) == 0 {
+		// Empty archive
+		return &compressedStream{Reader: strings.NewReader(""), start: 0}, nil
+	}
+
+	i := sort.Search(len(idx.Checkpoints), func(i int) bool {
+		return idx.Checkpoints[i].Offset > position
+	}) - 1
+	if i < 0 {
+		i = 0
+	}
+	cp := idx.Checkpoints[i]
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.Seek(cp.Member, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	// A multistream reader continues into the members that follow
+	dec, err := newDecompressor(bufio.NewReaderSize(file, 64*1024), CompressionOf(filePath))
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &compressedStream{
+		Reader:  dec,
+		start:   cp.Offset,
+		closers: []io.Closer{file, dec},
+	}, nil
+}
+
+// loadArchiveIndex reads an index file and validates it against the archive.
+// It returns nil when the index is missing, stale or of an earlier version.
+func loadArchiveIndex(idxPath string, info os.FileInfo) (*ArchiveIndex, error) {
+	data, err := os.ReadFile(idxPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var idx ArchiveIndex
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return nil, err
+	}
+
+	if idx.Version != archiveIndexVersion {
+		return nil, nil
+	}
+	if idx.SourceSize != info.Size() || idx.SourceModTime != info.ModTime().UnixNano() {
+		return nil, nil
+	}
+
+	return &idx, nil
+}
+
+// buildArchiveIndex decompresses an archive once, recording its checkpoints
+// and uncompressed size. Concurrent calls for the same archive share one
+// build.
+func buildArchiveIndex(filePath, idxPath string, info os.FileInfo, interval int64) (*ArchiveIndex, error) {
+	indexMutex.Lock()
+	if b, ok := indexBuilds[idxPath]; ok {
+		indexMutex.Unlock()
+		<-b.done
+		return b.idx, b.err
+	}
+	b := &indexBuild{done: make(chan struct{})}
+	indexBuilds[idxPath] = b
+	indexMutex.Unlock()
+
+	b.idx, b.err = writeArchiveIndex(filePath, idxPath, info, interval)
+
+	indexMutex.Lock()
+	delete(indexBuilds, idxPath)
+	indexMutex.Unlock()
+	close(b.done)
+	return b.idx, b.err
+}
+
+// writeArchiveIndex builds the index of an archive and writes it to idxPath
+func writeArchiveIndex(filePath, idxPath string, info os.FileInfo, interval int64) (*ArchiveIndex, error) {
+	// Another request may have built the index since it was looked up
+	if idx, err := loadArchiveIndex(idxPath, info); err == nil && idx != nil {
+		return idx, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(idxPath), 0755); err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	idx := &ArchiveIndex{
+		Version:       archiveIndexVersion,
+		Source:        filePath,
+		SourceSize:    info.Size(),
+		SourceModTime: info.ModTime().UnixNano(),
+		Interval:      interval,
+	}
+
+	format := CompressionOf(filePath)
+	if format == CompressionGzip {
+		err = indexGzipMembers(file, idx)
+	} else {
+		err = indexStream(file, format, idx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpIdx := idxPath + ".tmp"
+	if err := os.WriteFile(tmpIdx, data, 0644); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmpIdx, idxPath); err != nil {
+		os.Remove(tmpIdx)
+		return nil, err
+	}
+
+	// Earlier versions kept a recompressed copy of the archive next to
+	// its index
+	os.Remove(strings.TrimSuffix(idxPath, ".idx") + ".seg")
+
+	logger.Log.Printf("Indexed %s: %d checkpoint(s), %d uncompressed bytes", filePath, len(idx.Checkpoints), idx.UncompressedSize)
+	return idx, nil
+}
+
+// indexGzipMembers records a checkpoint at the start of each gzip member
+// that begins at least an interval after the previous checkpoint
+func indexGzipMembers(r io.Reader, idx *ArchiveIndex) error {
+	// gzip reads a ByteReader without buffering ahead, so the bytes counted
+	// end exactly where a member does
+	counter := &countingReader{r: bufio.NewReaderSize(r, 64*1024)}
+	gz, err := gzip.NewReader(counter)
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	var member int64
+	for {
+		gz.Multistream(false)
+		if n := len(idx.Checkpoints); n == 0 || idx.UncompressedSize-idx.Checkpoints[n-1].Offset >= idx.Interval {
+			idx.Checkpoints = append(idx.Checkpoints, Checkpoint{
+				Offset: idx.UncompressedSize,
+				Member: member,
+			})
+		}
+
+		n, err := io.Copy(io.Discard, gz)
+		idx.UncompressedSize += n
+		if err != nil {
+			return err
+		}
+
+		member = counter.n
+		if err := gz.Reset(counter); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// indexStream measures an archive that can only be read from the start
+func indexStream(r io.Reader, format string, idx *ArchiveIndex) error {
+	dec, err := newDecompressor(bufio.NewReaderSize(r, 64*1024), format)
+	if err != nil {
+		return err
+	}
+	defer dec.Close()
+
+	n, err := io.Copy(io.Discard, dec)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		idx.Checkpoints = []Checkpoint{{Offset: 0, Member: 0}}
+	}
+	idx.UncompressedSize = n
+	return nil
+}
+
+func logIndexError(filePath string, err error) {
+	logger.Log.Printf("Checkpoint index unavailable for %s: %v", filePath, err)
+}
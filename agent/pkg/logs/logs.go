@@ -2,30 +2,17 @@ package logs
 
 import (
 	"bufio"
-	"compress/gzip"
 	"context"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
-	"sort"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logger"
 )
 
-var (
-	scanBufPool = sync.Pool{
-		New: func() any {
-			// 64KB buffer per scanner; cap defined in scanner below
-			buf := make([]byte, 64*1024)
-			return &buf
-		},
-	}
-)
-
 func GetLogs(path string, positions []Position, isErrorLog bool, includeCompressed bool) (LogResult, error) {
 	fileInfo, err := os.Stat(path)
 	if err != nil {
@@ -55,8 +42,8 @@ func GetLog(filePath string, position int64) (LogResult, error) {
 	var result LogResult
 	var err error
 
-	if strings.HasSuffix(filePath, ".gz") {
-		result, err = readCompressedLogFile(filePath)
+	if IsCompressed(filePath) {
+		result, err = readCompressedLogFile(filePath, position, DefaultCompressedPageLines)
 		if err != nil {
 			return LogResult{}, fmt.Errorf("error reading compressed log file: %w", err)
 		}
@@ -92,41 +79,16 @@ func readLogFile(filePath string, position int64) (LogResult, error) {
 		}, nil
 	}
 
-	file, err := os.Open(filePath)
-	if err != nil {
-		return LogResult{}, err
-	}
-	defer file.Close()
-
-	_, err = file.Seek(position, 0)
-	if err != nil {
-		return LogResult{}, err
-	}
-
 	// PERFORMANCE FIX: Pre-allocate slice with estimated capacity
 	logs := make([]string, 0, 1000)
-	scanner := bufio.NewScanner(file)
-	bufPtr := scanBufPool.Get().(*[]byte)
-	defer scanBufPool.Put(bufPtr)
-	scanner.Buffer(*bufPtr, 1024*1024) // Handle larger lines
-
-	for scanner.Scan() {
-		line := scanner.Text()
-		if line != "" {
-			logs = append(logs, line)
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
+	currentPos, err := scanLogFile(filePath, position, 0, func(line string) error {
+		logs = append(logs, line)
+		return nil
+	})
+	if err != nil {
 		return LogResult{}, err
 	}
 
-	// Get current position after reading
-	currentPos, _ := file.Seek(0, io.SeekCurrent)
-	if currentPos < fileSize {
-		currentPos = fileSize
-	}
-
 	return LogResult{
 		Logs:      logs,
 		Positions: []Position{{Position: currentPos}},
@@ -134,28 +96,41 @@ func readLogFile(filePath string, position int64) (LogResult, error) {
 }
 
 // StreamFromPosition reads new lines from a file starting at position and emits in batches.
-// It stops on context cancellation or EOF without new data and returns the latest position.
+// It stops on context cancellation or EOF without new data and returns the offset just past
+// the last line it consumed, so a batch cut short by batchLines or maxBytes resumes where it
+// stopped. A line not yet terminated by a newline is left to be read once complete.
 func StreamFromPosition(ctx context.Context, filePath string, position int64, batchLines int, maxBytes int) (lines []string, nextPos int64, err error) {
-	fileInfo, err := os.Stat(filePath)
+	f, err := os.Open(filePath)
 	if err != nil {
 		return nil, position, err
 	}
+	defer f.Close()
 
-	// No new data
-	if position >= fileInfo.Size() {
-		return []string{}, fileInfo.Size(), nil
+	return StreamFromFile(ctx, f, position, batchLines, maxBytes)
+}
+
+// StreamFromFile is StreamFromPosition for a file that is already open. An
+// open file can still be read once it has been renamed or removed, which lets
+// a follower finish a file that was rotated away.
+func StreamFromFile(ctx context.Context, f *os.File, position int64, batchLines int, maxBytes int) (lines []string, nextPos int64, err error) {
+	// A file not read before, reported at -1, is read from its beginning
+	if position < 0 {
+		position = 0
 	}
 
-	f, err := os.Open(filePath)
+	fileInfo, err := f.Stat()
 	if err != nil {
 		return nil, position, err
 	}
-	defer f.Close()
 
-	if position > 0 {
-		if _, err := f.Seek(position, io.SeekStart); err != nil {
-			return nil, position, err
-		}
+	// No new data
+	if position >= fileInfo.Size() {
+		return []string{}, fileInfo.Size(), nil
+	}
+
+	// The file may have been read before, so always seek
+	if _, err := f.Seek(position, io.SeekStart); err != nil {
+		return nil, position, err
 	}
 
 	reader := bufio.NewReaderSize(f, 64*1024)
@@ -168,37 +143,38 @@ func StreamFromPosition(ctx context.Context, filePath string, position int64, ba
 		maxBytes = 512 * 1024
 	}
 
+	// The reader buffers ahead of the lines returned, so the offset is
+	// counted from the lines themselves rather than taken from the file
+	offset := position
 	for len(lines) < batchLines && bytesUsed < maxBytes {
 		select {
 		case <-ctx.Done():
-			return lines, position, ctx.Err()
+			return lines, offset, ctx.Err()
 		default:
 		}
 
 		line, err := reader.ReadString('\n')
 		if err != nil {
 			if err == io.EOF {
-				position, _ = f.Seek(0, io.SeekCurrent)
-				return lines, position, nil
+				return lines, offset, nil
 			}
-			return lines, position, err
+			return lines, offset, err
 		}
 
 		trimmed := strings.TrimRight(line, "\r\n")
 		if trimmed != "" {
 			entrySize := len(trimmed) + 1 // include newline
-			if bytesUsed+entrySize > maxBytes {
-				// stop and return what we have; caller may re-read from current position
-				position, _ = f.Seek(0, io.SeekCurrent)
-				return lines, position, nil
+			if bytesUsed+entrySize > maxBytes && len(lines) > 0 {
+				// stop and return what we have; the line is read again from offset
+				return lines, offset, nil
 			}
 			lines = append(lines, trimmed)
 			bytesUsed += entrySize
 		}
+		offset += int64(len(line))
 	}
 
-	position, _ = f.Seek(0, io.SeekCurrent)
-	return lines, position, nil
+	return lines, offset, nil
 }
 
 // tailLogFile reads the last N lines from a file
@@ -221,6 +197,8 @@ func tailLogFile(filePath string, numLines int) (LogResult, error) {
 	logs := make([]string, 0, numLines)
 	var offset int64 = 0
 	bufferSize := int64(8192)
+	// partial holds the start of the line cut by the previous chunk boundary
+	var partial string
 
 	for offset < fileSize && len(logs) < numLines {
 		// Calculate read position
@@ -242,12 +220,18 @@ func tailLogFile(filePath string, numLines int) (LogResult, error) {
 			break
 		}
 
-		// Split into lines (reading backwards)
-		lines := strings.Split(string(buffer), "\n")
+		// Split into lines (reading backwards). The first line may continue in
+		// the next chunk back, so it is held until that chunk is read.
+		lines := strings.Split(string(buffer)+partial, "\n")
+		first := 0
+		if startPos > 0 {
+			partial = lines[0]
+			first = 1
+		}
 
 		// PERFORMANCE FIX: Collect lines in reverse order, then reverse once at the end
 		// instead of prepending each line (which causes O(n²) allocations)
-		for i := len(lines) - 1; i >= 0; i-- {
+		for i := len(lines) - 1; i >= first; i-- {
 			if lines[i] != "" {
 				logs = append(logs, lines[i])
 				if len(logs) >= numLines {
@@ -378,106 +362,19 @@ func readErrorLogDirectly(filePath string, position int64) (LogResult, error) {
 	}, nil
 }
 
-func readCompressedLogFile(filePath string) (LogResult, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return LogResult{}, err
-	}
-	defer file.Close()
-
-	gzReader, err := gzip.NewReader(file)
-	if err != nil {
-		return LogResult{}, err
+// GetDirectoryLogs reads the log files of a directory. Files whose name
+// contains "error" are treated as error logs and everything else as access logs.
+func GetDirectoryLogs(dirPath string, positions []Position, isErrorLog bool, includeCompressed bool) (LogResult, error) {
+	src := Source{Path: dirPath, Exclude: "*error*"}
+	if isErrorLog {
+		src = Source{Path: dirPath, Include: "*error*"}
 	}
-	defer gzReader.Close()
 
-	content, err := io.ReadAll(gzReader)
+	files, err := src.Files(includeCompressed)
 	if err != nil {
 		return LogResult{}, err
 	}
-
-	var logs []string
-	for _, line := range strings.Split(string(content), "\n") {
-		if strings.TrimSpace(line) != "" {
-			logs = append(logs, line)
-		}
-	}
-
-	return LogResult{
-		Logs:      logs,
-		Positions: []Position{{Position: 0}},
-	}, nil
-}
-
-func GetDirectoryLogs(dirPath string, positions []Position, isErrorLog bool, includeCompressed bool) (LogResult, error) {
-	entries, err := os.ReadDir(dirPath)
-	if err != nil {
-		return LogResult{}, fmt.Errorf("failed to read directory: %w", err)
-	}
-
-	var logFiles []string
-	for _, entry := range entries {
-		fileName := entry.Name()
-		isLogFile := strings.HasSuffix(fileName, ".log")
-		isGzipFile := strings.HasSuffix(fileName, ".gz")
-
-		if (isLogFile || (isGzipFile && includeCompressed)) &&
-			(isErrorLog && strings.Contains(fileName, "error") || !isErrorLog && !strings.Contains(fileName, "error")) {
-			logFiles = append(logFiles, fileName)
-		}
-	}
-
-	// PERFORMANCE FIX: Use sort.Strings (O(n log n)) instead of bubble sort (O(n²))
-	sort.Strings(logFiles)
-
-	if len(logFiles) == 0 {
-		return LogResult{Logs: []string{}, Positions: []Position{}}, nil
-	}
-
-	posMap := make(map[string]int64)
-	for _, pos := range positions {
-		if pos.Filename != "" {
-			posMap[pos.Filename] = pos.Position
-		}
-	}
-
-	// PERFORMANCE FIX: Pre-allocate slices with estimated capacity
-	allLogs := make([]string, 0, 1000)
-	newPositions := make([]Position, 0, len(logFiles))
-
-	// If no positions provided, read last file with tail mode
-	if len(positions) == 0 && len(logFiles) > 0 {
-		lastFile := logFiles[len(logFiles)-1]
-		fullPath := filepath.Join(dirPath, lastFile)
-		result, err := tailLogFile(fullPath, 1000)
-		if err == nil {
-			return result, nil
-		}
-	}
-
-	for _, fileName := range logFiles {
-		fullPath := filepath.Join(dirPath, fileName)
-		position := posMap[fileName]
-
-		result, err := GetLog(fullPath, position)
-		if err != nil {
-			logger.Log.Printf("Error reading log file %s: %v", fileName, err)
-			continue
-		}
-
-		allLogs = append(allLogs, result.Logs...)
-
-		if len(result.Positions) > 0 {
-			newPos := result.Positions[0]
-			newPos.Filename = fileName
-			newPositions = append(newPositions, newPos)
-		}
-	}
-
-	return LogResult{
-		Logs:      allLogs,
-		Positions: newPositions,
-	}, nil
+	return readLogFiles(dirPath, files, positions)
 }
 
 // GetLogSizes analyzes log files and returns their sizes
@@ -525,7 +422,7 @@ func GetLogSizes(path string) (*LogSizesResult, error) {
 			if extension == ".log" {
 				summary.LogFilesSize += fileSize
 				summary.LogFilesCount++
-			} else if extension == ".gz" {
+			} else if IsCompressed(fileName) {
 				summary.CompressedFilesSize += fileSize
 				summary.CompressedFilesCount++
 			}
@@ -548,7 +445,7 @@ func GetLogSizes(path string) (*LogSizesResult, error) {
 		if extension == ".log" {
 			summary.LogFilesSize = fileSize
 			summary.LogFilesCount = 1
-		} else if extension == ".gz" {
+		} else if IsCompressed(fileName) {
 			summary.CompressedFilesSize = fileSize
 			summary.CompressedFilesCount = 1
 		}
@@ -2,8 +2,10 @@ package logs
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -69,6 +71,67 @@ func TestStreamFromPositionMaxBytes(t *testing.T) {
 	}
 }
 
+func TestStreamFromPositionResumesAfterBatch(t *testing.T) {
+	lines := make([]string, 2000)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %04d", i)
+	}
+	fp, cleanup := writeTempLog(t, lines)
+	defer cleanup()
+
+	ctx := context.Background()
+	var got []string
+	var pos int64
+	for {
+		batch, next, err := StreamFromPosition(ctx, fp, pos, 400, 512*1024)
+		if err != nil {
+			t.Fatalf("stream error: %v", err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+		if len(batch) > 400 {
+			t.Fatalf("batch of %d lines, want at most 400", len(batch))
+		}
+		got = append(got, batch...)
+		pos = next
+	}
+	if len(got) != len(lines) {
+		t.Fatalf("expected %d lines, got %d", len(lines), len(got))
+	}
+	for i := range got {
+		if got[i] != lines[i] {
+			t.Fatalf("line %d = %q, want %q", i, got[i], lines[i])
+		}
+	}
+}
+
+func TestStreamFromPositionWaitsForPartialLine(t *testing.T) {
+	fp, cleanup := writeTempLog(t, []string{"a"})
+	defer cleanup()
+
+	f, _ := os.OpenFile(fp, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString("par")
+	ctx := context.Background()
+	lines, pos, err := StreamFromPosition(ctx, fp, 0, 10, 1024)
+	if err != nil {
+		t.Fatalf("stream error: %v", err)
+	}
+	if len(lines) != 1 || pos != 2 {
+		t.Fatalf("expected [a] up to 2, got %v up to %d", lines, pos)
+	}
+
+	f.WriteString("tial\n")
+	f.Close()
+	lines, _, err = StreamFromPosition(ctx, fp, pos, 10, 1024)
+	if err != nil {
+		t.Fatalf("stream error 2: %v", err)
+	}
+	if len(lines) != 1 || lines[0] != "partial" {
+		t.Fatalf("expected [partial], got %v", lines)
+	}
+}
+
 func BenchmarkParseTraefikLogs(b *testing.B) {
 	lines := []string{
 		`{"ClientAddr":"1.1.1.1:1234","RequestMethod":"GET","RequestPath":"/","RequestHost":"example.com","StartUTC":"2024-01-01T00:00:00Z","DownstreamStatus":200,"RequestCount":1}`,
@@ -98,3 +161,25 @@ func BenchmarkStreamFromPosition(b *testing.B) {
 		}
 	}
 }
+
+func TestTailLogFileKeepsLinesAcrossChunks(t *testing.T) {
+	lines := make([]string, 300)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %03d %s", i, strings.Repeat("x", 90))
+	}
+	fp, cleanup := writeTempLog(t, lines)
+	defer cleanup()
+
+	result, err := tailLogFile(fp, 250)
+	if err != nil {
+		t.Fatalf("tail error: %v", err)
+	}
+	if len(result.Logs) != 250 {
+		t.Fatalf("expected 250 lines, got %d", len(result.Logs))
+	}
+	for i, line := range result.Logs {
+		if line != lines[50+i] {
+			t.Fatalf("line %d was split: %q", i, line)
+		}
+	}
+}
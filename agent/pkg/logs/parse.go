@@ -40,6 +40,19 @@ type TraefikLog struct {
 	EntryPointName      string    `json:"entryPointName"`
 	RequestReferer      string    `json:"RequestReferer"`
 	RequestUserAgent    string    `json:"RequestUserAgent"`
+
+	// Derived by the agent's enrichers rather than read from the log
+	ClientCountryCode   string    `json:"ClientCountryCode,omitempty"`
+	ClientCountry       string    `json:"ClientCountry,omitempty"`
+	ClientCity          string    `json:"ClientCity,omitempty"`
+	ClientASN           int       `json:"ClientASN,omitempty"`
+	ClientASOrg         string    `json:"ClientASOrg,omitempty"`
+	ClientPrivate       bool      `json:"ClientPrivate,omitempty"`
+	UserAgentClass      string    `json:"UserAgentClass,omitempty"`
+	UserAgentName       string    `json:"UserAgentName,omitempty"`
+	UserAgentBrowser    string    `json:"UserAgentBrowser,omitempty"`
+	UserAgentOS         string    `json:"UserAgentOS,omitempty"`
+	UserAgentDevice     string    `json:"UserAgentDevice,omitempty"`
 }
 
 // OPTIMIZATION: Compile regex once at package initialization
@@ -66,13 +79,26 @@ func ParseTraefikLog(logLine string) (*TraefikLog, error) {
 	return parseCLFLog(logLine)
 }
 
+// jsonLog also reads the headers Traefik logs when header fields are kept
+type jsonLog struct {
+	TraefikLog
+	HeaderUserAgent string `json:"request_User-Agent"`
+	HeaderReferer   string `json:"request_Referer"`
+}
+
 func parseJSONLog(logLine string) (*TraefikLog, error) {
-	var log TraefikLog
+	var log jsonLog
 	err := json.Unmarshal([]byte(logLine), &log)
 	if err != nil {
 		return nil, err
 	}
-	return &log, nil
+	if log.RequestUserAgent == "" {
+		log.RequestUserAgent = log.HeaderUserAgent
+	}
+	if log.RequestReferer == "" {
+		log.RequestReferer = log.HeaderReferer
+	}
+	return &log.TraefikLog, nil
 }
 
 func parseCLFLog(logLine string) (*TraefikLog, error) {
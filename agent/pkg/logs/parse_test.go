@@ -0,0 +1,18 @@
+package logs
+
+import "testing"
+
+func TestParseJSONHeaderFields(t *testing.T) {
+	entry, err := ParseTraefikLog(`{"ClientHost":"10.0.0.1","request_User-Agent":"curl/8.5.0","request_Referer":"https://example.com/"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.RequestUserAgent != "curl/8.5.0" || entry.RequestReferer != "https://example.com/" {
+		t.Errorf("header fields not read: %+v", entry)
+	}
+
+	entry, _ = ParseTraefikLog(`{"RequestUserAgent":"Wget/1.21","request_User-Agent":"curl/8.5.0"}`)
+	if entry.RequestUserAgent != "Wget/1.21" {
+		t.Errorf("RequestUserAgent = %q, want the field over the header", entry.RequestUserAgent)
+	}
+}
@@ -0,0 +1,160 @@
+package logs
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LineFunc receives log lines as they are read. Returning ErrSkipLine leaves
+// the line out of the line limit; any other error stops the read and is
+// passed back to the caller.
+type LineFunc func(line string) error
+
+// ErrSkipLine is returned by a LineFunc for lines it filtered out
+var ErrSkipLine = errors.New("skip line")
+
+// ScanLog passes the lines of a single file, starting at position, to fn and
+// returns the position just past the last line passed. At most maxLines lines
+// are read (0 for no limit; archives are always read one page at a time). A
+// position of -1 passes the last maxLines lines of the file instead.
+func ScanLog(filePath string, position int64, maxLines int, fn LineFunc) (Position, error) {
+	if _, err := os.Stat(filePath); err != nil {
+		return Position{}, fmt.Errorf("file not found: %s", filePath)
+	}
+
+	if position < 0 {
+		var result LogResult
+		var err error
+		if IsCompressed(filePath) {
+			result, err = readCompressedLogFile(filePath, -1, maxLines)
+		} else {
+			if maxLines <= 0 {
+				maxLines = 1000
+			}
+			result, err = tailLogFile(filePath, maxLines)
+		}
+		if err != nil {
+			return Position{}, err
+		}
+		for _, line := range result.Logs {
+			if err := fn(line); err != nil && !errors.Is(err, ErrSkipLine) {
+				return result.Positions[0], err
+			}
+		}
+		return result.Positions[0], nil
+	}
+
+	var next int64
+	var err error
+	if IsCompressed(filePath) {
+		if maxLines <= 0 {
+			maxLines = DefaultCompressedPageLines
+		}
+		next, err = scanCompressedLogFile(filePath, position, maxLines, fn)
+	} else {
+		next, err = scanLogFile(filePath, position, maxLines, fn)
+	}
+	return Position{Position: next}, err
+}
+
+// scanLogFile passes up to maxLines (0 for all) lines of an uncompressed file
+// to fn, starting at position, and returns the offset after the last line read
+func scanLogFile(filePath string, position int64, maxLines int, fn LineFunc) (int64, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return position, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return position, err
+	}
+
+	// If position >= fileSize, no new logs
+	if position >= info.Size() {
+		return info.Size(), nil
+	}
+
+	if position > 0 {
+		if _, err := file.Seek(position, io.SeekStart); err != nil {
+			return position, err
+		}
+	}
+
+	reader := bufio.NewReaderSize(file, 64*1024)
+	offset := position
+	for emitted := 0; maxLines <= 0 || emitted < maxLines; {
+		line, err := reader.ReadString('\n')
+		offset += int64(len(line))
+
+		if trimmed := strings.TrimRight(line, "\r\n"); trimmed != "" {
+			switch err := fn(trimmed); {
+			case err == nil:
+				emitted++
+			case !errors.Is(err, ErrSkipLine):
+				// Leave the rejected line to be read again
+				return offset - int64(len(line)), err
+			}
+		}
+
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return offset, err
+		}
+	}
+
+	return offset, nil
+}
+
+// ScanSourceHistory passes every line of a source, archives included, to fn,
+// oldest file first. Files last modified before since cannot hold newer
+// entries and are skipped. Positions are not tracked.
+func ScanSourceHistory(src Source, since time.Time, fn LineFunc) error {
+	files, err := src.Files(true)
+	if err != nil {
+		return err
+	}
+
+	type historyFile struct {
+		path    string
+		modTime time.Time
+	}
+	history := make([]historyFile, 0, len(files))
+	for _, name := range files {
+		path := filepath.Join(src.Root(), name)
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if !since.IsZero() && info.ModTime().Before(since) {
+			continue
+		}
+		history = append(history, historyFile{path: path, modTime: info.ModTime()})
+	}
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].modTime.Before(history[j].modTime)
+	})
+
+	for _, file := range history {
+		if IsCompressed(file.path) {
+			_, err = scanCompressedLogFile(file.path, 0, math.MaxInt, fn)
+		} else {
+			_, err = scanLogFile(file.path, 0, 0, fn)
+		}
+		if err != nil && !errors.Is(err, ErrSkipLine) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,356 @@
+package logs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logger"
+)
+
+// Source types
+const (
+	SourceTypeAccess = "access"
+	SourceTypeError  = "error"
+)
+
+// Source describes a named set of log files. Path may be a single file, a
+// directory or a glob pattern such as /var/log/traefik/*/access*.log.
+// Include and Exclude are optional glob patterns matched against file base
+// names to narrow down the files of a directory or pattern.
+type Source struct {
+	Name    string            `json:"name"`
+	Path    string            `json:"path"`
+	Type    string            `json:"type"`
+	Format  string            `json:"format,omitempty"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Include string            `json:"include,omitempty"`
+	Exclude string            `json:"exclude,omitempty"`
+}
+
+// SourceRange locates the lines of one source inside a merged LogResult
+type SourceRange struct {
+	Name   string            `json:"name"`
+	Type   string            `json:"type"`
+	Format string            `json:"format,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Start  int               `json:"start"`
+	Count  int               `json:"count"`
+}
+
+// Validate checks that a source definition is usable
+func (s Source) Validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("source name cannot be empty")
+	}
+	if s.Path == "" {
+		return fmt.Errorf("source %q: path cannot be empty", s.Name)
+	}
+	if s.Type != SourceTypeAccess && s.Type != SourceTypeError {
+		return fmt.Errorf("source %q: type must be %q or %q", s.Name, SourceTypeAccess, SourceTypeError)
+	}
+	for _, pattern := range []string{s.Path, s.Include, s.Exclude} {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("source %q: invalid pattern %q: %w", s.Name, pattern, err)
+		}
+	}
+	return nil
+}
+
+// IsPattern reports whether the source path contains glob meta characters
+func (s Source) IsPattern() bool {
+	return strings.ContainsAny(s.Path, "*?[")
+}
+
+// Root returns the directory that all files of the source live under. For a
+// glob pattern this is the longest directory prefix without meta characters.
+func (s Source) Root() string {
+	if s.IsPattern() {
+		dir := filepath.Dir(s.Path)
+		for strings.ContainsAny(dir, "*?[") {
+			dir = filepath.Dir(dir)
+		}
+		return filepath.Clean(dir)
+	}
+
+	if info, err := os.Stat(s.Path); err == nil && info.IsDir() {
+		return filepath.Clean(s.Path)
+	}
+	return filepath.Dir(filepath.Clean(s.Path))
+}
+
+// Files resolves the source to the log files it currently matches, as paths
+// relative to Root, sorted by name. Compressed archives are only included
+// when includeCompressed is set.
+func (s Source) Files(includeCompressed bool) ([]string, error) {
+	root := s.Root()
+
+	var candidates []string
+	switch {
+	case s.IsPattern():
+		matches, err := filepath.Glob(s.Path)
+		if err != nil {
+			return nil, err
+		}
+		candidates = matches
+	default:
+		info, err := os.Stat(s.Path)
+		if err != nil {
+			return nil, fmt.Errorf("path error: %w", err)
+		}
+		if !info.IsDir() {
+			return []string{filepath.Base(s.Path)}, nil
+		}
+
+		entries, err := os.ReadDir(s.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read directory: %w", err)
+		}
+		for _, entry := range entries {
+			candidates = append(candidates, filepath.Join(s.Path, entry.Name()))
+		}
+	}
+
+	files := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		info, err := os.Stat(candidate)
+		if err != nil || info.IsDir() {
+			continue
+		}
+
+		name := filepath.Base(candidate)
+		if !s.matchesName(name) {
+			continue
+		}
+		if IsCompressed(name) {
+			if !includeCompressed {
+				continue
+			}
+		} else if !s.IsPattern() && !strings.HasSuffix(name, ".log") {
+			// Directories only serve .log files and archives; patterns
+			// already say exactly which files they want.
+			continue
+		}
+
+		rel, err := filepath.Rel(root, candidate)
+		if err != nil {
+			continue
+		}
+		files = append(files, rel)
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// ActiveFile returns the full path of the file currently being written to,
+// which is the newest uncompressed file of the source.
+func (s Source) ActiveFile() (string, error) {
+	files, err := s.Files(false)
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("source %q has no log files", s.Name)
+	}
+	return filepath.Join(s.Root(), files[len(files)-1]), nil
+}
+
+func (s Source) matchesName(name string) bool {
+	if s.Include != "" {
+		if ok, _ := filepath.Match(s.Include, name); !ok {
+			return false
+		}
+	}
+	if s.Exclude != "" {
+		if ok, _ := filepath.Match(s.Exclude, name); ok {
+			return false
+		}
+	}
+	return true
+}
+
+// GetSourceLogs reads new lines from every file of a source. Positions are
+// keyed by file name relative to the source root. When none of the source's
+// files has a position yet, the newest uncompressed file is tailed instead.
+func GetSourceLogs(src Source, positions []Position, includeCompressed bool) (LogResult, error) {
+	files, err := src.Files(includeCompressed)
+	if err != nil {
+		return LogResult{}, err
+	}
+	return readLogFiles(src.Root(), files, positions)
+}
+
+// readLogFiles reads the given files (relative to root) from their positions
+func readLogFiles(root string, files []string, positions []Position) (LogResult, error) {
+	// PERFORMANCE FIX: Pre-allocate slices with estimated capacity
+	allLogs := make([]string, 0, 1000)
+	newPositions, err := scanLogFiles(root, files, positions, 0, func(line string) error {
+		allLogs = append(allLogs, line)
+		return nil
+	})
+	if err != nil {
+		return LogResult{}, err
+	}
+
+	return LogResult{
+		Logs:      allLogs,
+		Positions: newPositions,
+	}, nil
+}
+
+// ScanSourceLogs is the streaming form of GetSourceLogs. Lines are passed to
+// fn as they are read instead of being collected, and reading stops after
+// maxLines lines (0 for no limit). The returned positions point just past the
+// last line passed to fn, so a limited read never skips lines.
+func ScanSourceLogs(src Source, positions []Position, includeCompressed bool, maxLines int, fn LineFunc) ([]Position, error) {
+	files, err := src.Files(includeCompressed)
+	if err != nil {
+		return nil, err
+	}
+	return scanLogFiles(src.Root(), files, positions, maxLines, fn)
+}
+
+// scanLogFiles passes the lines of the given files (relative to root) to fn,
+// starting from their positions
+func scanLogFiles(root string, files []string, positions []Position, maxLines int, fn LineFunc) ([]Position, error) {
+	if len(files) == 0 {
+		return []Position{}, nil
+	}
+
+	known := make(map[string]bool, len(files))
+	for _, name := range files {
+		known[name] = true
+	}
+
+	posMap := make(map[string]int64)
+	for _, pos := range positions {
+		if pos.Filename != "" && known[pos.Filename] {
+			posMap[pos.Filename] = pos.Position
+		}
+	}
+
+	// If no positions provided, tail the newest uncompressed file of each
+	// directory and start following the other uncompressed files from their
+	// end. Archives are left without a position and paged through on
+	// subsequent requests when they are included.
+	if len(posMap) == 0 {
+		result, err := tailLogFiles(root, files)
+		if err != nil {
+			return nil, err
+		}
+		lines := result.Logs
+		if maxLines > 0 && len(lines) > maxLines {
+			lines = lines[len(lines)-maxLines:]
+		}
+		for _, line := range lines {
+			if err := fn(line); err != nil && !errors.Is(err, ErrSkipLine) {
+				return result.Positions, err
+			}
+		}
+		return result.Positions, nil
+	}
+
+	newPositions := make([]Position, 0, len(files))
+	emitted := 0
+	var fnErr error
+	count := func(line string) error {
+		err := fn(line)
+		switch {
+		case err == nil:
+			emitted++
+		case !errors.Is(err, ErrSkipLine):
+			fnErr = err
+		}
+		return err
+	}
+
+	for _, fileName := range files {
+		position, hasPosition := posMap[fileName]
+
+		remaining := maxLines - emitted
+		if maxLines > 0 && remaining <= 0 {
+			// Out of budget; keep the file where it was
+			if hasPosition {
+				newPositions = append(newPositions, Position{Position: position, Filename: fileName})
+			}
+			continue
+		}
+
+		fullPath := filepath.Join(root, fileName)
+		var next int64
+		var err error
+		if IsCompressed(fileName) {
+			pageLines := DefaultCompressedPageLines
+			if maxLines > 0 && remaining < pageLines {
+				pageLines = remaining
+			}
+			next, err = scanCompressedLogFile(fullPath, position, pageLines, count)
+		} else {
+			limit := 0
+			if maxLines > 0 {
+				limit = remaining
+			}
+			next, err = scanLogFile(fullPath, position, limit, count)
+		}
+		if fnErr != nil {
+			// The consumer gave up, e.g. the client went away
+			return newPositions, fnErr
+		}
+		if err != nil {
+			logger.Log.Printf("Error reading log file %s: %v", fileName, err)
+			continue
+		}
+
+		newPositions = append(newPositions, Position{Position: next, Filename: fileName})
+	}
+
+	return newPositions, nil
+}
+
+// tailLogFiles returns the last lines of the newest uncompressed file in each
+// directory, along with end-of-file positions for every uncompressed file.
+func tailLogFiles(root string, files []string) (LogResult, error) {
+	newest := make(map[string]string)
+	for _, fileName := range files {
+		if !IsCompressed(fileName) {
+			// files are sorted, so the last one seen per directory is the newest
+			newest[filepath.Dir(fileName)] = fileName
+		}
+	}
+
+	allLogs := make([]string, 0, 1000)
+	positions := make([]Position, 0, len(files))
+
+	for _, fileName := range files {
+		if IsCompressed(fileName) {
+			continue
+		}
+		fullPath := filepath.Join(root, fileName)
+
+		if newest[filepath.Dir(fileName)] == fileName {
+			result, err := tailLogFile(fullPath, 1000)
+			if err != nil {
+				logger.Log.Printf("Error reading log file %s: %v", fileName, err)
+				continue
+			}
+			allLogs = append(allLogs, result.Logs...)
+			positions = append(positions, Position{Position: result.Positions[0].Position, Filename: fileName})
+			continue
+		}
+
+		info, err := os.Stat(fullPath)
+		if err != nil {
+			continue
+		}
+		positions = append(positions, Position{Position: info.Size(), Filename: fileName})
+	}
+
+	return LogResult{
+		Logs:      allLogs,
+		Positions: positions,
+	}, nil
+}
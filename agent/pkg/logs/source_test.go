@@ -0,0 +1,75 @@
+package logs
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSourceFilesGlob(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"edge/access.log",
+		"edge/access-2024.log.gz",
+		"edge/traefik.log",
+		"internal/access.log",
+		"internal/notes.txt",
+	} {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte("x\n"), 0644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	src := Source{Name: "all", Path: filepath.Join(dir, "*", "access*"), Type: SourceTypeAccess}
+	if src.Root() != dir {
+		t.Fatalf("expected root %s, got %s", dir, src.Root())
+	}
+
+	files, err := src.Files(false)
+	if err != nil {
+		t.Fatalf("files: %v", err)
+	}
+	want := []string{"edge/access.log", "internal/access.log"}
+	if !reflect.DeepEqual(files, want) {
+		t.Fatalf("expected %v, got %v", want, files)
+	}
+
+	files, _ = src.Files(true)
+	if len(files) != 3 {
+		t.Fatalf("expected archive to be included, got %v", files)
+	}
+}
+
+func TestSourceLogsTailsEachDirectory(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a/access.log", "b/access.log"} {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(name+"\n"), 0644)
+	}
+
+	src := Source{Name: "multi", Path: filepath.Join(dir, "*", "access.log"), Type: SourceTypeAccess}
+	result, err := GetSourceLogs(src, nil, false)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if len(result.Logs) != 2 || len(result.Positions) != 2 {
+		t.Fatalf("expected one tailed line and position per instance, got %+v", result)
+	}
+
+	// Appending to one instance only yields the new line
+	f, _ := os.OpenFile(filepath.Join(dir, "b/access.log"), os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString("new\n")
+	f.Close()
+
+	result, err = GetSourceLogs(src, result.Positions, false)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !reflect.DeepEqual(result.Logs, []string{"new"}) {
+		t.Fatalf("expected only the new line, got %v", result.Logs)
+	}
+}
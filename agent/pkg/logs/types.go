@@ -4,12 +4,14 @@ package logs
 type Position struct {
 	Position int64  `json:"position"`
 	Filename string `json:"filename,omitempty"`
+	Source   string `json:"source,omitempty"`
 }
 
 // LogResult represents the result of reading logs
 type LogResult struct {
-	Logs      []string   `json:"logs"`
-	Positions []Position `json:"positions"`
+	Logs      []string      `json:"logs"`
+	Positions []Position    `json:"positions"`
+	Sources   []SourceRange `json:"sources,omitempty"`
 }
 
 // LogFileSize represents information about a log file
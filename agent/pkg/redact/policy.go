@@ -0,0 +1,60 @@
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// DefaultScope is the scope of the legacy single token and of requests to an
+// agent without authentication. Its profile also applies to scopes that have
+// none of their own.
+const DefaultScope = "default"
+
+// Config maps token scopes to redaction profiles
+type Config struct {
+	// HashKey keys the pseudonyms of the hash client_ip mode
+	HashKey string `json:"hash_key"`
+	// Profiles are keyed by scope. A scope without a profile falls back to
+	// the default profile; give it an empty profile ({}) to see raw data.
+	Profiles map[string]Rules `json:"profiles"`
+}
+
+// Policy holds the compiled redactor of every scope
+type Policy struct {
+	scopes   map[string]*Redactor
+	fallback *Redactor
+}
+
+// ParseConfig decodes a JSON redaction config
+func ParseConfig(data []byte) (Config, error) {
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse redaction config: %w", err)
+	}
+	return cfg, nil
+}
+
+// NewPolicy compiles the profiles of a config
+func NewPolicy(cfg Config) (*Policy, error) {
+	p := &Policy{scopes: make(map[string]*Redactor, len(cfg.Profiles))}
+	for scope, rules := range cfg.Profiles {
+		r, err := New(rules, []byte(cfg.HashKey))
+		if err != nil {
+			return nil, fmt.Errorf("redaction profile %q: %w", scope, err)
+		}
+		p.scopes[scope] = r
+	}
+	p.fallback = p.scopes[DefaultScope]
+	return p, nil
+}
+
+// For returns the redactor of a scope, or nil when its data is not redacted
+func (p *Policy) For(scope string) *Redactor {
+	if p == nil {
+		return nil
+	}
+	if r, ok := p.scopes[scope]; ok {
+		return r
+	}
+	return p.fallback
+}
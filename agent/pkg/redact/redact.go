@@ -0,0 +1,400 @@
+// Package redact removes personal data from access and error log lines
+// before they leave the agent.
+package redact
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+)
+
+// Client IP modes
+const (
+	IPKeep     = "keep"
+	IPTruncate = "truncate"
+	IPHash     = "hash"
+	IPRemove   = "remove"
+)
+
+// Masked replaces redacted query parameter and header values
+const Masked = "REDACTED"
+
+// Rules describe what one profile redacts. The zero value redacts nothing.
+type Rules struct {
+	// ClientIP is keep (default), truncate, hash or remove. Truncation keeps
+	// the first IPv4Prefix or IPv6Prefix bits; hashing replaces the address
+	// with a keyed pseudonym that is stable for a given key.
+	ClientIP   string `json:"client_ip"`
+	IPv4Prefix int    `json:"ipv4_prefix"`
+	IPv6Prefix int    `json:"ipv6_prefix"`
+
+	// QueryParams are masked in request paths and referers; "*" masks every
+	// parameter
+	QueryParams []string `json:"query_params"`
+	// Headers are masked wherever Traefik logs them (request_, origin_ and
+	// downstream_ fields); User-Agent and Referer also cover the
+	// RequestUserAgent and RequestReferer fields
+	Headers []string `json:"headers"`
+	// Paths rewrite request paths with regular expressions
+	Paths []PathRule `json:"paths"`
+
+	RemoveUsername bool `json:"remove_username"`
+}
+
+// PathRule replaces matches of Pattern in request paths with Replacement,
+// which may refer to groups as $1
+type PathRule struct {
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+}
+
+// IsZero reports whether the rules leave data untouched
+func (r Rules) IsZero() bool {
+	return (r.ClientIP == "" || r.ClientIP == IPKeep) && len(r.QueryParams) == 0 &&
+		len(r.Headers) == 0 && len(r.Paths) == 0 && !r.RemoveUsername
+}
+
+// forwardingHeaders carry client addresses and follow the ClientIP rule
+var forwardingHeaders = map[string]bool{
+	"x-forwarded-for":  true,
+	"x-real-ip":        true,
+	"forwarded":        true,
+	"true-client-ip":   true,
+	"cf-connecting-ip": true,
+}
+
+// headerFields are the agent's names for headers that Traefik also logs
+var headerFields = map[string]string{
+	"user-agent": "RequestUserAgent",
+	"referer":    "RequestReferer",
+}
+
+var (
+	ipv4Text = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
+	ipv6Text = regexp.MustCompile(`[0-9A-Fa-f]{0,4}(?::[0-9A-Fa-f]{0,4}){2,7}`)
+)
+
+// Redactor applies a set of rules. A nil Redactor leaves everything as is.
+type Redactor struct {
+	rules   Rules
+	key     []byte
+	v4Mask  net.IPMask
+	v6Mask  net.IPMask
+	params  map[string]bool
+	allQS   bool
+	headers map[string]bool
+	paths   []*regexp.Regexp
+	textQS  *regexp.Regexp
+}
+
+// New compiles rules. Hashing requires a key so pseudonyms cannot be
+// reversed by hashing candidate addresses.
+func New(rules Rules, key []byte) (*Redactor, error) {
+	if rules.IsZero() {
+		return nil, nil
+	}
+
+	r := &Redactor{
+		rules:   rules,
+		key:     key,
+		params:  make(map[string]bool),
+		headers: make(map[string]bool),
+	}
+
+	switch rules.ClientIP {
+	case "", IPKeep, IPRemove:
+	case IPTruncate:
+		v4, v6 := rules.IPv4Prefix, rules.IPv6Prefix
+		if v4 == 0 {
+			v4 = 24
+		}
+		if v6 == 0 {
+			v6 = 48
+		}
+		if v4 < 0 || v4 > 32 || v6 < 0 || v6 > 128 {
+			return nil, fmt.Errorf("invalid IP truncation prefix /%d or /%d", v4, v6)
+		}
+		r.v4Mask = net.CIDRMask(v4, 32)
+		r.v6Mask = net.CIDRMask(v6, 128)
+	case IPHash:
+		if len(key) == 0 {
+			return nil, fmt.Errorf("client_ip hash requires a hash key")
+		}
+	default:
+		return nil, fmt.Errorf("invalid client_ip mode %q: use keep, truncate, hash or remove", rules.ClientIP)
+	}
+
+	names := make([]string, 0, len(rules.QueryParams))
+	for _, name := range rules.QueryParams {
+		if name == "*" {
+			r.allQS = true
+			continue
+		}
+		r.params[strings.ToLower(name)] = true
+		names = append(names, regexp.QuoteMeta(name))
+	}
+	switch {
+	case r.allQS:
+		r.textQS = regexp.MustCompile(`([?&;][^=&#\s"']+=)[^&#\s"']*`)
+	case len(names) > 0:
+		r.textQS = regexp.MustCompile(`(?i)([?&;](?:` + strings.Join(names, "|") + `)=)[^&#\s"']*`)
+	}
+
+	for _, name := range rules.Headers {
+		r.headers[strings.ToLower(name)] = true
+	}
+
+	for _, rule := range rules.Paths {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path pattern %q: %v", rule.Pattern, err)
+		}
+		r.paths = append(r.paths, re)
+	}
+
+	return r, nil
+}
+
+// IP redacts one client address
+func (r *Redactor) IP(addr string) string {
+	if r == nil || addr == "" {
+		return addr
+	}
+	switch r.rules.ClientIP {
+	case IPRemove:
+		return ""
+	case IPTruncate:
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			return addr
+		}
+		if v4 := ip.To4(); v4 != nil {
+			return v4.Mask(r.v4Mask).String()
+		}
+		return ip.Mask(r.v6Mask).String()
+	case IPHash:
+		mac := hmac.New(sha256.New, r.key)
+		mac.Write([]byte(addr))
+		return "anon-" + hex.EncodeToString(mac.Sum(nil)[:8])
+	}
+	return addr
+}
+
+// hostPort redacts the address of a host:port pair, keeping the port
+func (r *Redactor) hostPort(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return r.IP(addr)
+	}
+	host = r.IP(host)
+	if host == "" {
+		return ""
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// URL masks query parameters and rewrites the path of a request path or URL
+func (r *Redactor) URL(raw string) string {
+	if r == nil || raw == "" {
+		return raw
+	}
+
+	path, query, hasQuery := strings.Cut(raw, "?")
+	for i, re := range r.paths {
+		path = re.ReplaceAllString(path, r.rules.Paths[i].Replacement)
+	}
+	if !hasQuery {
+		return path
+	}
+
+	if r.allQS || len(r.params) > 0 {
+		pairs := strings.Split(query, "&")
+		for i, pair := range pairs {
+			name, _, ok := strings.Cut(pair, "=")
+			if ok && (r.allQS || r.params[strings.ToLower(name)]) {
+				pairs[i] = name + "=" + Masked
+			}
+		}
+		query = strings.Join(pairs, "&")
+	}
+	return path + "?" + query
+}
+
+// Text scrubs free text such as error log lines: addresses follow the
+// ClientIP rule and query parameters and paths are handled as in URL
+func (r *Redactor) Text(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+
+	if r.rules.ClientIP != "" && r.rules.ClientIP != IPKeep {
+		s = ipv4Text.ReplaceAllStringFunc(s, r.textIP)
+		if strings.Contains(s, ":") {
+			s = ipv6Text.ReplaceAllStringFunc(s, r.textIP)
+		}
+	}
+	if r.textQS != nil {
+		s = r.textQS.ReplaceAllString(s, "${1}"+Masked)
+	}
+	for i, re := range r.paths {
+		s = re.ReplaceAllString(s, r.rules.Paths[i].Replacement)
+	}
+	return s
+}
+
+// textIP redacts a candidate address found in text
+func (r *Redactor) textIP(candidate string) string {
+	if net.ParseIP(candidate) == nil {
+		return candidate
+	}
+	if redacted := r.IP(candidate); redacted != "" {
+		return redacted
+	}
+	return "-"
+}
+
+// Entry redacts a parsed access log entry in place
+func (r *Redactor) Entry(entry *logs.TraefikLog) {
+	if r == nil || entry == nil {
+		return
+	}
+
+	if r.rules.ClientIP != "" && r.rules.ClientIP != IPKeep {
+		entry.ClientHost = r.IP(entry.ClientHost)
+		entry.ClientAddr = r.hostPort(entry.ClientAddr)
+		if entry.ClientAddr == "" {
+			entry.ClientPort = ""
+		}
+	}
+	if r.rules.RemoveUsername {
+		entry.ClientUsername = ""
+	}
+
+	entry.RequestPath = r.URL(entry.RequestPath)
+	entry.RequestReferer = r.URL(entry.RequestReferer)
+	if r.headers["user-agent"] {
+		entry.RequestUserAgent = Masked
+	}
+	if r.headers["referer"] {
+		entry.RequestReferer = Masked
+	}
+}
+
+// Line redacts an access log line. JSON lines keep every field they carry,
+// common log format lines are returned as JSON and anything else is treated
+// as text.
+func (r *Redactor) Line(line string) string {
+	if r == nil {
+		return line
+	}
+
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" {
+		return line
+	}
+	if trimmed[0] == '{' {
+		if redacted, ok := r.jsonLine(trimmed); ok {
+			return redacted
+		}
+		return r.Text(line)
+	}
+
+	entry, err := logs.ParseTraefikLog(trimmed)
+	if err != nil || entry == nil {
+		return r.Text(line)
+	}
+	r.Entry(entry)
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return r.Text(line)
+	}
+	return string(data)
+}
+
+func (r *Redactor) jsonLine(line string) (string, bool) {
+	dec := json.NewDecoder(strings.NewReader(line))
+	dec.UseNumber()
+	var fields map[string]interface{}
+	if err := dec.Decode(&fields); err != nil {
+		return "", false
+	}
+
+	ipRule := r.rules.ClientIP != "" && r.rules.ClientIP != IPKeep
+	for key, value := range fields {
+		s, isString := value.(string)
+		if !isString {
+			continue
+		}
+
+		switch key {
+		case "ClientHost":
+			if ipRule {
+				fields[key] = r.IP(s)
+			}
+			continue
+		case "ClientAddr":
+			if ipRule {
+				fields[key] = r.hostPort(s)
+			}
+			continue
+		case "ClientUsername":
+			if r.rules.RemoveUsername {
+				delete(fields, key)
+			}
+			continue
+		case "RequestPath", "RequestReferer":
+			fields[key] = r.URL(s)
+		}
+
+		header := headerName(key)
+		if header == "" {
+			continue
+		}
+		switch {
+		case r.headers[header]:
+			fields[key] = Masked
+		case ipRule && forwardingHeaders[header]:
+			fields[key] = r.Text(s)
+		case header == "referer":
+			fields[key] = r.URL(s)
+		}
+	}
+
+	for header, field := range headerFields {
+		if _, ok := fields[field]; ok && r.headers[header] {
+			fields[field] = Masked
+		}
+	}
+	if ipRule && r.rules.ClientIP == IPRemove {
+		if _, ok := fields["ClientPort"]; ok {
+			delete(fields, "ClientPort")
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(fields); err != nil {
+		return "", false
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), true
+}
+
+// headerName returns the lower-case header name of a Traefik header field
+// such as request_User-Agent, or "" for other fields
+func headerName(key string) string {
+	for _, prefix := range []string{"request_", "origin_", "downstream_"} {
+		if name, ok := strings.CutPrefix(key, prefix); ok {
+			return strings.ToLower(name)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,190 @@
+package redact
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+)
+
+const accessLine = `{"ClientAddr":"203.0.113.77:51234","ClientHost":"203.0.113.77","ClientPort":"51234","ClientUsername":"alice","DownstreamStatus":200,"Duration":1234567,"RequestPath":"/users/42/orders?token=s3cret&page=2","RequestReferer":"https://example.com/?session=abc","request_User-Agent":"curl/8.0","request_Authorization":"Bearer xyz","request_X-Forwarded-For":"198.51.100.9, 10.0.0.1","RequestUserAgent":"curl/8.0","StartUTC":"2024-05-01T12:00:00Z"}`
+
+func mustNew(t *testing.T, rules Rules, key string) *Redactor {
+	t.Helper()
+	r, err := New(rules, []byte(key))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return r
+}
+
+func decode(t *testing.T, line string) map[string]interface{} {
+	t.Helper()
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &fields); err != nil {
+		t.Fatalf("decode %q: %v", line, err)
+	}
+	return fields
+}
+
+func TestIPModes(t *testing.T) {
+	tests := []struct {
+		rules Rules
+		addr  string
+		want  string
+	}{
+		{Rules{ClientIP: IPTruncate}, "203.0.113.77", "203.0.113.0"},
+		{Rules{ClientIP: IPTruncate, IPv4Prefix: 16}, "203.0.113.77", "203.0.0.0"},
+		{Rules{ClientIP: IPTruncate}, "2001:db8:1234:5678::1", "2001:db8:1234::"},
+		{Rules{ClientIP: IPTruncate}, "not-an-ip", "not-an-ip"},
+		{Rules{ClientIP: IPRemove}, "203.0.113.77", ""},
+	}
+	for _, tt := range tests {
+		if got := mustNew(t, tt.rules, "").IP(tt.addr); got != tt.want {
+			t.Errorf("%s IP(%q) = %q, want %q", tt.rules.ClientIP, tt.addr, got, tt.want)
+		}
+	}
+
+	a := mustNew(t, Rules{ClientIP: IPHash}, "key-a")
+	b := mustNew(t, Rules{ClientIP: IPHash}, "key-b")
+	first := a.IP("203.0.113.77")
+	if !strings.HasPrefix(first, "anon-") || first != a.IP("203.0.113.77") {
+		t.Fatalf("expected a stable pseudonym, got %q", first)
+	}
+	if first == a.IP("203.0.113.78") || first == b.IP("203.0.113.77") {
+		t.Fatal("pseudonyms should differ per address and per key")
+	}
+}
+
+func TestNewRejectsBadRules(t *testing.T) {
+	for _, rules := range []Rules{
+		{ClientIP: IPHash},
+		{ClientIP: "scramble"},
+		{ClientIP: IPTruncate, IPv4Prefix: 40},
+		{Paths: []PathRule{{Pattern: "("}}},
+	} {
+		if _, err := New(rules, nil); err == nil {
+			t.Errorf("expected an error for %+v", rules)
+		}
+	}
+	if r, err := New(Rules{ClientIP: IPKeep}, nil); r != nil || err != nil {
+		t.Errorf("rules that keep everything should need no redactor")
+	}
+}
+
+func TestLineJSON(t *testing.T) {
+	r := mustNew(t, Rules{
+		ClientIP:       IPTruncate,
+		QueryParams:    []string{"Token", "session"},
+		Headers:        []string{"authorization", "User-Agent"},
+		Paths:          []PathRule{{Pattern: `/users/\d+`, Replacement: "/users/:id"}},
+		RemoveUsername: true,
+	}, "")
+
+	fields := decode(t, r.Line(accessLine))
+	want := map[string]interface{}{
+		"ClientAddr":              "203.0.113.0:51234",
+		"ClientHost":              "203.0.113.0",
+		"RequestPath":             "/users/:id/orders?token=REDACTED&page=2",
+		"RequestReferer":          "https://example.com/?session=REDACTED",
+		"request_User-Agent":      Masked,
+		"RequestUserAgent":        Masked,
+		"request_Authorization":   Masked,
+		"request_X-Forwarded-For": "198.51.100.0, 10.0.0.0",
+		"DownstreamStatus":        float64(200),
+	}
+	for key, value := range want {
+		if fields[key] != value {
+			t.Errorf("%s = %v, want %v", key, fields[key], value)
+		}
+	}
+	if _, ok := fields["ClientUsername"]; ok {
+		t.Error("ClientUsername should be removed")
+	}
+	if !strings.Contains(r.Line(accessLine), `"Duration":1234567`) {
+		t.Error("numbers should keep their exact form")
+	}
+}
+
+func TestLineAllQueryParams(t *testing.T) {
+	r := mustNew(t, Rules{QueryParams: []string{"*"}}, "")
+	fields := decode(t, r.Line(accessLine))
+	if fields["RequestPath"] != "/users/42/orders?token=REDACTED&page=REDACTED" {
+		t.Fatalf("unexpected path %v", fields["RequestPath"])
+	}
+	if fields["ClientHost"] != "203.0.113.77" {
+		t.Fatal("addresses should be kept without an IP rule")
+	}
+}
+
+func TestLineCommonLogFormat(t *testing.T) {
+	line := `192.0.2.10 - bob [01/May/2024:12:00:00 +0000] "GET /api?key=abc HTTP/1.1" 200 512 "-" "Mozilla/5.0" 7 "web@docker" "http://10.0.0.5:80" 12ms`
+	r := mustNew(t, Rules{ClientIP: IPRemove, QueryParams: []string{"key"}, RemoveUsername: true}, "")
+
+	entry, err := logs.ParseTraefikLog(r.Line(line))
+	if err != nil || entry == nil {
+		t.Fatalf("redacted CLF line should parse: %v", err)
+	}
+	if entry.ClientHost != "" || entry.ClientAddr != "" || entry.ClientUsername != "" || entry.RequestPath != "/api?key=REDACTED" {
+		t.Fatalf("unexpected entry %+v", entry)
+	}
+	if entry.DownstreamStatus != 200 || entry.RouterName != "web@docker" {
+		t.Fatalf("other fields should be kept: %+v", entry)
+	}
+}
+
+func TestText(t *testing.T) {
+	r := mustNew(t, Rules{ClientIP: IPTruncate, QueryParams: []string{"token"}}, "")
+	line := `time="2024-05-01T12:00:00Z" level=error msg="request from 203.0.113.77 and [2001:db8::1] to /x?token=abc failed" ua="Chrome/120.0.0.0"`
+	got := r.Text(line)
+
+	for _, leaked := range []string{"203.0.113.77", "2001:db8::1", "token=abc"} {
+		if strings.Contains(got, leaked) {
+			t.Errorf("%q leaked into %q", leaked, got)
+		}
+	}
+	for _, kept := range []string{"203.0.113.0", "12:00:00Z", "token=REDACTED"} {
+		if !strings.Contains(got, kept) {
+			t.Errorf("%q missing from %q", kept, got)
+		}
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`{
+		"hash_key": "k",
+		"profiles": {
+			"default": {"client_ip": "hash"},
+			"admin": {},
+			"support": {"client_ip": "truncate"}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("ParseConfig: %v", err)
+	}
+	p, err := NewPolicy(cfg)
+	if err != nil {
+		t.Fatalf("NewPolicy: %v", err)
+	}
+
+	if p.For("admin") != nil {
+		t.Error("admin should see raw data")
+	}
+	if got := p.For("support").IP("203.0.113.77"); got != "203.0.113.0" {
+		t.Errorf("support IP = %q", got)
+	}
+	for _, scope := range []string{DefaultScope, "unknown"} {
+		if got := p.For(scope).IP("203.0.113.77"); !strings.HasPrefix(got, "anon-") {
+			t.Errorf("%s IP = %q, want a pseudonym", scope, got)
+		}
+	}
+
+	var none *Policy
+	if none.For("anything") != nil {
+		t.Error("a nil policy should not redact")
+	}
+	if _, err := NewPolicy(Config{Profiles: map[string]Rules{"x": {ClientIP: IPHash}}}); err == nil {
+		t.Error("hashing without a key should fail")
+	}
+}
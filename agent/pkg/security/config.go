@@ -0,0 +1,260 @@
+package security
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+)
+
+// Duration is a time.Duration written as a string such as "5m" in JSON
+type Duration time.Duration
+
+// UnmarshalJSON reads a duration string
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"5m\"")
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil || v < 0 {
+		return fmt.Errorf("invalid duration %q", s)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalJSON writes a duration string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// PathScanRule flags a client that gets 404s for many distinct paths
+type PathScanRule struct {
+	Disabled bool     `json:"disabled"`
+	Window   Duration `json:"window"`
+	MinPaths int      `json:"min_paths"`
+}
+
+// BruteForceRule flags a client that repeatedly fails to log in
+type BruteForceRule struct {
+	Disabled    bool     `json:"disabled"`
+	Window      Duration `json:"window"`
+	MinFailures int      `json:"min_failures"`
+	// Paths are login routes, as patterns in which * matches any text
+	Paths    []string `json:"paths"`
+	Statuses []int    `json:"statuses"`
+}
+
+// ExploitPattern is a regular expression matched against request paths
+type ExploitPattern struct {
+	Name     string `json:"name"`
+	Pattern  string `json:"pattern"`
+	Severity string `json:"severity,omitempty"`
+}
+
+// ExploitRule flags requests for known exploit targets and payloads
+type ExploitRule struct {
+	Disabled bool `json:"disabled"`
+	// Patterns replace the default patterns when set
+	Patterns []ExploitPattern `json:"patterns"`
+}
+
+// RateRule flags a client that sends more requests than allowed in a window
+type RateRule struct {
+	Disabled    bool     `json:"disabled"`
+	Window      Duration `json:"window"`
+	MaxRequests int      `json:"max_requests"`
+}
+
+// Config tunes the detector. Zero fields take their default values.
+type Config struct {
+	PathScan     PathScanRule   `json:"path_scan"`
+	BruteForce   BruteForceRule `json:"brute_force"`
+	ExploitProbe ExploitRule    `json:"exploit_probe"`
+	Rate         RateRule       `json:"rate"`
+
+	// Cooldown is how long an event stays open. Findings of the same type
+	// for the same client are added to the open event instead of raising a
+	// new one.
+	Cooldown Duration `json:"cooldown"`
+	// MaxEvidence caps the log lines kept per event
+	MaxEvidence int `json:"max_evidence"`
+	// MaxEvents caps the events kept in memory
+	MaxEvents int `json:"max_events"`
+	// IgnoreClients are addresses or CIDR blocks that are never flagged
+	IgnoreClients []string `json:"ignore_clients"`
+}
+
+// DefaultExploitPatterns are matched against the lower-cased path and query,
+// both as logged and URL-decoded
+var DefaultExploitPatterns = []ExploitPattern{
+	{Name: "environment file", Pattern: `/\.env(\.[a-z]+)?($|[/?])`, Severity: SeverityHigh},
+	{Name: "git metadata", Pattern: `/\.(git|svn|hg)/`, Severity: SeverityHigh},
+	{Name: "path traversal", Pattern: `\.\.[/\\]|%2e%2e|%252e`, Severity: SeverityHigh},
+	{Name: "system file", Pattern: `/etc/(passwd|shadow)|/proc/self/|win\.ini|boot\.ini`, Severity: SeverityHigh},
+	{Name: "sql injection", Pattern: `union(\s|\+|/\*.*?\*/)+(all(\s|\+)+)?select|'\s*or\s+'?\d+'?\s*=\s*'?\d|\b(sleep|benchmark|pg_sleep)\s*\(|information_schema|;\s*drop\s+table`, Severity: SeverityHigh},
+	{Name: "log4shell", Pattern: `\$\{jndi:`, Severity: SeverityHigh},
+	{Name: "shell injection", Pattern: `(;|\||&&|\$\()\s*(wget|curl|cat|bash|sh|nc|id|uname)\b`, Severity: SeverityHigh},
+	{Name: "cross-site scripting", Pattern: `<script|javascript:|\bon(error|load)\s*=`, Severity: SeverityMedium},
+	{Name: "wordpress", Pattern: `/(wp-login\.php|xmlrpc\.php|wp-admin/|wp-config\.php)`, Severity: SeverityMedium},
+	{Name: "database admin", Pattern: `/(phpmyadmin|pma|myadmin|adminer(\.php)?)(/|$)`, Severity: SeverityMedium},
+	{Name: "cgi", Pattern: `/cgi-bin/`, Severity: SeverityMedium},
+	{Name: "server internals", Pattern: `/(actuator/(env|heapdump)|server-status|\.ds_store|web\.config|phpinfo\.php)`, Severity: SeverityMedium},
+	{Name: "backup file", Pattern: `\.(sql|bak|swp|old)($|\?)`, Severity: SeverityLow},
+}
+
+// DefaultLoginPaths are the routes watched for brute force by default
+var DefaultLoginPaths = []string{
+	"*login*", "*signin*", "*sign-in*", "*/auth*", "*/oauth/token*", "*/session*", "*/wp-login.php*", "*/xmlrpc.php*",
+}
+
+// DefaultConfig returns the default thresholds
+func DefaultConfig() Config {
+	return Config{
+		PathScan:     PathScanRule{Window: Duration(time.Minute), MinPaths: 20},
+		BruteForce:   BruteForceRule{Window: Duration(5 * time.Minute), MinFailures: 10, Paths: append([]string(nil), DefaultLoginPaths...), Statuses: []int{401, 403}},
+		ExploitProbe: ExploitRule{Patterns: append([]ExploitPattern(nil), DefaultExploitPatterns...)},
+		Rate:         RateRule{Window: Duration(time.Minute), MaxRequests: 600},
+		Cooldown:     Duration(10 * time.Minute),
+		MaxEvidence:  20,
+		MaxEvents:    1000,
+	}
+}
+
+// ParseConfig decodes a JSON config. Fields left out keep their defaults.
+func ParseConfig(data []byte) (Config, error) {
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse security config: %w", err)
+	}
+	return cfg, nil
+}
+
+type exploitMatcher struct {
+	name     string
+	severity string
+	re       *regexp.Regexp
+}
+
+// compiled is a validated config
+type compiled struct {
+	Config
+	exploits []exploitMatcher
+	ignore   []*net.IPNet
+	// horizon is the longest window, after which idle clients are dropped
+	horizon time.Duration
+}
+
+func compile(cfg Config) (*compiled, error) {
+	def := DefaultConfig()
+	if cfg.PathScan.Window <= 0 {
+		cfg.PathScan.Window = def.PathScan.Window
+	}
+	if cfg.PathScan.MinPaths <= 0 {
+		cfg.PathScan.MinPaths = def.PathScan.MinPaths
+	}
+	if cfg.BruteForce.Window <= 0 {
+		cfg.BruteForce.Window = def.BruteForce.Window
+	}
+	if cfg.BruteForce.MinFailures <= 0 {
+		cfg.BruteForce.MinFailures = def.BruteForce.MinFailures
+	}
+	if len(cfg.BruteForce.Paths) == 0 {
+		cfg.BruteForce.Paths = def.BruteForce.Paths
+	}
+	if len(cfg.BruteForce.Statuses) == 0 {
+		cfg.BruteForce.Statuses = def.BruteForce.Statuses
+	}
+	if len(cfg.ExploitProbe.Patterns) == 0 {
+		cfg.ExploitProbe.Patterns = def.ExploitProbe.Patterns
+	}
+	if cfg.Rate.Window <= 0 {
+		cfg.Rate.Window = def.Rate.Window
+	}
+	if cfg.Rate.MaxRequests <= 0 {
+		cfg.Rate.MaxRequests = def.Rate.MaxRequests
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = def.Cooldown
+	}
+	if cfg.MaxEvidence <= 0 {
+		cfg.MaxEvidence = def.MaxEvidence
+	}
+	if cfg.MaxEvents <= 0 {
+		cfg.MaxEvents = def.MaxEvents
+	}
+
+	c := &compiled{Config: cfg}
+	for i, p := range cfg.ExploitProbe.Patterns {
+		if p.Name == "" || p.Pattern == "" {
+			return nil, fmt.Errorf("exploit_probe.patterns[%d]: name and pattern are required", i)
+		}
+		severity := p.Severity
+		if severity == "" {
+			severity = SeverityMedium
+		}
+		if !validSeverity(severity) {
+			return nil, fmt.Errorf("exploit_probe.patterns[%d] %s: invalid severity %q", i, p.Name, severity)
+		}
+		re, err := regexp.Compile("(?i)" + p.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("exploit_probe.patterns[%d] %s: %v", i, p.Name, err)
+		}
+		c.exploits = append(c.exploits, exploitMatcher{name: p.Name, severity: severity, re: re})
+	}
+	for _, value := range cfg.IgnoreClients {
+		network, err := logs.ParseClientNetwork(value)
+		if err != nil {
+			return nil, fmt.Errorf("ignore_clients: %v", err)
+		}
+		c.ignore = append(c.ignore, network)
+	}
+
+	for _, w := range []Duration{cfg.PathScan.Window, cfg.BruteForce.Window, cfg.Rate.Window} {
+		if time.Duration(w) > c.horizon {
+			c.horizon = time.Duration(w)
+		}
+	}
+	return c, nil
+}
+
+func (c *compiled) ignored(client string) bool {
+	ip := net.ParseIP(client)
+	if ip == nil {
+		return false
+	}
+	for _, network := range c.ignore {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *compiled) loginPath(path string) bool {
+	path = strings.ToLower(path)
+	for _, pattern := range c.BruteForce.Paths {
+		if logs.MatchGlob(strings.ToLower(pattern), path) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *compiled) failedLogin(status int) bool {
+	for _, s := range c.BruteForce.Statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+func validSeverity(s string) bool {
+	return s == SeverityLow || s == SeverityMedium || s == SeverityHigh
+}
@@ -0,0 +1,417 @@
+// Package security watches parsed access log entries for path scans, login
+// brute force, exploit probes and abnormal request rates, and raises
+// structured events with the log lines that triggered them.
+package security
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+)
+
+// Event types
+const (
+	TypePathScan     = "path_scan"
+	TypeBruteForce   = "brute_force"
+	TypeExploitProbe = "exploit_probe"
+	TypeRateAnomaly  = "rate_anomaly"
+)
+
+// Types lists every event type
+var Types = []string{TypePathScan, TypeBruteForce, TypeExploitProbe, TypeRateAnomaly}
+
+// Severities, lowest first
+const (
+	SeverityLow    = "low"
+	SeverityMedium = "medium"
+	SeverityHigh   = "high"
+)
+
+// Event is one finding about one client. It stays open for the cooldown
+// after its last request, and further matching requests are added to it.
+type Event struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Severity  string    `json:"severity"`
+	Client    string    `json:"client"`
+	Summary   string    `json:"summary"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	// Count is the number of requests behind the event
+	Count int `json:"count"`
+	// Evidence holds the first log lines behind the event
+	Evidence []string `json:"evidence"`
+}
+
+// hit is one request kept in a client's sliding window
+type hit struct {
+	at   time.Time
+	path string
+	line string
+}
+
+type clientState struct {
+	lastSeen time.Time
+	requests []time.Time
+	notFound []hit
+	failures []hit
+}
+
+type eventKey struct {
+	typ    string
+	client string
+}
+
+// Detector keeps per-client sliding windows and the recent events. It is
+// safe for concurrent use.
+type Detector struct {
+	cfg *compiled
+
+	mu      sync.Mutex
+	clients map[string]*clientState
+	open    map[eventKey]*Event
+	// events is the retained history, oldest first
+	events []*Event
+	seq    uint64
+	// pruned is the log time of the last sweep of idle clients
+	pruned time.Time
+	subs   map[chan Event]struct{}
+}
+
+// New returns a detector for a config
+func New(cfg Config) (*Detector, error) {
+	c, err := compile(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Detector{
+		cfg:     c,
+		clients: make(map[string]*clientState),
+		open:    make(map[eventKey]*Event),
+		subs:    make(map[chan Event]struct{}),
+	}, nil
+}
+
+// Observe checks one parsed entry and its raw line. Time is taken from the
+// entry, so replayed logs give the same results as live ones. It returns
+// the events it raised.
+func (d *Detector) Observe(entry *logs.TraefikLog, line string) []Event {
+	if d == nil || entry == nil {
+		return nil
+	}
+	client := clientAddr(entry)
+	if client == "" || d.cfg.ignored(client) {
+		return nil
+	}
+	at := entry.StartUTC
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.prune(at)
+	cs := d.clients[client]
+	if cs == nil {
+		cs = &clientState{}
+		d.clients[client] = cs
+	}
+	if at.After(cs.lastSeen) {
+		cs.lastSeen = at
+	}
+
+	var raised []Event
+	report := func(typ, severity, summary string, first time.Time, count int, evidence []string) {
+		if ev, ok := d.report(typ, severity, client, summary, first, at, count, evidence); ok {
+			raised = append(raised, ev)
+		}
+	}
+
+	if !d.cfg.ExploitProbe.Disabled {
+		if name, severity, ok := d.exploit(entry.RequestPath); ok {
+			report(TypeExploitProbe, severity, fmt.Sprintf("exploit probe (%s)", name), at, 1, []string{line})
+		}
+	}
+
+	if rule := d.cfg.PathScan; !rule.Disabled && (entry.DownstreamStatus == 404 || entry.DownstreamStatus == 410) {
+		cs.notFound = window(append(cs.notFound, hit{at: at, path: pathOnly(entry.RequestPath), line: line}), at, time.Duration(rule.Window), rule.MinPaths*4)
+		if paths := distinctPaths(cs.notFound); paths >= rule.MinPaths {
+			report(TypePathScan, SeverityMedium, fmt.Sprintf("path scan: %d missing paths within %s", paths, time.Duration(rule.Window)), cs.notFound[0].at, len(cs.notFound), lines(cs.notFound))
+		}
+	}
+
+	if rule := d.cfg.BruteForce; !rule.Disabled && d.cfg.failedLogin(entry.DownstreamStatus) && d.cfg.loginPath(entry.RequestPath) {
+		cs.failures = window(append(cs.failures, hit{at: at, path: pathOnly(entry.RequestPath), line: line}), at, time.Duration(rule.Window), rule.MinFailures*4)
+		if len(cs.failures) >= rule.MinFailures {
+			report(TypeBruteForce, SeverityHigh, fmt.Sprintf("brute force: %d failed logins within %s", len(cs.failures), time.Duration(rule.Window)), cs.failures[0].at, len(cs.failures), lines(cs.failures))
+		}
+	}
+
+	if rule := d.cfg.Rate; !rule.Disabled {
+		cs.requests = append(cs.requests, at)
+		cutoff := at.Add(-time.Duration(rule.Window))
+		drop := 0
+		for drop < len(cs.requests) && !cs.requests[drop].After(cutoff) {
+			drop++
+		}
+		// Nothing beyond the threshold needs remembering
+		if over := len(cs.requests) - drop - (rule.MaxRequests + 1); over > 0 {
+			drop += over
+		}
+		cs.requests = cs.requests[drop:]
+		if len(cs.requests) > rule.MaxRequests {
+			report(TypeRateAnomaly, SeverityMedium, fmt.Sprintf("request rate: more than %d requests within %s", rule.MaxRequests, time.Duration(rule.Window)), cs.requests[0], len(cs.requests), []string{line})
+		}
+	}
+
+	return raised
+}
+
+// report adds a finding to the open event of its type and client, or opens
+// a new event. It reports whether a new event was raised.
+func (d *Detector) report(typ, severity, client, summary string, first, at time.Time, count int, evidence []string) (Event, bool) {
+	key := eventKey{typ: typ, client: client}
+	if ev := d.open[key]; ev != nil && at.Sub(ev.LastSeen) <= time.Duration(d.cfg.Cooldown) {
+		if at.After(ev.LastSeen) {
+			ev.LastSeen = at
+		}
+		ev.Count++
+		ev.Summary = summary
+		if severityRank(severity) > severityRank(ev.Severity) {
+			ev.Severity = severity
+		}
+		if len(ev.Evidence) < d.cfg.MaxEvidence && len(evidence) > 0 {
+			ev.Evidence = append(ev.Evidence, evidence[len(evidence)-1])
+		}
+		return Event{}, false
+	}
+
+	d.seq++
+	if len(evidence) > d.cfg.MaxEvidence {
+		evidence = evidence[len(evidence)-d.cfg.MaxEvidence:]
+	}
+	ev := &Event{
+		ID:        fmt.Sprintf("%d-%d", at.Unix(), d.seq),
+		Type:      typ,
+		Severity:  severity,
+		Client:    client,
+		Summary:   summary,
+		FirstSeen: first,
+		LastSeen:  at,
+		Count:     count,
+		Evidence:  append([]string(nil), evidence...),
+	}
+	d.open[key] = ev
+	d.events = append(d.events, ev)
+	if over := len(d.events) - d.cfg.MaxEvents; over > 0 {
+		d.events = append(d.events[:0:0], d.events[over:]...)
+	}
+
+	snapshot := ev.copy()
+	for ch := range d.subs {
+		// Slow subscribers miss events rather than stall detection
+		select {
+		case ch <- snapshot:
+		default:
+		}
+	}
+	return snapshot, true
+}
+
+// exploit matches a request path against the exploit patterns, as logged
+// and decoded
+func (d *Detector) exploit(path string) (string, string, bool) {
+	if path == "" {
+		return "", "", false
+	}
+	raw := strings.ToLower(path)
+	decoded := raw
+	if s, err := url.QueryUnescape(raw); err == nil {
+		decoded = s
+	}
+	for _, m := range d.cfg.exploits {
+		if m.re.MatchString(raw) || (decoded != raw && m.re.MatchString(decoded)) {
+			return m.name, m.severity, true
+		}
+	}
+	return "", "", false
+}
+
+// prune forgets idle clients and closed events at most once a minute of log
+// time
+func (d *Detector) prune(at time.Time) {
+	if at.Sub(d.pruned) < time.Minute {
+		return
+	}
+	d.pruned = at
+
+	for client, cs := range d.clients {
+		if at.Sub(cs.lastSeen) > d.cfg.horizon {
+			delete(d.clients, client)
+		}
+	}
+	for key, ev := range d.open {
+		if at.Sub(ev.LastSeen) > time.Duration(d.cfg.Cooldown) {
+			delete(d.open, key)
+		}
+	}
+}
+
+// Query selects events. Zero fields match everything.
+type Query struct {
+	Since    time.Time
+	Types    []string
+	Severity string
+	Client   string
+	Limit    int
+}
+
+// Events returns the retained events that match a query, most recent first
+func (d *Detector) Events(q Query) []Event {
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	events := make([]Event, 0)
+	for i := len(d.events) - 1; i >= 0; i-- {
+		ev := d.events[i]
+		if !q.Since.IsZero() && ev.LastSeen.Before(q.Since) {
+			continue
+		}
+		if len(q.Types) > 0 && !contains(q.Types, ev.Type) {
+			continue
+		}
+		if q.Severity != "" && !SeverityAtLeast(ev.Severity, q.Severity) {
+			continue
+		}
+		if q.Client != "" && ev.Client != q.Client {
+			continue
+		}
+		events = append(events, ev.copy())
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].LastSeen.After(events[j].LastSeen)
+	})
+	if q.Limit > 0 && len(events) > q.Limit {
+		events = events[:q.Limit]
+	}
+	return events
+}
+
+// Subscribe returns a channel that receives new events and a function that
+// cancels the subscription. Events are dropped when the channel is full.
+func (d *Detector) Subscribe(buffer int) (<-chan Event, func()) {
+	ch := make(chan Event, buffer)
+	d.mu.Lock()
+	d.subs[ch] = struct{}{}
+	d.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			d.mu.Lock()
+			delete(d.subs, ch)
+			d.mu.Unlock()
+		})
+	}
+}
+
+// ValidSeverity reports whether s is a known severity
+func ValidSeverity(s string) bool {
+	return validSeverity(s)
+}
+
+// SeverityAtLeast reports whether severity is min or higher
+func SeverityAtLeast(severity, min string) bool {
+	return severityRank(severity) >= severityRank(min)
+}
+
+// ValidType reports whether s is a known event type
+func ValidType(s string) bool {
+	return contains(Types, s)
+}
+
+func (ev *Event) copy() Event {
+	c := *ev
+	c.Evidence = append([]string(nil), ev.Evidence...)
+	return c
+}
+
+// window drops hits older than the window and keeps at most max of them
+func window(hits []hit, at time.Time, d time.Duration, max int) []hit {
+	cutoff := at.Add(-d)
+	drop := 0
+	for drop < len(hits) && !hits[drop].at.After(cutoff) {
+		drop++
+	}
+	if over := len(hits) - drop - max; over > 0 {
+		drop += over
+	}
+	if drop == 0 {
+		return hits
+	}
+	return append(hits[:0], hits[drop:]...)
+}
+
+func distinctPaths(hits []hit) int {
+	seen := make(map[string]struct{}, len(hits))
+	for _, h := range hits {
+		seen[h.path] = struct{}{}
+	}
+	return len(seen)
+}
+
+func lines(hits []hit) []string {
+	out := make([]string, len(hits))
+	for i, h := range hits {
+		out[i] = h.line
+	}
+	return out
+}
+
+func severityRank(s string) int {
+	switch s {
+	case SeverityLow:
+		return 1
+	case SeverityMedium:
+		return 2
+	case SeverityHigh:
+		return 3
+	}
+	return 0
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func pathOnly(path string) string {
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		return path[:i]
+	}
+	return path
+}
+
+func clientAddr(entry *logs.TraefikLog) string {
+	if entry.ClientHost != "" {
+		return entry.ClientHost
+	}
+	if host, _, err := net.SplitHostPort(entry.ClientAddr); err == nil {
+		return host
+	}
+	return entry.ClientAddr
+}
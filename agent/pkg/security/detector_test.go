@@ -0,0 +1,250 @@
+package security
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+)
+
+var base = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func request(client, path string, status int, at time.Time) (*logs.TraefikLog, string) {
+	entry := &logs.TraefikLog{ClientHost: client, RequestPath: path, DownstreamStatus: status, StartUTC: at}
+	line := fmt.Sprintf(`{"ClientHost":%q,"RequestPath":%q,"DownstreamStatus":%d}`, client, path, status)
+	return entry, line
+}
+
+func newDetector(t *testing.T, cfg Config) *Detector {
+	t.Helper()
+	d, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestPathScan(t *testing.T) {
+	d := newDetector(t, Config{PathScan: PathScanRule{MinPaths: 5, Window: Duration(time.Minute)}})
+
+	// Repeated 404s for one path are not a scan
+	for i := 0; i < 10; i++ {
+		if ev := d.Observe(request("81.2.69.1", "/favicon.ico", 404, base.Add(time.Duration(i)*time.Second))); len(ev) != 0 {
+			t.Fatalf("unexpected event %+v", ev)
+		}
+	}
+
+	var raised []Event
+	for i := 0; i < 8; i++ {
+		raised = append(raised, d.Observe(request("81.2.69.2", fmt.Sprintf("/admin%d?x=1", i), 404, base.Add(time.Duration(i)*time.Second)))...)
+	}
+	if len(raised) != 1 {
+		t.Fatalf("raised %d events, want 1", len(raised))
+	}
+	ev := raised[0]
+	if ev.Type != TypePathScan || ev.Client != "81.2.69.2" || ev.Count != 5 || len(ev.Evidence) != 5 {
+		t.Errorf("unexpected event %+v", ev)
+	}
+	if !ev.FirstSeen.Equal(base) || !ev.LastSeen.Equal(base.Add(4*time.Second)) {
+		t.Errorf("event spans %v..%v", ev.FirstSeen, ev.LastSeen)
+	}
+
+	// Later findings were added to the open event
+	events := d.Events(Query{})
+	if len(events) != 1 || events[0].Count != 8 || len(events[0].Evidence) != 8 {
+		t.Errorf("events = %+v", events)
+	}
+
+	// Spread-out 404s stay below the threshold within the window
+	for i := 0; i < 10; i++ {
+		if ev := d.Observe(request("81.2.69.3", fmt.Sprintf("/p%d", i), 404, base.Add(time.Duration(i)*20*time.Second))); len(ev) != 0 {
+			t.Fatalf("unexpected event for slow client: %+v", ev)
+		}
+	}
+}
+
+func TestBruteForce(t *testing.T) {
+	d := newDetector(t, Config{BruteForce: BruteForceRule{MinFailures: 3}})
+
+	var raised []Event
+	for i, status := range []int{401, 200, 403, 401, 401} {
+		raised = append(raised, d.Observe(request("81.2.69.1", "/api/login", status, base.Add(time.Duration(i)*time.Second)))...)
+	}
+	// Failures elsewhere do not count
+	for i := 0; i < 5; i++ {
+		raised = append(raised, d.Observe(request("81.2.69.2", "/api/items", 401, base.Add(time.Duration(i)*time.Second)))...)
+	}
+
+	if len(raised) != 1 || raised[0].Type != TypeBruteForce || raised[0].Count != 3 || raised[0].Severity != SeverityHigh {
+		t.Fatalf("raised = %+v", raised)
+	}
+	if strings.Contains(strings.Join(raised[0].Evidence, ""), `"DownstreamStatus":200`) {
+		t.Error("successful login in evidence")
+	}
+}
+
+func TestExploitProbe(t *testing.T) {
+	d := newDetector(t, Config{})
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/.env", "environment file"},
+		{"/app/.env.production", "environment file"},
+		{"/.git/config", "git metadata"},
+		{"/static/..%2f..%2fetc/passwd", "path traversal"},
+		{"/download?file=../../secret", "path traversal"},
+		{"/items?id=1%20UNION%20SELECT%20password%20FROM%20users", "sql injection"},
+		{"/items?id=1'%20or%20'1'='1", "sql injection"},
+		{"/search?q=%24%7Bjndi%3Aldap%3A%2F%2Fx%7D", "log4shell"},
+		{"/wp-login.php", "wordpress"},
+		{"/phpmyadmin/index.php", "database admin"},
+		{"/search?q=<script>alert(1)</script>", "cross-site scripting"},
+		{"/", ""},
+		{"/environment", ""},
+		{"/api/users/42?sort=name", ""},
+		{"/docs/select-a-union", ""},
+	}
+
+	for i, tt := range tests {
+		client := fmt.Sprintf("81.2.70.%d", i)
+		raised := d.Observe(request(client, tt.path, 404, base))
+		if tt.want == "" {
+			if len(raised) != 0 {
+				t.Errorf("%s: unexpected %+v", tt.path, raised)
+			}
+			continue
+		}
+		if len(raised) != 1 || raised[0].Type != TypeExploitProbe || !strings.Contains(raised[0].Summary, tt.want) {
+			t.Errorf("%s: raised %+v, want %s", tt.path, raised, tt.want)
+		}
+	}
+}
+
+func TestRateAnomaly(t *testing.T) {
+	d := newDetector(t, Config{Rate: RateRule{MaxRequests: 10, Window: Duration(time.Second)}})
+
+	var raised []Event
+	for i := 0; i < 30; i++ {
+		// 20 requests a second
+		raised = append(raised, d.Observe(request("81.2.69.1", "/", 200, base.Add(time.Duration(i)*50*time.Millisecond)))...)
+		// 5 requests a second
+		raised = append(raised, d.Observe(request("81.2.69.2", "/", 200, base.Add(time.Duration(i)*200*time.Millisecond)))...)
+	}
+	if len(raised) != 1 || raised[0].Type != TypeRateAnomaly || raised[0].Client != "81.2.69.1" || raised[0].Count != 11 {
+		t.Fatalf("raised = %+v", raised)
+	}
+	if got := len(d.clients["81.2.69.1"].requests); got > 11 {
+		t.Errorf("kept %d request times, want at most 11", got)
+	}
+}
+
+func TestCooldownAndQuery(t *testing.T) {
+	d := newDetector(t, Config{Cooldown: Duration(time.Minute), IgnoreClients: []string{"10.0.0.0/8"}})
+
+	d.Observe(request("81.2.69.1", "/.env", 404, base))
+	d.Observe(request("81.2.69.1", "/.git/HEAD", 404, base.Add(30*time.Second)))
+	// After the cooldown a new event is raised
+	if raised := d.Observe(request("81.2.69.1", "/.env", 404, base.Add(5*time.Minute))); len(raised) != 1 {
+		t.Errorf("raised %+v after cooldown", raised)
+	}
+	d.Observe(request("81.2.69.2", "/backup.sql", 404, base.Add(time.Minute)))
+	if raised := d.Observe(request("10.1.2.3", "/.env", 404, base)); len(raised) != 0 {
+		t.Errorf("ignored client raised %+v", raised)
+	}
+
+	all := d.Events(Query{})
+	if len(all) != 3 || !all[0].LastSeen.Equal(base.Add(5*time.Minute)) || all[2].Count != 2 {
+		t.Fatalf("events = %+v", all)
+	}
+	if got := d.Events(Query{Severity: SeverityHigh}); len(got) != 2 {
+		t.Errorf("high severity events = %d, want 2", len(got))
+	}
+	if got := d.Events(Query{Client: "81.2.69.2"}); len(got) != 1 || got[0].Severity != SeverityLow {
+		t.Errorf("client events = %+v", got)
+	}
+	if got := d.Events(Query{Since: base.Add(2 * time.Minute), Types: []string{TypeExploitProbe}, Limit: 5}); len(got) != 1 {
+		t.Errorf("since events = %+v", got)
+	}
+	if got := d.Events(Query{Types: []string{TypePathScan}}); len(got) != 0 {
+		t.Errorf("path scan events = %+v", got)
+	}
+
+	// Events returned are copies
+	all[0].Evidence[0] = "changed"
+	if d.Events(Query{Limit: 1})[0].Evidence[0] == "changed" {
+		t.Error("Events shares evidence with the detector")
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	d := newDetector(t, Config{})
+	ch, cancel := d.Subscribe(1)
+
+	d.Observe(request("81.2.69.1", "/.env", 404, base))
+	d.Observe(request("81.2.69.2", "/.env", 404, base))
+
+	select {
+	case ev := <-ch:
+		if ev.Client != "81.2.69.1" {
+			t.Errorf("received %+v", ev)
+		}
+	default:
+		t.Fatal("no event received")
+	}
+	// The second event did not fit in the buffer
+	select {
+	case ev := <-ch:
+		t.Errorf("unexpected %+v", ev)
+	default:
+	}
+
+	cancel()
+	cancel()
+	d.Observe(request("81.2.69.3", "/.env", 404, base))
+	if len(ch) != 0 {
+		t.Error("event delivered after cancel")
+	}
+}
+
+func TestConfig(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`{"path_scan":{"window":"30s","min_paths":3},"rate":{"disabled":true},"exploit_probe":{"patterns":[{"name":"secret","pattern":"^/secret","severity":"high"}]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := newDetector(t, cfg)
+	if d.cfg.PathScan.Window != Duration(30*time.Second) || d.cfg.BruteForce.MinFailures != 10 || !d.cfg.Rate.Disabled {
+		t.Errorf("unexpected config %+v", d.cfg.Config)
+	}
+	if raised := d.Observe(request("81.2.69.1", "/.env", 200, base)); len(raised) != 0 {
+		t.Errorf("default patterns should be replaced: %+v", raised)
+	}
+	if raised := d.Observe(request("81.2.69.1", "/secret/x", 200, base)); len(raised) != 1 || raised[0].Severity != SeverityHigh {
+		t.Errorf("custom pattern raised %+v", raised)
+	}
+	if len(DefaultExploitPatterns) < 10 || DefaultExploitPatterns[0].Name != "environment file" {
+		t.Error("ParseConfig changed the default patterns")
+	}
+
+	bad := []string{
+		`{"path_scan":{"window":"soon"}}`,
+		`{"cooldown":5}`,
+	}
+	for _, data := range bad {
+		if _, err := ParseConfig([]byte(data)); err == nil {
+			t.Errorf("ParseConfig(%s) succeeded", data)
+		}
+	}
+	for _, cfg := range []Config{
+		{ExploitProbe: ExploitRule{Patterns: []ExploitPattern{{Name: "x", Pattern: "("}}}},
+		{ExploitProbe: ExploitRule{Patterns: []ExploitPattern{{Name: "x", Pattern: "x", Severity: "critical"}}}},
+		{IgnoreClients: []string{"not-an-ip"}},
+	} {
+		if _, err := New(cfg); err == nil {
+			t.Errorf("New(%+v) succeeded", cfg)
+		}
+	}
+}
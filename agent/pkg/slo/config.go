@@ -0,0 +1,197 @@
+package slo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SLI types
+const (
+	// TypeAvailability counts responses other than 5xx as good
+	TypeAvailability = "availability"
+	// TypeLatency counts responses within the threshold as good
+	TypeLatency = "latency"
+)
+
+// Duration is a time.Duration written as a string such as "5m" or "30d" in
+// JSON
+type Duration time.Duration
+
+// UnmarshalJSON reads a duration string, in which d stands for 24 hours
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"30d\"")
+	}
+	v, err := ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalJSON writes a duration string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(FormatDuration(time.Duration(d)))
+}
+
+// ParseDuration parses a Go duration, or a whole number of days such as
+// "28d"
+func ParseDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil || v <= 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return v, nil
+}
+
+// FormatDuration formats a duration the way the configuration spells it,
+// e.g. 30d, 6h or 5m
+func FormatDuration(d time.Duration) string {
+	switch {
+	case d > 0 && d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d > 0 && d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d > 0 && d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	default:
+		return d.String()
+	}
+}
+
+// Objective declares an SLO over the traffic of some routers or services
+type Objective struct {
+	Name string `json:"name"`
+	// Type is availability or latency
+	Type string `json:"type"`
+	// Routers and Services select the traffic, as patterns in which *
+	// matches any text. An entry must match both lists when both are set;
+	// no lists select all traffic.
+	Routers  []string `json:"routers,omitempty"`
+	Services []string `json:"services,omitempty"`
+	// Target is the percentage of good requests, e.g. 99.9
+	Target float64 `json:"target"`
+	// Threshold is the latency a request must be served within to be good
+	Threshold Duration `json:"threshold,omitempty"`
+	// Window is the compliance window, 30d by default
+	Window Duration `json:"window"`
+}
+
+// Alert is a multi-window burn rate alert. It fires while the error budget
+// burns at least BurnRate times faster than sustainable over both windows.
+type Alert struct {
+	Name     string   `json:"name"`
+	Severity string   `json:"severity"`
+	Long     Duration `json:"long"`
+	Short    Duration `json:"short"`
+	BurnRate float64  `json:"burn_rate"`
+}
+
+// Config declares the objectives and the alerts evaluated for each of them
+type Config struct {
+	Objectives []Objective `json:"objectives"`
+	// Alerts replace DefaultAlerts when set
+	Alerts []Alert `json:"alerts,omitempty"`
+}
+
+// DefaultWindow is the compliance window of objectives that set none
+const DefaultWindow = 30 * 24 * time.Hour
+
+// MaxWindow is the longest compliance window
+const MaxWindow = 90 * 24 * time.Hour
+
+// DefaultAlerts are the page and ticket alerts recommended for a 30 day
+// window: each burns 2%, 5%, 10% and 10% of the budget respectively
+var DefaultAlerts = []Alert{
+	{Name: "fast burn", Severity: "page", Long: Duration(time.Hour), Short: Duration(5 * time.Minute), BurnRate: 14.4},
+	{Name: "medium burn", Severity: "page", Long: Duration(6 * time.Hour), Short: Duration(30 * time.Minute), BurnRate: 6},
+	{Name: "slow burn", Severity: "ticket", Long: Duration(24 * time.Hour), Short: Duration(2 * time.Hour), BurnRate: 3},
+	{Name: "budget drain", Severity: "ticket", Long: Duration(72 * time.Hour), Short: Duration(6 * time.Hour), BurnRate: 1},
+}
+
+// ParseConfig decodes a JSON config
+func ParseConfig(data []byte) (Config, error) {
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse SLO config: %w", err)
+	}
+	return cfg, nil
+}
+
+// normalize fills in defaults and validates the config
+func (c *Config) normalize() error {
+	if len(c.Objectives) == 0 {
+		return errors.New("at least one objective is required")
+	}
+	if len(c.Alerts) == 0 {
+		c.Alerts = append([]Alert(nil), DefaultAlerts...)
+	}
+
+	seen := make(map[string]bool, len(c.Objectives))
+	for i := range c.Objectives {
+		o := &c.Objectives[i]
+		if o.Name == "" {
+			return fmt.Errorf("objective %d has no name", i+1)
+		}
+		if seen[o.Name] {
+			return fmt.Errorf("duplicate objective %q", o.Name)
+		}
+		seen[o.Name] = true
+		if o.Type == "" {
+			o.Type = TypeAvailability
+		}
+		switch o.Type {
+		case TypeAvailability:
+		case TypeLatency:
+			if o.Threshold <= 0 {
+				return fmt.Errorf("objective %q: latency objectives need a threshold", o.Name)
+			}
+		default:
+			return fmt.Errorf("objective %q: unknown type %q, use availability or latency", o.Name, o.Type)
+		}
+		if o.Target <= 0 || o.Target >= 100 {
+			return fmt.Errorf("objective %q: target must be a percentage between 0 and 100", o.Name)
+		}
+		if o.Window == 0 {
+			o.Window = Duration(DefaultWindow)
+		}
+		if w := time.Duration(o.Window); w%time.Hour != 0 || w > MaxWindow {
+			return fmt.Errorf("objective %q: window must be whole hours up to %s", o.Name, FormatDuration(MaxWindow))
+		}
+	}
+
+	for i, a := range c.Alerts {
+		if a.Name == "" {
+			return fmt.Errorf("alert %d has no name", i+1)
+		}
+		long, short := time.Duration(a.Long), time.Duration(a.Short)
+		if short <= 0 || short%time.Minute != 0 || long%time.Minute != 0 {
+			return fmt.Errorf("alert %q: windows must be whole minutes", a.Name)
+		}
+		if long <= short {
+			return fmt.Errorf("alert %q: the long window must be longer than the short one", a.Name)
+		}
+		if a.BurnRate <= 0 {
+			return fmt.Errorf("alert %q: burn rate must be positive", a.Name)
+		}
+		for _, o := range c.Objectives {
+			if long > time.Duration(o.Window) {
+				return fmt.Errorf("alert %q: long window exceeds the window of objective %q", a.Name, o.Name)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,297 @@
+// Package slo tracks service level objectives declared over Traefik routers
+// and services: the SLI over the compliance window, the error budget left
+// and multi-window burn rate alerts.
+package slo
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+)
+
+// Objective states, from best to worst
+const (
+	StateNoData    = "no_data"
+	StateOK        = "ok"
+	StateBurning   = "burning"
+	StateExhausted = "exhausted"
+)
+
+// AlertStatus is the evaluation of one alert for one objective
+type AlertStatus struct {
+	Name     string  `json:"name"`
+	Severity string  `json:"severity"`
+	Long     string  `json:"long"`
+	Short    string  `json:"short"`
+	BurnRate float64 `json:"burn_rate"`
+	// LongBurnRate and ShortBurnRate are the burn rates over each window
+	LongBurnRate  float64 `json:"long_burn_rate"`
+	ShortBurnRate float64 `json:"short_burn_rate"`
+	Firing        bool    `json:"firing"`
+}
+
+// Status is the state of an objective
+type Status struct {
+	Objective
+	// State is no_data, ok, burning when an alert fires, or exhausted when
+	// no error budget is left
+	State string `json:"state"`
+	// Requests and Good count the selected requests within the window
+	Requests int64 `json:"requests"`
+	Good     int64 `json:"good"`
+	// SLI is the percentage of good requests, 100 without traffic
+	SLI float64 `json:"sli"`
+	// ErrorBudget is the number of bad requests the target allows for the
+	// traffic so far, and ErrorBudgetRemaining the percentage of it left,
+	// negative once overspent
+	ErrorBudget          float64 `json:"error_budget"`
+	ErrorBudgetRemaining float64 `json:"error_budget_remaining"`
+	// BurnRates are keyed by alert window. A burn rate of 1 spends the
+	// budget exactly over the compliance window.
+	BurnRates map[string]float64 `json:"burn_rates"`
+	Alerts    []AlertStatus      `json:"alerts"`
+}
+
+// counter counts the requests of one slice of time
+type counter struct {
+	index int64 // Unix time / resolution
+	total int64
+	good  int64
+}
+
+// ring keeps counters for consecutive slices of time
+type ring struct {
+	resolution time.Duration
+	counters   []counter
+}
+
+func newRing(resolution, span time.Duration) ring {
+	return ring{resolution: resolution, counters: make([]counter, int(span/resolution)+1)}
+}
+
+func (r *ring) add(ts time.Time, good bool) {
+	index := ts.UnixNano() / int64(r.resolution)
+	c := &r.counters[index%int64(len(r.counters))]
+	if c.index != index {
+		if c.index > index {
+			// Older than the ring
+			return
+		}
+		*c = counter{index: index}
+	}
+	c.total++
+	if good {
+		c.good++
+	}
+}
+
+// sum counts the requests within span up to now, to the resolution
+func (r *ring) sum(now time.Time, span time.Duration) (total, good int64) {
+	current := now.UnixNano() / int64(r.resolution)
+	from := current - int64(span/r.resolution)
+	for _, c := range r.counters {
+		if c.index > from && c.index <= current {
+			total += c.total
+			good += c.good
+		}
+	}
+	return total, good
+}
+
+// series tracks one objective: minutes cover the alert windows and hours
+// the compliance window
+type series struct {
+	objective Objective
+	routers   []string
+	services  []string
+	minutes   ring
+	hours     ring
+}
+
+func (s *series) matches(entry *logs.TraefikLog) bool {
+	return matchAny(s.routers, entry.RouterName) && matchAny(s.services, entry.ServiceName)
+}
+
+func (s *series) good(entry *logs.TraefikLog) bool {
+	if s.objective.Type == TypeLatency {
+		return entry.Duration <= int64(s.objective.Threshold)
+	}
+	return entry.DownstreamStatus < 500
+}
+
+// Tracker counts good and bad requests for every objective. It is safe for
+// concurrent use.
+type Tracker struct {
+	cfg Config
+	now func() time.Time
+
+	mu     sync.Mutex
+	series []*series
+	loaded bool
+}
+
+// New returns a Tracker, or an error when the configuration is invalid
+func New(cfg Config) (*Tracker, error) {
+	if err := cfg.normalize(); err != nil {
+		return nil, err
+	}
+
+	var longest time.Duration
+	for _, a := range cfg.Alerts {
+		if time.Duration(a.Long) > longest {
+			longest = time.Duration(a.Long)
+		}
+	}
+
+	t := &Tracker{cfg: cfg, now: time.Now}
+	for _, o := range cfg.Objectives {
+		t.series = append(t.series, &series{
+			objective: o,
+			routers:   lower(o.Routers),
+			services:  lower(o.Services),
+			minutes:   newRing(time.Minute, longest),
+			hours:     newRing(time.Hour, time.Duration(o.Window)),
+		})
+	}
+	return t, nil
+}
+
+// Config returns the configuration of the tracker, defaults filled in
+func (t *Tracker) Config() Config {
+	return t.cfg
+}
+
+// Observe records an access log entry against the objectives selecting it
+func (t *Tracker) Observe(entry *logs.TraefikLog) {
+	ts := entry.StartUTC
+	if ts.IsZero() {
+		ts = t.now()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, s := range t.series {
+		if !s.matches(entry) {
+			continue
+		}
+		good := s.good(entry)
+		s.minutes.add(ts, good)
+		s.hours.add(ts, good)
+	}
+}
+
+// SetLoaded records that the log history has been read
+func (t *Tracker) SetLoaded() {
+	t.mu.Lock()
+	t.loaded = true
+	t.mu.Unlock()
+}
+
+// Loaded reports whether the log history has been read, before which the
+// statuses only cover part of their windows
+func (t *Tracker) Loaded() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.loaded
+}
+
+// Statuses returns the status of every objective, in configuration order
+func (t *Tracker) Statuses() []Status {
+	now := t.now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	out := make([]Status, 0, len(t.series))
+	for _, s := range t.series {
+		out = append(out, t.status(s, now))
+	}
+	return out
+}
+
+func (t *Tracker) status(s *series, now time.Time) Status {
+	o := s.objective
+	allowed := 1 - o.Target/100
+	st := Status{
+		Objective: o,
+		SLI:       100,
+		BurnRates: make(map[string]float64),
+		Alerts:    make([]AlertStatus, 0, len(t.cfg.Alerts)),
+	}
+
+	st.Requests, st.Good = s.hours.sum(now, time.Duration(o.Window))
+	st.ErrorBudget = float64(st.Requests) * allowed
+	st.ErrorBudgetRemaining = 100
+	if st.Requests > 0 {
+		bad := float64(st.Requests - st.Good)
+		st.SLI = float64(st.Good) / float64(st.Requests) * 100
+		st.ErrorBudgetRemaining = (1 - bad/st.ErrorBudget) * 100
+	}
+
+	burnRate := func(span time.Duration) float64 {
+		name := FormatDuration(span)
+		if rate, ok := st.BurnRates[name]; ok {
+			return rate
+		}
+		total, good := s.minutes.sum(now, span)
+		rate := 0.0
+		if total > 0 {
+			rate = float64(total-good) / float64(total) / allowed
+		}
+		st.BurnRates[name] = rate
+		return rate
+	}
+
+	firing := false
+	for _, a := range t.cfg.Alerts {
+		as := AlertStatus{
+			Name:          a.Name,
+			Severity:      a.Severity,
+			Long:          FormatDuration(time.Duration(a.Long)),
+			Short:         FormatDuration(time.Duration(a.Short)),
+			BurnRate:      a.BurnRate,
+			LongBurnRate:  burnRate(time.Duration(a.Long)),
+			ShortBurnRate: burnRate(time.Duration(a.Short)),
+		}
+		as.Firing = as.LongBurnRate >= a.BurnRate && as.ShortBurnRate >= a.BurnRate
+		firing = firing || as.Firing
+		st.Alerts = append(st.Alerts, as)
+	}
+
+	switch {
+	case st.Requests == 0:
+		st.State = StateNoData
+	case st.ErrorBudgetRemaining <= 0:
+		st.State = StateExhausted
+	case firing:
+		st.State = StateBurning
+	default:
+		st.State = StateOK
+	}
+	return st
+}
+
+func lower(patterns []string) []string {
+	out := make([]string, len(patterns))
+	for i, p := range patterns {
+		out[i] = strings.ToLower(p)
+	}
+	return out
+}
+
+// matchAny reports whether value matches any of the patterns, or whether
+// there are none
+func matchAny(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	value = strings.ToLower(value)
+	for _, p := range patterns {
+		if logs.MatchGlob(p, value) {
+			return true
+		}
+	}
+	return false
+}
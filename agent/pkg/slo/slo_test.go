@@ -0,0 +1,152 @@
+package slo
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+)
+
+var now = time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+
+func newTracker(t *testing.T, data string) *Tracker {
+	t.Helper()
+	cfg, err := ParseConfig([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr.now = func() time.Time { return now }
+	return tr
+}
+
+// observe records n entries, ago before now
+func observe(tr *Tracker, router string, status int, duration time.Duration, ago time.Duration, n int) {
+	for i := 0; i < n; i++ {
+		tr.Observe(&logs.TraefikLog{
+			RouterName:       router,
+			ServiceName:      "api@docker",
+			DownstreamStatus: status,
+			Duration:         int64(duration),
+			StartUTC:         now.Add(-ago),
+		})
+	}
+}
+
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 0.01
+}
+
+func TestStatuses(t *testing.T) {
+	tr := newTracker(t, `{"objectives": [
+		{"name": "api", "routers": ["api*"], "target": 99},
+		{"name": "api latency", "type": "latency", "services": ["api@docker"], "target": 90, "threshold": "300ms", "window": "28d"},
+		{"name": "web", "routers": ["web@*"], "target": 99.9}
+	]}`)
+
+	// Ten days ago: a clean day
+	observe(tr, "api@docker", 200, 100*time.Millisecond, 10*24*time.Hour, 9000)
+	// Two minutes ago: an outage
+	observe(tr, "api@docker", 502, 10*time.Millisecond, 2*time.Minute, 50)
+	observe(tr, "api@docker", 200, time.Second, 2*time.Minute, 50)
+	// Outside the window
+	observe(tr, "api@docker", 500, 0, 40*24*time.Hour, 1000)
+	// Another router
+	observe(tr, "dashboard@internal", 500, 0, time.Minute, 10)
+
+	statuses := tr.Statuses()
+	if len(statuses) != 3 {
+		t.Fatalf("got %d statuses", len(statuses))
+	}
+
+	api := statuses[0]
+	if api.Requests != 9100 || api.Good != 9050 {
+		t.Fatalf("api counts: %d/%d", api.Good, api.Requests)
+	}
+	if !approx(api.SLI, 9050.0/9100*100) || !approx(api.ErrorBudget, 91) {
+		t.Errorf("api sli %v, budget %v", api.SLI, api.ErrorBudget)
+	}
+	if !approx(api.ErrorBudgetRemaining, (1-50.0/91)*100) {
+		t.Errorf("api budget remaining %v", api.ErrorBudgetRemaining)
+	}
+	// Half of the last hour failed against a 1% allowance
+	if !approx(api.BurnRates["1h"], 50) || !approx(api.BurnRates["5m"], 50) {
+		t.Errorf("api burn rates %v", api.BurnRates)
+	}
+	if api.State != StateBurning || !api.Alerts[0].Firing || !api.Alerts[1].Firing {
+		t.Errorf("api state %s, alerts %+v", api.State, api.Alerts)
+	}
+	if drain := api.Alerts[3]; !drain.Firing || drain.Long != "3d" {
+		t.Errorf("budget drain alert %+v", drain)
+	}
+
+	// Selected by service, so the other router counts too; only the slow
+	// responses are bad
+	latency := statuses[1]
+	if latency.Requests != 9110 || latency.Good != 9060 || latency.Window != Duration(28*24*time.Hour) {
+		t.Errorf("latency: %+v", latency)
+	}
+
+	web := statuses[2]
+	if web.State != StateNoData || web.SLI != 100 || web.ErrorBudgetRemaining != 100 {
+		t.Errorf("web: %+v", web)
+	}
+}
+
+func TestExhausted(t *testing.T) {
+	tr := newTracker(t, `{"objectives": [{"name": "api", "target": 99}],
+		"alerts": [{"name": "page", "long": "1h", "short": "5m", "burn_rate": 14.4}]}`)
+
+	observe(tr, "api@docker", 200, 0, 2*24*time.Hour, 98)
+	observe(tr, "api@docker", 503, 0, 2*24*time.Hour, 2)
+
+	st := tr.Statuses()[0]
+	if st.State != StateExhausted || !approx(st.ErrorBudgetRemaining, -100) {
+		t.Errorf("got %s with %v%% left", st.State, st.ErrorBudgetRemaining)
+	}
+	if len(st.Alerts) != 1 || st.Alerts[0].Firing || st.BurnRates["1h"] != 0 {
+		t.Errorf("alerts %+v, burn rates %v", st.Alerts, st.BurnRates)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"no objectives", `{}`},
+		{"no name", `{"objectives": [{"target": 99}]}`},
+		{"duplicate", `{"objectives": [{"name": "a", "target": 99}, {"name": "a", "target": 99}]}`},
+		{"unknown type", `{"objectives": [{"name": "a", "type": "errors", "target": 99}]}`},
+		{"latency without threshold", `{"objectives": [{"name": "a", "type": "latency", "target": 99}]}`},
+		{"target", `{"objectives": [{"name": "a", "target": 100}]}`},
+		{"window", `{"objectives": [{"name": "a", "target": 99, "window": "365d"}]}`},
+		{"bad duration", `{"objectives": [{"name": "a", "target": 99, "window": "month"}]}`},
+		{"alert windows", `{"objectives": [{"name": "a", "target": 99}], "alerts": [{"name": "x", "long": "5m", "short": "1h", "burn_rate": 2}]}`},
+		{"alert longer than window", `{"objectives": [{"name": "a", "target": 99, "window": "1d"}]}`},
+	}
+	for _, tt := range tests {
+		cfg, err := ParseConfig([]byte(tt.data))
+		if err == nil {
+			_, err = New(cfg)
+		}
+		if err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+
+	tr := newTracker(t, `{"objectives": [{"name": "a", "target": 99.5}]}`)
+	cfg := tr.Config()
+	if len(cfg.Alerts) != len(DefaultAlerts) || cfg.Objectives[0].Type != TypeAvailability {
+		t.Errorf("defaults not applied: %+v", cfg)
+	}
+	data, _ := json.Marshal(cfg.Objectives[0])
+	if string(data) != `{"name":"a","type":"availability","target":99.5,"window":"30d"}` {
+		t.Errorf("got %s", data)
+	}
+}
@@ -0,0 +1,224 @@
+// Package stats aggregates parsed access log entries into summaries and
+// top-N breakdowns.
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+)
+
+// DefaultTop is the length of top-N lists when none is requested
+const DefaultTop = 10
+
+// Count is one row of a top-N breakdown
+type Count struct {
+	Key   string `json:"key"`
+	Label string `json:"label,omitempty"`
+	Count int64  `json:"count"`
+}
+
+// Summary is the aggregate of a set of entries
+type Summary struct {
+	Requests        int64            `json:"requests"`
+	Bytes           int64            `json:"bytes"`
+	AvgDurationMS   float64          `json:"avg_duration_ms"`
+	StatusClasses   map[string]int64 `json:"status_classes"`
+	UniqueClients   int              `json:"unique_clients"`
+	PrivateRequests int64            `json:"private_requests"`
+	First           *time.Time       `json:"first,omitempty"`
+	Last            *time.Time       `json:"last,omitempty"`
+
+	TopRouters   []Count `json:"top_routers"`
+	TopServices  []Count `json:"top_services"`
+	TopHosts     []Count `json:"top_hosts"`
+	TopPaths     []Count `json:"top_paths"`
+	TopClients   []Count `json:"top_clients"`
+	TopCountries []Count `json:"top_countries"`
+	TopASNs      []Count `json:"top_asns"`
+
+	TopUserAgentClasses []Count `json:"top_user_agent_classes"`
+	TopUserAgents       []Count `json:"top_user_agents"`
+	TopBrowsers         []Count `json:"top_browsers"`
+	TopOSes             []Count `json:"top_oses"`
+	TopDevices          []Count `json:"top_devices"`
+}
+
+// counter counts keys and remembers a label per key
+type counter struct {
+	counts map[string]int64
+	labels map[string]string
+}
+
+func newCounter() *counter {
+	return &counter{counts: make(map[string]int64), labels: make(map[string]string)}
+}
+
+func (c *counter) add(key, label string) {
+	if key == "" {
+		return
+	}
+	c.counts[key]++
+	if label != "" {
+		c.labels[key] = label
+	}
+}
+
+// top returns the n most frequent keys, ties broken by key
+func (c *counter) top(n int) []Count {
+	rows := make([]Count, 0, len(c.counts))
+	for key, count := range c.counts {
+		rows = append(rows, Count{Key: key, Label: c.labels[key], Count: count})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Count != rows[j].Count {
+			return rows[i].Count > rows[j].Count
+		}
+		return rows[i].Key < rows[j].Key
+	})
+	if len(rows) > n {
+		rows = rows[:n]
+	}
+	return rows
+}
+
+// Aggregator accumulates entries. It is not safe for concurrent use.
+type Aggregator struct {
+	top int
+
+	requests      int64
+	bytes         int64
+	totalDuration int64
+	private       int64
+	statusClasses map[string]int64
+	first, last   time.Time
+
+	routers   *counter
+	services  *counter
+	hosts     *counter
+	paths     *counter
+	clients   *counter
+	countries *counter
+	asns      *counter
+
+	uaClasses *counter
+	uaNames   *counter
+	browsers  *counter
+	oses      *counter
+	devices   *counter
+}
+
+// New returns an aggregator whose top-N lists hold up to top rows
+func New(top int) *Aggregator {
+	if top <= 0 {
+		top = DefaultTop
+	}
+	return &Aggregator{
+		top:           top,
+		statusClasses: make(map[string]int64),
+		routers:       newCounter(),
+		services:      newCounter(),
+		hosts:         newCounter(),
+		paths:         newCounter(),
+		clients:       newCounter(),
+		countries:     newCounter(),
+		asns:          newCounter(),
+		uaClasses:     newCounter(),
+		uaNames:       newCounter(),
+		browsers:      newCounter(),
+		oses:          newCounter(),
+		devices:       newCounter(),
+	}
+}
+
+// Add counts one entry
+func (a *Aggregator) Add(entry *logs.TraefikLog) {
+	a.requests++
+	a.bytes += entry.DownstreamContentSize
+	a.totalDuration += entry.Duration
+	if entry.DownstreamStatus >= 100 && entry.DownstreamStatus < 600 {
+		a.statusClasses[fmt.Sprintf("%dxx", entry.DownstreamStatus/100)]++
+	}
+
+	if ts := entry.StartUTC; !ts.IsZero() {
+		if a.first.IsZero() || ts.Before(a.first) {
+			a.first = ts
+		}
+		if ts.After(a.last) {
+			a.last = ts
+		}
+	}
+
+	a.routers.add(entry.RouterName, "")
+	a.services.add(entry.ServiceName, "")
+	a.hosts.add(entry.RequestHost, "")
+	a.paths.add(pathOnly(entry.RequestPath), "")
+	a.clients.add(clientAddr(entry), "")
+
+	if entry.ClientPrivate {
+		a.private++
+	}
+	a.countries.add(entry.ClientCountryCode, entry.ClientCountry)
+	if entry.ClientASN != 0 {
+		a.asns.add("AS"+strconv.Itoa(entry.ClientASN), entry.ClientASOrg)
+	}
+
+	a.uaClasses.add(entry.UserAgentClass, "")
+	// Named agents are labelled with their class
+	a.uaNames.add(entry.UserAgentName, entry.UserAgentClass)
+	a.browsers.add(entry.UserAgentBrowser, "")
+	a.oses.add(entry.UserAgentOS, "")
+	a.devices.add(entry.UserAgentDevice, "")
+}
+
+// Summary returns the aggregate of the entries added so far
+func (a *Aggregator) Summary() Summary {
+	s := Summary{
+		Requests:        a.requests,
+		Bytes:           a.bytes,
+		StatusClasses:   a.statusClasses,
+		UniqueClients:   len(a.clients.counts),
+		PrivateRequests: a.private,
+		TopRouters:      a.routers.top(a.top),
+		TopServices:     a.services.top(a.top),
+		TopHosts:        a.hosts.top(a.top),
+		TopPaths:        a.paths.top(a.top),
+		TopClients:      a.clients.top(a.top),
+		TopCountries:    a.countries.top(a.top),
+		TopASNs:         a.asns.top(a.top),
+
+		TopUserAgentClasses: a.uaClasses.top(a.top),
+		TopUserAgents:       a.uaNames.top(a.top),
+		TopBrowsers:         a.browsers.top(a.top),
+		TopOSes:             a.oses.top(a.top),
+		TopDevices:          a.devices.top(a.top),
+	}
+	if a.requests > 0 {
+		s.AvgDurationMS = float64(a.totalDuration) / float64(a.requests) / float64(time.Millisecond)
+	}
+	if !a.first.IsZero() {
+		first, last := a.first, a.last
+		s.First, s.Last = &first, &last
+	}
+	return s
+}
+
+// pathOnly drops the query string of a request path
+func pathOnly(path string) string {
+	for i := 0; i < len(path); i++ {
+		if path[i] == '?' {
+			return path[:i]
+		}
+	}
+	return path
+}
+
+func clientAddr(entry *logs.TraefikLog) string {
+	if entry.ClientHost != "" {
+		return entry.ClientHost
+	}
+	return entry.ClientAddr
+}
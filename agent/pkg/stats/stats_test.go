@@ -0,0 +1,71 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+)
+
+func TestAggregator(t *testing.T) {
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	entries := []logs.TraefikLog{
+		{ClientHost: "81.2.69.1", RouterName: "api", RequestPath: "/a?x=1", DownstreamStatus: 200, Duration: int64(10 * time.Millisecond), DownstreamContentSize: 100, ClientCountryCode: "GB", ClientCountry: "United Kingdom", ClientASN: 20712, ClientASOrg: "Andrews & Arnold", UserAgentClass: "human", UserAgentBrowser: "Firefox", UserAgentOS: "Linux", UserAgentDevice: "desktop"},
+		{ClientHost: "81.2.69.1", RouterName: "api", RequestPath: "/a", DownstreamStatus: 404, Duration: int64(20 * time.Millisecond), DownstreamContentSize: 50, ClientCountryCode: "GB", ClientCountry: "United Kingdom", ClientASN: 20712, ClientASOrg: "Andrews & Arnold", UserAgentClass: "crawler", UserAgentName: "Googlebot"},
+		{ClientHost: "2.125.160.1", RouterName: "web", RequestPath: "/b", DownstreamStatus: 503, Duration: int64(30 * time.Millisecond), ClientCountryCode: "DE", ClientCountry: "Germany", UserAgentClass: "crawler", UserAgentName: "Googlebot"},
+		{ClientHost: "10.0.0.1", RouterName: "web", RequestPath: "/b", DownstreamStatus: 200, ClientPrivate: true},
+	}
+
+	agg := New(1)
+	for i := range entries {
+		entries[i].StartUTC = base.Add(time.Duration(len(entries)-i) * time.Minute)
+		agg.Add(&entries[i])
+	}
+	s := agg.Summary()
+
+	if s.Requests != 4 || s.Bytes != 150 || s.UniqueClients != 3 || s.PrivateRequests != 1 {
+		t.Errorf("unexpected totals %+v", s)
+	}
+	if s.AvgDurationMS != 15 {
+		t.Errorf("AvgDurationMS = %v, want 15", s.AvgDurationMS)
+	}
+	if s.StatusClasses["2xx"] != 2 || s.StatusClasses["4xx"] != 1 || s.StatusClasses["5xx"] != 1 {
+		t.Errorf("StatusClasses = %v", s.StatusClasses)
+	}
+	if !s.First.Equal(base.Add(time.Minute)) || !s.Last.Equal(base.Add(4*time.Minute)) {
+		t.Errorf("range = %v..%v", s.First, s.Last)
+	}
+
+	// Ties are broken by key
+	if len(s.TopRouters) != 1 || s.TopRouters[0] != (Count{Key: "api", Count: 2}) {
+		t.Errorf("TopRouters = %+v", s.TopRouters)
+	}
+	if s.TopPaths[0] != (Count{Key: "/a", Count: 2}) {
+		t.Errorf("TopPaths = %+v", s.TopPaths)
+	}
+	if s.TopCountries[0] != (Count{Key: "GB", Label: "United Kingdom", Count: 2}) {
+		t.Errorf("TopCountries = %+v", s.TopCountries)
+	}
+	if s.TopASNs[0] != (Count{Key: "AS20712", Label: "Andrews & Arnold", Count: 2}) {
+		t.Errorf("TopASNs = %+v", s.TopASNs)
+	}
+	if s.TopUserAgentClasses[0] != (Count{Key: "crawler", Count: 2}) {
+		t.Errorf("TopUserAgentClasses = %+v", s.TopUserAgentClasses)
+	}
+	if s.TopUserAgents[0] != (Count{Key: "Googlebot", Label: "crawler", Count: 2}) {
+		t.Errorf("TopUserAgents = %+v", s.TopUserAgents)
+	}
+	if s.TopBrowsers[0] != (Count{Key: "Firefox", Count: 1}) || s.TopDevices[0] != (Count{Key: "desktop", Count: 1}) {
+		t.Errorf("TopBrowsers = %+v, TopDevices = %+v", s.TopBrowsers, s.TopDevices)
+	}
+}
+
+func TestEmptySummary(t *testing.T) {
+	s := New(0).Summary()
+	if s.Requests != 0 || s.AvgDurationMS != 0 || s.First != nil {
+		t.Errorf("unexpected summary %+v", s)
+	}
+	if s.TopCountries == nil || len(s.TopCountries) != 0 {
+		t.Errorf("top lists should be empty, not nil: %#v", s.TopCountries)
+	}
+}
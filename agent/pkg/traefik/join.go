@@ -0,0 +1,184 @@
+package traefik
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+)
+
+// internalProvider serves Traefik's own routers (api, dashboard, ping),
+// which are not expected to see traffic
+const internalProvider = "internal"
+
+// Traffic counts the access log entries of a router or service
+type Traffic struct {
+	Requests     int64      `json:"requests"`
+	ClientErrors int64      `json:"client_errors"`
+	ServerErrors int64      `json:"server_errors"`
+	ErrorRate    float64    `json:"error_rate"`
+	LastSeen     *time.Time `json:"last_seen,omitempty"`
+}
+
+func (t *Traffic) add(entry *logs.TraefikLog) {
+	t.Requests++
+	switch {
+	case entry.DownstreamStatus >= 500:
+		t.ServerErrors++
+	case entry.DownstreamStatus >= 400:
+		t.ClientErrors++
+	}
+	if ts := entry.StartUTC; !ts.IsZero() && (t.LastSeen == nil || ts.After(*t.LastSeen)) {
+		t.LastSeen = &ts
+	}
+	t.ErrorRate = float64(t.ServerErrors) / float64(t.Requests)
+}
+
+// Usage accumulates traffic per router and service name
+type Usage struct {
+	routers  map[string]*Traffic
+	services map[string]*Traffic
+}
+
+// NewUsage returns an empty Usage
+func NewUsage() *Usage {
+	return &Usage{routers: make(map[string]*Traffic), services: make(map[string]*Traffic)}
+}
+
+// Add counts one access log entry
+func (u *Usage) Add(entry *logs.TraefikLog) {
+	if entry.RouterName != "" {
+		traffic(u.routers, entry.RouterName).add(entry)
+	}
+	if entry.ServiceName != "" {
+		traffic(u.services, entry.ServiceName).add(entry)
+	}
+}
+
+func traffic(m map[string]*Traffic, name string) *Traffic {
+	t, ok := m[name]
+	if !ok {
+		t = &Traffic{}
+		m[name] = t
+	}
+	return t
+}
+
+// RouterReport is a router with its traffic
+type RouterReport struct {
+	Router
+	Traffic Traffic `json:"traffic"`
+}
+
+// ServiceReport is a service with its traffic
+type ServiceReport struct {
+	Service
+	Traffic Traffic `json:"traffic"`
+	// DownServers are the servers the health check marks DOWN
+	DownServers []string `json:"down_servers,omitempty"`
+}
+
+// Report joins a topology to the traffic of a time range
+type Report struct {
+	Routers     []RouterReport  `json:"routers"`
+	Services    []ServiceReport `json:"services"`
+	Middlewares []Middleware    `json:"middlewares"`
+
+	// IdleRouters are enabled routers without a single request; Traefik's
+	// internal routers are left out
+	IdleRouters []string `json:"idle_routers"`
+	// ErroringServices are services that answered with 5xx, highest error
+	// rate first
+	ErroringServices []string `json:"erroring_services"`
+	// UnknownRouters and UnknownServices are names found in the logs but
+	// not in the topology, such as removed containers
+	UnknownRouters  []string `json:"unknown_routers"`
+	UnknownServices []string `json:"unknown_services"`
+}
+
+// Join matches the routers and services of a topology to their traffic.
+// Names are compared in their provider-qualified form, as in access logs.
+func Join(topo *Topology, usage *Usage) Report {
+	report := Report{
+		Routers:          make([]RouterReport, 0, len(topo.Routers)),
+		Services:         make([]ServiceReport, 0, len(topo.Services)),
+		Middlewares:      topo.Middlewares,
+		IdleRouters:      make([]string, 0),
+		ErroringServices: make([]string, 0),
+	}
+	if report.Middlewares == nil {
+		report.Middlewares = make([]Middleware, 0)
+	}
+
+	known := make(map[string]bool, len(topo.Routers))
+	for _, r := range topo.Routers {
+		name := qualify(r.Name, r.Provider)
+		known[name] = true
+		rr := RouterReport{Router: r}
+		if t, ok := usage.routers[name]; ok {
+			rr.Traffic = *t
+		} else if r.Status == "enabled" && r.Provider != internalProvider {
+			report.IdleRouters = append(report.IdleRouters, name)
+		}
+		report.Routers = append(report.Routers, rr)
+	}
+	report.UnknownRouters = unknown(usage.routers, known)
+
+	known = make(map[string]bool, len(topo.Services))
+	for _, s := range topo.Services {
+		name := qualify(s.Name, s.Provider)
+		known[name] = true
+		sr := ServiceReport{Service: s}
+		if t, ok := usage.services[name]; ok {
+			sr.Traffic = *t
+		}
+		for server, status := range s.ServerStatus {
+			if status != "UP" {
+				sr.DownServers = append(sr.DownServers, server)
+			}
+		}
+		sort.Strings(sr.DownServers)
+		report.Services = append(report.Services, sr)
+	}
+	report.UnknownServices = unknown(usage.services, known)
+
+	erroring := make([]ServiceReport, 0)
+	for _, sr := range report.Services {
+		if sr.Traffic.ServerErrors > 0 {
+			erroring = append(erroring, sr)
+		}
+	}
+	sort.SliceStable(erroring, func(i, j int) bool {
+		if erroring[i].Traffic.ErrorRate != erroring[j].Traffic.ErrorRate {
+			return erroring[i].Traffic.ErrorRate > erroring[j].Traffic.ErrorRate
+		}
+		return erroring[i].Name < erroring[j].Name
+	})
+	for _, sr := range erroring {
+		report.ErroringServices = append(report.ErroringServices, qualify(sr.Name, sr.Provider))
+	}
+
+	sort.Strings(report.IdleRouters)
+	return report
+}
+
+// qualify appends the provider to a name that lacks one
+func qualify(name, provider string) string {
+	if provider == "" || strings.Contains(name, "@") {
+		return name
+	}
+	return name + "@" + provider
+}
+
+// unknown returns the sorted names of m that are not known
+func unknown(m map[string]*Traffic, known map[string]bool) []string {
+	names := make([]string, 0)
+	for name := range m {
+		if !known[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,326 @@
+// Package traefik polls the Traefik API for the routers, services and
+// middlewares behind the names found in access logs, and joins that
+// topology to log traffic.
+package traefik
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults for Options
+const (
+	DefaultInterval = 30 * time.Second
+	DefaultTimeout  = 5 * time.Second
+)
+
+// perPage is the page size asked of list endpoints, and maxPages guards
+// against an API that never stops paginating
+const (
+	perPage  = 100
+	maxPages = 1000
+)
+
+// ErrNotFetched is reported while no poll has completed yet
+var ErrNotFetched = errors.New("the Traefik API has not been polled yet")
+
+// maxResponseBytes caps one API response
+const maxResponseBytes = 32 << 20
+
+// Router is an HTTP router as reported by the Traefik API
+type Router struct {
+	Name        string     `json:"name"`
+	Provider    string     `json:"provider"`
+	Status      string     `json:"status"`
+	Rule        string     `json:"rule"`
+	Priority    int64      `json:"priority,omitempty"`
+	EntryPoints []string   `json:"entryPoints"`
+	Using       []string   `json:"using,omitempty"`
+	Middlewares []string   `json:"middlewares,omitempty"`
+	Service     string     `json:"service"`
+	TLS         *RouterTLS `json:"tls,omitempty"`
+	Errors      []string   `json:"error,omitempty"`
+}
+
+// RouterTLS is the TLS configuration of a router
+type RouterTLS struct {
+	Options      string   `json:"options,omitempty"`
+	CertResolver string   `json:"certResolver,omitempty"`
+	Domains      []Domain `json:"domains,omitempty"`
+}
+
+// Domain is a certificate domain
+type Domain struct {
+	Main string   `json:"main"`
+	SANs []string `json:"sans,omitempty"`
+}
+
+// Service is an HTTP service as reported by the Traefik API
+type Service struct {
+	Name         string            `json:"name"`
+	Provider     string            `json:"provider"`
+	Status       string            `json:"status"`
+	Type         string            `json:"type"`
+	LoadBalancer *LoadBalancer     `json:"loadBalancer,omitempty"`
+	Weighted     *Weighted         `json:"weighted,omitempty"`
+	ServerStatus map[string]string `json:"serverStatus,omitempty"`
+	UsedBy       []string          `json:"usedBy,omitempty"`
+	Errors       []string          `json:"error,omitempty"`
+}
+
+// LoadBalancer lists the servers of a load-balancer service
+type LoadBalancer struct {
+	Servers        []Server `json:"servers"`
+	PassHostHeader *bool    `json:"passHostHeader,omitempty"`
+}
+
+// Server is one load-balancer server
+type Server struct {
+	URL    string `json:"url"`
+	Weight *int   `json:"weight,omitempty"`
+}
+
+// Weighted lists the services of a weighted round robin service
+type Weighted struct {
+	Services []WeightedService `json:"services"`
+}
+
+// WeightedService is one service of a weighted round robin
+type WeightedService struct {
+	Name   string `json:"name"`
+	Weight *int   `json:"weight,omitempty"`
+}
+
+// Middleware is an HTTP middleware as reported by the Traefik API. Its
+// configuration is left out, as it may hold credentials.
+type Middleware struct {
+	Name     string   `json:"name"`
+	Provider string   `json:"provider"`
+	Status   string   `json:"status"`
+	Type     string   `json:"type"`
+	UsedBy   []string `json:"usedBy,omitempty"`
+	Errors   []string `json:"error,omitempty"`
+}
+
+// Overview is the summary of /api/overview
+type Overview struct {
+	HTTP      OverviewSection `json:"http"`
+	TCP       OverviewSection `json:"tcp"`
+	UDP       OverviewSection `json:"udp"`
+	Features  map[string]any  `json:"features,omitempty"`
+	Providers []string        `json:"providers,omitempty"`
+}
+
+// OverviewSection counts the objects of one protocol
+type OverviewSection struct {
+	Routers     *Counts `json:"routers,omitempty"`
+	Services    *Counts `json:"services,omitempty"`
+	Middlewares *Counts `json:"middlewares,omitempty"`
+}
+
+// Counts is the health of one kind of object
+type Counts struct {
+	Total    int `json:"total"`
+	Warnings int `json:"warnings"`
+	Errors   int `json:"errors"`
+}
+
+// Topology is one snapshot of the Traefik configuration
+type Topology struct {
+	FetchedAt   time.Time    `json:"fetched_at"`
+	Overview    Overview     `json:"overview"`
+	Routers     []Router     `json:"routers"`
+	Services    []Service    `json:"services"`
+	Middlewares []Middleware `json:"middlewares"`
+}
+
+// Options configures a Client
+type Options struct {
+	// URL is the base address of the Traefik API, e.g. http://traefik:8080
+	URL string
+	// Username and Password are sent as basic auth when set
+	Username string
+	Password string
+	// Interval is the time between polls
+	Interval time.Duration
+	// Timeout bounds one poll
+	Timeout time.Duration
+	// HTTPClient replaces the default client
+	HTTPClient *http.Client
+}
+
+// Client polls the Traefik API and caches the last topology. It is safe
+// for concurrent use.
+type Client struct {
+	opts Options
+	base *url.URL
+	http *http.Client
+
+	mu   sync.RWMutex
+	topo *Topology
+	err  error
+}
+
+// New validates the options. Nothing is fetched until Refresh or Run.
+func New(opts Options) (*Client, error) {
+	base, err := url.Parse(strings.TrimRight(opts.URL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Traefik API URL: %w", err)
+	}
+	if base.Scheme != "http" && base.Scheme != "https" || base.Host == "" {
+		return nil, fmt.Errorf("invalid Traefik API URL %q: use http(s)://host[:port]", opts.URL)
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultInterval
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	client := opts.HTTPClient
+	if client == nil {
+		client = &http.Client{}
+	}
+	return &Client{opts: opts, base: base, http: client}, nil
+}
+
+// URL returns the base address of the API
+func (c *Client) URL() string {
+	return c.base.String()
+}
+
+// Topology returns the last fetched topology and the error of the last poll
+// if it failed. Before the first successful poll the topology is nil.
+func (c *Client) Topology() (*Topology, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.topo == nil && c.err == nil {
+		return nil, ErrNotFetched
+	}
+	return c.topo, c.err
+}
+
+// Run polls the API now and then every interval until ctx is done
+func (c *Client) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.opts.Interval)
+	defer ticker.Stop()
+	for {
+		c.Refresh(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh polls the API once. A failed poll keeps the previous topology.
+func (c *Client) Refresh(ctx context.Context) error {
+	topo, err := c.Fetch(ctx)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.err = err
+	if err == nil {
+		c.topo = topo
+	}
+	return err
+}
+
+// Fetch reads the whole topology from the API
+func (c *Client) Fetch(ctx context.Context) (*Topology, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.opts.Timeout)
+	defer cancel()
+
+	topo := &Topology{}
+	if _, err := c.get(ctx, "/api/overview", 0, &topo.Overview); err != nil {
+		return nil, err
+	}
+	if err := list(ctx, c, "/api/http/routers", &topo.Routers); err != nil {
+		return nil, err
+	}
+	if err := list(ctx, c, "/api/http/services", &topo.Services); err != nil {
+		return nil, err
+	}
+	if err := list(ctx, c, "/api/http/middlewares", &topo.Middlewares); err != nil {
+		return nil, err
+	}
+	topo.FetchedAt = time.Now().UTC()
+	return topo, nil
+}
+
+// list reads every page of a list endpoint. Traefik names the next page in
+// the X-Next-Page header and points it back at page 1 after the last one.
+func list[T any](ctx context.Context, c *Client, path string, out *[]T) error {
+	items := make([]T, 0)
+	for page := 1; page <= maxPages; {
+		var batch []T
+		next, err := c.get(ctx, path, page, &batch)
+		if err != nil {
+			return err
+		}
+		items = append(items, batch...)
+		if next <= page {
+			*out = items
+			return nil
+		}
+		page = next
+	}
+	return fmt.Errorf("%s: more than %d pages", path, maxPages)
+}
+
+// get decodes one API response into out and returns the next page, or 0
+func (c *Client) get(ctx context.Context, path string, page int, out any) (int, error) {
+	u := *c.base
+	u.Path += path
+	if page > 0 {
+		q := u.Query()
+		q.Set("page", strconv.Itoa(page))
+		q.Set("per_page", strconv.Itoa(perPage))
+		u.RawQuery = q.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.opts.Username != "" || c.opts.Password != "" {
+		req.SetBasicAuth(c.opts.Username, c.opts.Password)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+		return 0, fmt.Errorf("%s: unexpected status %s", path, resp.Status)
+	}
+
+	body := io.LimitReader(resp.Body, maxResponseBytes+1)
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return 0, err
+	}
+	if len(data) > maxResponseBytes {
+		return 0, fmt.Errorf("%s: response larger than %d bytes", path, maxResponseBytes)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return 0, fmt.Errorf("%s: %w", path, err)
+	}
+
+	next, _ := strconv.Atoi(resp.Header.Get("X-Next-Page"))
+	return next, nil
+}
@@ -0,0 +1,249 @@
+package traefik
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+)
+
+// fakeTraefik stands in for the Traefik API, paginating like Traefik does
+type fakeTraefik struct {
+	routers     []Router
+	services    []Service
+	middlewares []Middleware
+	failing     atomic.Bool
+}
+
+func (f *fakeTraefik) handler(t *testing.T) http.Handler {
+	mux := http.NewServeMux()
+	page := func(w http.ResponseWriter, r *http.Request, items any) {
+		v := reflect.ValueOf(items)
+		size, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+		n, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if size <= 0 || n <= 0 {
+			t.Errorf("%s: missing pagination", r.URL.Path)
+			size, n = v.Len(), 1
+		}
+		// Small pages so that tests cross page boundaries
+		size = min(size, 2)
+		start, end := min((n-1)*size, v.Len()), min(n*size, v.Len())
+		next := 1
+		if end < v.Len() {
+			next = n + 1
+		}
+		w.Header().Set("X-Next-Page", strconv.Itoa(next))
+		json.NewEncoder(w).Encode(v.Slice(start, end).Interface())
+	}
+
+	mux.HandleFunc("/api/overview", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"http":{"routers":{"total":%d,"warnings":0,"errors":0}},"features":{"accessLog":true},"providers":["Docker","File"]}`, len(f.routers))
+	})
+	mux.HandleFunc("/api/http/routers", func(w http.ResponseWriter, r *http.Request) { page(w, r, f.routers) })
+	mux.HandleFunc("/api/http/services", func(w http.ResponseWriter, r *http.Request) { page(w, r, f.services) })
+	mux.HandleFunc("/api/http/middlewares", func(w http.ResponseWriter, r *http.Request) { page(w, r, f.middlewares) })
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "admin" || pass != "secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if f.failing.Load() {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func newFake() *fakeTraefik {
+	return &fakeTraefik{
+		routers: []Router{
+			{Name: "api@docker", Provider: "docker", Status: "enabled", Rule: "Host(`api.example.com`)", EntryPoints: []string{"websecure"}, Middlewares: []string{"auth@file"}, Service: "api", TLS: &RouterTLS{CertResolver: "le"}},
+			{Name: "web@docker", Provider: "docker", Status: "enabled", Rule: "Host(`example.com`)", EntryPoints: []string{"web"}, Service: "web"},
+			{Name: "old@file", Provider: "file", Status: "enabled", Rule: "Path(`/old`)", Service: "web@docker"},
+			{Name: "dashboard@internal", Provider: "internal", Status: "enabled", Rule: "PathPrefix(`/dashboard`)", Service: "dashboard@internal"},
+			{Name: "broken@file", Provider: "file", Status: "disabled", Errors: []string{"middleware \"missing@file\" does not exist"}},
+		},
+		services: []Service{
+			{Name: "api@docker", Provider: "docker", Status: "enabled", Type: "loadbalancer",
+				LoadBalancer: &LoadBalancer{Servers: []Server{{URL: "http://10.0.0.2:80"}, {URL: "http://10.0.0.3:80"}}},
+				ServerStatus: map[string]string{"http://10.0.0.2:80": "UP", "http://10.0.0.3:80": "DOWN"}},
+			{Name: "web@docker", Provider: "docker", Status: "enabled", Type: "loadbalancer",
+				LoadBalancer: &LoadBalancer{Servers: []Server{{URL: "http://10.0.0.4:80"}}}},
+			{Name: "dashboard@internal", Provider: "internal", Status: "enabled"},
+		},
+		middlewares: []Middleware{
+			{Name: "auth@file", Provider: "file", Status: "enabled", Type: "basicauth", UsedBy: []string{"api@docker"}},
+		},
+	}
+}
+
+func TestClientFetch(t *testing.T) {
+	fake := newFake()
+	srv := httptest.NewServer(fake.handler(t))
+	defer srv.Close()
+
+	c, err := New(Options{URL: srv.URL + "/", Username: "admin", Password: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Topology(); err != ErrNotFetched {
+		t.Errorf("got %v before the first poll, want ErrNotFetched", err)
+	}
+	if err := c.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	topo, err := c.Topology()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(topo.Routers, fake.routers) {
+		t.Errorf("routers:\ngot  %+v\nwant %+v", topo.Routers, fake.routers)
+	}
+	if !reflect.DeepEqual(topo.Services, fake.services) {
+		t.Errorf("services:\ngot  %+v\nwant %+v", topo.Services, fake.services)
+	}
+	if !reflect.DeepEqual(topo.Middlewares, fake.middlewares) {
+		t.Errorf("middlewares:\ngot  %+v\nwant %+v", topo.Middlewares, fake.middlewares)
+	}
+	if topo.Overview.HTTP.Routers == nil || topo.Overview.HTTP.Routers.Total != 5 {
+		t.Errorf("unexpected overview %+v", topo.Overview)
+	}
+	if !reflect.DeepEqual(topo.Overview.Providers, []string{"Docker", "File"}) {
+		t.Errorf("unexpected providers %v", topo.Overview.Providers)
+	}
+	if topo.FetchedAt.IsZero() {
+		t.Error("fetch time not set")
+	}
+
+	// A failed poll keeps the last topology and reports the error
+	fake.failing.Store(true)
+	if err := c.Refresh(context.Background()); err == nil {
+		t.Fatal("expected an error")
+	}
+	again, err := c.Topology()
+	if err == nil || again != topo {
+		t.Errorf("got %p, %v; want the previous topology and an error", again, err)
+	}
+
+	fake.failing.Store(false)
+	c.Refresh(context.Background())
+	if _, err := c.Topology(); err != nil {
+		t.Errorf("error not cleared: %v", err)
+	}
+}
+
+func TestClientUnauthorized(t *testing.T) {
+	srv := httptest.NewServer(newFake().handler(t))
+	defer srv.Close()
+
+	c, _ := New(Options{URL: srv.URL, Username: "admin", Password: "wrong"})
+	if err := c.Refresh(context.Background()); err == nil {
+		t.Fatal("expected an error")
+	}
+	if topo, _ := c.Topology(); topo != nil {
+		t.Error("unexpected topology")
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	c, _ := New(Options{URL: srv.URL, Timeout: 50 * time.Millisecond})
+	start := time.Now()
+	if err := c.Refresh(context.Background()); err == nil {
+		t.Fatal("expected an error")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("poll took %s", elapsed)
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, value := range []string{"", "traefik:8080", "ftp://traefik", "http://"} {
+		if _, err := New(Options{URL: value}); err == nil {
+			t.Errorf("%q: expected an error", value)
+		}
+	}
+	c, err := New(Options{URL: "https://traefik.example.com:8443/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.URL() != "https://traefik.example.com:8443" {
+		t.Errorf("got %q", c.URL())
+	}
+}
+
+func TestJoin(t *testing.T) {
+	fake := newFake()
+	topo := &Topology{Routers: fake.routers, Services: fake.services, Middlewares: fake.middlewares}
+
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	usage := NewUsage()
+	add := func(router, service string, status, n int) {
+		for i := 0; i < n; i++ {
+			usage.Add(&logs.TraefikLog{RouterName: router, ServiceName: service, DownstreamStatus: status, StartUTC: ts.Add(time.Duration(i) * time.Second)})
+		}
+	}
+	add("api@docker", "api@docker", 200, 6)
+	add("api@docker", "api@docker", 502, 2)
+	add("api@docker", "api@docker", 404, 2)
+	add("old@file", "web@docker", 200, 3)
+	add("old@file", "web@docker", 503, 3)
+	add("gone@docker", "gone@docker", 200, 1)
+
+	report := Join(topo, usage)
+
+	api := report.Routers[0]
+	want := Traffic{Requests: 10, ClientErrors: 2, ServerErrors: 2, ErrorRate: 0.2}
+	last := ts.Add(5 * time.Second)
+	want.LastSeen = &last
+	if !reflect.DeepEqual(api.Traffic, want) {
+		t.Errorf("api traffic: got %+v, want %+v", api.Traffic, want)
+	}
+	if !reflect.DeepEqual(report.Services[0].DownServers, []string{"http://10.0.0.3:80"}) {
+		t.Errorf("down servers: got %v", report.Services[0].DownServers)
+	}
+
+	// dashboard@internal and the disabled router are not idle
+	if !reflect.DeepEqual(report.IdleRouters, []string{"web@docker"}) {
+		t.Errorf("idle routers: got %v", report.IdleRouters)
+	}
+	if !reflect.DeepEqual(report.ErroringServices, []string{"web@docker", "api@docker"}) {
+		t.Errorf("erroring services: got %v", report.ErroringServices)
+	}
+	if !reflect.DeepEqual(report.UnknownRouters, []string{"gone@docker"}) || !reflect.DeepEqual(report.UnknownServices, []string{"gone@docker"}) {
+		t.Errorf("unknown: got %v, %v", report.UnknownRouters, report.UnknownServices)
+	}
+	if len(report.Middlewares) != 1 {
+		t.Errorf("middlewares: got %v", report.Middlewares)
+	}
+}
+
+func TestQualify(t *testing.T) {
+	tests := []struct{ name, provider, want string }{
+		{"api", "docker", "api@docker"},
+		{"api@docker", "docker", "api@docker"},
+		{"api", "", "api"},
+	}
+	for _, tt := range tests {
+		if got := qualify(tt.name, tt.provider); got != tt.want {
+			t.Errorf("qualify(%q, %q) = %q, want %q", tt.name, tt.provider, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+// Package useragent classifies request user agents into browser, OS and
+// device families, and flags crawlers, monitoring probes, command-line
+// clients and scanners, using an embedded rule set that can be replaced at
+// runtime.
+package useragent
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sync"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+)
+
+// Classes of user agents
+const (
+	ClassHuman   = "human"
+	ClassCrawler = "crawler"
+	ClassMonitor = "monitor"
+	ClassCLI     = "cli"
+	ClassScanner = "scanner"
+	ClassUnknown = "unknown"
+)
+
+// Classes lists every class
+var Classes = []string{ClassHuman, ClassCrawler, ClassMonitor, ClassCLI, ClassScanner, ClassUnknown}
+
+// DefaultCacheSize is the number of user agents cached when none is given
+const DefaultCacheSize = 10000
+
+//go:embed rules.json
+var defaultRules []byte
+
+// Rule matches user agents against a case-insensitive regular expression
+type Rule struct {
+	Name string `json:"name"`
+	// Class is only used by agent rules
+	Class   string `json:"class,omitempty"`
+	Pattern string `json:"pattern"`
+	// Unless rejects a match when it also matches
+	Unless string `json:"unless,omitempty"`
+}
+
+// Rules is a rule set. Each list is tried in order and the first match wins.
+type Rules struct {
+	Version string `json:"version"`
+	// Agents name automated clients and give their class
+	Agents   []Rule `json:"agents"`
+	Browsers []Rule `json:"browsers"`
+	OS       []Rule `json:"os"`
+	Devices  []Rule `json:"devices"`
+}
+
+// DefaultRules returns the embedded rule set
+func DefaultRules() Rules {
+	var rules Rules
+	if err := json.Unmarshal(defaultRules, &rules); err != nil {
+		panic(fmt.Sprintf("useragent: invalid embedded rules: %v", err))
+	}
+	return rules
+}
+
+// LoadRules reads a rule set from a JSON file
+func LoadRules(path string) (Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Rules{}, err
+	}
+	var rules Rules
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return Rules{}, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// Result is the classification of one user agent
+type Result struct {
+	Class string `json:"class"`
+	// Name is the agent rule that matched, e.g. Googlebot or curl
+	Name    string `json:"name,omitempty"`
+	Browser string `json:"browser,omitempty"`
+	OS      string `json:"os,omitempty"`
+	Device  string `json:"device,omitempty"`
+}
+
+type rule struct {
+	name    string
+	class   string
+	pattern *regexp.Regexp
+	unless  *regexp.Regexp
+}
+
+func (r rule) match(ua string) bool {
+	return r.pattern.MatchString(ua) && (r.unless == nil || !r.unless.MatchString(ua))
+}
+
+// Classifier classifies user agents with a compiled rule set
+type Classifier struct {
+	version  string
+	agents   []rule
+	browsers []rule
+	oses     []rule
+	devices  []rule
+
+	mu        sync.Mutex
+	cache     map[string]Result
+	cacheSize int
+}
+
+// New compiles a rule set. cacheSize bounds the number of distinct user
+// agents remembered; 0 uses DefaultCacheSize.
+func New(rules Rules, cacheSize int) (*Classifier, error) {
+	if cacheSize <= 0 {
+		cacheSize = DefaultCacheSize
+	}
+	c := &Classifier{
+		version:   rules.Version,
+		cache:     make(map[string]Result),
+		cacheSize: cacheSize,
+	}
+
+	var err error
+	if c.agents, err = compile("agents", rules.Agents, true); err != nil {
+		return nil, err
+	}
+	if c.browsers, err = compile("browsers", rules.Browsers, false); err != nil {
+		return nil, err
+	}
+	if c.oses, err = compile("os", rules.OS, false); err != nil {
+		return nil, err
+	}
+	if c.devices, err = compile("devices", rules.Devices, false); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func compile(list string, rules []Rule, needClass bool) ([]rule, error) {
+	compiled := make([]rule, 0, len(rules))
+	for i, r := range rules {
+		if r.Name == "" || r.Pattern == "" {
+			return nil, fmt.Errorf("%s[%d]: name and pattern are required", list, i)
+		}
+		if needClass && !validClass(r.Class) {
+			return nil, fmt.Errorf("%s[%d] %s: invalid class %q", list, i, r.Name, r.Class)
+		}
+
+		c := rule{name: r.Name, class: r.Class}
+		var err error
+		if c.pattern, err = regexp.Compile("(?i)" + r.Pattern); err != nil {
+			return nil, fmt.Errorf("%s[%d] %s: %v", list, i, r.Name, err)
+		}
+		if r.Unless != "" {
+			if c.unless, err = regexp.Compile("(?i)" + r.Unless); err != nil {
+				return nil, fmt.Errorf("%s[%d] %s: %v", list, i, r.Name, err)
+			}
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled, nil
+}
+
+// validClass reports whether an agent rule may use a class
+func validClass(class string) bool {
+	switch class {
+	case ClassCrawler, ClassMonitor, ClassCLI, ClassScanner:
+		return true
+	}
+	return false
+}
+
+// Version returns the version of the rule set
+func (c *Classifier) Version() string {
+	if c == nil {
+		return ""
+	}
+	return c.version
+}
+
+// Classify classifies a user agent. An empty or unrecognised user agent is
+// ClassUnknown; one with a known browser and no agent rule is ClassHuman.
+func (c *Classifier) Classify(ua string) Result {
+	if c == nil {
+		return Result{}
+	}
+	if ua == "" || ua == "-" {
+		return Result{Class: ClassUnknown}
+	}
+
+	c.mu.Lock()
+	res, ok := c.cache[ua]
+	c.mu.Unlock()
+	if ok {
+		return res
+	}
+
+	res = c.classify(ua)
+
+	c.mu.Lock()
+	// User agents repeat heavily; starting over when full is cheaper than
+	// tracking recency and is rarely hit
+	if len(c.cache) >= c.cacheSize {
+		c.cache = make(map[string]Result)
+	}
+	c.cache[ua] = res
+	c.mu.Unlock()
+	return res
+}
+
+func (c *Classifier) classify(ua string) Result {
+	res := Result{Class: ClassUnknown}
+	if name, class := first(c.agents, ua); name != "" {
+		res.Name, res.Class = name, class
+	}
+	res.Browser, _ = first(c.browsers, ua)
+	res.OS, _ = first(c.oses, ua)
+	res.Device, _ = first(c.devices, ua)
+
+	if res.Name == "" && res.Browser != "" {
+		res.Class = ClassHuman
+	}
+	return res
+}
+
+func first(rules []rule, ua string) (string, string) {
+	for _, r := range rules {
+		if r.match(ua) {
+			return r.name, r.class
+		}
+	}
+	return "", ""
+}
+
+// Enrich sets the user agent fields of an entry
+func (c *Classifier) Enrich(entry *logs.TraefikLog) {
+	if c == nil || entry == nil {
+		return
+	}
+	res := c.Classify(entry.RequestUserAgent)
+	entry.UserAgentClass = res.Class
+	entry.UserAgentName = res.Name
+	entry.UserAgentBrowser = res.Browser
+	entry.UserAgentOS = res.OS
+	entry.UserAgentDevice = res.Device
+}
@@ -0,0 +1,168 @@
+package useragent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logs"
+)
+
+func TestClassify(t *testing.T) {
+	c, err := New(DefaultRules(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ua   string
+		want Result
+	}{
+		{
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+			Result{Class: ClassHuman, Browser: "Chrome", OS: "Windows", Device: "desktop"},
+		},
+		{
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36 Edg/124.0.2478.51",
+			Result{Class: ClassHuman, Browser: "Edge", OS: "Windows", Device: "desktop"},
+		},
+		{
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1",
+			Result{Class: ClassHuman, Browser: "Safari", OS: "iOS", Device: "mobile"},
+		},
+		{
+			"Mozilla/5.0 (iPad; CPU OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/124.0.6367.88 Mobile/15E148 Safari/604.1",
+			Result{Class: ClassHuman, Browser: "Chrome", OS: "iOS", Device: "tablet"},
+		},
+		{
+			"Mozilla/5.0 (Linux; Android 14; SM-X710) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+			Result{Class: ClassHuman, Browser: "Chrome", OS: "Android", Device: "tablet"},
+		},
+		{
+			"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Mobile Safari/537.36",
+			Result{Class: ClassHuman, Browser: "Chrome", OS: "Android", Device: "mobile"},
+		},
+		{
+			"Mozilla/5.0 (Macintosh; Intel Mac OS X 14.4; rv:125.0) Gecko/20100101 Firefox/125.0",
+			Result{Class: ClassHuman, Browser: "Firefox", OS: "macOS", Device: "desktop"},
+		},
+		{
+			"Mozilla/5.0 (X11; Linux x86_64; rv:125.0) Gecko/20100101 Firefox/125.0",
+			Result{Class: ClassHuman, Browser: "Firefox", OS: "Linux", Device: "desktop"},
+		},
+		{
+			"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			Result{Class: ClassCrawler, Name: "Googlebot"},
+		},
+		{
+			"Mozilla/5.0 (Linux; Android 6.0.1; Nexus 5X Build/MMB29P) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.6367.60 Mobile Safari/537.36 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			Result{Class: ClassCrawler, Name: "Googlebot", Browser: "Chrome", OS: "Android", Device: "mobile"},
+		},
+		{
+			"Mozilla/5.0 (compatible; SomeNewBot/1.0; +https://example.com/bot)",
+			Result{Class: ClassCrawler, Name: "Other crawler"},
+		},
+		{"Mozilla/5.0+(compatible; UptimeRobot/2.0; http://www.uptimerobot.com/)", Result{Class: ClassMonitor, Name: "UptimeRobot"}},
+		{"kube-probe/1.29", Result{Class: ClassMonitor, Name: "Kubernetes probe"}},
+		{"curl/8.5.0", Result{Class: ClassCLI, Name: "curl"}},
+		{"Wget/1.21.4", Result{Class: ClassCLI, Name: "Wget"}},
+		{"python-requests/2.31.0", Result{Class: ClassCLI, Name: "python-requests"}},
+		{"Go-http-client/1.1", Result{Class: ClassCLI, Name: "Go http client"}},
+		{"sqlmap/1.8#stable (https://sqlmap.org)", Result{Class: ClassScanner, Name: "sqlmap"}},
+		{"Mozilla/5.0 (compatible; Nmap Scripting Engine; https://nmap.org/book/nse.html)", Result{Class: ClassScanner, Name: "Nmap"}},
+		{"Mozilla/5.0 zgrab/0.x", Result{Class: ClassScanner, Name: "ZGrab"}},
+		{"", Result{Class: ClassUnknown}},
+		{"-", Result{Class: ClassUnknown}},
+		{"SomethingElse/1.0", Result{Class: ClassUnknown}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ua, func(t *testing.T) {
+			got := c.Classify(tt.ua)
+			if got != tt.want {
+				t.Errorf("Classify() = %+v, want %+v", got, tt.want)
+			}
+			// A second call is served from the cache
+			if again := c.Classify(tt.ua); again != got {
+				t.Errorf("cached Classify() = %+v, want %+v", again, got)
+			}
+		})
+	}
+}
+
+func TestEnrich(t *testing.T) {
+	c, err := New(DefaultRules(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry := &logs.TraefikLog{RequestUserAgent: "curl/8.5.0"}
+	c.Enrich(entry)
+	if entry.UserAgentClass != ClassCLI || entry.UserAgentName != "curl" {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+
+	var nilClassifier *Classifier
+	nilClassifier.Enrich(entry)
+	if res := nilClassifier.Classify("curl/8.5.0"); res != (Result{}) {
+		t.Errorf("nil classifier = %+v", res)
+	}
+}
+
+func TestCacheIsBounded(t *testing.T) {
+	c, err := New(DefaultRules(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ua := range []string{"curl/1", "curl/2", "curl/3"} {
+		c.Classify(ua)
+	}
+	if len(c.cache) > 2 {
+		t.Errorf("cache holds %d entries, want at most 2", len(c.cache))
+	}
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules Rules
+	}{
+		{"missing pattern", Rules{Browsers: []Rule{{Name: "X"}}}},
+		{"bad pattern", Rules{OS: []Rule{{Name: "X", Pattern: "("}}}},
+		{"bad unless", Rules{Devices: []Rule{{Name: "X", Pattern: "x", Unless: "("}}}},
+		{"agent without class", Rules{Agents: []Rule{{Name: "X", Pattern: "x"}}}},
+		{"agent marked human", Rules{Agents: []Rule{{Name: "X", Class: ClassHuman, Pattern: "x"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.rules, 0); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	path := filepath.Join(t.TempDir(), "rules.json")
+	os.WriteFile(path, []byte(`{"version":"test","agents":[{"name":"Probe","class":"monitor","pattern":"^probe/"}],"browsers":[{"name":"Chrome","pattern":"chrome/"}]}`), 0644)
+	rules, err := LoadRules(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := New(rules, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Version() != "test" {
+		t.Errorf("Version() = %q", c.Version())
+	}
+	if res := c.Classify("probe/1.0"); res != (Result{Class: ClassMonitor, Name: "Probe"}) {
+		t.Errorf("Classify(probe) = %+v", res)
+	}
+	if res := c.Classify("curl/8.5.0"); res.Class != ClassUnknown {
+		t.Errorf("replaced rules should not know curl: %+v", res)
+	}
+
+	os.WriteFile(path, []byte(`{`), 0644)
+	if _, err := LoadRules(path); err == nil {
+		t.Error("expected a parse error")
+	}
+}
@@ -0,0 +1,165 @@
+//go:build linux
+
+package watcher
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unsafe"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logger"
+	"golang.org/x/sys/unix"
+)
+
+const inotifyMask = unix.IN_MODIFY | unix.IN_CLOSE_WRITE | unix.IN_CREATE | unix.IN_DELETE |
+	unix.IN_MOVED_FROM | unix.IN_MOVED_TO | unix.IN_DELETE_SELF | unix.IN_MOVE_SELF
+
+// inotifyBackend watches directories with a single inotify instance
+type inotifyBackend struct {
+	file *os.File
+	fd   int
+	emit func(Event)
+
+	mu   sync.Mutex
+	wds  map[int]string
+	dirs map[string]int
+}
+
+func newNativeBackend(emit func(Event)) (backend, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &inotifyBackend{
+		// A non-blocking fd is registered with the runtime poller, so
+		// closing the file unblocks the reader goroutine.
+		file: os.NewFile(uintptr(fd), "inotify"),
+		fd:   fd,
+		emit: emit,
+		wds:  make(map[int]string),
+		dirs: make(map[string]int),
+	}
+	go b.readEvents()
+
+	return b, nil
+}
+
+func (b *inotifyBackend) add(dir string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	wd, err := unix.InotifyAddWatch(b.fd, dir, inotifyMask)
+	if err != nil {
+		return err
+	}
+	b.wds[wd] = dir
+	b.dirs[dir] = wd
+	return nil
+}
+
+func (b *inotifyBackend) remove(dir string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	wd, ok := b.dirs[dir]
+	if !ok {
+		return nil
+	}
+	delete(b.dirs, dir)
+	delete(b.wds, wd)
+
+	if _, err := unix.InotifyRmWatch(b.fd, uint32(wd)); err != nil && !errors.Is(err, unix.EINVAL) {
+		return err
+	}
+	return nil
+}
+
+func (b *inotifyBackend) close() error {
+	return b.file.Close()
+}
+
+func (b *inotifyBackend) readEvents() {
+	var buf [unix.SizeofInotifyEvent * 4096]byte
+
+	for {
+		n, err := b.file.Read(buf[:])
+		if err != nil {
+			if !errors.Is(err, os.ErrClosed) {
+				logger.Log.Printf("inotify read error: %v", err)
+			}
+			return
+		}
+
+		for offset := 0; offset+unix.SizeofInotifyEvent <= n; {
+			raw := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameLen := int(raw.Len)
+			nameStart := offset + unix.SizeofInotifyEvent
+			offset = nameStart + nameLen
+
+			if raw.Mask&unix.IN_Q_OVERFLOW != 0 {
+				b.emit(Event{Op: Overflow})
+				continue
+			}
+
+			b.mu.Lock()
+			dir, ok := b.wds[int(raw.Wd)]
+			if ok && raw.Mask&unix.IN_IGNORED != 0 {
+				// Watch removed by the kernel, e.g. directory deleted
+				delete(b.wds, int(raw.Wd))
+				delete(b.dirs, dir)
+			}
+			b.mu.Unlock()
+			if !ok {
+				continue
+			}
+
+			path := dir
+			if nameLen > 0 {
+				name := strings.TrimRight(string(buf[nameStart:nameStart+nameLen]), "\x00")
+				path = filepath.Join(dir, name)
+			}
+
+			if op := translateMask(raw.Mask); op != 0 {
+				b.emit(Event{Path: path, Op: op})
+			}
+		}
+	}
+}
+
+func translateMask(mask uint32) Op {
+	var op Op
+	if mask&(unix.IN_MODIFY|unix.IN_CLOSE_WRITE) != 0 {
+		op |= Write
+	}
+	if mask&(unix.IN_CREATE|unix.IN_MOVED_TO) != 0 {
+		op |= Create
+	}
+	if mask&(unix.IN_DELETE|unix.IN_DELETE_SELF) != 0 {
+		op |= Remove
+	}
+	if mask&(unix.IN_MOVED_FROM|unix.IN_MOVE_SELF) != 0 {
+		op |= Rename
+	}
+	return op
+}
+
+// isNetworkFilesystem reports whether dir lives on a filesystem where inotify
+// does not observe writes made by other hosts
+func isNetworkFilesystem(dir string) bool {
+	var st unix.Statfs_t
+	if err := unix.Statfs(dir, &st); err != nil {
+		return false
+	}
+
+	switch uint32(st.Type) {
+	case unix.NFS_SUPER_MAGIC, unix.SMB_SUPER_MAGIC, unix.CIFS_SUPER_MAGIC,
+		unix.FUSE_SUPER_MAGIC, unix.AFS_SUPER_MAGIC, unix.CODA_SUPER_MAGIC,
+		0xfe534d42: // SMB2
+		return true
+	}
+	return false
+}
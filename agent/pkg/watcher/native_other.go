@@ -0,0 +1,11 @@
+//go:build !linux
+
+package watcher
+
+func newNativeBackend(emit func(Event)) (backend, error) {
+	return nil, errNotSupported
+}
+
+func isNetworkFilesystem(dir string) bool {
+	return false
+}
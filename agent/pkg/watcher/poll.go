@@ -0,0 +1,148 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// pollBackend detects changes by periodically scanning watched directories.
+// It is used on platforms without inotify and for network filesystems.
+type pollBackend struct {
+	interval time.Duration
+	emit     func(Event)
+
+	mu      sync.Mutex
+	dirs    map[string]map[string]os.FileInfo
+	started bool
+	done    chan struct{}
+	once    sync.Once
+}
+
+func newPollBackend(interval time.Duration, emit func(Event)) *pollBackend {
+	return &pollBackend{
+		interval: interval,
+		emit:     emit,
+		dirs:     make(map[string]map[string]os.FileInfo),
+		done:     make(chan struct{}),
+	}
+}
+
+func (p *pollBackend) add(dir string) error {
+	snapshot, err := scanDir(dir)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.dirs[dir] = snapshot
+	if !p.started {
+		p.started = true
+		go p.run()
+	}
+	return nil
+}
+
+func (p *pollBackend) remove(dir string) error {
+	p.mu.Lock()
+	delete(p.dirs, dir)
+	p.mu.Unlock()
+	return nil
+}
+
+func (p *pollBackend) close() error {
+	p.once.Do(func() { close(p.done) })
+	return nil
+}
+
+func (p *pollBackend) run() {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+			for _, ev := range p.scan() {
+				p.emit(ev)
+			}
+		}
+	}
+}
+
+// scan compares every watched directory with its last snapshot. Events are
+// returned rather than emitted so the lock is not held while dispatching.
+func (p *pollBackend) scan() []Event {
+	p.mu.Lock()
+	dirs := make([]string, 0, len(p.dirs))
+	for dir := range p.dirs {
+		dirs = append(dirs, dir)
+	}
+	p.mu.Unlock()
+
+	var events []Event
+	for _, dir := range dirs {
+		current, err := scanDir(dir)
+		if err != nil {
+			// Directory vanished; report it so subscribers re-check
+			current = map[string]os.FileInfo{}
+		}
+
+		p.mu.Lock()
+		previous, ok := p.dirs[dir]
+		if !ok {
+			// Removed while scanning
+			p.mu.Unlock()
+			continue
+		}
+		p.dirs[dir] = current
+		p.mu.Unlock()
+
+		events = append(events, diffSnapshots(dir, previous, current)...)
+	}
+	return events
+}
+
+func scanDir(dir string) (map[string]os.FileInfo, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := make(map[string]os.FileInfo, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		snapshot[entry.Name()] = info
+	}
+	return snapshot, nil
+}
+
+func diffSnapshots(dir string, previous, current map[string]os.FileInfo) []Event {
+	var events []Event
+	for name, info := range current {
+		path := filepath.Join(dir, name)
+		old, ok := previous[name]
+		switch {
+		case !ok:
+			events = append(events, Event{Path: path, Op: Create})
+		case !os.SameFile(old, info):
+			// Replaced, e.g. rotated and recreated between two scans
+			events = append(events, Event{Path: path, Op: Create})
+		case old.Size() != info.Size() || !old.ModTime().Equal(info.ModTime()):
+			events = append(events, Event{Path: path, Op: Write})
+		}
+	}
+	for name := range previous {
+		if _, ok := current[name]; !ok {
+			events = append(events, Event{Path: filepath.Join(dir, name), Op: Remove})
+		}
+	}
+	return events
+}
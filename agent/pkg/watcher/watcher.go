@@ -0,0 +1,284 @@
+// Package watcher delivers change notifications for log files and directories.
+//
+// On Linux it uses inotify; on other platforms, and for directories on network
+// filesystems where inotify does not see remote writes, it falls back to
+// polling. Notifications are coalesced: a subscriber that has not consumed its
+// last wake-up receives a single pending wake-up for any number of changes.
+package watcher
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/agent/pkg/logger"
+)
+
+// Op describes the kind of change reported by an Event
+type Op uint32
+
+const (
+	Write Op = 1 << iota
+	Create
+	Remove
+	Rename
+	// Overflow means events were lost and every subscriber should re-check
+	Overflow
+)
+
+// Watch modes
+const (
+	ModeAuto   = "auto"
+	ModeNative = "inotify"
+	ModePoll   = "poll"
+)
+
+// DefaultPollInterval is used when no poll interval is configured
+const DefaultPollInterval = 250 * time.Millisecond
+
+var errNotSupported = errors.New("native file watching not supported on this platform")
+
+// Event is a change to a file inside a watched directory
+type Event struct {
+	Path string
+	Op   Op
+}
+
+// backend watches directories and reports changes to the files inside them
+type backend interface {
+	add(dir string) error
+	remove(dir string) error
+	close() error
+}
+
+// Options configures a Hub
+type Options struct {
+	// Mode selects the backend: auto, inotify or poll
+	Mode string
+	// PollInterval is the scan interval of the polling backend
+	PollInterval time.Duration
+}
+
+// Hub multiplexes directory watches across subscribers
+type Hub struct {
+	mode string
+
+	mu      sync.Mutex
+	native  backend
+	poller  *pollBackend
+	dirs    map[string]*watchedDir
+	subs    map[string]map[*Subscription]struct{}
+	closed  bool
+	dirOnly map[*Subscription]bool
+}
+
+type watchedDir struct {
+	refs    int
+	backend backend
+}
+
+// Subscription receives a wake-up on C whenever its path changes
+type Subscription struct {
+	C    <-chan struct{}
+	c    chan struct{}
+	hub  *Hub
+	path string
+	dir  string
+	once sync.Once
+}
+
+// New creates a Hub. When the native backend cannot be initialised the hub
+// falls back to polling.
+func New(opts Options) *Hub {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultPollInterval
+	}
+	if opts.Mode == "" {
+		opts.Mode = ModeAuto
+	}
+
+	h := &Hub{
+		mode:    opts.Mode,
+		dirs:    make(map[string]*watchedDir),
+		subs:    make(map[string]map[*Subscription]struct{}),
+		dirOnly: make(map[*Subscription]bool),
+	}
+	h.poller = newPollBackend(opts.PollInterval, h.dispatch)
+
+	if opts.Mode != ModePoll {
+		native, err := newNativeBackend(h.dispatch)
+		if err != nil {
+			logger.Log.Printf("File watching: falling back to polling every %v: %v", opts.PollInterval, err)
+		} else {
+			h.native = native
+		}
+	}
+
+	return h
+}
+
+// Mode returns the configured watch mode
+func (h *Hub) Mode() string {
+	return h.mode
+}
+
+// Subscribe watches a file or directory. A file subscription wakes on any
+// change to the file, including it being created, removed or renamed. A
+// directory subscription wakes when files are created, removed or renamed
+// inside it.
+func (h *Hub) Subscribe(path string) (*Subscription, error) {
+	path = filepath.Clean(path)
+
+	dir := filepath.Dir(path)
+	isDir := false
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		dir = path
+		isDir = true
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, fmt.Errorf("watcher is closed")
+	}
+
+	if err := h.watchDir(dir); err != nil {
+		return nil, err
+	}
+
+	c := make(chan struct{}, 1)
+	sub := &Subscription{C: c, c: c, hub: h, path: path, dir: dir}
+
+	if h.subs[path] == nil {
+		h.subs[path] = make(map[*Subscription]struct{})
+	}
+	h.subs[path][sub] = struct{}{}
+	if isDir {
+		h.dirOnly[sub] = true
+	}
+
+	return sub, nil
+}
+
+// watchDir adds a reference to a directory watch. Callers hold h.mu.
+func (h *Hub) watchDir(dir string) error {
+	if wd, ok := h.dirs[dir]; ok {
+		wd.refs++
+		return nil
+	}
+
+	var b backend = h.poller
+	if h.native != nil && (h.mode == ModeNative || !isNetworkFilesystem(dir)) {
+		b = h.native
+	}
+
+	if err := b.add(dir); err != nil {
+		if b == h.poller {
+			return err
+		}
+		// e.g. inotify watch limit reached
+		logger.Log.Printf("File watching: polling %s: %v", dir, err)
+		b = h.poller
+		if err := b.add(dir); err != nil {
+			return err
+		}
+	}
+
+	h.dirs[dir] = &watchedDir{refs: 1, backend: b}
+	return nil
+}
+
+// unwatchDir drops a reference to a directory watch. Callers hold h.mu.
+func (h *Hub) unwatchDir(dir string) {
+	wd, ok := h.dirs[dir]
+	if !ok {
+		return
+	}
+	wd.refs--
+	if wd.refs > 0 {
+		return
+	}
+	delete(h.dirs, dir)
+	if err := wd.backend.remove(dir); err != nil {
+		logger.Log.Printf("File watching: failed to remove watch on %s: %v", dir, err)
+	}
+}
+
+// dispatch wakes the subscribers affected by an event
+func (h *Hub) dispatch(ev Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if ev.Op&Overflow != 0 {
+		for _, subs := range h.subs {
+			for sub := range subs {
+				sub.wake()
+			}
+		}
+		return
+	}
+
+	path := filepath.Clean(ev.Path)
+	for sub := range h.subs[path] {
+		sub.wake()
+	}
+
+	// Directory subscribers only care about files appearing or disappearing
+	if ev.Op&(Create|Remove|Rename) != 0 {
+		for sub := range h.subs[filepath.Dir(path)] {
+			if h.dirOnly[sub] {
+				sub.wake()
+			}
+		}
+	}
+}
+
+// Close stops all backends. Open subscriptions stop receiving wake-ups.
+func (h *Hub) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil
+	}
+	h.closed = true
+
+	var firstErr error
+	if h.native != nil {
+		firstErr = h.native.close()
+	}
+	if err := h.poller.close(); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return firstErr
+}
+
+// wake delivers a coalesced wake-up without blocking
+func (s *Subscription) wake() {
+	select {
+	case s.c <- struct{}{}:
+	default:
+	}
+}
+
+// Close removes the subscription
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		h := s.hub
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		if subs, ok := h.subs[s.path]; ok {
+			delete(subs, s)
+			if len(subs) == 0 {
+				delete(h.subs, s.path)
+			}
+		}
+		delete(h.dirOnly, s)
+		h.unwatchDir(s.dir)
+	})
+}
@@ -0,0 +1,135 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func waitWake(t *testing.T, sub *Subscription, what string) {
+	t.Helper()
+	select {
+	case <-sub.C:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("no wake-up after %s", what)
+	}
+}
+
+func drain(sub *Subscription) {
+	for {
+		select {
+		case <-sub.C:
+		case <-time.After(100 * time.Millisecond):
+			return
+		}
+	}
+}
+
+func TestHubEvents(t *testing.T) {
+	modes := []string{ModePoll, ModeAuto}
+
+	for _, mode := range modes {
+		t.Run(mode, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "access.log")
+			if err := os.WriteFile(path, []byte("first\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			hub := New(Options{Mode: mode, PollInterval: 20 * time.Millisecond})
+			defer hub.Close()
+
+			fileSub, err := hub.Subscribe(path)
+			if err != nil {
+				t.Fatalf("subscribe file: %v", err)
+			}
+			defer fileSub.Close()
+
+			dirSub, err := hub.Subscribe(dir)
+			if err != nil {
+				t.Fatalf("subscribe dir: %v", err)
+			}
+			defer dirSub.Close()
+
+			f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := f.WriteString("second\n"); err != nil {
+				t.Fatal(err)
+			}
+			f.Close()
+			waitWake(t, fileSub, "write")
+
+			drain(dirSub)
+			if err := os.Rename(path, path+".1"); err != nil {
+				t.Fatal(err)
+			}
+			waitWake(t, dirSub, "rename")
+			waitWake(t, fileSub, "rename")
+
+			drain(fileSub)
+			if err := os.WriteFile(path, []byte("new\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			waitWake(t, fileSub, "create")
+
+			drain(dirSub)
+			if err := os.Remove(path); err != nil {
+				t.Fatal(err)
+			}
+			waitWake(t, dirSub, "remove")
+		})
+	}
+}
+
+func TestHubCoalescesBursts(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "access.log")
+
+	hub := New(Options{Mode: ModePoll, PollInterval: time.Hour})
+	defer hub.Close()
+
+	sub, err := hub.Subscribe(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+
+	for i := 0; i < 100; i++ {
+		hub.dispatch(Event{Path: path, Op: Write})
+	}
+
+	pending := 0
+	for done := false; !done; {
+		select {
+		case <-sub.C:
+			pending++
+		default:
+			done = true
+		}
+	}
+	if pending != 1 {
+		t.Fatalf("expected 1 coalesced wake-up, got %d", pending)
+	}
+}
+
+func TestSubscriptionClose(t *testing.T) {
+	dir := t.TempDir()
+	hub := New(Options{Mode: ModePoll, PollInterval: 20 * time.Millisecond})
+	defer hub.Close()
+
+	sub, err := hub.Subscribe(filepath.Join(dir, "access.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub.Close()
+	sub.Close()
+
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	if len(hub.dirs) != 0 || len(hub.subs) != 0 {
+		t.Fatalf("expected watches to be released, got %d dirs and %d paths", len(hub.dirs), len(hub.subs))
+	}
+}
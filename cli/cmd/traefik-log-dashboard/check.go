@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/config"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/logs/local"
+)
+
+// Results of a check
+const (
+	checkOK   = "ok"
+	checkWarn = "warn"
+	checkFail = "fail"
+)
+
+// checker runs the checks of one agent and prints their results
+type checker struct {
+	cfg    *config.Config
+	client *http.Client
+	failed int
+}
+
+func (c *checker) report(result, name, detail string) {
+	mark := map[string]string{checkOK: "✓", checkWarn: "!", checkFail: "✗"}[result]
+	fmt.Printf("%s %-14s %s\n", mark, name, detail)
+	if result == checkFail {
+		c.failed++
+	}
+}
+
+// get requests a path of the agent, with the token when auth is set
+func (c *checker) get(ctx context.Context, path string, auth bool) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.cfg.AgentURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if auth && c.cfg.AuthToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.cfg.AuthToken)
+	}
+	return c.client.Do(req)
+}
+
+func runCheck(cfg *config.Config, args []string) error {
+	fs := newFlagSet("check")
+	addAgentFlags(fs, cfg)
+	fs.StringVar(&cfg.AccessLogPath, "file", cfg.AccessLogPath, "check this access log instead of an agent")
+	timeout := fs.Duration("timeout", 5*time.Second, "timeout of each request")
+	if err := parseFlags(fs, cfg, args, false); err != nil {
+		return err
+	}
+
+	c := &checker{cfg: cfg, client: &http.Client{Timeout: *timeout}}
+	if cfg.LocalMode {
+		c.checkFile()
+	} else {
+		c.checkAgent(*timeout)
+	}
+	if c.failed > 0 {
+		fmt.Printf("\n%d check(s) failed\n", c.failed)
+		return errFailed
+	}
+	return nil
+}
+
+// checkFile checks that a local access log can be read and parsed
+func (c *checker) checkFile() {
+	info, err := os.Stat(c.cfg.AccessLogPath)
+	if err != nil {
+		c.report(checkFail, "Access log", err.Error())
+		return
+	}
+	c.report(checkOK, "Access log", fmt.Sprintf("%s, %d bytes, modified %s", c.cfg.AccessLogPath, info.Size(), info.ModTime().Format(time.RFC3339)))
+
+	entries, err := local.ReadHistory(local.Options{AccessPath: c.cfg.AccessLogPath, MaxEntries: 100, StripPrefix: stripRule(c.cfg)})
+	switch {
+	case err != nil:
+		c.report(checkFail, "Parsing", err.Error())
+	case len(entries) == 0:
+		c.report(checkWarn, "Parsing", "no access log entries in the last lines")
+	default:
+		c.report(checkOK, "Parsing", fmt.Sprintf("%d entries in the last lines", len(entries)))
+	}
+}
+
+// checkAgent checks connectivity, authentication, streaming and the
+// optional features of the agent
+func (c *checker) checkAgent(timeout time.Duration) {
+	ctx := context.Background()
+
+	if u, err := url.Parse(c.cfg.AgentURL); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		c.report(checkFail, "Agent URL", fmt.Sprintf("%q is not an http(s) URL", c.cfg.AgentURL))
+		return
+	}
+
+	// Connectivity: the status endpoint needs no token
+	start := time.Now()
+	resp, err := c.get(ctx, "/api/logs/status", false)
+	if err != nil {
+		c.report(checkFail, "Connectivity", err.Error())
+		return
+	}
+	var status struct {
+		AccessPath       string `json:"access_path"`
+		AccessPathExists bool   `json:"access_path_exists"`
+		AuthEnabled      *bool  `json:"auth_enabled"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&status)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || err != nil {
+		c.report(checkFail, "Connectivity", fmt.Sprintf("%s answered %d, is it a log dashboard agent?", c.cfg.AgentURL, resp.StatusCode))
+		return
+	}
+	c.report(checkOK, "Connectivity", fmt.Sprintf("%s answered in %s", c.cfg.AgentURL, time.Since(start).Round(time.Millisecond)))
+
+	if status.AccessPathExists {
+		c.report(checkOK, "Access logs", status.AccessPath)
+	} else {
+		c.report(checkWarn, "Access logs", fmt.Sprintf("the agent finds no logs at %s", status.AccessPath))
+	}
+
+	// Authentication, against an endpoint that reads nothing
+	resp, err = c.get(ctx, "/api/logs/files", true)
+	if err != nil {
+		c.report(checkFail, "Authentication", err.Error())
+		return
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusOK && c.cfg.AuthToken == "":
+		c.report(checkOK, "Authentication", "the agent requires no token")
+	case resp.StatusCode == http.StatusOK:
+		if status.AuthEnabled != nil && !*status.AuthEnabled {
+			c.report(checkWarn, "Authentication", "token sent, but the agent requires none")
+		} else {
+			c.report(checkOK, "Authentication", "token accepted")
+		}
+	case resp.StatusCode == http.StatusUnauthorized && c.cfg.AuthToken == "":
+		c.report(checkFail, "Authentication", "the agent requires a token: set AGENT_TOKEN or --token")
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		c.report(checkFail, "Authentication", fmt.Sprintf("token rejected: %s", strings.TrimSpace(string(body))))
+	default:
+		c.report(checkFail, "Authentication", fmt.Sprintf("agent returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body))))
+	}
+	if c.failed > 0 {
+		return
+	}
+
+	// Streaming; polling is used without it
+	streamCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	stream := &http.Client{}
+	req, _ := http.NewRequestWithContext(streamCtx, "GET", c.cfg.AgentURL+"/api/logs/stream", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	if c.cfg.AuthToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.cfg.AuthToken)
+	}
+	if resp, err := stream.Do(req); err != nil {
+		c.report(checkWarn, "Live stream", fmt.Sprintf("%v, the dashboard will poll", err))
+	} else {
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK && strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+			c.report(checkOK, "Live stream", "available")
+		} else {
+			c.report(checkWarn, "Live stream", fmt.Sprintf("agent returned status %d, the dashboard will poll", resp.StatusCode))
+		}
+	}
+
+	// Optional features
+	for _, feature := range []struct{ name, path string }{
+		{"Backend health", "/api/backends"},
+		{"SLOs", "/api/slo"},
+		{"Latency", "/api/latency"},
+		{"System stats", "/api/system/resources"},
+	} {
+		resp, err := c.get(ctx, feature.path, true)
+		if err != nil {
+			c.report(checkWarn, feature.name, err.Error())
+			continue
+		}
+		var body struct {
+			Status string `json:"status"`
+		}
+		json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body)
+		resp.Body.Close()
+		switch {
+		case resp.StatusCode == http.StatusNotFound:
+			c.report(checkWarn, feature.name, "not supported by this agent")
+		case resp.StatusCode != http.StatusOK:
+			c.report(checkWarn, feature.name, fmt.Sprintf("agent returned status %d", resp.StatusCode))
+		case body.Status == "disabled":
+			c.report(checkWarn, feature.name, "disabled on the agent")
+		default:
+			c.report(checkOK, feature.name, "enabled")
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/config"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/logs"
+)
+
+func runExport(cfg *config.Config, args []string) error {
+	fs := newFlagSet("export")
+	addAgentFlags(fs, cfg)
+	fs.StringVar(&cfg.ExportFormat, "format", cfg.ExportFormat, "csv, ndjson or columnar (EXPORT_FORMAT)")
+	fs.StringVar(&cfg.ExportSince, "since", cfg.ExportSince, "start: RFC 3339, Unix seconds or a duration back from now (EXPORT_SINCE)")
+	until := fs.String("until", "", "end, like --since; now by default")
+	columns := fs.String("columns", "", "comma separated columns, or all; the agent's defaults when empty")
+	limit := fs.Int("limit", 0, "rows at most, 0 for no limit")
+	fs.StringVar(&cfg.ExportDir, "o", cfg.ExportDir, "directory to save the export in, or - for stdout (EXPORT_DIR)")
+	if err := parseFlags(fs, cfg, args, false); err != nil {
+		return err
+	}
+
+	opts := logs.ExportOptions{
+		Format:  cfg.ExportFormat,
+		Since:   cfg.ExportSince,
+		Until:   *until,
+		Columns: splitList(*columns),
+		Limit:   *limit,
+	}
+
+	var (
+		result logs.ExportResult
+		err    error
+	)
+	if cfg.ExportDir == "-" {
+		result, err = logs.ExportLogs(cfg.AgentURL, cfg.AuthToken, opts, os.Stdout, nil)
+	} else {
+		result, err = logs.SaveExport(cfg.AgentURL, cfg.AuthToken, opts, cfg.ExportDir, nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	// The summary goes to stderr, out of the way of exports to stdout
+	msg := fmt.Sprintf("Exported %d rows (%d bytes)", result.Rows, result.Bytes)
+	if result.Path != "" {
+		msg += " to " + result.Path
+	}
+	if result.Truncated {
+		msg += " (truncated at the size limit)"
+	}
+	fmt.Fprintln(os.Stderr, msg)
+	return nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/config"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/logs"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/logs/filter"
+)
+
+// usageError reports bad flags or arguments
+type usageError struct {
+	msg string
+}
+
+func (e usageError) Error() string {
+	return e.msg
+}
+
+// newFlagSet returns the flags of a command, with its --help output
+func newFlagSet(name string) *flag.FlagSet {
+	cmd := findCommand(name)
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "%s\n\nUsage:\n  traefik-log-dashboard %s\n\nFlags:\n", cmd.summary, cmd.usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// addAgentFlags adds the flags that select the agent
+func addAgentFlags(fs *flag.FlagSet, cfg *config.Config) {
+	fs.StringVar(&cfg.AgentURL, "url", cfg.AgentURL, "agent URL (AGENT_URL)")
+	fs.StringVar(&cfg.AuthToken, "token", cfg.AuthToken, "agent token (AGENT_TOKEN)")
+}
+
+// addSourceFlags adds the flags that select where access logs come from:
+// the agent, local files, or stdin with a "-" argument
+func addSourceFlags(fs *flag.FlagSet, cfg *config.Config) {
+	addAgentFlags(fs, cfg)
+	fs.StringVar(&cfg.AccessLogPath, "file", cfg.AccessLogPath, "read this access log directly instead of asking an agent")
+	fs.StringVar(&cfg.StripPrefix, "strip-prefix", cfg.StripPrefix, "regular expression removed from the start of piped or local lines (STRIP_PREFIX)")
+}
+
+// parseFlags parses the arguments and applies the source flags. A "-"
+// argument reads stdin; autoPipe also reads it when it is not a terminal
+// and no source was given.
+func parseFlags(fs *flag.FlagSet, cfg *config.Config, args []string, autoPipe bool) error {
+	// Flags may follow a "-" argument too
+	dash := false
+	for {
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if fs.NArg() == 0 || fs.Arg(0) != "-" || dash {
+			break
+		}
+		dash, args = true, fs.Args()[1:]
+	}
+
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if set["file"] {
+		cfg.LocalMode = true
+	}
+
+	switch {
+	case fs.NArg() > 0:
+		return usageError{fmt.Sprintf("unexpected argument %q", fs.Arg(0))}
+	case dash, autoPipe && !set["file"] && !set["url"] && !isTerminal(os.Stdin):
+		cfg.LocalMode, cfg.PipeMode = true, true
+	}
+	return cfg.Validate()
+}
+
+// isTerminal reports whether f is a terminal rather than a pipe or file
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return true
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// entryFilter selects entries for tail, stats and top
+type entryFilter struct {
+	expr   string
+	parsed *filter.Filter
+	status string
+	router string
+	host   string
+	method string
+}
+
+func (f *entryFilter) addFlags(fs *flag.FlagSet) {
+	fs.StringVar(&f.expr, "filter", "", "filter expression, as typed after / in the dashboard, such as 'status:5xx dur>500ms'")
+	fs.StringVar(&f.status, "status", "", "only these statuses, such as 404 or 5xx, comma separated")
+	fs.StringVar(&f.router, "router", "", "only routers matching this glob, such as api*")
+	fs.StringVar(&f.host, "host", "", "only these request hosts, such as *.example.com, comma separated")
+	fs.StringVar(&f.method, "method", "", "only these request methods, comma separated")
+}
+
+// check validates the flags and builds the filter from them
+func (f *entryFilter) check() error {
+	parsed, err := filter.Parse(f.expr)
+	if err != nil {
+		return usageError{fmt.Sprintf("invalid filter: %v", err)}
+	}
+	// Each flag is a term of the filter language
+	for _, flag := range []struct{ name, value string }{
+		{filter.FieldStatus, strings.Join(splitList(f.status), ",")},
+		{filter.FieldRouter, f.router},
+		{filter.FieldHost, f.host},
+		{filter.FieldMethod, f.method},
+	} {
+		if flag.value == "" {
+			continue
+		}
+		if strings.ContainsAny(flag.value, " \t\"") {
+			return usageError{fmt.Sprintf("invalid --%s %q", flag.name, flag.value)}
+		}
+		term, err := filter.Parse(flag.name + ":" + flag.value)
+		if err != nil {
+			return usageError{fmt.Sprintf("invalid --%s: %v", flag.name, err)}
+		}
+		parsed.Terms = append(parsed.Terms, term.Terms...)
+	}
+	f.parsed = parsed
+	return nil
+}
+
+// match reports whether an entry passes the filter
+func (f *entryFilter) match(entry *logs.TraefikLog) bool {
+	return f.parsed.Match(entry)
+}
+
+// apply keeps the entries that pass the filter
+func (f *entryFilter) apply(entries []logs.TraefikLog) []logs.TraefikLog {
+	out := entries[:0]
+	for i := range entries {
+		if f.match(&entries[i]) {
+			out = append(out, entries[i])
+		}
+	}
+	return out
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// withinPeriod keeps the entries logged since the start of the period.
+// Entries without a readable timestamp are kept.
+func withinPeriod(entries []logs.TraefikLog, since time.Time) []logs.TraefikLog {
+	out := entries[:0]
+	for _, entry := range entries {
+		if t, err := logs.ParseTime(entry.StartUTC); err == nil && t.Before(since) {
+			continue
+		}
+		out = append(out, entry)
+	}
+	return out
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/logs"
+)
+
+func TestEntryFilter(t *testing.T) {
+	entries := []logs.TraefikLog{
+		{RequestMethod: "GET", RequestHost: "api.example.com", RouterName: "api@docker", DownstreamStatus: 200, Duration: 10e6},
+		{RequestMethod: "POST", RequestHost: "api.example.com", RouterName: "api@docker", DownstreamStatus: 503, Duration: 900e6},
+		{RequestMethod: "GET", RequestHost: "www.example.org", RouterName: "web@file", DownstreamStatus: 404, Duration: 5e6},
+	}
+	tests := []struct {
+		filter entryFilter
+		want   []int
+	}{
+		{entryFilter{}, []int{0, 1, 2}},
+		{entryFilter{status: "5xx"}, []int{1}},
+		{entryFilter{status: "404, 5xx"}, []int{1, 2}},
+		{entryFilter{router: "api*"}, []int{0, 1}},
+		{entryFilter{router: "api"}, nil},
+		{entryFilter{host: "API.example.com"}, []int{0, 1}},
+		{entryFilter{host: "*.example.org"}, []int{2}},
+		{entryFilter{method: "get"}, []int{0, 2}},
+		{entryFilter{method: "GET,POST"}, []int{0, 1, 2}},
+		{entryFilter{expr: "dur>100ms", router: "api*"}, []int{1}},
+		{entryFilter{expr: "-status:5xx", method: "GET", host: "api.example.com"}, []int{0}},
+	}
+	for _, tt := range tests {
+		f := tt.filter
+		if err := f.check(); err != nil {
+			t.Errorf("%+v: %v", tt.filter, err)
+			continue
+		}
+		var got []int
+		for i := range entries {
+			if f.match(&entries[i]) {
+				got = append(got, i)
+			}
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%+v: got %v, want %v", tt.filter, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%+v: got %v, want %v", tt.filter, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestEntryFilterErrors(t *testing.T) {
+	tests := []struct {
+		filter entryFilter
+		want   string
+	}{
+		{entryFilter{expr: `"open`}, "invalid filter"},
+		{entryFilter{status: "600"}, "--status"},
+		{entryFilter{status: "5x"}, "--status"},
+		{entryFilter{host: "a b"}, "--host"},
+		{entryFilter{method: `"GET"`}, "--method"},
+	}
+	for _, tt := range tests {
+		f := tt.filter
+		err := f.check()
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%+v: error %v, want one naming %s", tt.filter, err, tt.want)
+		}
+	}
+}
@@ -1,39 +1,116 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
-	tea "github.com/charmbracelet/bubbletea"
 	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/config"
-	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/model"
 	"github.com/joho/godotenv"
 )
 
+// Version information, set at build time
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildTime = "unknown"
+)
+
+// command is a subcommand of the CLI
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(cfg *config.Config, args []string) error
+}
+
+// commands lists the subcommands; the first is the default
+var commands []*command
+
+func init() {
+	// Set here as the commands look themselves up for their --help
+	commands = []*command{
+		{"tui", "tui [flags] [-]", "Run the interactive dashboard (default)", runTUI},
+		{"tail", "tail [flags] [-]", "Print access log lines as they arrive", runTail},
+		{"stats", "stats [flags] [-]", "Print a summary of a time range", runStats},
+		{"top", "top [flags] [-]", "Refresh the top routers and clients", runTop},
+		{"export", "export [flags]", "Save an export of access logs from the agent", runExport},
+		{"check", "check [flags]", "Check agent connectivity and authentication", runCheck},
+		{"version", "version [flags]", "Print version information", runVersion},
+	}
+}
+
+// errFailed is returned by commands that already reported why they failed
+var errFailed = errors.New("failed")
+
 func main() {
 	// Load .env file if exists
 	_ = godotenv.Load()
 
-	// Load configuration
+	// Load configuration; flags override it
 	cfg, err := config.Load()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Create initial model
-	m := model.NewModel(cfg)
-
-	// Create program
-	p := tea.NewProgram(
-		m,
-		tea.WithAltScreen(),
-		tea.WithMouseCellMotion(),
-	)
+	args := os.Args[1:]
+	cmd := commands[0]
+	if len(args) > 0 {
+		switch args[0] {
+		case "help", "-h", "-help", "--help":
+			if len(args) > 1 {
+				if c := findCommand(args[1]); c != nil {
+					c.run(cfg, []string{"--help"})
+					return
+				}
+			}
+			printUsage(os.Stdout)
+			return
+		case "-version", "--version":
+			args[0] = "version"
+		}
+		if c := findCommand(args[0]); c != nil {
+			cmd, args = c, args[1:]
+		} else if !strings.HasPrefix(args[0], "-") {
+			fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", args[0])
+			printUsage(os.Stderr)
+			os.Exit(2)
+		}
+	}
 
-	// Run the program
-	if _, err := p.Run(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error running program: %v\n", err)
+	if err := cmd.run(cfg, args); err != nil {
+		switch {
+		case errors.Is(err, flag.ErrHelp):
+			return
+		case errors.Is(err, errFailed):
+			os.Exit(1)
+		case errors.As(err, new(usageError)):
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(2)
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
-}
\ No newline at end of file
+}
+
+func findCommand(name string) *command {
+	for _, c := range commands {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "Traefik Log Dashboard CLI\n\nUsage:\n  traefik-log-dashboard [command] [flags]\n\nCommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-8s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintf(w, "\nRun 'traefik-log-dashboard <command> --help' for the flags of a command.\n")
+	fmt.Fprintf(w, "Flags override the environment variables described in the README.\n")
+}
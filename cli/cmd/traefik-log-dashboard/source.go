@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/config"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/logs"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/logs/local"
+)
+
+// stripRule compiles the configured prefix rule, checked by Validate
+func stripRule(cfg *config.Config) *regexp.Regexp {
+	if cfg.StripPrefix == "" {
+		return nil
+	}
+	return regexp.MustCompile(cfg.StripPrefix)
+}
+
+// readEntries reads the entries logged since a time, at most limit of
+// them: from stdin until it is closed, from the history of a local file, or
+// from an export of the agent
+func readEntries(cfg *config.Config, since time.Time, limit int) ([]logs.TraefikLog, error) {
+	var entries []logs.TraefikLog
+	switch {
+	case cfg.PipeMode:
+		return readPipe(os.Stdin, stripRule(cfg), since, limit)
+	case cfg.LocalMode:
+		var err error
+		entries, err = local.ReadHistory(local.Options{
+			AccessPath:  cfg.AccessLogPath,
+			MaxEntries:  limit,
+			StripPrefix: stripRule(cfg),
+		})
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return logs.FetchEntries(cfg.AgentURL, cfg.AuthToken, logs.ExportOptions{
+			Since: since.UTC().Format(time.RFC3339),
+			Limit: limit,
+			Tail:  true,
+		})
+	}
+	if !since.IsZero() {
+		entries = withinPeriod(entries, since)
+	}
+	return entries, nil
+}
+
+// readPipe reads r until it is closed, keeping only the last limit entries
+// logged since a time
+func readPipe(r io.Reader, strip *regexp.Regexp, since time.Time, limit int) ([]logs.TraefikLog, error) {
+	ring := logs.NewRing(limit)
+	pipe := local.NewPipe(r, strip)
+	pipe.Start()
+	for batch := range pipe.C() {
+		if batch.Err != nil {
+			return nil, batch.Err
+		}
+		if !since.IsZero() {
+			batch.Entries = withinPeriod(batch.Entries, since)
+		}
+		ring.Push(batch.Entries...)
+	}
+	return ring.Entries(), nil
+}
+
+// follow delivers entries as they are logged until ctx is done or stdin is
+// closed. Local files start with up to history entries. The agent's stream
+// falls back to polling while it is unavailable. Changes of state are
+// reported on stderr.
+func follow(ctx context.Context, cfg *config.Config, history int, deliver func([]logs.TraefikLog)) error {
+	var source interface {
+		C() <-chan logs.StreamBatch
+		Start()
+		Stop()
+	}
+	switch {
+	case cfg.PipeMode:
+		source = local.NewPipe(os.Stdin, stripRule(cfg))
+	case cfg.LocalMode:
+		source = local.New(local.Options{
+			AccessPath:  cfg.AccessLogPath,
+			MaxEntries:  history,
+			StripPrefix: stripRule(cfg),
+		})
+	default:
+		source = logs.NewStreamer(cfg.AgentURL, cfg.AuthToken)
+	}
+	source.Start()
+	defer source.Stop()
+
+	// Polling runs while the agent cannot stream
+	var ticker *time.Ticker
+	var poll <-chan time.Time
+	defer func() {
+		if ticker != nil {
+			ticker.Stop()
+		}
+	}()
+
+	state := ""
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-poll:
+			entries, err := logs.FetchAccessLogs(cfg.AgentURL, cfg.AuthToken, cfg.MaxLogs)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "poll failed: %v\n", err)
+				continue
+			}
+			deliver(entries)
+		case batch, ok := <-source.C():
+			if !ok {
+				return nil
+			}
+			if batch.State != state {
+				state = batch.State
+				reportState(batch)
+			}
+			if batch.State == logs.StreamPolling && ticker == nil {
+				ticker = time.NewTicker(cfg.RefreshInterval)
+				poll = ticker.C
+			} else if batch.State == logs.StreamLive && ticker != nil {
+				ticker.Stop()
+				ticker, poll = nil, nil
+			}
+			if len(batch.Entries) > 0 {
+				deliver(batch.Entries)
+			}
+			if batch.State == logs.StreamEnded && batch.Err != nil {
+				return batch.Err
+			}
+		}
+	}
+}
+
+// reportState explains on stderr why entries may not arrive
+func reportState(batch logs.StreamBatch) {
+	switch batch.State {
+	case logs.StreamReconnecting:
+		fmt.Fprintf(os.Stderr, "stream lost (%v), reconnecting in %s\n", batch.Err, batch.Retry)
+	case logs.StreamPolling:
+		fmt.Fprintf(os.Stderr, "stream unavailable (%v), polling\n", batch.Err)
+	case logs.StreamWaiting:
+		fmt.Fprintf(os.Stderr, "waiting for the log file: %v\n", batch.Err)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadPipe(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	var input strings.Builder
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&input, `{"RequestPath":"/%d","StartUTC":%q}`+"\n", i, start.Add(time.Duration(i)*time.Second).Format(time.RFC3339Nano))
+		input.WriteString("not an entry\n")
+	}
+
+	tests := []struct {
+		name  string
+		since time.Time
+		limit int
+		want  []string
+	}{
+		{"last entries", time.Time{}, 3, []string{"/1997", "/1998", "/1999"}},
+		{"all within the limit", start.Add(1998 * time.Second), 10, []string{"/1998", "/1999"}},
+		{"since and limit", start.Add(1000 * time.Second), 2, []string{"/1998", "/1999"}},
+		{"none since", start.Add(time.Hour), 10, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := readPipe(strings.NewReader(input.String()), nil, tt.since, tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, entry := range entries {
+				got = append(got, entry.RequestPath)
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("paths = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/config"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/logs"
+)
+
+// statsReport is the output of stats
+type statsReport struct {
+	Source         string         `json:"source"`
+	Since          *time.Time     `json:"since,omitempty"`
+	Requests       int            `json:"requests"`
+	RequestsPerSec float64        `json:"requests_per_sec"`
+	ErrorRate      float64        `json:"error_rate"`
+	Status         map[string]int `json:"status"`
+	AvgMS          float64        `json:"avg_ms"`
+	P95MS          float64        `json:"p95_ms"`
+	P99MS          float64        `json:"p99_ms"`
+	TopRouters     []statsRow     `json:"top_routers"`
+	TopServices    []statsRow     `json:"top_services"`
+	TopRoutes      []statsRow     `json:"top_routes"`
+	TopClients     []statsRow     `json:"top_clients"`
+}
+
+// statsRow is one line of a top list
+type statsRow struct {
+	Name      string   `json:"name"`
+	Count     int      `json:"count"`
+	AvgMS     *float64 `json:"avg_ms,omitempty"`
+	ErrorRate *float64 `json:"error_rate,omitempty"`
+}
+
+func runStats(cfg *config.Config, args []string) error {
+	fs := newFlagSet("stats")
+	addSourceFlags(fs, cfg)
+	var filter entryFilter
+	filter.addFlags(fs)
+	period := fs.Duration("period", time.Hour, "time range to summarize, back from now; with --file or stdin only when set")
+	top := fs.Int("top", 10, "entries of each top list")
+	limit := fs.Int("limit", 100000, "entries read at most")
+	asJSON := fs.Bool("json", false, "print JSON instead of a table")
+	if err := parseFlags(fs, cfg, args, false); err != nil {
+		return err
+	}
+	if err := filter.check(); err != nil {
+		return err
+	}
+	if *period <= 0 {
+		return usageError{"--period must be positive"}
+	}
+	if *top <= 0 {
+		return usageError{"--top must be positive"}
+	}
+	if *limit <= 0 {
+		return usageError{"--limit must be positive"}
+	}
+
+	// Local entries are summed whole unless a period is asked for
+	var since time.Time
+	periodSet := false
+	fs.Visit(func(f *flag.Flag) { periodSet = periodSet || f.Name == "period" })
+	if !cfg.LocalMode || periodSet {
+		since = time.Now().Add(-*period)
+	}
+
+	entries, err := readEntries(cfg, since, *limit)
+	if err != nil {
+		return err
+	}
+	entries = filter.apply(entries)
+
+	report := buildStats(entries, *top)
+	report.Source = sourceName(cfg)
+	if !since.IsZero() {
+		report.Since = &since
+		report.RequestsPerSec = float64(report.Requests) / period.Seconds()
+	} else if span := timeSpan(entries); span > 0 {
+		report.RequestsPerSec = float64(report.Requests) / span.Seconds()
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	printStats(os.Stdout, report)
+	return nil
+}
+
+func buildStats(entries []logs.TraefikLog, top int) statsReport {
+	m := logs.CalculateMetricsTop(entries, top)
+	report := statsReport{
+		Requests:  m.TotalRequests,
+		ErrorRate: m.ErrorRate,
+		Status: map[string]int{
+			"2xx": m.Status2xx,
+			"3xx": m.Status3xx,
+			"4xx": m.Status4xx,
+			"5xx": m.Status5xx,
+		},
+		AvgMS:       m.AvgResponseTime,
+		P95MS:       m.P95ResponseTime,
+		P99MS:       m.P99ResponseTime,
+		TopRouters:  []statsRow{},
+		TopServices: []statsRow{},
+		TopRoutes:   []statsRow{},
+		TopClients:  []statsRow{},
+	}
+	for _, r := range m.TopRouters {
+		report.TopRouters = append(report.TopRouters, statsRow{Name: r.Name, Count: r.Count, AvgMS: ptr(r.AvgDuration)})
+	}
+	for _, s := range m.TopServices {
+		report.TopServices = append(report.TopServices, statsRow{Name: s.Name, Count: s.Count, AvgMS: ptr(s.AvgDuration), ErrorRate: ptr(s.ErrorRate)})
+	}
+	for _, r := range m.TopRoutes {
+		report.TopRoutes = append(report.TopRoutes, statsRow{Name: r.Method + " " + r.Path, Count: r.Count, AvgMS: ptr(r.AvgDuration)})
+	}
+	for _, c := range m.TopClients {
+		report.TopClients = append(report.TopClients, statsRow{Name: c.Host, Count: c.Count, ErrorRate: ptr(c.ErrorRate)})
+	}
+	return report
+}
+
+func ptr(f float64) *float64 {
+	return &f
+}
+
+func printStats(w io.Writer, r statsReport) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	since := "all entries"
+	if r.Since != nil {
+		since = "since " + r.Since.Local().Format("2006-01-02 15:04:05")
+	}
+	fmt.Fprintf(tw, "Source\t%s (%s)\n", r.Source, since)
+	fmt.Fprintf(tw, "Requests\t%d\t%.2f req/s\n", r.Requests, r.RequestsPerSec)
+	fmt.Fprintf(tw, "Status\t2xx %d\t3xx %d\t4xx %d\t5xx %d\n", r.Status["2xx"], r.Status["3xx"], r.Status["4xx"], r.Status["5xx"])
+	fmt.Fprintf(tw, "Error rate\t%.2f%%\n", r.ErrorRate)
+	fmt.Fprintf(tw, "Latency\tavg %.1fms\tp95 %.1fms\tp99 %.1fms\n", r.AvgMS, r.P95MS, r.P99MS)
+	tw.Flush()
+
+	printRows(w, "Top routers", r.TopRouters)
+	printRows(w, "Top services", r.TopServices)
+	printRows(w, "Top routes", r.TopRoutes)
+	printRows(w, "Top clients", r.TopClients)
+}
+
+// printRows prints a top list as a table
+func printRows(w io.Writer, title string, rows []statsRow) {
+	if len(rows) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n%s\n", title)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "COUNT\tAVG\tERRORS\t\n")
+	for _, row := range rows {
+		avg, errs := "-", "-"
+		if row.AvgMS != nil {
+			avg = formatDuration(time.Duration(*row.AvgMS * float64(time.Millisecond)))
+		}
+		if row.ErrorRate != nil {
+			errs = fmt.Sprintf("%.1f%%", *row.ErrorRate)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t  %s\n", row.Count, avg, errs, row.Name)
+	}
+	tw.Flush()
+}
+
+// sourceName describes where entries come from
+func sourceName(cfg *config.Config) string {
+	switch {
+	case cfg.PipeMode:
+		return "stdin"
+	case cfg.LocalMode:
+		return cfg.AccessLogPath
+	default:
+		return cfg.AgentURL
+	}
+}
+
+// timeSpan is the time between the first and the last entry
+func timeSpan(entries []logs.TraefikLog) time.Duration {
+	var first, last time.Time
+	for _, entry := range entries {
+		t, err := logs.ParseTime(entry.StartUTC)
+		if err != nil {
+			continue
+		}
+		if first.IsZero() || t.Before(first) {
+			first = t
+		}
+		if t.After(last) {
+			last = t
+		}
+	}
+	return last.Sub(first)
+}
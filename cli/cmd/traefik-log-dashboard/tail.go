@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/config"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/logs"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/ui/styles"
+	"github.com/muesli/termenv"
+)
+
+func runTail(cfg *config.Config, args []string) error {
+	fs := newFlagSet("tail")
+	addSourceFlags(fs, cfg)
+	var filter entryFilter
+	filter.addFlags(fs)
+	lines := fs.Int("lines", 10, "entries of history to print first, with --file")
+	fs.IntVar(lines, "n", 10, "shorthand for --lines")
+	asJSON := fs.Bool("json", false, "print entries as JSON lines")
+	noColor := fs.Bool("no-color", os.Getenv("NO_COLOR") != "", "disable colours (NO_COLOR)")
+	if err := parseFlags(fs, cfg, args, false); err != nil {
+		return err
+	}
+	if err := filter.check(); err != nil {
+		return err
+	}
+	if *noColor {
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	enc := json.NewEncoder(os.Stdout)
+	return follow(ctx, cfg, *lines, func(entries []logs.TraefikLog) {
+		for i := range entries {
+			entry := &entries[i]
+			if !filter.match(entry) {
+				continue
+			}
+			if *asJSON {
+				enc.Encode(entry)
+				continue
+			}
+			fmt.Println(formatLine(entry))
+		}
+	})
+}
+
+var (
+	tailTimeStyle   = lipgloss.NewStyle().Foreground(styles.Muted)
+	tailMethodStyle = lipgloss.NewStyle().Bold(true)
+	tailRouterStyle = lipgloss.NewStyle().Foreground(styles.Primary)
+)
+
+// formatLine renders an entry as one line: time, status, method, URL,
+// duration and router
+func formatLine(entry *logs.TraefikLog) string {
+	ts := entry.StartUTC
+	if t, err := logs.ParseTime(entry.StartUTC); err == nil {
+		ts = t.Local().Format("15:04:05.000")
+	}
+
+	status := fmt.Sprintf("%3d", entry.DownstreamStatus)
+	switch {
+	case entry.DownstreamStatus >= 500:
+		status = styles.ErrorStyle.Render(status)
+	case entry.DownstreamStatus >= 400:
+		status = styles.WarningStyle.Render(status)
+	case entry.DownstreamStatus >= 300:
+		status = styles.AccentStyle.Render(status)
+	default:
+		status = styles.SuccessStyle.Render(status)
+	}
+
+	duration := time.Duration(entry.Duration)
+	elapsed := formatDuration(duration)
+	switch {
+	case duration >= time.Second:
+		elapsed = styles.ErrorStyle.Render(elapsed)
+	case duration >= 300*time.Millisecond:
+		elapsed = styles.WarningStyle.Render(elapsed)
+	}
+
+	parts := []string{
+		tailTimeStyle.Render(ts),
+		status,
+		tailMethodStyle.Render(fmt.Sprintf("%-6s", entry.RequestMethod)),
+		entry.RequestHost + entry.RequestPath,
+		elapsed,
+	}
+	if entry.RouterName != "" {
+		parts = append(parts, tailRouterStyle.Render(entry.RouterName))
+	}
+	if entry.ClientHost != "" {
+		parts = append(parts, tailTimeStyle.Render(entry.ClientHost))
+	}
+	return strings.Join(parts, " ")
+}
+
+// formatDuration shortens a duration for tables and lines
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return fmt.Sprintf("%.2fs", d.Seconds())
+	case d >= 10*time.Millisecond:
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	default:
+		return fmt.Sprintf("%.1fms", float64(d)/float64(time.Millisecond))
+	}
+}
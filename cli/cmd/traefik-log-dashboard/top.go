@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/config"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/logs"
+)
+
+// maxTopEntries caps the entries top keeps in its window
+const maxTopEntries = 100000
+
+// topWindow holds the entries logged within the last period
+type topWindow struct {
+	mu      sync.Mutex
+	period  time.Duration
+	entries []logs.TraefikLog
+	times   []time.Time
+}
+
+// add keeps entries by the time they were logged, or by now for those
+// without a readable time
+func (w *topWindow) add(now time.Time, entries ...logs.TraefikLog) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, entry := range entries {
+		at := now
+		if t, err := logs.ParseTime(entry.StartUTC); err == nil {
+			at = t
+		}
+		w.entries = append(w.entries, entry)
+		w.times = append(w.times, at)
+	}
+	if over := len(w.entries) - maxTopEntries; over > 0 {
+		w.entries, w.times = w.entries[over:], w.times[over:]
+	}
+}
+
+// snapshot drops the entries older than the period and returns the rest
+func (w *topWindow) snapshot(now time.Time) []logs.TraefikLog {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	// Entries are not quite in order across sources; look at each
+	entries, times := w.entries[:0], w.times[:0]
+	for i, at := range w.times {
+		if now.Sub(at) <= w.period {
+			entries, times = append(entries, w.entries[i]), append(times, at)
+		}
+	}
+	w.entries, w.times = entries, times
+	return append([]logs.TraefikLog(nil), w.entries...)
+}
+
+func runTop(cfg *config.Config, args []string) error {
+	fs := newFlagSet("top")
+	addSourceFlags(fs, cfg)
+	var filter entryFilter
+	filter.addFlags(fs)
+	period := fs.Duration("period", 5*time.Minute, "window of the ranking")
+	top := fs.Int("top", 10, "rows of each list")
+	fs.DurationVar(&cfg.RefreshInterval, "refresh", cfg.RefreshInterval, "refresh interval (REFRESH_INTERVAL)")
+	count := fs.Int("count", 0, "refreshes before exiting, 0 for no limit")
+	if err := parseFlags(fs, cfg, args, false); err != nil {
+		return err
+	}
+	if err := filter.check(); err != nil {
+		return err
+	}
+	if *period <= 0 {
+		return usageError{"--period must be positive"}
+	}
+	if *top <= 0 {
+		return usageError{"--top must be positive"}
+	}
+	if *count < 0 {
+		return usageError{"--count must be zero or more"}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	window := &topWindow{period: *period}
+	if !cfg.LocalMode {
+		// The agent streams new entries only; start from the recent ones
+		since := time.Now().Add(-*period)
+		entries, err := readEntries(cfg, since, maxTopEntries)
+		if err != nil {
+			return err
+		}
+		window.add(time.Now(), filter.apply(entries)...)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- follow(ctx, cfg, cfg.MaxLogs, func(entries []logs.TraefikLog) {
+			window.add(time.Now(), filter.apply(entries)...)
+		})
+	}()
+
+	clear := isTerminal(os.Stdout)
+	ticker := time.NewTicker(cfg.RefreshInterval)
+	defer ticker.Stop()
+	for n := 1; ; n++ {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-done:
+			// Stdin was closed: show what was read
+			printTop(os.Stdout, window.snapshot(time.Now()), *period, *top, clear)
+			return err
+		case <-ticker.C:
+		}
+		printTop(os.Stdout, window.snapshot(time.Now()), *period, *top, clear)
+		if *count > 0 && n >= *count {
+			return nil
+		}
+	}
+}
+
+func printTop(w io.Writer, entries []logs.TraefikLog, period time.Duration, top int, clear bool) {
+	m := logs.CalculateMetricsTop(entries, top)
+	if clear {
+		fmt.Fprint(w, "\033[H\033[2J")
+	} else {
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "%s  last %s  %d requests  %.2f req/s  %.1f%% errors  p95 %s\n\n",
+		time.Now().Format("15:04:05"), period, m.TotalRequests,
+		float64(m.TotalRequests)/period.Seconds(), m.ErrorRate,
+		formatDuration(time.Duration(m.P95ResponseTime*float64(time.Millisecond))))
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "REQUESTS\tSHARE\tAVG\t  ROUTER\n")
+	for _, r := range m.TopRouters {
+		fmt.Fprintf(tw, "%d\t%.1f%%\t%s\t  %s\n", r.Count, share(r.Count, m.TotalRequests),
+			formatDuration(time.Duration(r.AvgDuration*float64(time.Millisecond))), r.Name)
+	}
+	tw.Flush()
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(tw, "REQUESTS\tSHARE\tERRORS\t  CLIENT\n")
+	for _, c := range m.TopClients {
+		fmt.Fprintf(tw, "%d\t%.1f%%\t%.1f%%\t  %s\n", c.Count, share(c.Count, m.TotalRequests), c.ErrorRate, c.Host)
+	}
+	tw.Flush()
+}
+
+func share(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total) * 100
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/logs"
+)
+
+func TestTopWindow(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	at := func(path string, ago time.Duration) logs.TraefikLog {
+		return logs.TraefikLog{RequestPath: path, StartUTC: now.Add(-ago).Format(time.RFC3339Nano)}
+	}
+
+	w := &topWindow{period: 5 * time.Minute}
+	// Replayed history arrives all at once, partly out of order
+	w.add(now,
+		at("/old", time.Hour),
+		at("/recent", time.Minute),
+		at("/edge", 5*time.Minute),
+		at("/older", 6*time.Minute),
+		logs.TraefikLog{RequestPath: "/untimed"},
+	)
+
+	paths := func(entries []logs.TraefikLog) string {
+		var out []string
+		for _, entry := range entries {
+			out = append(out, entry.RequestPath)
+		}
+		return strings.Join(out, " ")
+	}
+	if got := paths(w.snapshot(now)); got != "/recent /edge /untimed" {
+		t.Errorf("snapshot = %s", got)
+	}
+	// Entries without a time count from when they arrived
+	if got := paths(w.snapshot(now.Add(3 * time.Minute))); got != "/recent /untimed" {
+		t.Errorf("later snapshot = %s", got)
+	}
+	if got := paths(w.snapshot(now.Add(time.Hour))); got != "" {
+		t.Errorf("expired snapshot = %s", got)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/config"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/model"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/ui/dashboard"
+)
+
+func runTUI(cfg *config.Config, args []string) error {
+	fs := newFlagSet("tui")
+	addSourceFlags(fs, cfg)
+	fs.StringVar(&cfg.ErrorLogPath, "error-file", cfg.ErrorLogPath, "error log to tail along with --file (ERROR_LOG_PATH)")
+	fs.DurationVar(&cfg.RefreshInterval, "refresh", cfg.RefreshInterval, "refresh interval (REFRESH_INTERVAL)")
+	fs.IntVar(&cfg.MaxLogs, "max-logs", cfg.MaxLogs, "entries kept for the dashboard (MAX_LOGS)")
+	fs.BoolVar(&cfg.DemoMode, "demo", cfg.DemoMode, "run with demo data (DEMO_MODE)")
+	fs.Func("columns", "access log columns, comma-separated (LOG_COLUMNS)", func(s string) error {
+		cfg.Columns = config.ParseColumns(s)
+		return nil
+	})
+	fs.Func("sort", "access log sort column; a leading - sorts descending", func(s string) error {
+		cfg.SortDesc = strings.HasPrefix(s, "-")
+		cfg.SortBy = strings.TrimPrefix(s, "-")
+		return nil
+	})
+	fs.Func("layout", "dashboard cards, rows separated by ; (DASHBOARD_LAYOUT)", func(s string) error {
+		cfg.Layout = strings.Split(s, ";")
+		return nil
+	})
+	if err := parseFlags(fs, cfg, args, true); err != nil {
+		return err
+	}
+	if len(cfg.Layout) > 0 {
+		if _, err := dashboard.ParseLayout(cfg.Layout); err != nil {
+			return usageError{fmt.Sprintf("invalid layout: %v", err)}
+		}
+	}
+
+	// Create program; with piped input the keyboard is read from the
+	// terminal
+	opts := []tea.ProgramOption{
+		tea.WithAltScreen(),
+		tea.WithMouseCellMotion(),
+	}
+	if cfg.PipeMode {
+		opts = append(opts, tea.WithInputTTY())
+	}
+	p := tea.NewProgram(model.NewModel(cfg), opts...)
+
+	_, err := p.Run()
+	return err
+}
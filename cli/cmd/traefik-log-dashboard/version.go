@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/config"
+)
+
+func runVersion(cfg *config.Config, args []string) error {
+	fs := newFlagSet("version")
+	short := fs.Bool("short", false, "print the version only")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *short {
+		fmt.Println(Version)
+		return nil
+	}
+	fmt.Printf("traefik-log-dashboard %s\n", Version)
+	fmt.Printf("  commit:  %s\n", Commit)
+	fmt.Printf("  built:   %s\n", BuildTime)
+	fmt.Printf("  go:      %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	return nil
+}
@@ -2,10 +2,13 @@ package config
 
 import (
 	"fmt"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/env"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/logs/local"
 )
 
 // Config holds the application configuration
@@ -22,9 +25,34 @@ type Config struct {
 	RefreshInterval time.Duration
 	MaxLogs         int
 	
+	// Layout lists the rows of dashboard cards; empty for the default
+	Layout          []string
+	
+	// Access log table: the columns shown, in order, and the column the
+	// entries are sorted by. They are kept in SettingsFile.
+	Columns         []string
+	SortBy          string
+	SortDesc        bool
+	SettingsFile    string
+	
 	// Feature flags
 	DemoMode          bool
 	SystemMonitoring  bool
+	// Streaming follows the agent's SSE stream, polling only when it is
+	// unavailable
+	Streaming         bool
+	// LocalMode reads the log files directly instead of asking an agent
+	LocalMode         bool
+	// PipeMode reads access logs from stdin, in local mode
+	PipeMode          bool
+	// StripPrefix is a regular expression removed from the start of local
+	// access log lines, such as the pod and timestamp kubectl adds
+	StripPrefix       string
+
+	// Export settings
+	ExportDir    string
+	ExportFormat string
+	ExportSince  string
 }
 
 // Load creates a new Config with values from environment or defaults
@@ -38,14 +66,43 @@ func Load() (*Config, error) {
 		MaxLogs:          parseInt(env.GetEnv("MAX_LOGS", "1000")),
 		DemoMode:         parseBool(env.GetEnv("DEMO_MODE", "false")),
 		SystemMonitoring: parseBool(env.GetEnv("SYSTEM_MONITORING", "true")),
+		Streaming:        parseBool(env.GetEnv("STREAMING", "true")),
+		LocalMode:        parseBool(env.GetEnv("LOCAL_MODE", "false")),
+		StripPrefix:      env.GetEnv("STRIP_PREFIX", local.DefaultStripPrefix),
+		ExportDir:        env.GetEnv("EXPORT_DIR", "."),
+		ExportFormat:     env.GetEnv("EXPORT_FORMAT", "csv"),
+		ExportSince:      env.GetEnv("EXPORT_SINCE", "1h"),
+		SettingsFile:     settingsPath(),
+	}
+
+	settings, err := loadSettings(cfg.SettingsFile)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Layout = settings.Layout
+	if layout := env.GetEnv("DASHBOARD_LAYOUT", ""); layout != "" {
+		cfg.Layout = strings.Split(layout, ";")
+	}
+	cfg.Columns = settings.Columns
+	cfg.SortBy = settings.SortBy
+	cfg.SortDesc = settings.SortDesc
+	if columns := env.GetEnv("LOG_COLUMNS", ""); columns != "" {
+		cfg.Columns = ParseColumns(columns)
+	}
+	if len(cfg.Columns) == 0 {
+		cfg.Columns = DefaultColumns
 	}
 
-	return cfg, cfg.Validate()
+	return cfg, nil
 }
 
 // Validate checks if the configuration is valid
 func (c *Config) Validate() error {
-	if c.AgentURL == "" {
+	if c.LocalMode {
+		if c.AccessLogPath == "" && !c.PipeMode {
+			return fmt.Errorf("access log path cannot be empty in local mode")
+		}
+	} else if c.AgentURL == "" {
 		return fmt.Errorf("agent URL cannot be empty")
 	}
 
@@ -57,6 +114,25 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("max logs must be at least 1")
 	}
 
+	if _, err := regexp.Compile(c.StripPrefix); err != nil {
+		return fmt.Errorf("invalid strip prefix: %v", err)
+	}
+
+	for _, column := range c.Columns {
+		if !validColumn(column) {
+			return fmt.Errorf("unknown column %q (available: %s)", column, strings.Join(AccessLogColumns, ", "))
+		}
+	}
+	if c.SortBy != "" && !validColumn(c.SortBy) {
+		return fmt.Errorf("unknown sort column %q", c.SortBy)
+	}
+
+	switch c.ExportFormat {
+	case "csv", "ndjson", "columnar":
+	default:
+		return fmt.Errorf("export format must be csv, ndjson or columnar")
+	}
+
 	return nil
 }
 
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/env"
+)
+
+// AccessLogColumns are the columns the access log table can show
+var AccessLogColumns = []string{
+	"time", "client", "method", "path", "host", "router", "service",
+	"backend", "status", "size", "duration", "user_agent",
+}
+
+// DefaultColumns are shown until the columns are configured
+var DefaultColumns = []string{"time", "client", "method", "path", "router", "status", "duration"}
+
+// Settings are the display preferences kept between runs
+type Settings struct {
+	// Layout lists the rows of dashboard cards, such as
+	// "timeline:2, distribution"
+	Layout   []string `json:"layout,omitempty"`
+	Columns  []string `json:"columns,omitempty"`
+	SortBy   string   `json:"sort_by,omitempty"`
+	SortDesc bool     `json:"sort_desc,omitempty"`
+}
+
+// settingsPath returns where the settings are kept, under the user's
+// configuration directory unless SETTINGS_FILE is set
+func settingsPath() string {
+	if path := env.GetEnv("SETTINGS_FILE", ""); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "traefik-log-dashboard", "settings.json")
+}
+
+// loadSettings reads the settings file; a missing file gives no settings
+func loadSettings(path string) (Settings, error) {
+	var s Settings
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, fmt.Errorf("invalid settings file %s: %v", path, err)
+	}
+	return s, nil
+}
+
+// SaveSettings writes the layout and table settings to the settings file
+func (c *Config) SaveSettings() error {
+	if c.SettingsFile == "" {
+		return fmt.Errorf("no settings file")
+	}
+	data, err := json.MarshalIndent(Settings{
+		Layout:   c.Layout,
+		Columns:  c.Columns,
+		SortBy:   c.SortBy,
+		SortDesc: c.SortDesc,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.SettingsFile), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(c.SettingsFile, append(data, '\n'), 0o644)
+}
+
+// ParseColumns splits a comma-separated list of column names
+func ParseColumns(s string) []string {
+	var columns []string
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		name = strings.ReplaceAll(name, "-", "_")
+		if name != "" {
+			columns = append(columns, name)
+		}
+	}
+	return columns
+}
+
+// validColumn reports whether name is an access log column
+func validColumn(name string) bool {
+	for _, column := range AccessLogColumns {
+		if column == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseColumns(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{" , ,", nil},
+		{"time,status", []string{"time", "status"}},
+		{" Time , STATUS ", []string{"time", "status"}},
+		{"user-agent,user_agent", []string{"user_agent", "user_agent"}},
+		{"path,,duration,", []string{"path", "duration"}},
+		{"bogus", []string{"bogus"}},
+	}
+	for _, tt := range tests {
+		got := ParseColumns(tt.in)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") || len(got) != len(tt.want) {
+			t.Errorf("%q: got %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestValidateColumns(t *testing.T) {
+	tests := []struct {
+		columns []string
+		sortBy  string
+		want    string
+	}{
+		{DefaultColumns, "", ""},
+		{AccessLogColumns, "user_agent", ""},
+		{[]string{"time", "bogus"}, "", `unknown column "bogus"`},
+		{[]string{"time"}, "bogus", `unknown sort column "bogus"`},
+		// Sorting by a hidden column is allowed
+		{[]string{"time"}, "size", ""},
+	}
+	for _, tt := range tests {
+		cfg := &Config{
+			AgentURL:        "http://localhost:5000",
+			RefreshInterval: time.Second,
+			MaxLogs:         1,
+			ExportFormat:    "csv",
+			Columns:         tt.columns,
+			SortBy:          tt.sortBy,
+		}
+		err := cfg.Validate()
+		switch {
+		case tt.want == "" && err != nil:
+			t.Errorf("%v sort %q: %v", tt.columns, tt.sortBy, err)
+		case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+			t.Errorf("%v sort %q: error %v, want %s", tt.columns, tt.sortBy, err, tt.want)
+		}
+	}
+}
+
+func TestSettingsRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "settings.json")
+	cfg := &Config{
+		SettingsFile: path,
+		Layout:       []string{"timeline:2, distribution"},
+		Columns:      []string{"status", "path"},
+		SortBy:       "duration",
+		SortDesc:     true,
+	}
+	if err := cfg.SaveSettings(); err != nil {
+		t.Fatal(err)
+	}
+	s, err := loadSettings(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(s.Layout, ";") != "timeline:2, distribution" || strings.Join(s.Columns, ",") != "status,path" || s.SortBy != "duration" || !s.SortDesc {
+		t.Errorf("settings = %+v", s)
+	}
+
+	if s, err := loadSettings(filepath.Join(t.TempDir(), "missing.json")); err != nil || len(s.Columns) != 0 {
+		t.Errorf("missing file: %+v, %v", s, err)
+	}
+}
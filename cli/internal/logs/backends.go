@@ -0,0 +1,91 @@
+package logs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Backend statuses reported by the agent
+const (
+	BackendHealthy  = "healthy"
+	BackendIdle     = "idle"
+	BackendDegraded = "degraded"
+	BackendDown     = "down"
+)
+
+// BackendWindow is the activity of a backend within one sliding window
+type BackendWindow struct {
+	Requests     int64   `json:"requests"`
+	ServerErrors int64   `json:"server_errors"`
+	ErrorRate    float64 `json:"error_rate"`
+	BadGateway   int64   `json:"status_502"`
+	Timeouts     int64   `json:"status_504"`
+	Retries      int64   `json:"retries"`
+	AvgMS        float64 `json:"avg_ms"`
+	P50MS        float64 `json:"p50_ms"`
+	P95MS        float64 `json:"p95_ms"`
+	P99MS        float64 `json:"p99_ms"`
+}
+
+// BackendHealth is the health of one backend server as scored by the agent
+type BackendHealth struct {
+	Service   string                   `json:"service"`
+	URL       string                   `json:"url"`
+	Status    string                   `json:"status"`
+	Reason    string                   `json:"reason"`
+	Score     int                      `json:"score"`
+	LastSeen  time.Time                `json:"last_seen"`
+	LastError *time.Time               `json:"last_error"`
+	Windows   map[string]BackendWindow `json:"windows"`
+}
+
+// BackendsReport is the agent's view of all backends
+type BackendsReport struct {
+	Windows      []string        `json:"windows"`
+	StatusWindow string          `json:"status_window"`
+	Counts       map[string]int  `json:"counts"`
+	Backends     []BackendHealth `json:"backends"`
+}
+
+// FetchBackends fetches per-backend health from the agent. It returns nil
+// without an error when the agent has backend health tracking disabled.
+func FetchBackends(agentURL, authToken string) (*BackendsReport, error) {
+	url := fmt.Sprintf("%s/api/backends", agentURL)
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if authToken != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authToken))
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("agent returned status %d: %s", resp.StatusCode, body)
+	}
+
+	var result struct {
+		Status string `json:"status"`
+		BackendsReport
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if result.Status == "disabled" {
+		return nil, nil
+	}
+
+	return &result.BackendsReport, nil
+}
@@ -0,0 +1,86 @@
+package logs
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// Prefixes of the header fields in Traefik access logs
+var headerPrefixes = []string{"request_", "downstream_", "origin_"}
+
+// traefikLog has the fields of TraefikLog without its JSON methods
+type traefikLog TraefikLog
+
+// UnmarshalJSON decodes an access log line, keeping its header fields
+func (l *TraefikLog) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, (*traefikLog)(l)); err != nil {
+		return err
+	}
+	if !strings.Contains(string(data), `_`) {
+		return nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	for key, raw := range fields {
+		if !isHeader(key) {
+			continue
+		}
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			continue
+		}
+		if l.Headers == nil {
+			l.Headers = make(map[string]string)
+		}
+		l.Headers[key] = value
+	}
+	// Traefik logs the user agent as request_User-Agent
+	if l.RequestUserAgent == "" {
+		l.RequestUserAgent = l.Headers["request_User-Agent"]
+	}
+	return nil
+}
+
+// MarshalJSON encodes an entry with its header fields, as Traefik logs it
+func (l TraefikLog) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(traefikLog(l))
+	if err != nil || len(l.Headers) == 0 {
+		return data, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for key, value := range l.Headers {
+		if _, ok := fields[key]; ok {
+			continue
+		}
+		raw, _ := json.Marshal(value)
+		fields[key] = raw
+	}
+	return json.Marshal(fields)
+}
+
+// HeaderNames returns the keys of the header fields, sorted
+func (l *TraefikLog) HeaderNames() []string {
+	names := make([]string, 0, len(l.Headers))
+	for name := range l.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func isHeader(key string) bool {
+	for _, prefix := range headerPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,191 @@
+package logs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExportOptions selects what the agent's export endpoint returns
+type ExportOptions struct {
+	Format  string // csv, ndjson or columnar
+	Since   string // RFC 3339, Unix seconds or a duration such as 1h
+	Until   string
+	Columns []string // empty for the agent's default columns
+	Limit   int      // 0 for no row limit
+	// Tail keeps the last Limit rows rather than the first ones
+	Tail bool
+}
+
+// ExportResult describes a finished export
+type ExportResult struct {
+	Filename  string // suggested by the agent
+	Path      string // where SaveExport wrote the file
+	Bytes     int64
+	Rows      int
+	Truncated bool
+}
+
+// exportExtensions maps export formats to file extensions
+var exportExtensions = map[string]string{
+	"csv":      ".csv",
+	"ndjson":   ".ndjson",
+	"columnar": ".tldc",
+}
+
+// ExportLogs streams an export from the agent into w. progress, if set, is
+// called with the number of bytes received so far as the export arrives.
+func ExportLogs(agentURL, authToken string, opts ExportOptions, w io.Writer, progress func(int64)) (ExportResult, error) {
+	query := url.Values{}
+	if opts.Format != "" {
+		query.Set("format", opts.Format)
+	}
+	if opts.Since != "" {
+		query.Set("since", opts.Since)
+	}
+	if opts.Until != "" {
+		query.Set("until", opts.Until)
+	}
+	if len(opts.Columns) > 0 {
+		query.Set("columns", strings.Join(opts.Columns, ","))
+	}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Tail {
+		query.Set("tail", "true")
+	}
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/logs/export?%s", agentURL, query.Encode()), nil)
+	if err != nil {
+		return ExportResult{}, err
+	}
+
+	if authToken != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authToken))
+	}
+
+	// Exports can take a while; the agent streams them, so only the wait
+	// for the first byte is bounded
+	client := &http.Client{Transport: &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		ResponseHeaderTimeout: 30 * time.Second,
+	}}
+	resp, err := client.Do(req)
+	if err != nil {
+		return ExportResult{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return ExportResult{}, fmt.Errorf("agent returned status %d: %s", resp.StatusCode, body)
+	}
+
+	var result ExportResult
+	buf := make([]byte, 64*1024)
+	for {
+		n, readErr := resp.Body.Read(buf)
+		if n > 0 {
+			if _, err := w.Write(buf[:n]); err != nil {
+				return result, err
+			}
+			result.Bytes += int64(n)
+			if progress != nil {
+				progress(result.Bytes)
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return result, readErr
+		}
+	}
+
+	// Trailers are only available once the body has been read
+	result.Rows, _ = strconv.Atoi(resp.Trailer.Get("X-Export-Rows"))
+	result.Truncated = resp.Trailer.Get("X-Export-Truncated") == "true"
+	if msg := resp.Trailer.Get("X-Export-Error"); msg != "" {
+		return result, fmt.Errorf("export incomplete: %s", msg)
+	}
+
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+		result.Filename = filepath.Base(params["filename"])
+	}
+	return result, nil
+}
+
+// FetchEntries returns the access log entries of an export, for one-shot
+// summaries over a time range. Every column is requested.
+func FetchEntries(agentURL, authToken string, opts ExportOptions) ([]TraefikLog, error) {
+	opts.Format = "ndjson"
+	opts.Columns = []string{"all"}
+
+	pr, pw := io.Pipe()
+	go func() {
+		_, err := ExportLogs(agentURL, authToken, opts, pw, nil)
+		pw.CloseWithError(err)
+	}()
+	defer pr.Close()
+
+	var entries []TraefikLog
+	dec := json.NewDecoder(pr)
+	for {
+		var entry TraefikLog
+		if err := dec.Decode(&entry); err == io.EOF {
+			return entries, nil
+		} else if err != nil {
+			return entries, err
+		}
+		entries = append(entries, entry)
+	}
+}
+
+// SaveExport downloads an export into dir. The file is written under a
+// temporary name and renamed once the export is complete.
+func SaveExport(agentURL, authToken string, opts ExportOptions, dir string, progress func(int64)) (ExportResult, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return ExportResult{}, err
+	}
+
+	tmp, err := os.CreateTemp(dir, ".export-*.part")
+	if err != nil {
+		return ExportResult{}, err
+	}
+	defer os.Remove(tmp.Name())
+
+	result, err := ExportLogs(agentURL, authToken, opts, tmp, progress)
+	if err == nil {
+		err = tmp.Chmod(0644)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return result, err
+	}
+
+	filename := result.Filename
+	if filename == "" || filename == "." || filename == string(filepath.Separator) {
+		format := opts.Format
+		if format == "" {
+			format = "csv"
+		}
+		filename = fmt.Sprintf("access-%s%s", time.Now().UTC().Format("20060102T150405Z"), exportExtensions[format])
+	}
+
+	result.Path = filepath.Join(dir, filename)
+	if err := os.Rename(tmp.Name(), result.Path); err != nil {
+		return result, err
+	}
+	return result, nil
+}
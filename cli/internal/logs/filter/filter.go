@@ -0,0 +1,407 @@
+// Package filter parses the filter language of the dashboard and matches
+// access log entries against it.
+//
+// A filter is a list of terms that must all match. A term is free text,
+// searched in the method, path, host, router, service, client and user
+// agent of an entry, or a predicate on a field:
+//
+//	status:5xx        status code or class; status:404,5xx for either
+//	status>=400       status comparison
+//	method:POST       request method
+//	router:api*       router name; * matches any text
+//	service:web*      service name
+//	host:example.com  request host
+//	path:/api/*       request path
+//	ip:10.0.0.0/8     client address or network
+//	dur>500ms         duration comparison, with <, <=, > or >=
+//
+// Values are case-insensitive and a comma separates alternatives. A leading
+// "-" negates a term, and quotes keep spaces in text: "not found".
+package filter
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/logs"
+)
+
+// Fields of predicates
+const (
+	FieldText     = ""
+	FieldStatus   = "status"
+	FieldMethod   = "method"
+	FieldRouter   = "router"
+	FieldService  = "service"
+	FieldHost     = "host"
+	FieldPath     = "path"
+	FieldIP       = "ip"
+	FieldDuration = "dur"
+)
+
+// textFields are matched against globs
+var textFields = map[string]bool{
+	FieldMethod:  true,
+	FieldRouter:  true,
+	FieldService: true,
+	FieldHost:    true,
+	FieldPath:    true,
+}
+
+// Term is one condition of a filter
+type Term struct {
+	Field  string
+	Negate bool
+	// Op is ":" or a comparison: <, <=, > or >=
+	Op string
+	// Values are the alternatives of a ":" term, lowercased
+	Values []string
+
+	statuses [][2]int
+	networks []*net.IPNet
+	limit    int64
+}
+
+// Filter is a parsed filter. The zero value matches every entry.
+type Filter struct {
+	Terms []Term
+}
+
+// Parse parses a filter expression; an empty one matches every entry
+func Parse(expr string) (*Filter, error) {
+	words, err := split(expr)
+	if err != nil {
+		return nil, err
+	}
+	f := &Filter{}
+	for _, word := range words {
+		term, err := parseTerm(word)
+		if err != nil {
+			return nil, err
+		}
+		f.Terms = append(f.Terms, term)
+	}
+	return f, nil
+}
+
+// split cuts an expression at spaces outside quotes. Quotes are kept so that
+// quoted text is not taken for a predicate.
+func split(expr string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	quoted := false
+	for _, r := range expr {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			word.WriteRune(r)
+		case !quoted && (r == ' ' || r == '\t'):
+			if word.Len() > 0 {
+				words = append(words, word.String())
+				word.Reset()
+			}
+		default:
+			word.WriteRune(r)
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	if word.Len() > 0 {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+func parseTerm(word string) (Term, error) {
+	var term Term
+	if len(word) > 1 && word[0] == '-' {
+		term.Negate = true
+		word = word[1:]
+	}
+
+	// Quoted text is always free text
+	if strings.HasPrefix(word, `"`) {
+		text := strings.Trim(word, `"`)
+		if text == "" {
+			return term, fmt.Errorf("empty quoted text")
+		}
+		term.Op, term.Values = ":", []string{strings.ToLower(text)}
+		return term, nil
+	}
+
+	field, op, value := cut(word)
+	if op == "" {
+		term.Op, term.Values = ":", []string{strings.ToLower(word)}
+		return term, nil
+	}
+	term.Field, term.Op = strings.ToLower(field), op
+	if value == "" {
+		return term, fmt.Errorf("%s%s needs a value", field, op)
+	}
+
+	switch {
+	case term.Field == FieldStatus && op == ":":
+		for _, v := range splitValues(value) {
+			lo, hi, err := parseStatus(v)
+			if err != nil {
+				return term, err
+			}
+			term.statuses = append(term.statuses, [2]int{lo, hi})
+		}
+	case term.Field == FieldStatus:
+		code, err := strconv.Atoi(value)
+		if err != nil || code < 100 || code > 599 {
+			return term, fmt.Errorf("invalid status %q", value)
+		}
+		term.limit = int64(code)
+	case term.Field == FieldDuration && op != ":":
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return term, fmt.Errorf("invalid duration %q, use a value such as 500ms or 2s", value)
+		}
+		term.limit = int64(d)
+	case term.Field == FieldDuration:
+		return term, fmt.Errorf("compare durations with <, <=, > or >=, such as dur>500ms")
+	case op != ":":
+		return term, fmt.Errorf("%s cannot be compared, use %s:", field, field)
+	case term.Field == FieldIP:
+		for _, v := range splitValues(value) {
+			network, err := parseNetwork(v)
+			if err != nil {
+				return term, err
+			}
+			term.networks = append(term.networks, network)
+		}
+	case textFields[term.Field]:
+	default:
+		return term, fmt.Errorf("unknown field %q, use status, method, router, service, host, path, ip or dur", field)
+	}
+	term.Values = splitValues(strings.ToLower(value))
+	return term, nil
+}
+
+// cut splits a word at its operator. Words without a known field
+// operator, such as URLs, are free text.
+func cut(word string) (string, string, string) {
+	i := strings.IndexAny(word, ":<>")
+	if i <= 0 {
+		return "", "", ""
+	}
+	field := word[:i]
+	for _, r := range field {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return "", "", ""
+		}
+	}
+	op := word[i : i+1]
+	if op == ":" && strings.HasPrefix(word[i:], "://") {
+		return "", "", ""
+	}
+	if op != ":" && i+1 < len(word) && word[i+1] == '=' {
+		op += "="
+	}
+	return field, op, word[i+len(op):]
+}
+
+func splitValues(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// parseStatus parses a status such as 404 or a class such as 5xx
+func parseStatus(s string) (int, int, error) {
+	if len(s) == 3 && strings.EqualFold(s[1:], "xx") && s[0] >= '1' && s[0] <= '5' {
+		lo := int(s[0]-'0') * 100
+		return lo, lo + 99, nil
+	}
+	code, err := strconv.Atoi(s)
+	if err != nil || code < 100 || code > 599 {
+		return 0, 0, fmt.Errorf("invalid status %q, use a code such as 404 or a class such as 5xx", s)
+	}
+	return code, code, nil
+}
+
+// parseNetwork parses an address or a CIDR network
+func parseNetwork(s string) (*net.IPNet, error) {
+	if strings.Contains(s, "/") {
+		_, network, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network %q", s)
+		}
+		return network, nil
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid address %q", s)
+	}
+	bits := 128
+	if ip4 := ip.To4(); ip4 != nil {
+		ip, bits = ip4, 32
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+// Empty reports whether the filter matches every entry
+func (f *Filter) Empty() bool {
+	return f == nil || len(f.Terms) == 0
+}
+
+// Match reports whether an entry matches every term
+func (f *Filter) Match(entry *logs.TraefikLog) bool {
+	if f == nil {
+		return true
+	}
+	for i := range f.Terms {
+		if f.Terms[i].match(entry) == f.Terms[i].Negate {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply returns the entries that match
+func (f *Filter) Apply(entries []logs.TraefikLog) []logs.TraefikLog {
+	if f.Empty() {
+		return entries
+	}
+	out := make([]logs.TraefikLog, 0, len(entries))
+	for i := range entries {
+		if f.Match(&entries[i]) {
+			out = append(out, entries[i])
+		}
+	}
+	return out
+}
+
+// Highlights returns the text a match was found for, to highlight it
+func (f *Filter) Highlights() []string {
+	if f == nil {
+		return nil
+	}
+	var out []string
+	for _, t := range f.Terms {
+		if t.Negate {
+			continue
+		}
+		switch t.Field {
+		case FieldText:
+			out = append(out, t.Values...)
+		case FieldMethod, FieldRouter, FieldService, FieldHost, FieldPath:
+			for _, v := range t.Values {
+				for _, part := range strings.Split(v, "*") {
+					if part != "" {
+						out = append(out, part)
+					}
+				}
+			}
+		}
+	}
+	return out
+}
+
+func (t *Term) match(entry *logs.TraefikLog) bool {
+	switch t.Field {
+	case FieldText:
+		text := t.Values[0]
+		for _, s := range []string{entry.RequestMethod, entry.RequestPath, entry.RequestHost, entry.RouterName, entry.ServiceName, entry.ClientHost, entry.RequestUserAgent, strconv.Itoa(entry.DownstreamStatus)} {
+			if strings.Contains(strings.ToLower(s), text) {
+				return true
+			}
+		}
+		return false
+	case FieldStatus:
+		if t.Op != ":" {
+			return compare(int64(entry.DownstreamStatus), t.Op, t.limit)
+		}
+		for _, r := range t.statuses {
+			if entry.DownstreamStatus >= r[0] && entry.DownstreamStatus <= r[1] {
+				return true
+			}
+		}
+		return false
+	case FieldDuration:
+		return compare(entry.Duration, t.Op, t.limit)
+	case FieldIP:
+		host := entry.ClientHost
+		if host == "" {
+			host = entry.ClientAddr
+		}
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		ip := net.ParseIP(strings.Trim(host, "[]"))
+		if ip == nil {
+			return false
+		}
+		for _, network := range t.networks {
+			if network.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var value string
+	switch t.Field {
+	case FieldMethod:
+		value = entry.RequestMethod
+	case FieldRouter:
+		value = entry.RouterName
+	case FieldService:
+		value = entry.ServiceName
+	case FieldHost:
+		value = entry.RequestHost
+	case FieldPath:
+		value = entry.RequestPath
+	}
+	value = strings.ToLower(value)
+	for _, pattern := range t.Values {
+		if glob(pattern, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func compare(value int64, op string, limit int64) bool {
+	switch op {
+	case "<":
+		return value < limit
+	case "<=":
+		return value <= limit
+	case ">":
+		return value > limit
+	default:
+		return value >= limit
+	}
+}
+
+// glob matches a pattern where * matches any text, / included
+func glob(pattern, s string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == s
+	}
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, part)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(part):]
+	}
+	return len(s) >= len(last) && strings.HasSuffix(s, last)
+}
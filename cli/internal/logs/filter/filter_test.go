@@ -0,0 +1,139 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/logs"
+)
+
+var entries = []logs.TraefikLog{
+	{RequestMethod: "GET", RequestPath: "/api/users", RequestHost: "example.com", RouterName: "api@docker", ServiceName: "api", ClientHost: "10.1.2.3", DownstreamStatus: 200, Duration: 20e6},
+	{RequestMethod: "POST", RequestPath: "/api/orders", RequestHost: "example.com", RouterName: "api@docker", ServiceName: "api", ClientHost: "192.168.1.5", DownstreamStatus: 503, Duration: 800e6},
+	{RequestMethod: "GET", RequestPath: "/", RequestHost: "www.example.org", RouterName: "web@file", ServiceName: "web", ClientHost: "2001:db8::1", DownstreamStatus: 404, Duration: 2e6, RequestUserAgent: "curl/8.0"},
+	{RequestMethod: "GET", RequestPath: "/health", RouterName: "dashboard@internal", ClientAddr: "10.0.0.9:51000", DownstreamStatus: 301, Duration: 600e6},
+}
+
+// matches returns the indexes of the entries matching expr
+func matches(t *testing.T, expr string) []int {
+	t.Helper()
+	f, err := Parse(expr)
+	if err != nil {
+		t.Fatalf("%q: %v", expr, err)
+	}
+	var out []int
+	for i := range entries {
+		if f.Match(&entries[i]) {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []int
+	}{
+		{"", []int{0, 1, 2, 3}},
+		{"   ", []int{0, 1, 2, 3}},
+		{"status:5xx", []int{1}},
+		{"status:404,5xx", []int{1, 2}},
+		{"status:200", []int{0}},
+		{"status>=400", []int{1, 2}},
+		{"status<300", []int{0}},
+		{"-status:2xx", []int{1, 2, 3}},
+		{"method:post", []int{1}},
+		{"method:GET,POST", []int{0, 1, 2, 3}},
+		{"router:api*", []int{0, 1}},
+		{"router:*@internal", []int{3}},
+		{"router:api", nil},
+		{"service:web", []int{2}},
+		{"host:example.com", []int{0, 1}},
+		{"host:*.example.org", []int{2}},
+		{"path:/api/*", []int{0, 1}},
+		{"path:/api/*ers", []int{0, 1}},
+		{"path:/*/orders", []int{1}},
+		{"ip:10.0.0.0/8", []int{0, 3}},
+		{"ip:192.168.1.5", []int{1}},
+		{"ip:2001:db8::/32", []int{2}},
+		{"-ip:10.0.0.0/8", []int{1, 2}},
+		{"dur>500ms", []int{1, 3}},
+		{"dur>=800ms", []int{1}},
+		{"dur<10ms", []int{2}},
+		{"dur<=20ms", []int{0, 2}},
+		{"orders", []int{1}},
+		{"CURL", []int{2}},
+		{"503", []int{1}},
+		{`"api/users"`, []int{0}},
+		{`"not found"`, nil},
+		{"-api", []int{2, 3}},
+		{"api status:5xx dur>500ms", []int{1}},
+		{"http://example.com", nil},
+	}
+	for _, tt := range tests {
+		got := matches(t, tt.expr)
+		if len(got) != len(tt.want) {
+			t.Errorf("%q: got %v, want %v", tt.expr, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%q: got %v, want %v", tt.expr, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	f, err := Parse(`-status:4xx,5xx  "bad gateway" dur>=1.5s router:API*`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Terms) != 4 {
+		t.Fatalf("got %d terms: %+v", len(f.Terms), f.Terms)
+	}
+	if st := f.Terms[0]; st.Field != FieldStatus || !st.Negate || len(st.Values) != 2 {
+		t.Errorf("status term %+v", st)
+	}
+	if text := f.Terms[1]; text.Field != FieldText || text.Values[0] != "bad gateway" {
+		t.Errorf("text term %+v", text)
+	}
+	if dur := f.Terms[2]; dur.Field != FieldDuration || dur.Op != ">=" || dur.limit != 1.5e9 {
+		t.Errorf("duration term %+v", dur)
+	}
+	if router := f.Terms[3]; router.Op != ":" || router.Values[0] != "api*" {
+		t.Errorf("router term %+v", router)
+	}
+
+	highlights := f.Highlights()
+	if len(highlights) != 2 || highlights[0] != "bad gateway" || highlights[1] != "api" {
+		t.Errorf("highlights %q", highlights)
+	}
+
+	var empty *Filter
+	if !empty.Empty() || !empty.Match(&entries[0]) {
+		t.Error("a nil filter must match everything")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{
+		"status:6xx",
+		"status:abc",
+		"status>abc",
+		"status:",
+		"dur>fast",
+		"dur:500ms",
+		"ip:10.0.0.0/33",
+		"ip:nowhere",
+		"method>GET",
+		"stauts:500",
+		`"unterminated`,
+		`""`,
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("%q: expected an error", expr)
+		}
+	}
+}
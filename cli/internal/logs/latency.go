@@ -0,0 +1,106 @@
+package logs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// LatencySummary sums up one latency distribution
+type LatencySummary struct {
+	AvgMS float64 `json:"avg_ms"`
+	P50MS float64 `json:"p50_ms"`
+	P90MS float64 `json:"p90_ms"`
+	P95MS float64 `json:"p95_ms"`
+	P99MS float64 `json:"p99_ms"`
+	MaxMS float64 `json:"max_ms"`
+}
+
+// LatencyBreakdown separates the time Traefik added from the time spent in
+// the backend
+type LatencyBreakdown struct {
+	Duration LatencySummary `json:"duration"`
+	Origin   LatencySummary `json:"origin"`
+	Overhead LatencySummary `json:"overhead"`
+}
+
+// LatencyRow is a latency range of the heatmap; UpperMS is 0 for the last,
+// unbounded row
+type LatencyRow struct {
+	LowerMS float64 `json:"lower_ms"`
+	UpperMS float64 `json:"upper_ms"`
+}
+
+// LatencyColumn is one time step of the heatmap
+type LatencyColumn struct {
+	Start         time.Time `json:"start"`
+	Requests      uint64    `json:"requests"`
+	Counts        []uint64  `json:"counts"`
+	P50MS         float64   `json:"p50_ms"`
+	P95MS         float64   `json:"p95_ms"`
+	P99MS         float64   `json:"p99_ms"`
+	OriginAvgMS   float64   `json:"origin_avg_ms"`
+	OverheadAvgMS float64   `json:"overhead_avg_ms"`
+}
+
+// LatencySeries is the heatmap of all traffic or of one router or service
+type LatencySeries struct {
+	Key       string           `json:"key"`
+	Requests  uint64           `json:"requests"`
+	Breakdown LatencyBreakdown `json:"breakdown"`
+	Columns   []LatencyColumn  `json:"columns"`
+}
+
+// LatencyHeatmap is the agent's latency heatmap
+type LatencyHeatmap struct {
+	Since   time.Time       `json:"since"`
+	Until   time.Time       `json:"until"`
+	Step    string          `json:"step"`
+	Metric  string          `json:"metric"`
+	Rows    []LatencyRow    `json:"rows"`
+	Overall LatencySeries   `json:"overall"`
+	Groups  []LatencySeries `json:"groups"`
+}
+
+// FetchLatency fetches the latency heatmap between since and until from
+// the agent, with one row per doubling of latency. A zero until means now.
+func FetchLatency(agentURL, authToken string, since, until time.Time) (*LatencyHeatmap, error) {
+	query := url.Values{}
+	query.Set("since", since.UTC().Format(time.RFC3339))
+	if !until.IsZero() {
+		query.Set("until", until.UTC().Format(time.RFC3339))
+	}
+	query.Set("rows_per_doubling", "1")
+	url := fmt.Sprintf("%s/api/latency?%s", agentURL, query.Encode())
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if authToken != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authToken))
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("agent returned status %d: %s", resp.StatusCode, body)
+	}
+
+	var heatmap LatencyHeatmap
+	if err := json.NewDecoder(resp.Body).Decode(&heatmap); err != nil {
+		return nil, err
+	}
+
+	return &heatmap, nil
+}
@@ -0,0 +1,384 @@
+// Package local reads Traefik log files directly, so the dashboard can run
+// on a Traefik host without the agent. Rotated and gzipped files next to the
+// active one provide the history.
+package local
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/logs"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/logs/traefik"
+)
+
+// Options select the files and how much history is kept
+type Options struct {
+	AccessPath string
+	// ErrorPath is optional
+	ErrorPath string
+	// MaxEntries and MaxErrors cap the history read at start
+	MaxEntries int
+	MaxErrors  int
+	// Interval is how often the files are checked for new lines
+	Interval time.Duration
+	// StripPrefix removes what log shippers add before each access line
+	StripPrefix *regexp.Regexp
+}
+
+// Tailer reads the history of the log files, then follows them across
+// rotations. Batches are read from C, like those of a logs.Streamer.
+type Tailer struct {
+	opts   Options
+	out    chan logs.StreamBatch
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// New returns a Tailer; Start begins reading
+func New(opts Options) *Tailer {
+	if opts.Interval <= 0 {
+		opts.Interval = 500 * time.Millisecond
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Tailer{
+		opts:   opts,
+		out:    make(chan logs.StreamBatch, 16),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// C delivers the batches; it is closed once the tailer stops
+func (t *Tailer) C() <-chan logs.StreamBatch {
+	return t.out
+}
+
+// Start reads in the background
+func (t *Tailer) Start() {
+	go t.run()
+}
+
+// Stop stops following the files
+func (t *Tailer) Stop() {
+	t.cancel()
+}
+
+func (t *Tailer) run() {
+	defer close(t.out)
+
+	access := &follower{path: t.opts.AccessPath}
+	var errorFile *follower
+	if t.opts.ErrorPath != "" {
+		errorFile = &follower{path: t.opts.ErrorPath}
+	}
+
+	// History first, oldest entries first
+	var batch logs.StreamBatch
+	for {
+		lines, err := access.history(t.opts.MaxEntries)
+		if err == nil {
+			batch.Entries = parseEntries(lines, t.opts.StripPrefix)
+			break
+		}
+		if !t.send(logs.StreamBatch{State: logs.StreamWaiting, Err: err, Retry: t.opts.Interval}) {
+			return
+		}
+		select {
+		case <-t.ctx.Done():
+			return
+		case <-time.After(t.opts.Interval):
+		}
+	}
+	if errorFile != nil {
+		if lines, err := errorFile.history(t.opts.MaxErrors); err == nil {
+			batch.ErrorLines = lines
+		}
+	}
+	batch.State = logs.StreamLive
+	if !t.send(batch) {
+		return
+	}
+
+	state := logs.StreamLive
+	ticker := time.NewTicker(t.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		var next logs.StreamBatch
+		lines, err := access.read()
+		if err != nil {
+			next.State, next.Err = logs.StreamWaiting, err
+		} else {
+			next.State = logs.StreamLive
+			next.Entries = parseEntries(lines, t.opts.StripPrefix)
+		}
+		if errorFile != nil {
+			if lines, err := errorFile.read(); err == nil {
+				next.ErrorLines = lines
+			}
+		}
+		if len(next.Entries) == 0 && len(next.ErrorLines) == 0 && next.State == state {
+			continue
+		}
+		state = next.State
+		if !t.send(next) {
+			return
+		}
+	}
+}
+
+func (t *Tailer) send(batch logs.StreamBatch) bool {
+	select {
+	case t.out <- batch:
+		return true
+	case <-t.ctx.Done():
+		return false
+	}
+}
+
+// ReadHistory reads the history of the access log and its rotated copies
+// once, without following them
+func ReadHistory(opts Options) ([]logs.TraefikLog, error) {
+	access := &follower{path: opts.AccessPath}
+	lines, err := access.history(opts.MaxEntries)
+	if err != nil {
+		return nil, err
+	}
+	return parseEntries(lines, opts.StripPrefix), nil
+}
+
+// parseEntries parses access log lines, skipping those that are not
+func parseEntries(lines []string, strip *regexp.Regexp) []logs.TraefikLog {
+	entries := make([]logs.TraefikLog, 0, len(lines))
+	for _, line := range lines {
+		if entry := parseLine(line, strip); entry != nil {
+			entries = append(entries, *entry)
+		}
+	}
+	return entries
+}
+
+// DefaultStripPrefix matches the pod prefix of kubectl logs --prefix and
+// the timestamp of --timestamps or of log shippers
+const DefaultStripPrefix = `^(\[[^\]]*\]\s+)?(\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?\s+)?`
+
+// parseLine parses an access log line once the prefix is stripped. A JSON
+// entry after an unknown prefix is found too. It returns nil for lines that
+// are not access log entries.
+func parseLine(line string, strip *regexp.Regexp) *logs.TraefikLog {
+	if strip != nil {
+		if loc := strip.FindStringIndex(line); loc != nil && loc[0] == 0 {
+			line = line[loc[1]:]
+		}
+	}
+	entry, err := traefik.ParseLog(line)
+	if err == nil && entry != nil {
+		return entry
+	}
+	if i := strings.Index(line, "{"); i > 0 {
+		if entry, err := traefik.ParseLog(line[i:]); err == nil && entry != nil {
+			return entry
+		}
+	}
+	return nil
+}
+
+// follower reads the complete lines appended to a file, starting over when
+// the file is truncated or replaced
+type follower struct {
+	path     string
+	position int64
+	info     os.FileInfo
+}
+
+// history returns the last max lines of the file and its rotated copies,
+// oldest first, and positions the follower at the end of the file
+func (f *follower) history(max int) ([]string, error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", f.path)
+	}
+	if max <= 0 {
+		// No history: follow from the end
+		f.position, f.info = info.Size(), info
+		return nil, nil
+	}
+
+	lines, position, err := readLines(f.path, 0, max)
+	if err != nil {
+		return nil, err
+	}
+	f.position, f.info = position, info
+
+	// Older lines from rotated files, newest file first
+	for _, path := range rotated(f.path) {
+		if len(lines) >= max {
+			break
+		}
+		older, _, err := readLines(path, 0, max-len(lines))
+		if err != nil {
+			continue
+		}
+		lines = append(older, lines...)
+	}
+	return lines, nil
+}
+
+// read returns the lines appended since the last read
+func (f *follower) read() ([]string, error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	if f.info != nil && !os.SameFile(f.info, info) {
+		// Renamed and recreated: finish the old file first
+		lines = f.drain()
+		f.position = 0
+	} else if info.Size() < f.position {
+		// Truncated
+		f.position = 0
+	}
+	f.info = info
+	if info.Size() == f.position {
+		return lines, nil
+	}
+
+	more, position, err := readLines(f.path, f.position, 0)
+	if err != nil {
+		return lines, err
+	}
+	f.position = position
+	return append(lines, more...), nil
+}
+
+// drain returns the lines appended to the file followed so far since the
+// last read, once it has been renamed to one of its rotated names.
+// Compressed copies are not looked at, as the offset no longer applies.
+func (f *follower) drain() []string {
+	for _, path := range rotated(f.path) {
+		if strings.HasSuffix(path, ".gz") {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil || !os.SameFile(f.info, info) {
+			continue
+		}
+		lines, _, err := readLines(path, f.position, 0)
+		if err != nil {
+			return nil
+		}
+		return lines
+	}
+	return nil
+}
+
+// readLines reads the complete lines of a file from offset, keeping the last
+// max when max is positive. It returns the offset after the last complete
+// line. Gzipped files are read whole.
+func readLines(path string, offset int64, max int) ([]string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, offset, err
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, offset, err
+		}
+		defer gz.Close()
+		r = gz
+	} else if offset > 0 {
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			return nil, offset, err
+		}
+	}
+
+	var lines []string
+	reader := bufio.NewReaderSize(r, 64*1024)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			// A partial last line is read again once complete
+			if err == io.EOF {
+				break
+			}
+			return lines, offset, err
+		}
+		offset += int64(len(line))
+		if line = strings.TrimRight(line, "\r\n"); line == "" {
+			continue
+		}
+		lines = append(lines, line)
+		if max > 0 && len(lines) > 2*max {
+			lines = append(lines[:0], lines[len(lines)-max:]...)
+		}
+	}
+	if max > 0 && len(lines) > max {
+		lines = lines[len(lines)-max:]
+	}
+	return lines, offset, nil
+}
+
+// rotatedSuffix matches what rotation appends to the name of a file: a
+// number or a date, optionally compressed
+var rotatedSuffix = regexp.MustCompile(`^[.-]\d[\w.:-]*$`)
+
+// rotated lists the rotated copies of a file, such as access.log.1,
+// access.log.2.gz or access.log-20240501.gz, newest first
+func rotated(path string) []string {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	type file struct {
+		path    string
+		modTime time.Time
+	}
+	var files []file
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, base) || !rotatedSuffix.MatchString(name[len(base):]) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, file{filepath.Join(dir, name), info.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.After(files[j].modTime)
+	})
+
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.path
+	}
+	return paths
+}
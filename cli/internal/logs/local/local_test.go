@@ -0,0 +1,247 @@
+package local
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// write replaces the content of path, setting its modification time to
+// age before now
+func write(t *testing.T, path, content string, age time.Duration) {
+	t.Helper()
+	data := []byte(content)
+	if strings.HasSuffix(path, ".gz") {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		gz.Write(data)
+		gz.Close()
+		data = buf.Bytes()
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	mod := time.Now().Add(-age)
+	if err := os.Chtimes(path, mod, mod); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func appendTo(t *testing.T, path, content string) {
+	t.Helper()
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func expect(t *testing.T, what string, got []string, want ...string) {
+	t.Helper()
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("%s = %v, want %v", what, got, want)
+	}
+}
+
+func TestRotated(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "access.log")
+	for name, age := range map[string]time.Duration{
+		"access.log":             0,
+		"access.log.1":           time.Hour,
+		"access.log.2.gz":        2 * time.Hour,
+		"access.log-20240501.gz": 3 * time.Hour,
+		"access.log-2024-04-30":  4 * time.Hour,
+		"access.log.bak":         time.Hour,
+		"access.log.lock":        time.Hour,
+		"access.logs":            time.Hour,
+		"error.log.1":            time.Hour,
+	} {
+		write(t, filepath.Join(dir, name), "", age)
+	}
+
+	var got []string
+	for _, p := range rotated(path) {
+		got = append(got, filepath.Base(p))
+	}
+	expect(t, "rotated", got, "access.log.1", "access.log.2.gz", "access.log-20240501.gz", "access.log-2024-04-30")
+}
+
+func TestHistory(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "access.log")
+	write(t, filepath.Join(dir, "access.log.2.gz"), "a\nb\n", 2*time.Hour)
+	write(t, filepath.Join(dir, "access.log.1"), "c\nd\n", time.Hour)
+	write(t, path, "e\nf\npartial", 0)
+
+	tests := []struct {
+		max  int
+		want []string
+	}{
+		{0, nil},
+		{1, []string{"f"}},
+		{3, []string{"d", "e", "f"}},
+		{5, []string{"b", "c", "d", "e", "f"}},
+		{10, []string{"a", "b", "c", "d", "e", "f"}},
+	}
+	for _, tt := range tests {
+		f := &follower{path: path}
+		lines, err := f.history(tt.max)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect(t, "history", lines, tt.want...)
+	}
+
+	// The partial line is read once complete
+	f := &follower{path: path}
+	f.history(10)
+	appendTo(t, path, " line\ng\n")
+	lines, err := f.read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect(t, "read", lines, "partial line", "g")
+}
+
+func TestFollow(t *testing.T) {
+	tests := []struct {
+		name   string
+		rotate func(t *testing.T, path string)
+		want   []string
+	}{
+		{
+			name: "truncated",
+			rotate: func(t *testing.T, path string) {
+				write(t, path, "x\n", 0)
+			},
+			want: []string{"x"},
+		},
+		{
+			name: "renamed and recreated",
+			rotate: func(t *testing.T, path string) {
+				appendTo(t, path, "c\n")
+				if err := os.Rename(path, path+".1"); err != nil {
+					t.Fatal(err)
+				}
+				write(t, path, "x\n", 0)
+			},
+			want: []string{"c", "x"},
+		},
+		{
+			name: "renamed and compressed",
+			rotate: func(t *testing.T, path string) {
+				appendTo(t, path, "c\n")
+				if err := os.Remove(path); err != nil {
+					t.Fatal(err)
+				}
+				write(t, path+".1.gz", "a\nb\nc\n", 0)
+				write(t, path, "x\n", 0)
+			},
+			want: []string{"x"},
+		},
+		{
+			name: "removed",
+			rotate: func(t *testing.T, path string) {
+				os.Remove(path)
+			},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "access.log")
+			write(t, path, "a\nb\n", 0)
+			f := &follower{path: path}
+			if _, err := f.history(10); err != nil {
+				t.Fatal(err)
+			}
+
+			tt.rotate(t, path)
+			lines, err := f.read()
+			if tt.want == nil && err == nil {
+				t.Errorf("read after removal: no error")
+			}
+			expect(t, "read", lines, tt.want...)
+
+			// Later lines are followed in the new file
+			if tt.want != nil {
+				appendTo(t, path, "y\n")
+				lines, _ = f.read()
+				expect(t, "next read", lines, "y")
+			}
+		})
+	}
+}
+
+func TestDefaultStripPrefix(t *testing.T) {
+	strip := regexp.MustCompile(DefaultStripPrefix)
+	tests := []struct {
+		line string
+		want string
+	}{
+		{`{"a":1}`, `{"a":1}`},
+		{`[pod/traefik-7d9f/traefik] {"a":1}`, `{"a":1}`},
+		{`2024-05-01T10:00:00Z {"a":1}`, `{"a":1}`},
+		{`2024-05-01T10:00:00.123456789Z {"a":1}`, `{"a":1}`},
+		{`2024-05-01 10:00:00+02:00 {"a":1}`, `{"a":1}`},
+		{`2024-05-01T10:00:00.5+0200 {"a":1}`, `{"a":1}`},
+		{`[pod/traefik-7d9f/traefik] 2024-05-01T10:00:00Z {"a":1}`, `{"a":1}`},
+		{`10.0.0.1 - - [01/May/2024:10:00:00 +0000] "GET / HTTP/1.1"`, `10.0.0.1 - - [01/May/2024:10:00:00 +0000] "GET / HTTP/1.1"`},
+		{`2024-05-01 {"a":1}`, `2024-05-01 {"a":1}`},
+		{`traefik-1  | {"a":1}`, `traefik-1  | {"a":1}`},
+	}
+	for _, tt := range tests {
+		got := tt.line
+		if loc := strip.FindStringIndex(tt.line); loc != nil && loc[0] == 0 {
+			got = tt.line[loc[1]:]
+		}
+		if got != tt.want {
+			t.Errorf("%q: stripped to %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestParseLine(t *testing.T) {
+	const (
+		entry = `{"RequestPath":"/api","DownstreamStatus":200}`
+		clf   = `10.0.0.1 - - [01/May/2024:10:00:00 +0000] "GET /web HTTP/1.1" 200 512 "-" "curl/8.0" 1 "web@docker" "http://10.0.0.2:80" 3ms`
+	)
+	strip := regexp.MustCompile(DefaultStripPrefix)
+	tests := []struct {
+		line  string
+		strip *regexp.Regexp
+		want  string
+	}{
+		{entry, strip, "/api"},
+		{"[pod/traefik-7d9f/traefik] " + entry, strip, "/api"},
+		{"2024-05-01T10:00:00.123Z " + entry, strip, "/api"},
+		{clf, strip, "/web"},
+		{"[pod/traefik-7d9f/traefik] 2024-05-01T10:00:00Z " + clf, strip, "/web"},
+		// JSON is found after an unknown prefix, or without a rule
+		{"traefik-1  | " + entry, strip, "/api"},
+		{"[pod/traefik-7d9f/traefik] " + entry, nil, "/api"},
+		// CLF needs the prefix stripped
+		{"2024-05-01T10:00:00Z " + clf, nil, ""},
+		{`time="2024-05-01T10:00:00Z" level=info msg="Configuration loaded"`, strip, ""},
+		{`{"level":"info"`, strip, ""},
+		{"", strip, ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if e := parseLine(tt.line, tt.strip); e != nil {
+			got = e.RequestPath
+		}
+		if got != tt.want {
+			t.Errorf("%q: path %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
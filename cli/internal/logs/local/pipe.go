@@ -0,0 +1,99 @@
+package local
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/logs"
+)
+
+// maxBatch caps the entries of one batch read from a pipe
+const maxBatch = 500
+
+// Pipe reads access log lines from a stream such as stdin, for
+// `kubectl logs -f traefik | traefik-log-dashboard`. Batches are read
+// from C, like those of a Tailer.
+type Pipe struct {
+	r      io.Reader
+	strip  *regexp.Regexp
+	out    chan logs.StreamBatch
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewPipe returns a Pipe reading r; Start begins reading. strip may be nil.
+func NewPipe(r io.Reader, strip *regexp.Regexp) *Pipe {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Pipe{
+		r:      r,
+		strip:  strip,
+		out:    make(chan logs.StreamBatch, 16),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// C delivers the batches; it is closed once the stream ends
+func (p *Pipe) C() <-chan logs.StreamBatch {
+	return p.out
+}
+
+// Start reads in the background
+func (p *Pipe) Start() {
+	go p.run()
+}
+
+// Stop stops delivering batches. A blocked read is left to end with the
+// process.
+func (p *Pipe) Stop() {
+	p.cancel()
+}
+
+func (p *Pipe) run() {
+	defer close(p.out)
+
+	if !p.send(logs.StreamBatch{State: logs.StreamLive}) {
+		return
+	}
+
+	reader := bufio.NewReaderSize(p.r, 64*1024)
+	var pending []logs.TraefikLog
+	for {
+		line, err := reader.ReadString('\n')
+		if line = strings.TrimRight(line, "\r\n"); line != "" {
+			if entry := parseLine(line, p.strip); entry != nil {
+				pending = append(pending, *entry)
+			}
+		}
+		if err != nil {
+			// The entries read so far stay on the dashboard
+			state := logs.StreamEnded
+			if err == io.EOF {
+				err = nil
+			}
+			p.send(logs.StreamBatch{Entries: pending, State: state, Err: err})
+			return
+		}
+
+		// Deliver once caught up with the writer, so that a large
+		// backlog arrives in few batches
+		if len(pending) > 0 && (reader.Buffered() == 0 || len(pending) >= maxBatch) {
+			if !p.send(logs.StreamBatch{Entries: pending, State: logs.StreamLive}) {
+				return
+			}
+			pending = nil
+		}
+	}
+}
+
+func (p *Pipe) send(batch logs.StreamBatch) bool {
+	select {
+	case p.out <- batch:
+		return true
+	case <-p.ctx.Done():
+		return false
+	}
+}
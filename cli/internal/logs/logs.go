@@ -40,6 +40,12 @@ type TraefikLog struct {
 	EntryPointName        string  `json:"entryPointName"`
 	RequestReferer        string  `json:"request_Referer"`
 	RequestUserAgent      string  `json:"request_User_Agent"`
+	TLSVersion            string  `json:"TLSVersion"`
+	TLSCipher             string  `json:"TLSCipher"`
+	TLSClientSubject      string  `json:"TLSClientSubject"`
+	// Headers holds the header fields Traefik logs, keyed as logged, such
+	// as request_X-Forwarded-For or downstream_Content-Type
+	Headers               map[string]string `json:"-"`
 }
 
 // SystemStats represents system resource statistics
@@ -188,4 +194,16 @@ func FetchSystemStats(agentURL, authToken string) (*SystemStats, error) {
 	}
 	
 	return &stats, nil
-}
\ No newline at end of file
+}
+
+// clfTimeLayout is the timestamp layout of Common Log Format lines
+const clfTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+// ParseTime parses the StartUTC or StartLocal of an entry, as logged in
+// JSON or Common Log Format
+func ParseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(clfTimeLayout, s)
+}
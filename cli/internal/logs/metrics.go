@@ -20,6 +20,13 @@ type Metrics struct {
 	TopRoutes        []RouteMetric
 	TopServices      []ServiceMetric
 	TopRouters       []RouterMetric
+	TopClients       []ClientMetric
+	// Backends is the agent's per-backend health; nil when unavailable
+	Backends         *BackendsReport
+	// SLO is the agent's service level objectives; nil when unavailable
+	SLO              *SLOReport
+	// Latency is the agent's latency heatmap; nil when unavailable
+	Latency          *LatencyHeatmap
 }
 
 // RouteMetric represents metrics for a route
@@ -45,8 +52,21 @@ type RouterMetric struct {
 	AvgDuration float64
 }
 
+// ClientMetric represents metrics for a client address
+type ClientMetric struct {
+	Host      string
+	Count     int
+	ErrorRate float64
+}
+
 // CalculateMetrics calculates metrics from log entries
 func CalculateMetrics(logs []TraefikLog) *Metrics {
+	return CalculateMetricsTop(logs, 10)
+}
+
+// CalculateMetricsTop calculates metrics from log entries, keeping the top
+// limit routes, services, routers and clients
+func CalculateMetricsTop(logs []TraefikLog, limit int) *Metrics {
 	if len(logs) == 0 {
 		return &Metrics{}
 	}
@@ -84,13 +104,16 @@ func CalculateMetrics(logs []TraefikLog) *Metrics {
 	metrics.P99ResponseTime = percentile(durations, 99)
 
 	// Calculate top routes
-	metrics.TopRoutes = calculateTopRoutes(logs, 10)
+	metrics.TopRoutes = calculateTopRoutes(logs, limit)
 
 	// Calculate top services
-	metrics.TopServices = calculateTopServices(logs, 10)
+	metrics.TopServices = calculateTopServices(logs, limit)
 
 	// Calculate top routers
-	metrics.TopRouters = calculateTopRouters(logs, 10)
+	metrics.TopRouters = calculateTopRouters(logs, limit)
+
+	// Calculate top clients
+	metrics.TopClients = calculateTopClients(logs, limit)
 
 	return metrics
 }
@@ -142,9 +165,11 @@ func calculateTopServices(logs []TraefikLog, limit int) []ServiceMetric {
 		if sm, exists := serviceMap[log.ServiceName]; exists {
 			sm.Count++
 			sm.AvgDuration = (sm.AvgDuration*float64(sm.Count-1) + float64(log.Duration)/1000000) / float64(sm.Count)
+			failed := 0.0
 			if log.DownstreamStatus >= 400 {
-				sm.ErrorRate = (sm.ErrorRate*float64(sm.Count-1) + 1) / float64(sm.Count)
+				failed = 100
 			}
+			sm.ErrorRate = (sm.ErrorRate*float64(sm.Count-1) + failed) / float64(sm.Count)
 		} else {
 			errorRate := 0.0
 			if log.DownstreamStatus >= 400 {
@@ -212,6 +237,51 @@ func calculateTopRouters(logs []TraefikLog, limit int) []RouterMetric {
 	return routers
 }
 
+// calculateTopClients calculates top clients by request count
+func calculateTopClients(logs []TraefikLog, limit int) []ClientMetric {
+	clientMap := make(map[string]*ClientMetric)
+	errors := make(map[string]int)
+
+	for _, log := range logs {
+		host := log.ClientHost
+		if host == "" {
+			host = log.ClientAddr
+		}
+		if host == "" {
+			continue
+		}
+
+		cm, exists := clientMap[host]
+		if !exists {
+			cm = &ClientMetric{Host: host}
+			clientMap[host] = cm
+		}
+		cm.Count++
+		if log.DownstreamStatus >= 400 {
+			errors[host]++
+		}
+	}
+
+	clients := make([]ClientMetric, 0, len(clientMap))
+	for host, cm := range clientMap {
+		cm.ErrorRate = float64(errors[host]) / float64(cm.Count) * 100
+		clients = append(clients, *cm)
+	}
+
+	sort.Slice(clients, func(i, j int) bool {
+		if clients[i].Count != clients[j].Count {
+			return clients[i].Count > clients[j].Count
+		}
+		return clients[i].Host < clients[j].Host
+	})
+
+	if len(clients) > limit {
+		clients = clients[:limit]
+	}
+
+	return clients
+}
+
 // average calculates the average of a slice of float64
 func average(values []float64) float64 {
 	if len(values) == 0 {
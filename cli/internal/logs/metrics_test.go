@@ -0,0 +1,30 @@
+package logs
+
+import "testing"
+
+func TestTopServicesErrorRate(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		want     float64
+	}{
+		{"no errors", []int{200, 200, 304}, 0},
+		{"all errors", []int{500, 404}, 100},
+		{"first failed", []int{502, 200, 200, 200}, 25},
+		{"later failed", []int{200, 500, 503, 200}, 50},
+		{"one request", []int{500}, 100},
+	}
+	for _, tt := range tests {
+		var entries []TraefikLog
+		for _, status := range tt.statuses {
+			entries = append(entries, TraefikLog{ServiceName: "api", DownstreamStatus: status})
+		}
+		services := calculateTopServices(entries, 10)
+		if len(services) != 1 {
+			t.Fatalf("%s: services = %+v", tt.name, services)
+		}
+		if got := services[0].ErrorRate; got < tt.want-1e-9 || got > tt.want+1e-9 {
+			t.Errorf("%s: error rate = %v%%, want %v%%", tt.name, got, tt.want)
+		}
+	}
+}
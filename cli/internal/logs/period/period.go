@@ -1,6 +1,8 @@
 package period
 
 import (
+	"fmt"
+	"strings"
 	"time"
 )
 
@@ -112,4 +114,69 @@ func ThisMonth() Period {
 // Custom creates a custom period
 func Custom(start, end time.Time) Period {
 	return Period{Start: start, End: end}
-}
\ No newline at end of file
+}
+
+// Last returns a period for the last d
+func Last(d time.Duration) Period {
+	now := time.Now()
+	return Period{
+		Start: now.Add(-d),
+		End:   now,
+	}
+}
+
+// Preset is a named period relative to the current time
+type Preset struct {
+	Name   string
+	Period func() Period
+	// Live presets end now, so new entries fall inside them
+	Live bool
+}
+
+// Presets are the periods offered by the time range picker
+var Presets = []Preset{
+	{Name: "Last 15 minutes", Period: func() Period { return Last(15 * time.Minute) }, Live: true},
+	{Name: "Last hour", Period: LastHour, Live: true},
+	{Name: "Last 6 hours", Period: func() Period { return Last(6 * time.Hour) }, Live: true},
+	{Name: "Last 24 hours", Period: Last24Hours, Live: true},
+	{Name: "Today", Period: Today, Live: true},
+	{Name: "Yesterday", Period: Yesterday},
+	{Name: "This week", Period: ThisWeek, Live: true},
+	{Name: "Last 7 days", Period: LastWeek, Live: true},
+	{Name: "This month", Period: ThisMonth, Live: true},
+	{Name: "Last 30 days", Period: LastMonth, Live: true},
+}
+
+// timeLayouts are the absolute times ParseTime accepts, in local time
+// unless they carry an offset
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// ParseTime reads the bound of a custom period: "now", a duration before
+// now such as 2h, a time of day today such as 14:30, or a date and time
+// such as 2024-05-01 14:30
+func ParseTime(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "now" {
+		return now, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), t.Second(), 0, now.Location()), nil
+		}
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: use now, a duration such as 2h, 14:30 or 2006-01-02 15:04", s)
+}
@@ -0,0 +1,69 @@
+package period
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	berlin := time.FixedZone("CEST", 2*60*60)
+	now := time.Date(2024, 5, 1, 15, 4, 5, 0, berlin)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"", now},
+		{" now ", now},
+		{"2h", now.Add(-2 * time.Hour)},
+		{"90m", now.Add(-90 * time.Minute)},
+		{"1h30m", now.Add(-90 * time.Minute)},
+		// Times of day are today, in now's zone
+		{"14:30", time.Date(2024, 5, 1, 14, 30, 0, 0, berlin)},
+		{"09:15:30", time.Date(2024, 5, 1, 9, 15, 30, 0, berlin)},
+		{"23:59", time.Date(2024, 5, 1, 23, 59, 0, 0, berlin)},
+		{"2024-04-30", time.Date(2024, 4, 30, 0, 0, 0, 0, berlin)},
+		{"2024-04-30 08:00", time.Date(2024, 4, 30, 8, 0, 0, 0, berlin)},
+		{"2024-04-30T08:00", time.Date(2024, 4, 30, 8, 0, 0, 0, berlin)},
+		{"2024-04-30 08:00:15", time.Date(2024, 4, 30, 8, 0, 15, 0, berlin)},
+		// Offsets are kept
+		{"2024-04-30T08:00:00Z", time.Date(2024, 4, 30, 8, 0, 0, 0, time.UTC)},
+		{"2024-04-30T08:00:00.5-05:00", time.Date(2024, 4, 30, 13, 0, 0, 5e8, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := ParseTime(tt.in, now)
+		if err != nil {
+			t.Errorf("%q: %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("%q: got %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseTimeErrors(t *testing.T) {
+	now := time.Date(2024, 5, 1, 15, 4, 5, 0, time.UTC)
+	for _, in := range []string{"yesterday", "25:00", "2024-13-01", "14:30 today", "2h ago", "1 hour"} {
+		if got, err := ParseTime(in, now); err == nil || !strings.Contains(err.Error(), "invalid time") {
+			t.Errorf("%q: got %v, %v, want an invalid time error", in, got, err)
+		}
+	}
+}
+
+func TestPresets(t *testing.T) {
+	for _, preset := range Presets {
+		p := preset.Period()
+		if !p.Start.Before(p.End) {
+			t.Errorf("%s: %v to %v", preset.Name, p.Start, p.End)
+		}
+		if preset.Live && time.Since(p.End) > time.Minute {
+			t.Errorf("%s: live preset ends at %v", preset.Name, p.End)
+		}
+	}
+
+	y := Yesterday()
+	if y.Start.Hour() != 0 || y.Start.Minute() != 0 || y.End.Sub(y.Start) < 23*time.Hour || !y.End.Before(Today().Start) {
+		t.Errorf("yesterday = %v to %v", y.Start, y.End)
+	}
+}
@@ -0,0 +1,92 @@
+package logs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// SLO states reported by the agent
+const (
+	SLONoData    = "no_data"
+	SLOOK        = "ok"
+	SLOBurning   = "burning"
+	SLOExhausted = "exhausted"
+)
+
+// SLOAlert is a multi-window burn rate alert of an objective
+type SLOAlert struct {
+	Name          string  `json:"name"`
+	Severity      string  `json:"severity"`
+	Long          string  `json:"long"`
+	Short         string  `json:"short"`
+	BurnRate      float64 `json:"burn_rate"`
+	LongBurnRate  float64 `json:"long_burn_rate"`
+	ShortBurnRate float64 `json:"short_burn_rate"`
+	Firing        bool    `json:"firing"`
+}
+
+// SLOStatus is the state of one objective as computed by the agent
+type SLOStatus struct {
+	Name                 string             `json:"name"`
+	Type                 string             `json:"type"`
+	Target               float64            `json:"target"`
+	Threshold            string             `json:"threshold"`
+	Window               string             `json:"window"`
+	State                string             `json:"state"`
+	Requests             int64              `json:"requests"`
+	Good                 int64              `json:"good"`
+	SLI                  float64            `json:"sli"`
+	ErrorBudgetRemaining float64            `json:"error_budget_remaining"`
+	BurnRates            map[string]float64 `json:"burn_rates"`
+	Alerts               []SLOAlert         `json:"alerts"`
+}
+
+// SLOReport is the agent's view of all objectives
+type SLOReport struct {
+	Loading    bool        `json:"loading"`
+	Firing     int         `json:"firing"`
+	Objectives []SLOStatus `json:"objectives"`
+}
+
+// FetchSLO fetches the SLO statuses from the agent. It returns nil without
+// an error when the agent has no SLOs configured.
+func FetchSLO(agentURL, authToken string) (*SLOReport, error) {
+	url := fmt.Sprintf("%s/api/slo", agentURL)
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if authToken != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authToken))
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("agent returned status %d: %s", resp.StatusCode, body)
+	}
+
+	var result struct {
+		Status string `json:"status"`
+		SLOReport
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if result.Status == "disabled" {
+		return nil, nil
+	}
+
+	return &result.SLOReport, nil
+}
@@ -0,0 +1,272 @@
+package logs
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Stream states
+const (
+	StreamConnecting   = "connecting"
+	StreamLive         = "live"
+	StreamReconnecting = "reconnecting"
+	StreamPolling      = "polling"
+	// StreamWaiting means a local log file is missing
+	StreamWaiting = "waiting"
+	// StreamEnded means a piped input was closed
+	StreamEnded = "ended"
+)
+
+// Reconnect delays of the stream
+const (
+	streamMinBackoff = time.Second
+	streamMaxBackoff = 30 * time.Second
+	// streamMaxBatch caps the entries of one batch
+	streamMaxBatch = 500
+)
+
+// StreamBatch is what the stream delivers: new entries, a change of state,
+// or both
+type StreamBatch struct {
+	Entries []TraefikLog
+	// ErrorLines are new error log lines, in local mode
+	ErrorLines []string
+	State      string
+	// Err is why the stream is reconnecting or polling
+	Err error
+	// Retry is when the next connection attempt is made
+	Retry time.Duration
+}
+
+// Streamer follows the agent's SSE stream of access logs, reconnecting with
+// backoff when it ends or drops. Batches are read from C.
+type Streamer struct {
+	agentURL  string
+	authToken string
+	client    *http.Client
+
+	out    chan StreamBatch
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewStreamer returns a Streamer; Start connects it
+func NewStreamer(agentURL, authToken string) *Streamer {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Streamer{
+		agentURL:  agentURL,
+		authToken: authToken,
+		client:    &http.Client{},
+		out:       make(chan StreamBatch, 16),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// C delivers the batches; it is closed once the streamer stops
+func (s *Streamer) C() <-chan StreamBatch {
+	return s.out
+}
+
+// Start connects in the background
+func (s *Streamer) Start() {
+	go s.run()
+}
+
+// Stop disconnects and stops reconnecting
+func (s *Streamer) Stop() {
+	s.cancel()
+}
+
+// streamUnavailable means the agent cannot stream, as opposed to a dropped
+// connection
+type streamUnavailable struct {
+	err error
+}
+
+func (e streamUnavailable) Error() string {
+	return e.err.Error()
+}
+
+func (s *Streamer) run() {
+	defer close(s.out)
+
+	backoff := streamMinBackoff
+	for {
+		connected, err := s.connect()
+		if s.ctx.Err() != nil {
+			return
+		}
+
+		state := StreamReconnecting
+		if _, ok := err.(streamUnavailable); ok {
+			// Older agents, or too many clients: poll meanwhile
+			state = StreamPolling
+			backoff = streamMaxBackoff
+		} else if connected {
+			// The agent ends streams after a while; pick up right away
+			backoff = streamMinBackoff
+		}
+		if !s.send(StreamBatch{State: state, Err: err, Retry: backoff}) {
+			return
+		}
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if !connected {
+			backoff *= 2
+			if backoff > streamMaxBackoff {
+				backoff = streamMaxBackoff
+			}
+		}
+	}
+}
+
+// connect reads one stream until it ends. It reports whether the stream was
+// established, and why it ended.
+func (s *Streamer) connect() (bool, error) {
+	req, err := http.NewRequestWithContext(s.ctx, "GET", fmt.Sprintf("%s/api/logs/stream", s.agentURL), nil)
+	if err != nil {
+		return false, streamUnavailable{err}
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if s.authToken != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", s.authToken))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return false, streamUnavailable{fmt.Errorf("agent returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))}
+	}
+
+	if !s.send(StreamBatch{State: StreamLive}) {
+		return true, nil
+	}
+
+	reader := bufio.NewReaderSize(resp.Body, 64*1024)
+	var pending []TraefikLog
+	event, data := "", ""
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			s.flush(&pending)
+			if err == io.EOF {
+				return true, fmt.Errorf("stream closed by the agent")
+			}
+			return true, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		switch {
+		case line == "":
+			// End of an event
+			if event == "end" {
+				s.flush(&pending)
+				return true, fmt.Errorf("stream ended: %s", data)
+			}
+			if (event == "" || event == "message") && data != "" {
+				var entry TraefikLog
+				if err := json.Unmarshal([]byte(data), &entry); err == nil {
+					pending = append(pending, entry)
+				}
+			}
+			event, data = "", ""
+
+			// Deliver once caught up with what the agent sent
+			if reader.Buffered() == 0 || len(pending) >= streamMaxBatch {
+				if !s.flush(&pending) {
+					return true, nil
+				}
+			}
+		case strings.HasPrefix(line, ":"):
+			// Comment or keep-alive
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			value := strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " ")
+			if data != "" {
+				data += "\n"
+			}
+			data += value
+		}
+	}
+}
+
+// flush sends the pending entries, reporting false once stopped
+func (s *Streamer) flush(pending *[]TraefikLog) bool {
+	if len(*pending) == 0 {
+		return true
+	}
+	ok := s.send(StreamBatch{Entries: *pending, State: StreamLive})
+	*pending = nil
+	return ok
+}
+
+func (s *Streamer) send(batch StreamBatch) bool {
+	select {
+	case s.out <- batch:
+		return true
+	case <-s.ctx.Done():
+		return false
+	}
+}
+
+// Ring keeps the most recent entries up to its capacity
+type Ring struct {
+	entries []TraefikLog
+	start   int
+	size    int
+}
+
+// NewRing returns an empty Ring holding up to capacity entries
+func NewRing(capacity int) *Ring {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &Ring{entries: make([]TraefikLog, capacity)}
+}
+
+// Push adds entries, dropping the oldest ones beyond the capacity
+func (r *Ring) Push(entries ...TraefikLog) {
+	capacity := len(r.entries)
+	if len(entries) > capacity {
+		entries = entries[len(entries)-capacity:]
+	}
+	for _, entry := range entries {
+		r.entries[(r.start+r.size)%capacity] = entry
+		if r.size < capacity {
+			r.size++
+		} else {
+			r.start = (r.start + 1) % capacity
+		}
+	}
+}
+
+// Len returns the number of entries held
+func (r *Ring) Len() int {
+	return r.size
+}
+
+// Entries returns the entries, oldest first
+func (r *Ring) Entries() []TraefikLog {
+	out := make([]TraefikLog, r.size)
+	for i := range out {
+		out[i] = r.entries[(r.start+i)%len(r.entries)]
+	}
+	return out
+}
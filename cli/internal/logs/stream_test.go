@@ -0,0 +1,204 @@
+package logs
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// sse serves bodies as event streams, one per connection; the last one is
+// repeated
+func sse(t *testing.T, bodies ...string) *httptest.Server {
+	t.Helper()
+	var conns atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/logs/stream" {
+			http.NotFound(w, r)
+			return
+		}
+		n := int(conns.Add(1)) - 1
+		if n >= len(bodies) {
+			n = len(bodies) - 1
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, bodies[n])
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// next returns the next batch of s, failing after a few seconds
+func next(t *testing.T, s *Streamer) StreamBatch {
+	t.Helper()
+	select {
+	case batch, ok := <-s.C():
+		if !ok {
+			t.Fatal("stream closed")
+		}
+		return batch
+	case <-time.After(5 * time.Second):
+		t.Fatal("no batch")
+	}
+	return StreamBatch{}
+}
+
+// streamPaths reads the entries of one connection, up to the batch that
+// reports it ended
+func streamPaths(t *testing.T, s *Streamer) ([]string, StreamBatch) {
+	t.Helper()
+	var out []string
+	for {
+		batch := next(t, s)
+		for _, entry := range batch.Entries {
+			out = append(out, entry.RequestPath)
+		}
+		if batch.State != StreamLive {
+			return out, batch
+		}
+	}
+}
+
+func TestStreamerEvents(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "one event per entry",
+			body: "data: {\"RequestPath\":\"/a\"}\n\ndata: {\"RequestPath\":\"/b\"}\n\n",
+			want: []string{"/a", "/b"},
+		},
+		{
+			name: "data lines joined",
+			body: "data: {\"RequestPath\":\ndata: \"/a\"}\n\n",
+			want: []string{"/a"},
+		},
+		{
+			name: "CRLF line endings",
+			body: "data: {\"RequestPath\":\"/a\"}\r\n\r\n",
+			want: []string{"/a"},
+		},
+		{
+			name: "comments skipped",
+			body: ": keep-alive\n\ndata: {\"RequestPath\":\"/a\"}\n: inside an event\n\n",
+			want: []string{"/a"},
+		},
+		{
+			name: "message events only",
+			body: "event: stats\ndata: {\"RequestPath\":\"/stats\"}\n\nevent: message\ndata: {\"RequestPath\":\"/a\"}\n\n",
+			want: []string{"/a"},
+		},
+		{
+			name: "bad JSON skipped",
+			body: "data: {\"RequestPath\":\n\ndata: {\"RequestPath\":\"/a\"}\n\n",
+			want: []string{"/a"},
+		},
+		{
+			name: "end event",
+			body: "data: {\"RequestPath\":\"/a\"}\n\nevent: end\ndata: max duration\n\ndata: {\"RequestPath\":\"/b\"}\n\n",
+			want: []string{"/a"},
+		},
+		{
+			name: "unterminated event dropped",
+			body: "data: {\"RequestPath\":\"/a\"}\n\ndata: {\"RequestPath\":\"/b\"}",
+			want: []string{"/a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewStreamer(sse(t, tt.body).URL, "")
+			s.Start()
+			defer s.Stop()
+
+			if batch := next(t, s); batch.State != StreamLive {
+				t.Fatalf("state = %q, want %q", batch.State, StreamLive)
+			}
+			got, end := streamPaths(t, s)
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("paths = %v, want %v", got, tt.want)
+			}
+			if end.State != StreamReconnecting {
+				t.Errorf("state = %q, want %q", end.State, StreamReconnecting)
+			}
+		})
+	}
+}
+
+func TestStreamerReconnect(t *testing.T) {
+	s := NewStreamer(sse(t,
+		"data: {\"RequestPath\":\"/a\"}\n\nevent: end\ndata: max duration\n\n",
+		"data: {\"RequestPath\":\"/b\"}\n\n",
+	).URL, "")
+	s.Start()
+	defer s.Stop()
+
+	for _, want := range []string{"/a", "/b"} {
+		if batch := next(t, s); batch.State != StreamLive {
+			t.Fatalf("state = %q, want %q", batch.State, StreamLive)
+		}
+		got, end := streamPaths(t, s)
+		if len(got) != 1 || got[0] != want {
+			t.Errorf("paths = %v, want [%s]", got, want)
+		}
+		// A stream that was established is picked up again right away
+		if end.State != StreamReconnecting || end.Retry != streamMinBackoff {
+			t.Errorf("state = %q retry %v, want %q retry %v", end.State, end.Retry, StreamReconnecting, streamMinBackoff)
+		}
+	}
+}
+
+func TestStreamerUnavailable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	s := NewStreamer(srv.URL, "")
+	s.Start()
+	defer s.Stop()
+
+	batch := next(t, s)
+	if batch.State != StreamPolling || batch.Err == nil || batch.Retry != streamMaxBackoff {
+		t.Errorf("batch = %+v, want polling after %v", batch, streamMaxBackoff)
+	}
+}
+
+func TestRing(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity int
+		pushes   [][]string
+		want     []string
+	}{
+		{"empty", 3, nil, nil},
+		{"below capacity", 3, [][]string{{"a", "b"}}, []string{"a", "b"}},
+		{"at capacity", 3, [][]string{{"a", "b", "c"}}, []string{"a", "b", "c"}},
+		{"overflow one push", 3, [][]string{{"a", "b", "c", "d", "e"}}, []string{"c", "d", "e"}},
+		{"overflow across pushes", 3, [][]string{{"a", "b"}, {"c"}, {"d", "e"}}, []string{"c", "d", "e"}},
+		{"wraps twice", 2, [][]string{{"a"}, {"b"}, {"c"}, {"d"}, {"e"}}, []string{"d", "e"}},
+		{"zero capacity holds one", 0, [][]string{{"a", "b"}}, []string{"b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRing(tt.capacity)
+			for _, push := range tt.pushes {
+				entries := make([]TraefikLog, len(push))
+				for i, path := range push {
+					entries[i].RequestPath = path
+				}
+				r.Push(entries...)
+			}
+
+			var got []string
+			for _, entry := range r.Entries() {
+				got = append(got, entry.RequestPath)
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") || r.Len() != len(tt.want) {
+				t.Errorf("entries = %v (len %d), want %v", got, r.Len(), tt.want)
+			}
+		})
+	}
+}
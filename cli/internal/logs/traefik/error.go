@@ -1,6 +1,7 @@
 package traefik
 
 import (
+	"encoding/json"
 	"regexp"
 	"strings"
 )
@@ -10,6 +11,8 @@ type ErrorLog struct {
 	Timestamp string
 	Level     string
 	Message   string
+	// Fields holds the other fields of JSON and key=value lines
+	Fields    map[string]string
 }
 
 // Error log pattern for Traefik
@@ -21,6 +24,18 @@ func ParseErrorLog(logLine string) (*ErrorLog, error) {
 		return nil, nil
 	}
 
+	// JSON lines, as logged with --log.format=json
+	if strings.HasPrefix(strings.TrimSpace(logLine), "{") {
+		if entry := parseJSONErrorLog(logLine); entry != nil {
+			return entry, nil
+		}
+	}
+
+	// key=value lines, as logged by Traefik v2
+	if strings.HasPrefix(logLine, "time=") || strings.HasPrefix(logLine, "level=") {
+		return parseKeyValueErrorLog(logLine), nil
+	}
+
 	// Try to extract timestamp, level, and message
 	matches := errorPattern.FindStringSubmatch(logLine)
 	if matches == nil {
@@ -58,4 +73,68 @@ func (e *ErrorLog) GetLogLevel() string {
 	}
 	
 	return "unknown"
-}
\ No newline at end of file
+}
+
+// parseJSONErrorLog parses a JSON error log line, or returns nil
+func parseJSONErrorLog(logLine string) *ErrorLog {
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(logLine), &fields); err != nil {
+		return nil
+	}
+	values := make(map[string]string, len(fields))
+	for key, value := range fields {
+		if s, ok := value.(string); ok {
+			values[key] = s
+		} else {
+			data, _ := json.Marshal(value)
+			values[key] = string(data)
+		}
+	}
+	return errorLogFromFields(values)
+}
+
+// keyValuePattern matches key=value and key="quoted value" pairs
+var keyValuePattern = regexp.MustCompile(`([\w.-]+)=("(?:[^"\\]|\\.)*"|\S*)`)
+
+// parseKeyValueErrorLog parses a line of key=value pairs
+func parseKeyValueErrorLog(logLine string) *ErrorLog {
+	values := make(map[string]string)
+	for _, m := range keyValuePattern.FindAllStringSubmatch(logLine, -1) {
+		value := m[2]
+		if strings.HasPrefix(value, `"`) {
+			var unquoted string
+			if err := json.Unmarshal([]byte(value), &unquoted); err == nil {
+				value = unquoted
+			} else {
+				value = strings.Trim(value, `"`)
+			}
+		}
+		values[m[1]] = value
+	}
+	return errorLogFromFields(values)
+}
+
+// errorLogFromFields takes the timestamp, level and message out of the
+// fields of a line
+func errorLogFromFields(values map[string]string) *ErrorLog {
+	entry := &ErrorLog{Level: "unknown", Fields: map[string]string{}}
+	for key, value := range values {
+		switch key {
+		case "time", "timestamp", "ts":
+			entry.Timestamp = value
+		case "level", "lvl":
+			entry.Level = strings.ToLower(value)
+		case "msg", "message":
+			entry.Message = value
+		default:
+			entry.Fields[key] = value
+		}
+	}
+	if entry.Message == "" {
+		if err, ok := entry.Fields["error"]; ok {
+			entry.Message = err
+			delete(entry.Fields, "error")
+		}
+	}
+	return entry
+}
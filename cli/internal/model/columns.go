@@ -0,0 +1,358 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/config"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/logs"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/ui/styles"
+	"github.com/mattn/go-runewidth"
+)
+
+// column describes a column of the access log table
+type column struct {
+	title string
+	// min is the narrowest useful width and width the preferred one; flex
+	// columns share whatever space is left
+	min   int
+	width int
+	flex  bool
+	right bool
+	// priority decides which columns are dropped first on narrow
+	// terminals, lowest first
+	priority int
+	value    func(*logs.TraefikLog) string
+	less     func(a, b *logs.TraefikLog) bool
+}
+
+var columns = map[string]column{
+	"time": {title: "Time", min: 8, width: 8, priority: 7,
+		value: func(l *logs.TraefikLog) string { return entryTime(l) },
+		less:  entryBefore},
+	"client": {title: "Client", min: 7, width: 15, priority: 5,
+		value: func(l *logs.TraefikLog) string { return l.ClientHost },
+		less:  func(a, b *logs.TraefikLog) bool { return a.ClientHost < b.ClientHost }},
+	"method": {title: "Method", min: 4, width: 7, priority: 8,
+		value: func(l *logs.TraefikLog) string { return l.RequestMethod },
+		less:  func(a, b *logs.TraefikLog) bool { return a.RequestMethod < b.RequestMethod }},
+	"path": {title: "Path", min: 12, width: 30, flex: true, priority: 10,
+		value: func(l *logs.TraefikLog) string { return l.RequestPath },
+		less:  func(a, b *logs.TraefikLog) bool { return a.RequestPath < b.RequestPath }},
+	"host": {title: "Host", min: 8, width: 20, flex: true, priority: 6,
+		value: func(l *logs.TraefikLog) string { return l.RequestHost },
+		less:  func(a, b *logs.TraefikLog) bool { return a.RequestHost < b.RequestHost }},
+	"router": {title: "Router", min: 8, width: 18, priority: 5,
+		value: func(l *logs.TraefikLog) string { return l.RouterName },
+		less:  func(a, b *logs.TraefikLog) bool { return a.RouterName < b.RouterName }},
+	"service": {title: "Service", min: 8, width: 18, priority: 4,
+		value: func(l *logs.TraefikLog) string { return l.ServiceName },
+		less:  func(a, b *logs.TraefikLog) bool { return a.ServiceName < b.ServiceName }},
+	"backend": {title: "Backend", min: 8, width: 21, priority: 2,
+		value: func(l *logs.TraefikLog) string { return backendAddr(l) },
+		less:  func(a, b *logs.TraefikLog) bool { return backendAddr(a) < backendAddr(b) }},
+	"status": {title: "Status", min: 3, width: 6, right: true, priority: 9,
+		value: func(l *logs.TraefikLog) string { return countValue(l.DownstreamStatus) },
+		less:  func(a, b *logs.TraefikLog) bool { return a.DownstreamStatus < b.DownstreamStatus }},
+	"size": {title: "Size", min: 6, width: 8, right: true, priority: 3,
+		value: func(l *logs.TraefikLog) string { return sizeValue(l.DownstreamContentSize) },
+		less:  func(a, b *logs.TraefikLog) bool { return a.DownstreamContentSize < b.DownstreamContentSize }},
+	"duration": {title: "Duration", min: 6, width: 9, right: true, priority: 8,
+		value: func(l *logs.TraefikLog) string { return shortDuration(l.Duration) },
+		less:  func(a, b *logs.TraefikLog) bool { return a.Duration < b.Duration }},
+	"user_agent": {title: "User Agent", min: 10, width: 30, flex: true, priority: 1,
+		value: func(l *logs.TraefikLog) string { return l.RequestUserAgent },
+		less:  func(a, b *logs.TraefikLog) bool { return a.RequestUserAgent < b.RequestUserAgent }},
+}
+
+// layoutColumns fits the configured columns into width, dropping the
+// lowest priority ones until the rest fit, and returns the widths
+func layoutColumns(names []string, width int) ([]string, []int) {
+	names = append([]string(nil), names...)
+	needed := func() int {
+		total := 0
+		for _, name := range names {
+			total += minWidth(name) + 1
+		}
+		return total - 1
+	}
+	for len(names) > 1 && needed() > width {
+		drop := 0
+		for i, name := range names {
+			if columns[name].priority < columns[names[drop]].priority {
+				drop = i
+			}
+		}
+		names = append(names[:drop], names[drop+1:]...)
+	}
+
+	widths := make([]int, len(names))
+	spare := width - needed()
+	for i, name := range names {
+		widths[i] = minWidth(name)
+	}
+	// Grow every column towards its preferred width, then give the rest
+	// to the flexible ones
+	for i, name := range names {
+		grow := min(max(columns[name].width-widths[i], 0), spare)
+		widths[i] += grow
+		spare -= grow
+	}
+	var flex []int
+	for i, name := range names {
+		if columns[name].flex {
+			flex = append(flex, i)
+		}
+	}
+	for n, i := range flex {
+		grow := spare / (len(flex) - n)
+		widths[i] += grow
+		spare -= grow
+	}
+	return names, widths
+}
+
+// minWidth keeps room for the title of a column
+func minWidth(name string) int {
+	return max(columns[name].min, runewidth.StringWidth(columns[name].title))
+}
+
+// formatRow renders cells into their column widths
+func formatRow(names []string, widths []int, cell func(name string) string) string {
+	parts := make([]string, len(names))
+	for i, name := range names {
+		value := runewidth.Truncate(cell(name), widths[i], "…")
+		if columns[name].right {
+			parts[i] = runewidth.FillLeft(value, widths[i])
+		} else {
+			parts[i] = runewidth.FillRight(value, widths[i])
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// tableHeader renders the column titles, marking the sort column
+func (m Model) tableHeader(names []string, widths []int) string {
+	return formatRow(names, widths, func(name string) string {
+		title := columns[name].title
+		if name == m.cfg.SortBy {
+			if m.cfg.SortDesc {
+				return title + " ▼"
+			}
+			return title + " ▲"
+		}
+		return title
+	})
+}
+
+// sortEntries orders a copy of the entries by the sort column; without
+// one they keep the order they were logged in
+func sortEntries(entries []logs.TraefikLog, by string, desc bool) []logs.TraefikLog {
+	col, ok := columns[by]
+	if !ok {
+		return entries
+	}
+	out := make([]logs.TraefikLog, len(entries))
+	copy(out, entries)
+	sort.SliceStable(out, func(i, j int) bool {
+		if desc {
+			return col.less(&out[j], &out[i])
+		}
+		return col.less(&out[i], &out[j])
+	})
+	return out
+}
+
+// cycleSort moves the sort to the next visible column, ascending, then
+// back to log order after the last one
+func (m Model) cycleSort() (tea.Model, tea.Cmd) {
+	names := m.cfg.Columns
+	next := ""
+	if m.cfg.SortBy == "" {
+		next = names[0]
+	} else {
+		for i, name := range names {
+			if name == m.cfg.SortBy && i+1 < len(names) {
+				next = names[i+1]
+			}
+		}
+	}
+	m.cfg.SortBy = next
+	m.cfg.SortDesc = false
+	m.recalculate()
+	return m, m.saveSettings()
+}
+
+// reverseSort flips the sort order
+func (m Model) reverseSort() (tea.Model, tea.Cmd) {
+	if m.cfg.SortBy == "" {
+		return m, nil
+	}
+	m.cfg.SortDesc = !m.cfg.SortDesc
+	m.recalculate()
+	return m, m.saveSettings()
+}
+
+type settingsMsg struct {
+	err error
+}
+
+// saveSettings keeps the table settings for the next run
+func (m Model) saveSettings() tea.Cmd {
+	return func() tea.Msg {
+		return settingsMsg{err: m.cfg.SaveSettings()}
+	}
+}
+
+// columnOrder lists the shown columns in order, then the hidden ones
+func (m Model) columnOrder() []string {
+	order := append([]string(nil), m.cfg.Columns...)
+	for _, name := range config.AccessLogColumns {
+		if !m.columnShown(name) {
+			order = append(order, name)
+		}
+	}
+	return order
+}
+
+func (m Model) columnShown(name string) bool {
+	for _, shown := range m.cfg.Columns {
+		if shown == name {
+			return true
+		}
+	}
+	return false
+}
+
+// handleColumnsKey edits the columns: moving the cursor, showing or
+// hiding the column under it and moving it within the shown ones
+func (m Model) handleColumnsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	order := m.columnOrder()
+	name := order[m.columnCursor]
+
+	switch msg.String() {
+	case "ctrl+c":
+		return m.quit()
+
+	case "esc", "enter", "c", "q":
+		m.editingColumns = false
+		return m, m.saveSettings()
+
+	case "up", "k":
+		if m.columnCursor > 0 {
+			m.columnCursor--
+		}
+
+	case "down", "j":
+		if m.columnCursor < len(order)-1 {
+			m.columnCursor++
+		}
+
+	case " ", "x":
+		if m.columnShown(name) {
+			if len(m.cfg.Columns) == 1 {
+				return m, nil
+			}
+			shown := make([]string, 0, len(m.cfg.Columns)-1)
+			for _, column := range m.cfg.Columns {
+				if column != name {
+					shown = append(shown, column)
+				}
+			}
+			m.cfg.Columns = shown
+		} else {
+			m.cfg.Columns = append(append([]string(nil), m.cfg.Columns...), name)
+		}
+		// Follow the column to its new place
+		for i, column := range m.columnOrder() {
+			if column == name {
+				m.columnCursor = i
+			}
+		}
+
+	case "K", "shift+up":
+		if i := m.columnCursor; i > 0 && i < len(m.cfg.Columns) {
+			m.cfg.Columns = swapColumns(m.cfg.Columns, i, i-1)
+			m.columnCursor--
+		}
+
+	case "J", "shift+down":
+		if i := m.columnCursor; i < len(m.cfg.Columns)-1 {
+			m.cfg.Columns = swapColumns(m.cfg.Columns, i, i+1)
+			m.columnCursor++
+		}
+	}
+	return m, nil
+}
+
+func swapColumns(names []string, i, j int) []string {
+	out := append([]string(nil), names...)
+	out[i], out[j] = out[j], out[i]
+	return out
+}
+
+// renderColumns renders the column editor
+func (m Model) renderColumns() string {
+	var sb strings.Builder
+	sb.WriteString(styles.SubtitleStyle.Render("Columns"))
+	sb.WriteString("\n\n")
+	for i, name := range m.columnOrder() {
+		mark := "[ ]"
+		if m.columnShown(name) {
+			mark = "[x]"
+		}
+		line := fmt.Sprintf("%s %s", mark, columns[name].title)
+		if i == m.columnCursor {
+			sb.WriteString(styles.SelectedStyle.Render(line))
+		} else {
+			sb.WriteString(styles.DefaultStyle.Render(line))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// entryTime is the local time of day an entry started
+func entryTime(l *logs.TraefikLog) string {
+	if t, err := logs.ParseTime(l.StartUTC); err == nil {
+		return t.Local().Format("15:04:05")
+	}
+	if t, err := logs.ParseTime(l.StartLocal); err == nil {
+		return t.Format("15:04:05")
+	}
+	return ""
+}
+
+// entryBefore orders entries by when they started. Timestamps are parsed,
+// as their fractional seconds vary in length; unreadable ones come first.
+func entryBefore(a, b *logs.TraefikLog) bool {
+	ta, errA := logs.ParseTime(a.StartUTC)
+	tb, errB := logs.ParseTime(b.StartUTC)
+	if errA != nil || errB != nil {
+		return errA != nil && errB == nil
+	}
+	return ta.Before(tb)
+}
+
+func backendAddr(l *logs.TraefikLog) string {
+	if l.ServiceAddr != "" {
+		return l.ServiceAddr
+	}
+	return l.ServiceURL
+}
+
+// shortDuration formats a duration in nanoseconds for the table
+func shortDuration(ns int64) string {
+	switch {
+	case ns <= 0:
+		return ""
+	case ns < 1_000_000:
+		return fmt.Sprintf("%dµs", ns/1000)
+	case ns < 10_000_000_000:
+		return fmt.Sprintf("%dms", ns/1_000_000)
+	default:
+		return fmt.Sprintf("%.1fs", float64(ns)/1e9)
+	}
+}
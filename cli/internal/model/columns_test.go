@@ -0,0 +1,98 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/config"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/logs"
+)
+
+func TestColumnsDefined(t *testing.T) {
+	for _, name := range config.AccessLogColumns {
+		col, ok := columns[name]
+		if !ok {
+			t.Errorf("%s: no column", name)
+			continue
+		}
+		if col.value == nil || col.less == nil || col.min > col.width {
+			t.Errorf("%s: incomplete column %+v", name, col)
+		}
+	}
+	if len(columns) != len(config.AccessLogColumns) {
+		t.Errorf("%d columns, %d configurable", len(columns), len(config.AccessLogColumns))
+	}
+}
+
+func TestLayoutColumns(t *testing.T) {
+	tests := []struct {
+		names  []string
+		width  int
+		want   []string
+		widths []int
+	}{
+		// Room to spare goes to the flexible path
+		{[]string{"time", "status", "path"}, 60, []string{"time", "status", "path"}, []int{8, 6, 44}},
+		// Preferred widths first, then the minimums
+		{[]string{"time", "status", "path"}, 40, []string{"time", "status", "path"}, []int{8, 6, 24}},
+		{[]string{"time", "status", "path"}, 28, []string{"time", "status", "path"}, []int{8, 6, 12}},
+		// The lowest priority columns go first
+		{[]string{"time", "user_agent", "status", "path"}, 30, []string{"time", "status", "path"}, []int{8, 6, 14}},
+		{[]string{"time", "status", "path"}, 20, []string{"status", "path"}, []int{6, 13}},
+		// One column is always kept, cut to the width
+		{[]string{"path"}, 5, []string{"path"}, []int{5}},
+		// Flexible columns share what is left
+		{[]string{"path", "host"}, 61, []string{"path", "host"}, []int{35, 25}},
+	}
+	for _, tt := range tests {
+		names, widths := layoutColumns(tt.names, tt.width)
+		if strings.Join(names, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%v in %d: columns %v, want %v", tt.names, tt.width, names, tt.want)
+			continue
+		}
+		for i := range widths {
+			if widths[i] != tt.widths[i] {
+				t.Errorf("%v in %d: widths %v, want %v", tt.names, tt.width, widths, tt.widths)
+				break
+			}
+		}
+	}
+}
+
+func TestSortEntries(t *testing.T) {
+	entries := []logs.TraefikLog{
+		{RequestPath: "/a", DownstreamStatus: 500, Duration: 3e6, StartUTC: "2024-05-01T10:00:00.5Z"},
+		{RequestPath: "/b", DownstreamStatus: 200, Duration: 1e6, StartUTC: "2024-05-01T10:00:01Z"},
+		{RequestPath: "/c", DownstreamStatus: 200, Duration: 2e6, StartUTC: "2024-05-01T10:00:00Z"},
+		{RequestPath: "/d", DownstreamStatus: 404, Duration: 2e6, StartUTC: ""},
+	}
+	tests := []struct {
+		by   string
+		desc bool
+		want string
+	}{
+		{"", false, "/a /b /c /d"},
+		{"bogus", true, "/a /b /c /d"},
+		{"status", false, "/b /c /d /a"},
+		{"status", true, "/a /d /b /c"},
+		// Ties keep the order they were logged in either way
+		{"duration", false, "/b /c /d /a"},
+		{"duration", true, "/a /c /d /b"},
+		{"path", true, "/d /c /b /a"},
+		{"time", false, "/d /c /a /b"},
+		{"time", true, "/b /a /c /d"},
+	}
+	for _, tt := range tests {
+		sorted := sortEntries(entries, tt.by, tt.desc)
+		var got []string
+		for _, entry := range sorted {
+			got = append(got, entry.RequestPath)
+		}
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("by %q desc %v: %v, want %s", tt.by, tt.desc, got, tt.want)
+		}
+	}
+	if entries[0].RequestPath != "/a" || entries[3].RequestPath != "/d" {
+		t.Errorf("entries reordered in place: %v", entries)
+	}
+}
@@ -0,0 +1,366 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aymanbagabas/go-osc52/v2"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/logs"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/logs/traefik"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/ui/styles"
+)
+
+type copiedMsg struct {
+	what string
+	err  error
+}
+
+// openDetail shows the selected entry of a log view
+func (m Model) openDetail() (tea.Model, tea.Cmd) {
+	switch m.currentView {
+	case AccessLogsView:
+		m.detail = len(m.accessLogs) > 0
+	case ErrorLogsView:
+		m.detail = len(m.errorLogs) > 0
+	}
+	m.notice = ""
+	return m, nil
+}
+
+// handleDetailKey navigates between entries and copies the one shown
+func (m Model) handleDetailKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	count := len(m.accessLogs)
+	if m.currentView == ErrorLogsView {
+		count = len(m.errorLogs)
+	}
+
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return m.quit()
+
+	case "esc", "enter", "backspace":
+		m.detail = false
+		return m, nil
+
+	case "n", "right", "l", "down", "j":
+		if m.selectedIndex < count-1 {
+			m.selectedIndex++
+		}
+		return m, nil
+
+	case "p", "left", "h", "up", "k":
+		if m.selectedIndex > 0 {
+			m.selectedIndex--
+		}
+		return m, nil
+
+	case "c":
+		if m.selectedIndex >= count {
+			return m, nil
+		}
+		var data []byte
+		if m.currentView == ErrorLogsView {
+			data, _ = json.MarshalIndent(errorLogJSON(m.errorLogs[m.selectedIndex]), "", "  ")
+		} else {
+			data, _ = json.MarshalIndent(m.accessLogs[m.selectedIndex], "", "  ")
+		}
+		return m, copyToClipboard("JSON", string(data))
+
+	case "u":
+		if m.currentView != AccessLogsView || m.selectedIndex >= count {
+			return m, nil
+		}
+		return m, copyToClipboard("curl command", curlCommand(&m.accessLogs[m.selectedIndex]))
+	}
+	return m, nil
+}
+
+// copyToClipboard copies text through the terminal with OSC 52, which works
+// over SSH when the terminal supports it
+func copyToClipboard(what, text string) tea.Cmd {
+	return func() tea.Msg {
+		seq := osc52.New(text)
+		if os.Getenv("TMUX") == "" && strings.HasPrefix(os.Getenv("TERM"), "screen") {
+			seq = seq.Screen()
+		}
+		_, err := seq.WriteTo(os.Stdout)
+		return copiedMsg{what: what, err: err}
+	}
+}
+
+// curlCommand replays a request with the headers Traefik logged
+func curlCommand(entry *logs.TraefikLog) string {
+	scheme := entry.RequestScheme
+	if scheme == "" {
+		scheme = "http"
+	}
+	host := entry.RequestAddr
+	if host == "" {
+		host = entry.RequestHost
+	}
+
+	parts := []string{"curl"}
+	if entry.RequestMethod != "" && entry.RequestMethod != "GET" {
+		parts = append(parts, "-X", entry.RequestMethod)
+	}
+	parts = append(parts, shellQuote(scheme+"://"+host+entry.RequestPath))
+	for _, name := range entry.HeaderNames() {
+		header := strings.TrimPrefix(name, "request_")
+		if header == name || skipCurlHeader(header) {
+			continue
+		}
+		parts = append(parts, "-H", shellQuote(header+": "+entry.Headers[name]))
+	}
+	return strings.Join(parts, " ")
+}
+
+// skipCurlHeader leaves out the headers Traefik or curl set themselves
+func skipCurlHeader(header string) bool {
+	header = strings.ToLower(header)
+	return strings.HasPrefix(header, "x-forwarded-") || header == "x-real-ip" || header == "content-length" || header == "host"
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// errorLogJSON describes an error log line for copying
+func errorLogJSON(line string) map[string]interface{} {
+	out := map[string]interface{}{"raw": line}
+	entry, _ := traefik.ParseErrorLog(line)
+	if entry == nil {
+		return out
+	}
+	out["time"] = entry.Timestamp
+	out["level"] = entry.Level
+	out["message"] = entry.Message
+	if len(entry.Fields) > 0 {
+		out["fields"] = entry.Fields
+	}
+	return out
+}
+
+// detailRow is a labelled value of the detail pane; empty values are left
+// out
+type detailRow struct {
+	label string
+	value string
+}
+
+// renderSection renders a group of rows under a title
+func renderSection(title string, rows []detailRow, width int) string {
+	var sb strings.Builder
+	sb.WriteString(styles.CardTitleStyle.Render(title))
+	labelWidth := 14
+	for _, row := range rows {
+		if row.value == "" {
+			continue
+		}
+		sb.WriteString("\n")
+		sb.WriteString(styles.CardLabelStyle.Render(fmt.Sprintf("  %-*s", labelWidth, row.label)))
+		sb.WriteString(truncate(row.value, max(width-labelWidth-4, 10)))
+	}
+	return sb.String()
+}
+
+// renderDetail renders the detail pane of the selected entry
+func (m Model) renderDetail() string {
+	if m.currentView == ErrorLogsView {
+		if m.selectedIndex >= len(m.errorLogs) {
+			return styles.MutedStyle.Render("No entry selected")
+		}
+		return m.renderErrorDetail(m.errorLogs[m.selectedIndex])
+	}
+	if m.selectedIndex >= len(m.accessLogs) {
+		return styles.MutedStyle.Render("No entry selected")
+	}
+	entry := &m.accessLogs[m.selectedIndex]
+
+	title := styles.SubtitleStyle.Render(fmt.Sprintf("Access Log %d of %d", m.selectedIndex+1, len(m.accessLogs)))
+
+	// Two columns on wide terminals
+	columns := 1
+	if m.width >= 110 {
+		columns = 2
+	}
+	colWidth := (m.width - 4) / columns
+
+	client := renderSection("Client", []detailRow{
+		{"Address", entry.ClientAddr},
+		{"Host", entry.ClientHost},
+		{"Port", entry.ClientPort},
+		{"Username", entry.ClientUsername},
+		{"User agent", entry.RequestUserAgent},
+		{"Referer", entry.RequestReferer},
+	}, colWidth)
+	request := renderSection("Request", []detailRow{
+		{"Method", entry.RequestMethod},
+		{"URL", requestURL(entry)},
+		{"Protocol", entry.RequestProtocol},
+		{"Entrypoint", entry.EntryPointName},
+		{"Size", sizeValue(entry.RequestContentSize)},
+		{"Count", countValue(entry.RequestCount)},
+	}, colWidth)
+	response := renderSection("Response", []detailRow{
+		{"Status", statusValue(entry.DownstreamStatus)},
+		{"Size", sizeValue(entry.DownstreamContentSize)},
+	}, colWidth)
+	routing := renderSection("Routing", []detailRow{
+		{"Router", entry.RouterName},
+		{"Service", entry.ServiceName},
+		{"Retries", countValue(entry.RetryAttempts)},
+	}, colWidth)
+	backend := renderSection("Backend", []detailRow{
+		{"URL", entry.ServiceURL},
+		{"Address", entry.ServiceAddr},
+		{"Status", statusValue(entry.OriginStatus)},
+		{"Size", sizeValue(entry.OriginContentSize)},
+	}, colWidth)
+	timing := renderSection("Timing", []detailRow{
+		{"Start", entry.StartUTC},
+		{"Local", entry.StartLocal},
+		{"Duration", durationValue(entry.Duration)},
+		{"Backend", durationValue(entry.OriginDuration)},
+		{"Traefik", durationValue(entry.Overhead)},
+	}, colWidth) + "\n" + timingBar(entry, colWidth-4)
+	tls := ""
+	if entry.TLSVersion != "" || entry.TLSCipher != "" {
+		tls = renderSection("TLS", []detailRow{
+			{"Version", entry.TLSVersion},
+			{"Cipher", entry.TLSCipher},
+			{"Client", entry.TLSClientSubject},
+		}, colWidth)
+	}
+
+	left := []string{client, request, response}
+	right := []string{routing, backend, timing}
+	if tls != "" {
+		right = append(right, tls)
+	}
+
+	var body string
+	if columns == 2 {
+		leftCol := lipgloss.NewStyle().Width(colWidth).Render(strings.Join(left, "\n\n"))
+		rightCol := lipgloss.NewStyle().Width(colWidth).Render(strings.Join(right, "\n\n"))
+		body = lipgloss.JoinHorizontal(lipgloss.Top, leftCol, rightCol)
+	} else {
+		body = strings.Join(append(left, right...), "\n\n")
+	}
+
+	if names := entry.HeaderNames(); len(names) > 0 {
+		rows := make([]detailRow, 0, len(names))
+		for _, name := range names {
+			rows = append(rows, detailRow{name, entry.Headers[name]})
+		}
+		body += "\n\n" + renderSection("Headers", rows, m.width-4)
+	}
+
+	return title + "\n\n" + body
+}
+
+// renderErrorDetail renders the detail pane of an error log line
+func (m Model) renderErrorDetail(line string) string {
+	title := styles.SubtitleStyle.Render(fmt.Sprintf("Error Log %d of %d", m.selectedIndex+1, len(m.errorLogs)))
+	entry, _ := traefik.ParseErrorLog(line)
+	if entry == nil {
+		return title
+	}
+
+	level := entry.Level
+	switch entry.GetLogLevel() {
+	case "error":
+		level = styles.ErrorStyle.Render(level)
+	case "warning":
+		level = styles.WarningStyle.Render(level)
+	}
+	body := renderSection("Entry", []detailRow{
+		{"Time", entry.Timestamp},
+		{"Level", level},
+		{"Message", entry.Message},
+	}, m.width-4)
+
+	if len(entry.Fields) > 0 {
+		names := make([]string, 0, len(entry.Fields))
+		for name := range entry.Fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		rows := make([]detailRow, 0, len(names))
+		for _, name := range names {
+			rows = append(rows, detailRow{name, entry.Fields[name]})
+		}
+		body += "\n\n" + renderSection("Fields", rows, m.width-4)
+	}
+
+	raw := lipgloss.NewStyle().Width(m.width - 6).Foreground(styles.Muted).Render(line)
+	return title + "\n\n" + body + "\n\n" + styles.CardTitleStyle.Render("Raw") + "\n" + raw
+}
+
+// timingBar splits the duration between the backend and Traefik
+func timingBar(entry *logs.TraefikLog, width int) string {
+	origin, overhead := entry.OriginDuration, entry.Overhead
+	if overhead == 0 && entry.Duration > origin {
+		overhead = entry.Duration - origin
+	}
+	total := origin + overhead
+	if total <= 0 || width < 10 {
+		return ""
+	}
+
+	originWidth := int(float64(width)*float64(origin)/float64(total) + 0.5)
+	bar := lipgloss.NewStyle().Foreground(styles.Primary).Render(strings.Repeat("█", originWidth)) +
+		lipgloss.NewStyle().Foreground(styles.Warning).Render(strings.Repeat("█", width-originWidth))
+	legend := fmt.Sprintf("%s %.0f%%  %s %.0f%%",
+		lipgloss.NewStyle().Foreground(styles.Primary).Render("■ Backend"), float64(origin)/float64(total)*100,
+		lipgloss.NewStyle().Foreground(styles.Warning).Render("■ Traefik"), float64(overhead)/float64(total)*100)
+	return "  " + bar + "\n  " + legend
+}
+
+func requestURL(entry *logs.TraefikLog) string {
+	host := entry.RequestAddr
+	if host == "" {
+		host = entry.RequestHost
+	}
+	if host == "" {
+		return entry.RequestPath
+	}
+	scheme := entry.RequestScheme
+	if scheme == "" {
+		scheme = "http"
+	}
+	return scheme + "://" + host + entry.RequestPath
+}
+
+func statusValue(status int) string {
+	if status == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d %s", status, styles.StatusBadge(status))
+}
+
+func sizeValue(size int) string {
+	if size == 0 {
+		return ""
+	}
+	return formatBytes(int64(size))
+}
+
+func countValue(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+func durationValue(ns int64) string {
+	if ns == 0 {
+		return ""
+	}
+	return time.Duration(ns).String()
+}
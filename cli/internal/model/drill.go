@@ -0,0 +1,86 @@
+package model
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/logs"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/ui/dashboard"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/ui/styles"
+)
+
+// drillOrigin is the dashboard a drill-down left from. The focused card
+// and row are kept by the model, so the filter is all there is to restore.
+type drillOrigin struct {
+	crumbs      []string
+	filterInput string
+}
+
+// drillDown shows the access logs behind the selected row of the focused
+// card, narrowing the filter in use to them
+func (m Model) drillDown() (tea.Model, tea.Cmd) {
+	data := m.dashboardData()
+	rows := dashboard.Rows(m.layout, data, m.focusCard)
+	if m.cardRow < 0 || m.cardRow >= len(rows) {
+		return m, nil
+	}
+	row := rows[m.cardRow]
+	names := dashboard.Cards(m.layout, data)
+
+	origin := &drillOrigin{
+		crumbs:      []string{"Dashboard", dashboard.CardTitle(names[m.focusCard]), row.Label},
+		filterInput: m.filterInput,
+	}
+	m.setFilter(strings.TrimSpace(m.filterInput + " " + row.Filter))
+	if m.filterErr != nil {
+		m.setFilter(origin.filterInput)
+		return m, nil
+	}
+	m.origin = origin
+	m.currentView = AccessLogsView
+	if row.Entry != nil {
+		for i := range m.accessLogs {
+			if sameEntry(&m.accessLogs[i], row.Entry) {
+				m.selectedIndex = i
+				break
+			}
+		}
+	}
+	return m, nil
+}
+
+// drillBack returns to the dashboard a drill-down left from
+func (m Model) drillBack() (tea.Model, tea.Cmd) {
+	m.setFilter(m.origin.filterInput)
+	m.origin = nil
+	m.currentView = DashboardView
+	return m, nil
+}
+
+// sameEntry tells whether two entries are the same request
+func sameEntry(a, b *logs.TraefikLog) bool {
+	return a.StartUTC == b.StartUTC &&
+		a.ClientHost == b.ClientHost &&
+		a.RequestMethod == b.RequestMethod &&
+		a.RequestPath == b.RequestPath &&
+		a.DownstreamStatus == b.DownstreamStatus
+}
+
+// renderBreadcrumb shows where the access logs were drilled into from;
+// empty when they were not
+func (m Model) renderBreadcrumb() string {
+	if m.origin == nil {
+		return ""
+	}
+	sep := styles.MutedStyle.Render(" › ")
+	crumbs := make([]string, len(m.origin.crumbs))
+	for i, crumb := range m.origin.crumbs {
+		crumbs[i] = styles.MutedStyle.Render(crumb)
+		if i == len(crumbs)-1 {
+			crumbs[i] = styles.AccentStyle.Render(crumb)
+		}
+	}
+	line := strings.Join(crumbs, sep) + "  " + styles.MutedStyle.Render("esc: back")
+	return lipgloss.NewStyle().MaxWidth(m.width - 4).Render(line)
+}
@@ -0,0 +1,192 @@
+package model
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/logs/filter"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/ui/styles"
+)
+
+// maxFilterHistory caps the filters remembered
+const maxFilterHistory = 20
+
+// highlightStyle marks the text a filter matched
+var highlightStyle = lipgloss.NewStyle().
+	Background(styles.Warning).
+	Foreground(lipgloss.Color("#000000"))
+
+// startFilter opens the filter bar on the current filter
+func (m Model) startFilter() (tea.Model, tea.Cmd) {
+	m.filtering = true
+	m.filterSaved = m.filterInput
+	m.historyIndex = len(m.filterHistory)
+	return m, nil
+}
+
+// handleFilterKey edits the filter; the list narrows as it is typed
+func (m Model) handleFilterKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return m.quit()
+
+	case tea.KeyEnter:
+		m.filtering = false
+		if m.filterErr != nil {
+			// Keep editing until the filter parses
+			m.filtering = true
+			return m, nil
+		}
+		m.rememberFilter(m.filterInput)
+		return m, nil
+
+	case tea.KeyEsc:
+		// Back to the filter in use before editing
+		m.filtering = false
+		m.setFilter(m.filterSaved)
+		return m, nil
+
+	case tea.KeyUp:
+		if m.historyIndex > 0 {
+			m.historyIndex--
+			m.setFilter(m.filterHistory[m.historyIndex])
+		}
+		return m, nil
+
+	case tea.KeyDown:
+		if m.historyIndex < len(m.filterHistory)-1 {
+			m.historyIndex++
+			m.setFilter(m.filterHistory[m.historyIndex])
+		} else {
+			m.historyIndex = len(m.filterHistory)
+			m.setFilter("")
+		}
+		return m, nil
+
+	case tea.KeyBackspace:
+		if r := []rune(m.filterInput); len(r) > 0 {
+			m.setFilter(string(r[:len(r)-1]))
+		}
+		return m, nil
+
+	case tea.KeyCtrlU:
+		m.setFilter("")
+		return m, nil
+
+	case tea.KeyCtrlW:
+		// Delete the last word
+		input := strings.TrimRight(m.filterInput, " ")
+		if i := strings.LastIndex(input, " "); i >= 0 {
+			m.setFilter(input[:i+1])
+		} else {
+			m.setFilter("")
+		}
+		return m, nil
+
+	case tea.KeySpace:
+		m.setFilter(m.filterInput + " ")
+		return m, nil
+
+	case tea.KeyRunes:
+		m.setFilter(m.filterInput + string(msg.Runes))
+		return m, nil
+	}
+	return m, nil
+}
+
+// setFilter applies a filter expression. One that does not parse leaves
+// the last valid filter in use.
+func (m *Model) setFilter(input string) {
+	m.filterInput = input
+	f, err := filter.Parse(input)
+	m.filterErr = err
+	if err != nil {
+		return
+	}
+	m.filter = f
+	m.selectedIndex = 0
+	m.recalculate()
+}
+
+// rememberFilter adds a filter to the history, most recent last
+func (m *Model) rememberFilter(input string) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return
+	}
+	history := make([]string, 0, len(m.filterHistory)+1)
+	for _, h := range m.filterHistory {
+		if h != input {
+			history = append(history, h)
+		}
+	}
+	history = append(history, input)
+	if len(history) > maxFilterHistory {
+		history = history[len(history)-maxFilterHistory:]
+	}
+	m.filterHistory = history
+	m.historyIndex = len(history)
+}
+
+// renderFilterBar shows the filter being edited or in use; empty when
+// there is none
+func (m Model) renderFilterBar() string {
+	if !m.filtering && m.filter.Empty() {
+		return ""
+	}
+
+	line := styles.AccentStyle.Render("/") + " " + m.filterInput
+	if m.filtering {
+		line += "█"
+	}
+	switch {
+	case m.filterErr != nil:
+		line += "  " + styles.ErrorStyle.Render(m.filterErr.Error())
+	case m.filtering:
+		line += "  " + styles.MutedStyle.Render("enter: apply • esc: cancel • ↑/↓: history")
+	case m.origin != nil:
+		line += "  " + styles.MutedStyle.Render("/: edit • esc: back")
+	default:
+		line += "  " + styles.MutedStyle.Render("/: edit • esc: clear")
+	}
+	return lipgloss.NewStyle().Width(m.width).Padding(0, 2).Render(line)
+}
+
+// highlight marks the parts of a line that the filter searched for
+func highlight(line string, terms []string, base lipgloss.Style) string {
+	if len(terms) == 0 {
+		return base.Render(line)
+	}
+
+	// Mark the matched runes, then render runs of marked and unmarked text
+	lower := strings.ToLower(line)
+	marked := make([]bool, len(line))
+	for _, term := range terms {
+		for start := 0; start < len(lower); {
+			i := strings.Index(lower[start:], term)
+			if i < 0 {
+				break
+			}
+			for j := start + i; j < start+i+len(term) && j < len(marked); j++ {
+				marked[j] = true
+			}
+			start += i + len(term)
+		}
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(line); {
+		j := i
+		for j < len(line) && marked[j] == marked[i] {
+			j++
+		}
+		if marked[i] {
+			sb.WriteString(highlightStyle.Render(line[i:j]))
+		} else {
+			sb.WriteString(base.Render(line[i:j]))
+		}
+		i = j
+	}
+	return sb.String()
+}
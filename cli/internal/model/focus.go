@@ -0,0 +1,93 @@
+package model
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/ui/dashboard"
+)
+
+// dashboardData is what the dashboard cards are drawn from
+func (m Model) dashboardData() dashboard.Data {
+	return dashboard.Data{
+		Metrics:     m.metrics,
+		Entries:     m.accessLogs,
+		SystemStats: m.systemStats,
+	}
+}
+
+// moveFocus focuses the next or previous dashboard card, wrapping around
+func (m Model) moveFocus(step int) (tea.Model, tea.Cmd) {
+	if m.metrics == nil {
+		return m, nil
+	}
+	count := len(dashboard.Cards(m.layout, m.dashboardData()))
+	if count == 0 {
+		return m, nil
+	}
+	m.cardRow = -1
+	if !m.focusing {
+		m.focusing = true
+		m.focusCard = 0
+		if step < 0 {
+			m.focusCard = count - 1
+		}
+		return m, nil
+	}
+	m.focusCard = ((m.focusCard+step)%count + count) % count
+	return m, nil
+}
+
+// moveRow selects the next or previous row of the focused card, moving on
+// to the next or previous card past its ends
+func (m Model) moveRow(step int) (tea.Model, tea.Cmd) {
+	rows := dashboard.Rows(m.layout, m.dashboardData(), m.focusCard)
+	row := m.cardRow + step
+	if row < -1 || row >= len(rows) {
+		// An expanded card keeps the focus
+		if m.expanded {
+			return m, nil
+		}
+		return m.moveFocus(step)
+	}
+	m.cardRow = row
+	return m, nil
+}
+
+// handleFocusKey moves between the dashboard cards and their rows, and
+// expands the focused card
+func (m Model) handleFocusKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "tab", "right", "l":
+		return m.moveFocus(1)
+
+	case "shift+tab", "left", "h":
+		return m.moveFocus(-1)
+
+	case "down", "j":
+		return m.moveRow(1)
+
+	case "up", "k":
+		return m.moveRow(-1)
+
+	case "enter":
+		if m.cardRow >= 0 {
+			return m.drillDown()
+		}
+		m.expanded = !m.expanded
+		return m, nil
+
+	case "z":
+		m.expanded = !m.expanded
+		return m, nil
+
+	case "esc":
+		if m.cardRow >= 0 {
+			m.cardRow = -1
+		} else if m.expanded {
+			m.expanded = false
+		} else {
+			m.focusing = false
+		}
+		return m, nil
+	}
+	return m.handleNormalKey(msg)
+}
@@ -1,11 +1,16 @@
 package model
 
 import (
+	"os"
+	"regexp"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/config"
 	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/logs"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/logs/filter"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/logs/local"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/ui/dashboard"
 )
 
 // ViewMode represents the current view
@@ -24,17 +29,74 @@ type Model struct {
 	width           int
 	height          int
 	
-	// Data
+	// Data; accessLogs holds the entries that pass the filter, out of
+	// totalLogs, and errorLogs the errorLines within the time range
 	accessLogs      []logs.TraefikLog
+	totalLogs       int
+	demoLogs        []logs.TraefikLog
 	errorLogs       []string
+	errorLines      []string
 	metrics         *logs.Metrics
 	systemStats     *logs.SystemStats
+	agentData       agentData
+	
+	// Streaming: recent entries are kept in ring, fed by streamer while
+	// it is live and by polling otherwise. In local mode the streamer
+	// tails the log files.
+	ring            *logs.Ring
+	streamer        streamer
+	streamState     string
+	streamRetry     time.Duration
+	streamErr       error
+	
+	// Filter: narrows the access logs and the metrics. While filtering,
+	// keys edit filterInput; filterSaved is restored on escape.
+	filter          *filter.Filter
+	filterInput     string
+	filterSaved     string
+	filtering       bool
+	filterErr       error
+	filterHistory   []string
+	historyIndex    int
+	
+	// Time range: rangeLogs holds what the agent sent for it; rangeSeq
+	// tells the answers to earlier ranges apart
+	timeRange       timeRange
+	rangeLogs       []logs.TraefikLog
+	rangeSeq        int
+	picking         bool
+	pickCursor      int
+	customEditing   bool
+	customInputs    [2]string
+	customField     int
+	customErr       error
+	
+	// Dashboard: the card layout, and the focused card while moving
+	// between them, with its selected row or -1. origin is where the
+	// access logs were drilled into from, restored on escape.
+	layout          dashboard.Layout
+	focusing        bool
+	focusCard       int
+	cardRow         int
+	expanded        bool
+	origin          *drillOrigin
+	
+	// detail shows the selected entry of the log view
+	detail          bool
+	
+	// Column editor of the access log table
+	editingColumns  bool
+	columnCursor    int
 	
 	// State
 	loading         bool
 	err             error
 	lastUpdate      time.Time
 	selectedIndex   int
+	exporting       bool
+	exportStatus    string
+	// notice reports the outcome of the last action, such as a copy
+	notice          string
 	
 	// Navigation
 	activeTab       int
@@ -43,23 +105,75 @@ type Model struct {
 	quitting        bool
 }
 
+// streamer delivers batches of entries as they are logged
+type streamer interface {
+	C() <-chan logs.StreamBatch
+	Start()
+	Stop()
+}
+
 // NewModel creates a new Model
 func NewModel(cfg *config.Config) Model {
-	return Model{
+	m := Model{
 		cfg:         cfg,
 		currentView: DashboardView,
 		loading:     true,
 		lastUpdate:  time.Now(),
 		activeTab:   0,
+		ring:        logs.NewRing(cfg.MaxLogs),
+		streamState: logs.StreamPolling,
+	}
+	layout, err := dashboard.ParseLayout(cfg.Layout)
+	if err != nil {
+		// Checked by the tui command; fall back on bad settings
+		layout, _ = dashboard.ParseLayout(dashboard.DefaultLayout)
+	}
+	m.layout = layout
+	var strip *regexp.Regexp
+	if cfg.StripPrefix != "" {
+		// Checked by Validate
+		strip = regexp.MustCompile(cfg.StripPrefix)
 	}
+	switch {
+	case cfg.PipeMode:
+		m.streamer = local.NewPipe(os.Stdin, strip)
+		m.streamState = logs.StreamConnecting
+	case cfg.LocalMode:
+		m.streamer = local.New(local.Options{
+			AccessPath:  cfg.AccessLogPath,
+			ErrorPath:   cfg.ErrorLogPath,
+			MaxEntries:  cfg.MaxLogs,
+			MaxErrors:   maxErrorLogs,
+			StripPrefix: strip,
+		})
+		m.streamState = logs.StreamConnecting
+	case cfg.Streaming && !cfg.DemoMode:
+		m.streamer = logs.NewStreamer(cfg.AgentURL, cfg.AuthToken)
+		m.streamState = logs.StreamConnecting
+	}
+	return m
 }
 
 // Init initializes the model
 func (m Model) Init() tea.Cmd {
-	return tea.Batch(
-		m.fetchData(),
-		m.tick(),
-	)
+	cmds := []tea.Cmd{m.fetchData(), m.tick()}
+	if m.streamer != nil {
+		m.streamer.Start()
+		cmds = append(cmds, m.waitForStream())
+	}
+	return tea.Batch(cmds...)
+}
+
+// waitForStream returns a command that delivers the next stream batch
+func (m Model) waitForStream() tea.Cmd {
+	streamer := m.streamer
+	return func() tea.Msg {
+		batch, ok := <-streamer.C()
+		if !ok {
+			return nil
+		}
+		return streamMsg(batch)
+	}
 }
 
 // tick returns a command that waits for the refresh interval
@@ -69,23 +183,50 @@ func (m Model) tick() tea.Cmd {
 	})
 }
 
-// fetchData fetches all data from the agent
+// maxErrorLogs caps the error log lines shown
+const maxErrorLogs = 100
+
+// fetchData fetches all data from the agent. While the stream is live,
+// access logs come from it and are not polled. In local mode there is
+// nothing to fetch.
 func (m Model) fetchData() tea.Cmd {
+	if m.cfg.LocalMode {
+		return nil
+	}
+	live := m.streamState == logs.StreamLive
 	return func() tea.Msg {
 		// Fetch access logs
-		accessLogs, err := logs.FetchAccessLogs(m.cfg.AgentURL, m.cfg.AuthToken, m.cfg.MaxLogs)
-		if err != nil {
-			return errMsg{err}
+		var accessLogs []logs.TraefikLog
+		if !live {
+			var err error
+			accessLogs, err = logs.FetchAccessLogs(m.cfg.AgentURL, m.cfg.AuthToken, m.cfg.MaxLogs)
+			if err != nil {
+				return errMsg{err}
+			}
 		}
 
 		// Fetch error logs
-		errorLogs, err := logs.FetchErrorLogs(m.cfg.AgentURL, m.cfg.AuthToken, 100)
+		errorLogs, err := logs.FetchErrorLogs(m.cfg.AgentURL, m.cfg.AuthToken, maxErrorLogs)
 		if err != nil {
 			return errMsg{err}
 		}
 
-		// Calculate metrics
-		metrics := logs.CalculateMetrics(accessLogs)
+		// Backend health, SLOs and latency are optional; older agents
+		// don't serve them
+		var data agentData
+		if backends, err := logs.FetchBackends(m.cfg.AgentURL, m.cfg.AuthToken); err == nil {
+			data.backends = backends
+		}
+		if objectives, err := logs.FetchSLO(m.cfg.AgentURL, m.cfg.AuthToken); err == nil {
+			data.slo = objectives
+		}
+		since, until := time.Now().Add(-time.Hour), time.Time{}
+		if m.timeRange.active() {
+			since, until = m.timeRange.bounds()
+		}
+		if heatmap, err := logs.FetchLatency(m.cfg.AgentURL, m.cfg.AuthToken, since, until); err == nil {
+			data.latency = heatmap
+		}
 
 		// Fetch system stats if enabled
 		var systemStats *logs.SystemStats
@@ -95,9 +236,59 @@ func (m Model) fetchData() tea.Cmd {
 
 		return dataMsg{
 			accessLogs:  accessLogs,
+			polled:      !live,
 			errorLogs:   errorLogs,
-			metrics:     metrics,
+			agentData:   data,
 			systemStats: systemStats,
 		}
 	}
-}
\ No newline at end of file
+}
+
+// agentData holds what the agent computes itself
+type agentData struct {
+	backends *logs.BackendsReport
+	slo      *logs.SLOReport
+	latency  *logs.LatencyHeatmap
+}
+
+// recalculate derives the metrics from the entries in the ring, or those
+// of the time range, that pass the filter. What the agent computes itself
+// is not filtered.
+func (m *Model) recalculate() {
+	entries := m.ring.Entries()
+	if m.cfg.DemoMode && m.demoLogs != nil {
+		entries = m.demoLogs
+	}
+	if m.timeRange.active() {
+		entries = m.inRange(entries)
+	}
+	m.errorLogs = m.errorsInRange()
+	m.totalLogs = len(entries)
+	m.accessLogs = sortEntries(m.filter.Apply(entries), m.cfg.SortBy, m.cfg.SortDesc)
+	if m.selectedIndex >= len(m.accessLogs) {
+		m.selectedIndex = max(0, len(m.accessLogs)-1)
+	}
+	m.metrics = logs.CalculateMetrics(m.accessLogs)
+	m.metrics.Backends = m.agentData.backends
+	m.metrics.SLO = m.agentData.slo
+	m.metrics.Latency = m.agentData.latency
+}
+
+// exportLogs saves an export of the configured time range to the export directory
+func (m Model) exportLogs() tea.Cmd {
+	return func() tea.Msg {
+		opts := logs.ExportOptions{
+			Format: m.cfg.ExportFormat,
+			Since:  m.cfg.ExportSince,
+		}
+		if m.timeRange.active() {
+			since, until := m.timeRange.bounds()
+			opts.Since = since.UTC().Format(time.RFC3339)
+			if !until.IsZero() {
+				opts.Until = until.UTC().Format(time.RFC3339)
+			}
+		}
+		result, err := logs.SaveExport(m.cfg.AgentURL, m.cfg.AuthToken, opts, m.cfg.ExportDir, nil)
+		return exportMsg{result: result, err: err}
+	}
+}
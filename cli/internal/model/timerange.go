@@ -0,0 +1,341 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/logs"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/logs/period"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/logs/traefik"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/ui/styles"
+)
+
+// maxRangeLogs caps the entries fetched for a time range
+const maxRangeLogs = 100000
+
+// timeRange is the window the dashboard shows. The zero value shows the
+// latest entries, whenever they were logged.
+type timeRange struct {
+	label  string
+	period func() period.Period
+	// live ranges end now and take in new entries
+	live bool
+}
+
+func (r timeRange) active() bool {
+	return r.period != nil
+}
+
+// bounds returns the start and end of the range for the agent; live
+// ranges have no end
+func (r timeRange) bounds() (since, until time.Time) {
+	p := r.period()
+	if r.live {
+		return p.Start, time.Time{}
+	}
+	return p.Start, p.End
+}
+
+type rangeMsg struct {
+	seq     int
+	entries []logs.TraefikLog
+	err     error
+}
+
+// fetchRange fetches the entries of the time range from the agent. In
+// local and demo mode the entries at hand are filtered instead.
+func (m Model) fetchRange() tea.Cmd {
+	if !m.timeRange.active() || m.cfg.LocalMode || m.cfg.DemoMode {
+		return nil
+	}
+	seq := m.rangeSeq
+	// The newest entries, so that none are missing up to the live ones
+	opts := logs.ExportOptions{Limit: maxRangeLogs, Tail: true}
+	since, until := m.timeRange.bounds()
+	opts.Since = since.UTC().Format(time.RFC3339)
+	if !until.IsZero() {
+		opts.Until = until.UTC().Format(time.RFC3339)
+	}
+	return func() tea.Msg {
+		entries, err := logs.FetchEntries(m.cfg.AgentURL, m.cfg.AuthToken, opts)
+		return rangeMsg{seq: seq, entries: entries, err: err}
+	}
+}
+
+// setTimeRange shows r, fetching its entries when they come from the agent
+func (m Model) setTimeRange(r timeRange) (tea.Model, tea.Cmd) {
+	m.timeRange = r
+	m.rangeLogs = nil
+	m.rangeSeq++
+	m.selectedIndex = 0
+	m.picking = false
+	m.recalculate()
+
+	cmd := m.fetchRange()
+	if cmd == nil {
+		return m, nil
+	}
+	m.loading = true
+	return m, tea.Batch(cmd, m.fetchData())
+}
+
+// inRange keeps the entries logged within the time range. Once the agent
+// has sent the range, entries logged after it are added from the ring.
+func (m Model) inRange(entries []logs.TraefikLog) []logs.TraefikLog {
+	if m.rangeLogs != nil {
+		entries = mergeRange(m.rangeLogs, entries)
+	}
+
+	p := m.timeRange.period()
+	out := make([]logs.TraefikLog, 0, len(entries))
+	for _, entry := range entries {
+		if t, err := logs.ParseTime(entry.StartUTC); err == nil && inPeriod(p, t) {
+			out = append(out, entry)
+		}
+	}
+	return out
+}
+
+// mergeRange appends to the entries fetched for a range those of recent
+// that were logged after the last of them
+func mergeRange(fetched, recent []logs.TraefikLog) []logs.TraefikLog {
+	var last time.Time
+	for _, entry := range fetched {
+		if t, err := logs.ParseTime(entry.StartUTC); err == nil && t.After(last) {
+			last = t
+		}
+	}
+	merged := make([]logs.TraefikLog, len(fetched), len(fetched)+len(recent))
+	copy(merged, fetched)
+	for _, entry := range recent {
+		if t, err := logs.ParseTime(entry.StartUTC); err == nil && t.After(last) {
+			merged = append(merged, entry)
+		}
+	}
+	return merged
+}
+
+// errorsInRange keeps the error lines logged within the time range, along
+// with the lines without a time
+func (m Model) errorsInRange() []string {
+	if !m.timeRange.active() {
+		return m.errorLines
+	}
+	p := m.timeRange.period()
+	out := make([]string, 0, len(m.errorLines))
+	for _, line := range m.errorLines {
+		if entry, err := traefik.ParseErrorLog(line); err == nil {
+			if t, err := logs.ParseTime(entry.Timestamp); err == nil && !inPeriod(p, t) {
+				continue
+			}
+		}
+		out = append(out, line)
+	}
+	return out
+}
+
+// inPeriod includes both ends of the period
+func inPeriod(p period.Period, t time.Time) bool {
+	return !t.Before(p.Start) && !t.After(p.End)
+}
+
+// rangeChoices are the entries of the picker: the latest entries, the
+// presets and a custom range
+func rangeChoices() []string {
+	choices := []string{"Latest entries"}
+	for _, preset := range period.Presets {
+		choices = append(choices, preset.Name)
+	}
+	return append(choices, "Custom range...")
+}
+
+// startPicker opens the time range picker on the active range
+func (m Model) startPicker() (tea.Model, tea.Cmd) {
+	m.picking = true
+	m.customEditing = false
+	m.customErr = nil
+	m.pickCursor = m.rangeChoice()
+	return m, nil
+}
+
+// rangeChoice is the picker entry of the active range
+func (m Model) rangeChoice() int {
+	choices := rangeChoices()
+	if !m.timeRange.active() {
+		return 0
+	}
+	for i, choice := range choices {
+		if choice == m.timeRange.label {
+			return i
+		}
+	}
+	return len(choices) - 1
+}
+
+// handlePickerKey chooses a preset or moves to the custom range inputs
+func (m Model) handlePickerKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.customEditing {
+		return m.handleCustomKey(msg)
+	}
+	choices := rangeChoices()
+
+	switch msg.String() {
+	case "ctrl+c":
+		return m.quit()
+
+	case "esc", "t", "q":
+		m.picking = false
+
+	case "up", "k":
+		if m.pickCursor > 0 {
+			m.pickCursor--
+		}
+
+	case "down", "j":
+		if m.pickCursor < len(choices)-1 {
+			m.pickCursor++
+		}
+
+	case "enter":
+		switch {
+		case m.pickCursor == 0:
+			return m.setTimeRange(timeRange{})
+		case m.pickCursor == len(choices)-1:
+			m.customEditing = true
+			m.customField = 0
+			m.customErr = nil
+		default:
+			preset := period.Presets[m.pickCursor-1]
+			return m.setTimeRange(timeRange{label: preset.Name, period: preset.Period, live: preset.Live})
+		}
+	}
+	return m, nil
+}
+
+// handleCustomKey edits the start and end of a custom range
+func (m Model) handleCustomKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	input := &m.customInputs[m.customField]
+
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return m.quit()
+
+	case tea.KeyEsc:
+		m.customEditing = false
+
+	case tea.KeyTab, tea.KeyShiftTab, tea.KeyUp, tea.KeyDown:
+		m.customField = 1 - m.customField
+
+	case tea.KeyEnter:
+		r, err := customRange(m.customInputs[0], m.customInputs[1], time.Now())
+		if err != nil {
+			m.customErr = err
+			return m, nil
+		}
+		return m.setTimeRange(r)
+
+	case tea.KeyBackspace:
+		if *input != "" {
+			runes := []rune(*input)
+			*input = string(runes[:len(runes)-1])
+		}
+
+	case tea.KeyCtrlU:
+		*input = ""
+
+	case tea.KeySpace:
+		*input += " "
+
+	case tea.KeyRunes:
+		*input += string(msg.Runes)
+	}
+	m.customErr = nil
+	return m, nil
+}
+
+// customRange builds a range from the picker inputs. An empty or "now" end
+// makes a live range.
+func customRange(start, end string, now time.Time) (timeRange, error) {
+	from, err := period.ParseTime(start, now)
+	if err != nil {
+		return timeRange{}, fmt.Errorf("start: %v", err)
+	}
+	if strings.TrimSpace(start) == "" {
+		return timeRange{}, fmt.Errorf("start: required")
+	}
+
+	end = strings.TrimSpace(end)
+	if end == "" || end == "now" {
+		if !from.Before(now) {
+			return timeRange{}, fmt.Errorf("start must be in the past")
+		}
+		return timeRange{
+			label:  "Since " + formatRangeTime(from, now),
+			period: func() period.Period { return period.Custom(from, time.Now()) },
+			live:   true,
+		}, nil
+	}
+
+	to, err := period.ParseTime(end, now)
+	if err != nil {
+		return timeRange{}, fmt.Errorf("end: %v", err)
+	}
+	if !from.Before(to) {
+		return timeRange{}, fmt.Errorf("start must be before end")
+	}
+	return timeRange{
+		label:  formatRangeTime(from, now) + " – " + formatRangeTime(to, from),
+		period: func() period.Period { return period.Custom(from, to) },
+	}, nil
+}
+
+// formatRangeTime leaves out the date when it is the same as ref's
+func formatRangeTime(t, ref time.Time) string {
+	if t.Year() == ref.Year() && t.YearDay() == ref.YearDay() {
+		return t.Format("15:04")
+	}
+	return t.Format("2006-01-02 15:04")
+}
+
+// renderPicker renders the time range picker
+func (m Model) renderPicker() string {
+	var sb strings.Builder
+	sb.WriteString(styles.SubtitleStyle.Render("Time Range"))
+	sb.WriteString("\n\n")
+
+	active := m.rangeChoice()
+	for i, choice := range rangeChoices() {
+		mark := "  "
+		if i == active {
+			mark = "● "
+		}
+		if i == m.pickCursor {
+			sb.WriteString(styles.SelectedStyle.Render(mark + choice))
+		} else {
+			sb.WriteString(styles.DefaultStyle.Render(mark + choice))
+		}
+		sb.WriteString("\n")
+	}
+
+	if m.customEditing {
+		sb.WriteString("\n")
+		for i, label := range []string{"Start", "End"} {
+			value := m.customInputs[i]
+			if i == m.customField {
+				value += "█"
+			}
+			sb.WriteString(styles.CardLabelStyle.Render(fmt.Sprintf("  %-6s ", label)))
+			sb.WriteString(value)
+			sb.WriteString("\n")
+		}
+		sb.WriteString(styles.MutedStyle.Render("  now, 2h (ago), 14:30 or 2006-01-02 15:04; an empty end follows new entries"))
+		sb.WriteString("\n")
+		if m.customErr != nil {
+			sb.WriteString(styles.ErrorStyle.Render("  " + m.customErr.Error()))
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
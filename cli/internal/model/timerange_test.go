@@ -0,0 +1,114 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/logs"
+)
+
+func TestMergeRange(t *testing.T) {
+	entry := func(path, start string) logs.TraefikLog {
+		return logs.TraefikLog{RequestPath: path, StartUTC: start}
+	}
+	fetched := []logs.TraefikLog{
+		entry("/a", "2024-05-01T10:00:00Z"),
+		// Fractional seconds vary in length
+		entry("/b", "2024-05-01T10:00:01.5Z"),
+		entry("/c", "2024-05-01T10:00:01Z"),
+	}
+	tests := []struct {
+		name    string
+		fetched []logs.TraefikLog
+		recent  []logs.TraefikLog
+		want    string
+	}{
+		{"nothing new", fetched, nil, "/a /b /c"},
+		{
+			"overlap",
+			fetched,
+			[]logs.TraefikLog{
+				entry("/b", "2024-05-01T10:00:01.5Z"),
+				entry("/d", "2024-05-01T10:00:01.75Z"),
+				entry("/e", "2024-05-01T10:00:02Z"),
+			},
+			"/a /b /c /d /e",
+		},
+		{
+			"offsets",
+			fetched,
+			[]logs.TraefikLog{
+				entry("/early", "2024-05-01T12:00:01+02:00"),
+				entry("/late", "2024-05-01T12:00:02+02:00"),
+			},
+			"/a /b /c /late",
+		},
+		{
+			"unreadable times",
+			fetched,
+			[]logs.TraefikLog{entry("/x", ""), entry("/y", "yesterday"), entry("/z", "2024-05-01T10:00:03Z")},
+			"/a /b /c /z",
+		},
+		{"empty range", []logs.TraefikLog{}, []logs.TraefikLog{entry("/a", "2024-05-01T10:00:00Z")}, "/a"},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, entry := range mergeRange(tt.fetched, tt.recent) {
+			got = append(got, entry.RequestPath)
+		}
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("%s: %v, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCustomRange(t *testing.T) {
+	now := time.Date(2024, 5, 1, 15, 0, 0, 0, time.Local)
+	tests := []struct {
+		start, end string
+		from, to   time.Time
+		live       bool
+		label      string
+		err        string
+	}{
+		{start: "2h", from: now.Add(-2 * time.Hour), live: true, label: "Since 13:00"},
+		{start: "2h", end: "now", from: now.Add(-2 * time.Hour), live: true, label: "Since 13:00"},
+		{start: "14:30", end: "14:45", from: now.Add(-30 * time.Minute), to: now.Add(-15 * time.Minute), label: "14:30 – 14:45"},
+		{start: "2024-04-30 23:00", end: "1h", from: now.Add(-16 * time.Hour), to: now.Add(-time.Hour), label: "2024-04-30 23:00 – 2024-05-01 14:00"},
+		{start: "2024-04-30 08:00", end: "2024-04-30 09:00", from: now.Add(-31 * time.Hour), to: now.Add(-30 * time.Hour), label: "2024-04-30 08:00 – 09:00"},
+		{start: "", err: "start: required"},
+		{start: "now", err: "start must be in the past"},
+		{start: "16:00", err: "start must be in the past"},
+		{start: "14:45", end: "14:30", err: "start must be before end"},
+		{start: "14:30", end: "14:30", err: "start must be before end"},
+		{start: "soon", err: "start: invalid time"},
+		{start: "1h", end: "later", err: "end: invalid time"},
+	}
+	for _, tt := range tests {
+		r, err := customRange(tt.start, tt.end, now)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%q – %q: error %v, want %s", tt.start, tt.end, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q – %q: %v", tt.start, tt.end, err)
+			continue
+		}
+		p := r.period()
+		if !p.Start.Equal(tt.from) || r.live != tt.live || r.label != tt.label {
+			t.Errorf("%q – %q: from %v live %v label %q, want from %v live %v label %q", tt.start, tt.end, p.Start, r.live, r.label, tt.from, tt.live, tt.label)
+		}
+		if !tt.live && !p.End.Equal(tt.to) {
+			t.Errorf("%q – %q: to %v, want %v", tt.start, tt.end, p.End, tt.to)
+		}
+		// Live ranges follow the clock
+		if tt.live {
+			if _, until := r.bounds(); !until.IsZero() {
+				t.Errorf("%q: live range ends at %v", tt.start, until)
+			}
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package model
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -12,16 +14,25 @@ import (
 type tickMsg time.Time
 
 type dataMsg struct {
-	accessLogs  []logs.TraefikLog
+	accessLogs []logs.TraefikLog
+	// polled is false when access logs were left to the stream
+	polled      bool
 	errorLogs   []string
-	metrics     *logs.Metrics
+	agentData   agentData
 	systemStats *logs.SystemStats
 }
 
+type streamMsg logs.StreamBatch
+
 type errMsg struct {
 	err error
 }
 
+type exportMsg struct {
+	result logs.ExportResult
+	err    error
+}
+
 // Update handles messages and updates the model
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
@@ -34,26 +45,103 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.handleKeyPress(msg)
 
 	case tickMsg:
-		// Auto-refresh data
+		// Auto-refresh data; local files are followed as they grow
+		if m.cfg.LocalMode {
+			return m, nil
+		}
 		return m, tea.Batch(
 			m.fetchData(),
 			m.tick(),
 		)
 
 	case dataMsg:
-		m.accessLogs = msg.accessLogs
-		m.errorLogs = msg.errorLogs
-		m.metrics = msg.metrics
+		// The agent returns the lines since the last read, so polled
+		// lines add to the ring like streamed ones
+		if msg.polled {
+			m.ring.Push(msg.accessLogs...)
+		}
+		m.errorLines = msg.errorLogs
+		m.agentData = msg.agentData
 		m.systemStats = msg.systemStats
+		m.recalculate()
 		m.loading = false
 		m.err = nil
 		m.lastUpdate = time.Now()
 		return m, nil
 
+	case streamMsg:
+		m.streamState = msg.State
+		m.streamRetry = msg.Retry
+		m.streamErr = msg.Err
+		if m.cfg.DemoMode {
+			return m, m.waitForStream()
+		}
+		if len(msg.ErrorLines) > 0 {
+			m.errorLines = append(m.errorLines, msg.ErrorLines...)
+			if len(m.errorLines) > maxErrorLogs {
+				m.errorLines = m.errorLines[len(m.errorLines)-maxErrorLogs:]
+			}
+			m.errorLogs = m.errorsInRange()
+		}
+		// In local mode the first batch ends loading, even when empty
+		if len(msg.Entries) > 0 || (m.cfg.LocalMode && msg.State != logs.StreamConnecting && m.loading) {
+			m.ring.Push(msg.Entries...)
+			m.recalculate()
+			m.loading = false
+			m.lastUpdate = time.Now()
+		}
+		return m, m.waitForStream()
+
+	case rangeMsg:
+		if msg.seq != m.rangeSeq {
+			return m, nil
+		}
+		m.loading = false
+		if msg.err != nil {
+			m.notice = fmt.Sprintf("Could not load %s: %v", strings.ToLower(m.timeRange.label), msg.err)
+			return m, nil
+		}
+		m.rangeLogs = msg.entries
+		if m.rangeLogs == nil {
+			m.rangeLogs = []logs.TraefikLog{}
+		}
+		if len(m.rangeLogs) >= maxRangeLogs {
+			m.notice = fmt.Sprintf("Showing the first %d entries of the range", maxRangeLogs)
+		}
+		m.recalculate()
+		m.lastUpdate = time.Now()
+		return m, nil
+
+	case settingsMsg:
+		if msg.err != nil {
+			m.notice = fmt.Sprintf("Could not save settings: %v", msg.err)
+		}
+		return m, nil
+
+	case copiedMsg:
+		if msg.err != nil {
+			m.notice = fmt.Sprintf("Copy failed: %v", msg.err)
+		} else {
+			m.notice = fmt.Sprintf("Copied the %s to the clipboard", msg.what)
+		}
+		return m, nil
+
 	case errMsg:
 		m.err = msg.err
 		m.loading = false
 		return m, nil
+
+	case exportMsg:
+		m.exporting = false
+		if msg.err != nil {
+			m.exportStatus = fmt.Sprintf("Export failed: %v", msg.err)
+			return m, nil
+		}
+		m.exportStatus = fmt.Sprintf("Exported %d rows (%s) to %s", msg.result.Rows, formatBytes(msg.result.Bytes), msg.result.Path)
+		if msg.result.Truncated {
+			m.exportStatus += " (truncated at the size limit)"
+		}
+		return m, nil
 	}
 
 	return m, nil
@@ -61,33 +149,118 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 // handleKeyPress handles keyboard input
 func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.filtering {
+		return m.handleFilterKey(msg)
+	}
+	if m.detail {
+		return m.handleDetailKey(msg)
+	}
+	if m.editingColumns {
+		return m.handleColumnsKey(msg)
+	}
+	if m.picking {
+		return m.handlePickerKey(msg)
+	}
+	if m.focusing && m.currentView == DashboardView {
+		return m.handleFocusKey(msg)
+	}
+	return m.handleNormalKey(msg)
+}
+
+// handleNormalKey handles the keys of the views themselves
+func (m Model) handleNormalKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "q", "ctrl+c":
-		m.quitting = true
-		return m, tea.Quit
+		return m.quit()
+
+	case "/":
+		return m.startFilter()
+
+	case "enter":
+		return m.openDetail()
+
+	case "t":
+		return m.startPicker()
+
+	case "s":
+		// Sort the access logs by the next column
+		if m.currentView == AccessLogsView {
+			return m.cycleSort()
+		}
+		return m, nil
+
+	case "S":
+		if m.currentView == AccessLogsView {
+			return m.reverseSort()
+		}
+		return m, nil
+
+	case "c":
+		// Choose and order the access log columns
+		if m.currentView == AccessLogsView {
+			m.editingColumns = true
+			m.columnCursor = 0
+		}
+		return m, nil
+
+	case "esc":
+		// Back to the dashboard drilled down from, or clear the filter
+		if m.origin != nil {
+			return m.drillBack()
+		}
+		if !m.filter.Empty() {
+			m.setFilter("")
+		}
+		return m, nil
 
 	case "r":
 		// Refresh data
+		if m.cfg.LocalMode {
+			return m, nil
+		}
 		m.loading = true
-		return m, m.fetchData()
+		m.rangeSeq++
+		return m, tea.Batch(m.fetchData(), m.fetchRange())
+
+	case "e":
+		// Save an export of recent access logs
+		if m.exporting || m.cfg.DemoMode || m.cfg.LocalMode {
+			return m, nil
+		}
+		m.exporting = true
+		m.exportStatus = fmt.Sprintf("Exporting %s since %s...", m.cfg.ExportFormat, m.cfg.ExportSince)
+		return m, m.exportLogs()
 
 	case "tab":
+		// Focus the dashboard cards
+		if m.currentView == DashboardView {
+			return m.moveFocus(1)
+		}
 		// Cycle through tabs
 		m.activeTab = (m.activeTab + 1) % 3
 		return m, nil
 
+	case "shift+tab":
+		if m.currentView == DashboardView {
+			return m.moveFocus(-1)
+		}
+		return m, nil
+
 	case "1":
 		m.currentView = DashboardView
+		m.origin = nil
 		return m, nil
 
 	case "2":
 		m.currentView = AccessLogsView
 		m.selectedIndex = 0
+		m.origin = nil
 		return m, nil
 
 	case "3":
 		m.currentView = ErrorLogsView
 		m.selectedIndex = 0
+		m.origin = nil
 		return m, nil
 
 	case "up", "k":
@@ -125,12 +298,22 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "d":
 		// Toggle demo mode
 		m.cfg.DemoMode = !m.cfg.DemoMode
+		m.demoLogs = nil
 		if m.cfg.DemoMode {
-			m.accessLogs = logs.GenerateDemoLogs(100)
-			m.metrics = logs.CalculateMetrics(m.accessLogs)
+			m.demoLogs = logs.GenerateDemoLogs(100)
 		}
+		m.recalculate()
 		return m, nil
 	}
 
 	return m, nil
+}
+
+// quit stops the stream and exits
+func (m Model) quit() (tea.Model, tea.Cmd) {
+	m.quitting = true
+	if m.streamer != nil {
+		m.streamer.Stop()
+	}
+	return m, tea.Quit
 }
\ No newline at end of file
@@ -1,10 +1,14 @@
 package model
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"path/filepath"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/logs"
 	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/ui/dashboard"
 	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/ui/styles"
 )
@@ -21,17 +25,28 @@ func (m Model) View() string {
 	header := m.renderHeader()
 
 	// Render main content based on current view
-	switch m.currentView {
-	case DashboardView:
+	switch {
+	case m.picking:
+		content = m.renderPicker()
+	case m.currentView == DashboardView:
 		content = m.renderDashboard()
-	case AccessLogsView:
-		content = m.renderAccessLogs()
-	case ErrorLogsView:
-		content = m.renderErrorLogs()
+	default:
+		if m.detail {
+			content = m.renderDetail()
+		} else if m.editingColumns {
+			content = m.renderColumns()
+		} else if m.currentView == AccessLogsView {
+			content = m.renderAccessLogs()
+		} else {
+			content = m.renderErrorLogs()
+		}
 	}
 
-	// Render footer
+	// Render footer, under the filter bar when there is one
 	footer := m.renderFooter()
+	if bar := m.renderFilterBar(); bar != "" {
+		footer = lipgloss.JoinVertical(lipgloss.Left, bar, footer)
+	}
 
 	// Combine all sections
 	availableHeight := m.height - lipgloss.Height(header) - lipgloss.Height(footer) - 2
@@ -53,6 +68,9 @@ func (m Model) renderHeader() string {
 	title := styles.TitleStyle.Render(" Traefik Log Dashboard CLI")
 	
 	status := "Connected"
+	if m.cfg.LocalMode {
+		status = "Local"
+	}
 	statusColor := styles.SuccessStyle
 	if m.err != nil {
 		status = "Disconnected"
@@ -63,6 +81,9 @@ func (m Model) renderHeader() string {
 	}
 	
 	statusText := statusColor.Render(status)
+	if stream := m.renderStreamState(); stream != "" {
+		statusText += "  " + stream
+	}
 	
 	lastUpdate := ""
 	if !m.lastUpdate.IsZero() {
@@ -79,6 +100,9 @@ func (m Model) renderHeader() string {
 	)
 	
 	headerRight := lastUpdate
+	if m.timeRange.active() {
+		headerRight = lipgloss.NewStyle().Foreground(styles.Primary).Render("◷ "+m.timeRange.label) + "  " + lastUpdate
+	}
 	
 	headerStyle := lipgloss.NewStyle().
 		Width(m.width).
@@ -96,6 +120,51 @@ func (m Model) renderHeader() string {
 	return headerStyle.Render(header)
 }
 
+// renderStreamState shows whether access logs are streamed or polled
+func (m Model) renderStreamState() string {
+	if m.cfg.DemoMode {
+		return ""
+	}
+	if m.cfg.LocalMode {
+		return m.renderLocalState()
+	}
+	switch m.streamState {
+	case logs.StreamLive:
+		return styles.SuccessStyle.Render("● Live")
+	case logs.StreamConnecting:
+		return styles.MutedStyle.Render("○ Connecting")
+	case logs.StreamReconnecting:
+		return styles.WarningStyle.Render(fmt.Sprintf("○ Reconnecting in %s", m.streamRetry))
+	default:
+		return styles.MutedStyle.Render(fmt.Sprintf("○ Polling every %s", m.cfg.RefreshInterval))
+	}
+}
+
+// renderLocalState shows which file is tailed, or why it cannot be
+func (m Model) renderLocalState() string {
+	name := filepath.Base(m.cfg.AccessLogPath)
+	if m.cfg.PipeMode {
+		name = "stdin"
+	}
+	switch m.streamState {
+	case logs.StreamLive:
+		return styles.SuccessStyle.Render(fmt.Sprintf("● Tailing %s", name))
+	case logs.StreamWaiting:
+		reason := "missing"
+		if m.streamErr != nil && !errors.Is(m.streamErr, fs.ErrNotExist) {
+			reason = m.streamErr.Error()
+		}
+		return styles.WarningStyle.Render(fmt.Sprintf("○ Waiting for %s (%s)", name, reason))
+	case logs.StreamEnded:
+		if m.streamErr != nil {
+			return styles.ErrorStyle.Render(fmt.Sprintf("■ %s failed: %v", name, m.streamErr))
+		}
+		return styles.MutedStyle.Render(fmt.Sprintf("■ %s closed", name))
+	default:
+		return styles.MutedStyle.Render(fmt.Sprintf("○ Reading %s", name))
+	}
+}
+
 // renderDashboard renders the dashboard view
 func (m Model) renderDashboard() string {
 	if m.err != nil {
@@ -112,21 +181,57 @@ func (m Model) renderDashboard() string {
 		return styles.MutedStyle.Render("No data available")
 	}
 	
-	return dashboard.Render(m.metrics, m.systemStats, m.width, m.height-8)
+	focus := -1
+	if m.focusing {
+		focus = m.focusCard
+	}
+	return dashboard.Render(m.dashboardData(), m.layout, dashboard.Options{
+		Width:    m.width,
+		Height:   m.height - 8,
+		Focus:    focus,
+		Row:      m.cardRow,
+		Expanded: m.expanded,
+	})
 }
 
-// renderAccessLogs renders the access logs view
+// renderAccessLogs renders the access logs view, under the breadcrumb of
+// a drill-down
 func (m Model) renderAccessLogs() string {
+	var sb strings.Builder
+	crumb := m.renderBreadcrumb()
+	if crumb != "" {
+		sb.WriteString(crumb)
+		sb.WriteString("\n\n")
+	}
+	
 	if len(m.accessLogs) == 0 {
-		return styles.MutedStyle.Render("No access logs available")
+		if m.totalLogs > 0 {
+			sb.WriteString(styles.MutedStyle.Render(fmt.Sprintf("No access logs match the filter (%d entries)", m.totalLogs)))
+		} else {
+			sb.WriteString(styles.MutedStyle.Render("No access logs available"))
+		}
+		return sb.String()
 	}
 	
-	var sb strings.Builder
-	sb.WriteString(styles.SubtitleStyle.Render(fmt.Sprintf("Access Logs (%d)", len(m.accessLogs))))
+	title := fmt.Sprintf("Access Logs (%d)", len(m.accessLogs))
+	if !m.filter.Empty() {
+		title = fmt.Sprintf("Access Logs (%d of %d)", len(m.accessLogs), m.totalLogs)
+	}
+	sb.WriteString(styles.SubtitleStyle.Render(title))
 	sb.WriteString("\n\n")
+	terms := m.filter.Highlights()
+	
+	// Columns that fit the terminal, under a header
+	names, widths := layoutColumns(m.cfg.Columns, m.width-4)
+	sb.WriteString(styles.CardLabelStyle.Render(m.tableHeader(names, widths)))
+	sb.WriteString("\n")
 	
 	// Display logs (limited to visible area)
-	maxVisible := min(m.height-12, len(m.accessLogs))
+	visible := m.height - 13
+	if crumb != "" {
+		visible -= 2
+	}
+	maxVisible := min(visible, len(m.accessLogs))
 	start := max(0, m.selectedIndex-maxVisible+1)
 	end := min(len(m.accessLogs), start+maxVisible)
 	
@@ -138,16 +243,15 @@ func (m Model) renderAccessLogs() string {
 			style = styles.SelectedStyle
 		}
 		
-		line := fmt.Sprintf(
-			"%s %s %s %d %dms",
-			log.RequestMethod,
-			truncate(log.RequestPath, 40),
-			log.ClientHost,
-			log.DownstreamStatus,
-			log.Duration/1000000,
-		)
+		line := formatRow(names, widths, func(name string) string {
+			return columns[name].value(&log)
+		})
 		
-		sb.WriteString(style.Render(line))
+		if i == m.selectedIndex {
+			sb.WriteString(style.Render(line))
+		} else {
+			sb.WriteString(highlight(line, terms, style))
+		}
 		sb.WriteString("\n")
 	}
 	
@@ -188,12 +292,68 @@ func (m Model) renderFooter() string {
 		"1: Dashboard",
 		"2: Access Logs",
 		"3: Error Logs",
+		"/: Filter",
+		"t: Time range",
 		"r: Refresh",
+		"e: Export",
 		"d: Demo",
 		"q: Quit",
 	}
 	
+	if m.detail {
+		keybindings = []string{
+			"←/→: Previous/Next",
+			"c: Copy JSON",
+			"esc: Back",
+			"q: Quit",
+		}
+		if m.currentView == AccessLogsView {
+			keybindings = append(keybindings[:2], append([]string{"u: Copy curl"}, keybindings[2:]...)...)
+		}
+	} else if m.picking {
+		keybindings = []string{
+			"↑/↓: Select",
+			"enter: Apply",
+			"esc: Cancel",
+		}
+		if m.customEditing {
+			keybindings[0] = "tab: Start/End"
+		}
+	} else if m.currentView == DashboardView && m.focusing {
+		keybindings = []string{
+			"tab/←/→: Move",
+			"↑/↓: Rows",
+			"enter: Expand",
+			"esc: Done",
+			"q: Quit",
+		}
+		if m.cardRow >= 0 {
+			keybindings[2] = "enter: Show requests"
+			keybindings[3] = "esc: Deselect"
+		} else if m.expanded {
+			keybindings[2] = "enter: Collapse"
+		}
+	} else if m.editingColumns {
+		keybindings = []string{
+			"space: Show/Hide",
+			"J/K: Move",
+			"enter: Done",
+		}
+	} else if m.currentView == AccessLogsView {
+		keybindings = append(keybindings[:5], append([]string{"enter: Details", "s/S: Sort", "c: Columns"}, keybindings[5:]...)...)
+	} else if m.currentView != DashboardView {
+		keybindings = append(keybindings[:5], append([]string{"enter: Details"}, keybindings[5:]...)...)
+	} else {
+		keybindings = append(keybindings[:5], append([]string{"tab: Cards"}, keybindings[5:]...)...)
+	}
+	
 	footerText := strings.Join(keybindings, " • ")
+	if m.exportStatus != "" {
+		footerText = truncate(m.exportStatus, max(m.width-8, 20)) + "\n" + footerText
+	}
+	if m.notice != "" {
+		footerText = truncate(m.notice, max(m.width-8, 20)) + "\n" + footerText
+	}
 	
 	footerStyle := lipgloss.NewStyle().
 		Width(m.width).
@@ -225,4 +385,20 @@ func truncate(s string, maxLen int) string {
 		return s
 	}
 	return s[:maxLen-3] + "..."
-}
\ No newline at end of file
+}
+
+// formatBytes formats a byte count for status messages
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit && exp < 3; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %s", float64(bytes)/float64(div), []string{"KB", "MB", "GB", "TB"}[exp])
+}
@@ -3,14 +3,17 @@ package cards
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/logs"
 	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/ui/styles"
 )
 
-// RenderBackends renders the backends/services metrics card
-func RenderBackends(services []logs.ServiceMetric, width int) string {
+// RenderBackends renders the backends/services metrics card. When the agent
+// reports per-backend health it shows each backend server; otherwise it falls
+// back to service-level counts. selected is the highlighted row, or -1.
+func RenderBackends(report *logs.BackendsReport, services []logs.ServiceMetric, selected, width int) string {
 	if width < 40 {
 		return ""
 	}
@@ -24,6 +27,11 @@ func RenderBackends(services []logs.ServiceMetric, width int) string {
 	b.WriteString(styles.CardTitleStyle.Width(cardWidth).Render("⚙  Backends/Services"))
 	b.WriteString("\n")
 
+	if report != nil && len(report.Backends) > 0 {
+		b.WriteString(renderBackendHealth(report, selected, cardWidth, contentWidth))
+		return b.String()
+	}
+
 	if len(services) == 0 {
 		b.WriteString(styles.CardStyle.Width(cardWidth).Render(
 			styles.MutedStyle.Render("No service data available"),
@@ -67,7 +75,7 @@ func RenderBackends(services []logs.ServiceMetric, width int) string {
 			"Req: %s  Avg: %s  Err: %.1f%%",
 			formatNumber(svc.Count),
 			formatDuration(svc.AvgDuration),
-			svc.ErrorRate*100,
+			svc.ErrorRate,
 		)
 
 		// Create progress bar for request volume (relative to max)
@@ -79,10 +87,10 @@ func RenderBackends(services []logs.ServiceMetric, width int) string {
 		if barWidth > 30 {
 			barWidth = 30
 		}
-		requestBar := renderProgressBar(float64(svc.Count)/float64(maxRequests), barWidth, svc.ErrorRate > 0.05)
+		requestBar := renderProgressBar(float64(svc.Count)/float64(maxRequests), barWidth, svc.ErrorRate > 5)
 
 		// Service name cell
-		nameCell := styles.TableCellStyle.Width(nameWidth).Render(serviceName)
+		nameCell := styles.TableCellStyle.Width(nameWidth).Render(markSelected(serviceName, i, selected))
 
 		// Metrics cell with progress bar
 		metricsContent := fmt.Sprintf("%s\n%s", metricsText, requestBar)
@@ -104,6 +112,122 @@ func RenderBackends(services []logs.ServiceMetric, width int) string {
 	return b.String()
 }
 
+// renderBackendHealth renders one row per backend server, worst first
+func renderBackendHealth(report *logs.BackendsReport, selected, cardWidth, contentWidth int) string {
+	var b strings.Builder
+
+	// Summary of statuses
+	var counts []string
+	for _, status := range []string{logs.BackendDown, logs.BackendDegraded, logs.BackendIdle, logs.BackendHealthy} {
+		if n := report.Counts[status]; n > 0 {
+			counts = append(counts, backendStatusStyle(status).Render(fmt.Sprintf("%d %s", n, status)))
+		}
+	}
+	summary := strings.Join(counts, styles.MutedStyle.Render(" · "))
+	if report.StatusWindow != "" {
+		summary += styles.MutedStyle.Render(fmt.Sprintf("  (last %s)", report.StatusWindow))
+	}
+	b.WriteString(summary)
+	b.WriteString("\n")
+
+	nameWidth := contentWidth / 2
+	if nameWidth > 40 {
+		nameWidth = 40
+	}
+	metricsWidth := contentWidth - nameWidth - 2
+
+	header := lipgloss.JoinHorizontal(
+		lipgloss.Top,
+		styles.TableHeaderStyle.Width(nameWidth).Render("Backend"),
+		styles.TableHeaderStyle.Width(metricsWidth).Render("Health"),
+	)
+	b.WriteString(header)
+	b.WriteString("\n")
+
+	backends := report.Backends
+	displayCount := len(backends)
+	if displayCount > 8 {
+		displayCount = 8
+	}
+
+	for i, backend := range backends[:displayCount] {
+		w := backend.Windows[report.StatusWindow]
+		statusStyle := backendStatusStyle(backend.Status)
+
+		// Service on the first line, server address below
+		address := strings.TrimPrefix(strings.TrimPrefix(backend.URL, "http://"), "https://")
+		nameContent := fmt.Sprintf("%s %s\n  %s",
+			statusStyle.Render("●"),
+			markSelected(truncateText(backend.Service, nameWidth-4), i, selected),
+			styles.MutedStyle.Render(truncateText(address, nameWidth-4)),
+		)
+
+		metricsContent := fmt.Sprintf("%s  Err: %.1f%%  p95: %s\n%s",
+			statusStyle.Render(fmt.Sprintf("%3d %s", backend.Score, backend.Status)),
+			w.ErrorRate*100,
+			formatDuration(w.P95MS),
+			styles.MutedStyle.Render(fmt.Sprintf("502: %d  504: %d  Retries: %d  Seen: %s",
+				w.BadGateway, w.Timeouts, w.Retries, formatAge(backend.LastSeen))),
+		)
+
+		row := lipgloss.JoinHorizontal(
+			lipgloss.Top,
+			styles.TableCellStyle.Width(nameWidth).Render(nameContent),
+			styles.TableCellStyle.Width(metricsWidth).Render(metricsContent),
+		)
+		b.WriteString(row)
+		b.WriteString("\n")
+	}
+
+	if len(backends) > displayCount {
+		footer := styles.MutedStyle.Render(
+			fmt.Sprintf("... and %d more backends", len(backends)-displayCount),
+		)
+		b.WriteString(styles.CardStyle.Width(cardWidth).Render(footer))
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// backendStatusStyle colours a backend status
+func backendStatusStyle(status string) lipgloss.Style {
+	switch status {
+	case logs.BackendDown:
+		return styles.ErrorStyle
+	case logs.BackendDegraded:
+		return styles.WarningStyle
+	case logs.BackendIdle:
+		return styles.MutedStyle
+	default:
+		return styles.SuccessStyle
+	}
+}
+
+// truncateText shortens s to at most width characters
+func truncateText(s string, width int) string {
+	if width < 4 || len(s) <= width {
+		return s
+	}
+	return s[:width-3] + "..."
+}
+
+// formatAge formats how long ago t was
+func formatAge(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds ago", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	default:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	}
+}
+
 // renderProgressBar renders a horizontal progress bar
 func renderProgressBar(percentage float64, width int, isError bool) string {
 	if width < 5 {
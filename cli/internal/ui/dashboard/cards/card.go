@@ -48,4 +48,15 @@ func RenderWithHeight(title, content string, width, height int) string {
 	)
 
 	return styles.CardStyle.Width(width).Height(height).Render(cardContent)
-}
\ No newline at end of file
+}
+
+// selectedStyle marks the selected row of a focused card
+var selectedStyle = styles.SelectedStyle.UnsetPadding()
+
+// markSelected highlights the text of row i when it is the selected one
+func markSelected(text string, i, selected int) string {
+	if i != selected {
+		return text
+	}
+	return selectedStyle.Render(text)
+}
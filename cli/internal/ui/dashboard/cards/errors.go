@@ -3,35 +3,25 @@ package cards
 import (
 	"fmt"
 	"strings"
-	"time" // Import the 'time' package
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/logs"
 	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/ui/styles"
 )
 
-// RenderErrors renders the recent errors card
-func RenderErrors(errorLogs []logs.TraefikLog, width int) string {
+// RenderErrors renders the recent errors card, newest first; selected is
+// the highlighted error, or -1
+func RenderErrors(errorLogs []logs.TraefikLog, selected, width int) string {
 	if width < 40 {
 		return ""
 	}
 
-	cardWidth := width
-	contentWidth := cardWidth - 4
-
-	var b strings.Builder
-
-	// Header with error count
+	contentWidth := width - 6
 	errorCount := len(errorLogs)
-	headerText := fmt.Sprintf("⚠️  Recent Errors (%d)", errorCount)
-	b.WriteString(styles.CardTitleStyle.Width(cardWidth).Render(headerText))
-	b.WriteString("\n")
+	title := fmt.Sprintf("⚠️  Recent Errors (%d)", errorCount)
 
 	if errorCount == 0 {
-		b.WriteString(styles.CardStyle.Width(cardWidth).Render(
-			styles.SuccessStyle.Render("✓ No errors detected"),
-		))
-		return b.String()
+		return Render(title, styles.SuccessStyle.Render("✓ No errors detected"), width)
 	}
 
 	// Limit to most recent 6 errors
@@ -40,17 +30,13 @@ func RenderErrors(errorLogs []logs.TraefikLog, width int) string {
 		displayCount = 6
 	}
 
+	var lines []string
 	for i := 0; i < displayCount; i++ {
 		log := errorLogs[i]
 
-		// FIX: Parse the timestamp string into a time.Time object before formatting
-		var timestamp string
-		parsedTime, err := time.Parse(time.RFC3339Nano, log.StartUTC)
-		if err != nil {
-			// If parsing fails, use a fallback or the raw string
-			timestamp = "??:??:??"
-		} else {
-			timestamp = parsedTime.Format("15:04:05")
+		timestamp := "??:??:??"
+		if parsedTime, err := logs.ParseTime(log.StartUTC); err == nil {
+			timestamp = parsedTime.Local().Format("15:04:05")
 		}
 
 		// Status code with color
@@ -70,72 +56,51 @@ func RenderErrors(errorLogs []logs.TraefikLog, width int) string {
 			method = method[:7]
 		}
 
-		path := log.RequestPath
-		maxPathLen := contentWidth - 35
-		if maxPathLen < 20 {
-			maxPathLen = 20
-		}
-		if len(path) > maxPathLen {
-			path = path[:maxPathLen-3] + "..."
-		}
-
 		// Client info
-		client := log.ClientHost
-		if len(client) > 20 {
-			client = client[:17] + "..."
+		client := truncateString(log.ClientHost, 20)
+
+		maxPathLen := contentWidth - 20 - len(method) - len(client)
+		if maxPathLen < 10 {
+			maxPathLen = 10
 		}
+		path := markSelected(truncateString(log.RequestPath, maxPathLen), i, selected)
 
-		// Build error entry
-		errorLine := fmt.Sprintf(
+		lines = append(lines, fmt.Sprintf(
 			"%s %s %s %s → %s",
 			styles.MutedStyle.Render(timestamp),
 			statusStyle.Render(statusText),
 			styles.AccentStyle.Render(method),
 			path,
 			styles.MutedStyle.Render(client),
-		)
-
-		b.WriteString(styles.CardStyle.Width(cardWidth).Render(errorLine))
-		b.WriteString("\n")
+		))
 
 		// Add router/service info if available
 		if log.RouterName != "" || log.ServiceName != "" {
-			detailLine := fmt.Sprintf(
+			lines = append(lines, styles.MutedStyle.Render(fmt.Sprintf(
 				"    Router: %s | Service: %s",
 				truncateString(log.RouterName, 20),
 				truncateString(log.ServiceName, 20),
-			)
-			b.WriteString(styles.CardStyle.Width(cardWidth).Render(
-				styles.MutedStyle.Render(detailLine),
-			))
-			b.WriteString("\n")
+			)))
 		}
 	}
 
 	if errorCount > displayCount {
-		footer := styles.MutedStyle.Render(
+		lines = append(lines, styles.MutedStyle.Render(
 			fmt.Sprintf("... and %d more errors", errorCount-displayCount),
-		)
-		b.WriteString(styles.CardStyle.Width(cardWidth).Render(footer))
-		b.WriteString("\n")
+		))
 	}
 
-	return b.String()
+	return Render(title, strings.Join(lines, "\n"), width)
 }
 
-
-// RenderErrorSummary renders a summary of error types
-func RenderErrorSummary(metrics *logs.Metrics, width int) string {
+// RenderErrorSummary renders a summary of error types; selected is the
+// highlighted class, 0 for 4xx and 1 for 5xx, or -1
+func RenderErrorSummary(metrics *logs.Metrics, selected, width int) string {
 	if width < 40 {
 		return ""
 	}
 
-	cardWidth := width
-	var b strings.Builder
-
-	// Header
-	b.WriteString(styles.CardTitleStyle.Width(cardWidth).Render("📊 Error Summary"))
-	b.WriteString("\n")
+	title := "📊 Error Summary"
 
 	// Calculate error counts
 	total4xx := metrics.Status4xx
@@ -143,15 +108,11 @@ func RenderErrorSummary(metrics *logs.Metrics, width int) string {
 	totalErrors := total4xx + total5xx
 
 	if totalErrors == 0 {
-		b.WriteString(styles.CardStyle.Width(cardWidth).Render(
-			styles.SuccessStyle.Render("✓ No errors in current period"),
-		))
-		return b.String()
+		return Render(title, styles.SuccessStyle.Render("✓ No errors in current period"), width)
 	}
 
-	// Error rate
-	errorRate := metrics.ErrorRate * 100
-	errorRateText := fmt.Sprintf("Error Rate: %.2f%%", errorRate)
+	// Error rate, already a percentage
+	errorRate := metrics.ErrorRate
 	var errorRateStyle lipgloss.Style
 	if errorRate > 5.0 {
 		errorRateStyle = styles.ErrorStyle
@@ -161,38 +122,24 @@ func RenderErrorSummary(metrics *logs.Metrics, width int) string {
 		errorRateStyle = styles.SuccessStyle
 	}
 
-	b.WriteString(styles.CardStyle.Width(cardWidth).Render(
-		errorRateStyle.Render(errorRateText),
-	))
-	b.WriteString("\n")
+	lines := []string{
+		errorRateStyle.Render(fmt.Sprintf("Error Rate: %.2f%%", errorRate)),
+		"",
+	}
 
 	// 4xx vs 5xx breakdown
-	barWidth := cardWidth - 20
+	barWidth := width - 22
 	if barWidth > 40 {
 		barWidth = 40
 	}
 
-	// 4xx errors
-	pct4xx := 0.0
-	if totalErrors > 0 {
-		pct4xx = float64(total4xx) / float64(totalErrors)
-	}
-	bar4xx := renderColoredBar(pct4xx, barWidth, styles.WarningStyle)
-	line4xx := fmt.Sprintf("4xx: %6d  %s", total4xx, bar4xx)
-	b.WriteString(styles.CardStyle.Width(cardWidth).Render(line4xx))
-	b.WriteString("\n")
-
-	// 5xx errors
-	pct5xx := 0.0
-	if totalErrors > 0 {
-		pct5xx = float64(total5xx) / float64(totalErrors)
-	}
-	bar5xx := renderColoredBar(pct5xx, barWidth, styles.ErrorStyle)
-	line5xx := fmt.Sprintf("5xx: %6d  %s", total5xx, bar5xx)
-	b.WriteString(styles.CardStyle.Width(cardWidth).Render(line5xx))
-	b.WriteString("\n")
+	pct4xx := float64(total4xx) / float64(totalErrors)
+	lines = append(lines, fmt.Sprintf("%s %6d  %s", markSelected("4xx:", 0, selected), total4xx, renderColoredBar(pct4xx, barWidth, styles.WarningStyle)))
+
+	pct5xx := float64(total5xx) / float64(totalErrors)
+	lines = append(lines, fmt.Sprintf("%s %6d  %s", markSelected("5xx:", 1, selected), total5xx, renderColoredBar(pct5xx, barWidth, styles.ErrorStyle)))
 
-	return b.String()
+	return Render(title, strings.Join(lines, "\n"), width)
 }
 
 // renderColoredBar renders a colored progress bar
@@ -0,0 +1,150 @@
+package cards
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/logs"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/ui/styles"
+)
+
+// heatShades are the block characters of a heatmap cell, emptiest first
+var heatShades = []string{" ", "░", "▒", "▓", "█"}
+
+// RenderLatencyHeatmap renders the latency distribution over time with
+// block characters, slowest row on top, followed by the split between
+// Traefik overhead and backend time
+func RenderLatencyHeatmap(heatmap *logs.LatencyHeatmap, width int) string {
+	if width < 40 || heatmap == nil {
+		return ""
+	}
+
+	cardWidth := width
+	contentWidth := cardWidth - 4 // Account for borders and padding
+
+	var b strings.Builder
+
+	// Header
+	b.WriteString(styles.CardTitleStyle.Width(cardWidth).Render("⏱  Latency Heatmap"))
+	b.WriteString("\n")
+
+	series := heatmap.Overall
+	if series.Requests == 0 || len(heatmap.Rows) == 0 {
+		b.WriteString(styles.CardStyle.Width(cardWidth).Render(
+			styles.MutedStyle.Render("No latency data available"),
+		))
+		return b.String()
+	}
+
+	// Merge adjacent columns until they fit
+	labelWidth := 9
+	heatWidth := contentWidth - labelWidth - 1
+	perCell := (len(series.Columns) + heatWidth - 1) / heatWidth
+	if perCell < 1 {
+		perCell = 1
+	}
+	cells := (len(series.Columns) + perCell - 1) / perCell
+	repeat := 1
+	if cells > 0 && heatWidth/cells > 1 {
+		repeat = heatWidth / cells
+	}
+
+	grid := make([][]uint64, len(heatmap.Rows))
+	var maxCount uint64
+	for row := range grid {
+		grid[row] = make([]uint64, cells)
+		for i, column := range series.Columns {
+			if row < len(column.Counts) {
+				grid[row][i/perCell] += column.Counts[row]
+			}
+		}
+		for _, n := range grid[row] {
+			if n > maxCount {
+				maxCount = n
+			}
+		}
+	}
+
+	var lines []string
+	for row := len(heatmap.Rows) - 1; row >= 0; row-- {
+		var line strings.Builder
+		line.WriteString(styles.MutedStyle.Render(fmt.Sprintf("%*s ", labelWidth-1, rowLabel(heatmap.Rows[row]))))
+		for _, n := range grid[row] {
+			line.WriteString(heatStyle(n, maxCount).Render(strings.Repeat(heatShade(n, maxCount), repeat)))
+		}
+		lines = append(lines, line.String())
+	}
+
+	// Time axis
+	axisWidth := cells * repeat
+	start := heatmap.Since.Local().Format("15:04")
+	end := heatmap.Until.Local().Format("15:04")
+	gap := axisWidth - len(start) - len(end)
+	if gap < 1 {
+		gap = 1
+	}
+	lines = append(lines, styles.MutedStyle.Render(strings.Repeat(" ", labelWidth)+start+strings.Repeat(" ", gap)+end))
+
+	b.WriteString(styles.CardStyle.Width(cardWidth).Render(strings.Join(lines, "\n")))
+	b.WriteString("\n")
+
+	// Where the time goes
+	breakdown := series.Breakdown
+	summary := func(label string, s logs.LatencySummary) string {
+		return fmt.Sprintf("%s p50 %s  p95 %s  p99 %s",
+			styles.CardLabelStyle.Render(label),
+			formatDuration(s.P50MS), formatDuration(s.P95MS), formatDuration(s.P99MS))
+	}
+	b.WriteString(styles.CardStyle.Width(cardWidth).Render(strings.Join([]string{
+		summary("Total:   ", breakdown.Duration),
+		summary("Backend: ", breakdown.Origin),
+		summary("Traefik: ", breakdown.Overhead),
+	}, "\n")))
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// rowLabel names a heatmap row by its upper bound
+func rowLabel(row logs.LatencyRow) string {
+	if row.UpperMS == 0 {
+		return "> " + formatLatency(row.LowerMS)
+	}
+	return "≤ " + formatLatency(row.UpperMS)
+}
+
+// formatLatency formats a row bound, keeping a decimal below 10ms where
+// rows are close together
+func formatLatency(ms float64) string {
+	if ms < 10 {
+		return fmt.Sprintf("%.1fms", ms)
+	}
+	return formatDuration(ms)
+}
+
+// heatShade picks the block character of a cell
+func heatShade(n, maxCount uint64) string {
+	if n == 0 || maxCount == 0 {
+		return heatShades[0]
+	}
+	level := 1 + int(float64(n)/float64(maxCount)*float64(len(heatShades)-2)+0.5)
+	if level >= len(heatShades) {
+		level = len(heatShades) - 1
+	}
+	return heatShades[level]
+}
+
+// heatStyle colours a cell by how busy it is
+func heatStyle(n, maxCount uint64) lipgloss.Style {
+	switch {
+	case maxCount == 0 || n*4 < maxCount:
+		return styles.MutedStyle
+	case n*2 < maxCount:
+		return styles.SuccessStyle
+	case n*4 < maxCount*3:
+		return styles.WarningStyle
+	default:
+		return styles.ErrorStyle
+	}
+}
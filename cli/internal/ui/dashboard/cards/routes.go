@@ -9,26 +9,18 @@ import (
 	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/ui/styles"
 )
 
-// RenderRouters renders the routers metrics card
-func RenderRouters(routers []logs.RouterMetric, width int) string {
+// RenderRouters renders the routers metrics card; selected is the
+// highlighted router, or -1
+func RenderRouters(routers []logs.RouterMetric, selected, width int) string {
 	if width < 40 {
 		return ""
 	}
 
-	cardWidth := width
-	contentWidth := cardWidth - 4
-
-	var b strings.Builder
-
-	// Header
-	b.WriteString(styles.CardTitleStyle.Width(cardWidth).Render("🔀 Routers"))
-	b.WriteString("\n")
+	title := "🔀 Routers"
+	contentWidth := width - 6
 
 	if len(routers) == 0 {
-		b.WriteString(styles.CardStyle.Width(cardWidth).Render(
-			styles.MutedStyle.Render("No router data available"),
-		))
-		return b.String()
+		return Render(title, styles.MutedStyle.Render("No router data available"), width)
 	}
 
 	// Calculate column widths
@@ -36,16 +28,14 @@ func RenderRouters(routers []logs.RouterMetric, width int) string {
 	if nameWidth > 40 {
 		nameWidth = 40
 	}
-	metricsWidth := contentWidth - nameWidth - 2
+	metricsWidth := contentWidth - nameWidth
 
 	// Table header
-	header := lipgloss.JoinHorizontal(
+	lines := []string{lipgloss.JoinHorizontal(
 		lipgloss.Top,
 		styles.TableHeaderStyle.Width(nameWidth).Render("Router"),
 		styles.TableHeaderStyle.Width(metricsWidth).Render("Metrics"),
-	)
-	b.WriteString(header)
-	b.WriteString("\n")
+	)}
 
 	// Limit to top 8 routers
 	displayCount := len(routers)
@@ -53,53 +43,35 @@ func RenderRouters(routers []logs.RouterMetric, width int) string {
 		displayCount = 8
 	}
 
+	maxRequests := routers[0].Count
+	if maxRequests == 0 {
+		maxRequests = 1
+	}
+	barWidth := metricsWidth - 2
+	if barWidth > 30 {
+		barWidth = 30
+	}
+
 	for i := 0; i < displayCount; i++ {
 		router := routers[i]
 
-		// Truncate router name if too long
-		routerName := router.Name
-		if len(routerName) > nameWidth-2 {
-			routerName = routerName[:nameWidth-5] + "..."
-		}
-
-		// FIX: Removed reference to router.ErrorRate which does not exist
 		metricsText := fmt.Sprintf(
 			"Req: %s  Avg: %s",
 			formatNumber(router.Count),
 			formatDuration(router.AvgDuration),
 		)
-
-		// Create progress bar for request volume
-		maxRequests := routers[0].Count
-		if maxRequests == 0 {
-			maxRequests = 1
-		}
-		barWidth := metricsWidth - 2
-		if barWidth > 30 {
-			barWidth = 30
-		}
-		// FIX: Removed error condition from progress bar
 		requestBar := renderProgressBar(float64(router.Count)/float64(maxRequests), barWidth, false)
 
-		// Router name cell
-		nameCell := styles.TableCellStyle.Width(nameWidth).Render(routerName)
-
-		// Metrics cell with progress bar
-		metricsContent := fmt.Sprintf("%s\n%s", metricsText, requestBar)
-		metricsCell := styles.TableCellStyle.Width(metricsWidth).Render(metricsContent)
-
-		row := lipgloss.JoinHorizontal(lipgloss.Top, nameCell, metricsCell)
-		b.WriteString(row)
-		b.WriteString("\n")
+		nameCell := styles.TableCellStyle.Width(nameWidth).Render(markSelected(truncateText(router.Name, nameWidth-2), i, selected))
+		metricsCell := styles.TableCellStyle.Width(metricsWidth).Render(metricsText + "\n" + requestBar)
+		lines = append(lines, lipgloss.JoinHorizontal(lipgloss.Top, nameCell, metricsCell))
 	}
 
 	if len(routers) > displayCount {
-		footer := styles.MutedStyle.Render(
+		lines = append(lines, styles.MutedStyle.Render(
 			fmt.Sprintf("... and %d more routers", len(routers)-displayCount),
-		)
-		b.WriteString(styles.CardStyle.Width(cardWidth).Render(footer))
-		b.WriteString("\n")
+		))
 	}
 
-	return b.String()
-}
\ No newline at end of file
+	return Render(title, strings.Join(lines, "\n"), width)
+}
@@ -0,0 +1,138 @@
+package cards
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/logs"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/ui/styles"
+)
+
+// RenderSLO renders the service level objectives card: the SLI and error
+// budget left of every objective, and the burn rate alerts that fire
+func RenderSLO(report *logs.SLOReport, width int) string {
+	if width < 40 || report == nil {
+		return ""
+	}
+
+	cardWidth := width
+	contentWidth := cardWidth - 4 // Account for borders and padding
+
+	var b strings.Builder
+
+	// Header
+	title := "🎯 SLOs"
+	if report.Firing > 0 {
+		title += styles.ErrorStyle.Render(fmt.Sprintf("  %d alerts firing", report.Firing))
+	}
+	if report.Loading {
+		title += styles.MutedStyle.Render("  (reading history)")
+	}
+	b.WriteString(styles.CardTitleStyle.Width(cardWidth).Render(title))
+	b.WriteString("\n")
+
+	if len(report.Objectives) == 0 {
+		b.WriteString(styles.CardStyle.Width(cardWidth).Render(
+			styles.MutedStyle.Render("No objectives"),
+		))
+		return b.String()
+	}
+
+	nameWidth := contentWidth * 3 / 10
+	sliWidth := contentWidth / 5
+	budgetWidth := contentWidth / 4
+	alertWidth := contentWidth - nameWidth - sliWidth - budgetWidth - 2
+
+	header := lipgloss.JoinHorizontal(
+		lipgloss.Top,
+		styles.TableHeaderStyle.Width(nameWidth).Render("Objective"),
+		styles.TableHeaderStyle.Width(sliWidth).Render("SLI"),
+		styles.TableHeaderStyle.Width(budgetWidth).Render("Error Budget"),
+		styles.TableHeaderStyle.Width(alertWidth).Render("Burn Rate"),
+	)
+	b.WriteString(header)
+	b.WriteString("\n")
+
+	for _, o := range report.Objectives {
+		stateStyle := sloStateStyle(o.State)
+
+		objective := fmt.Sprintf("%.4g%% %s", o.Target, o.Type)
+		if o.Threshold != "" {
+			objective = fmt.Sprintf("%.4g%% ≤ %s", o.Target, o.Threshold)
+		}
+		nameContent := fmt.Sprintf("%s %s\n  %s",
+			stateStyle.Render("●"),
+			truncateText(o.Name, nameWidth-4),
+			styles.MutedStyle.Render(fmt.Sprintf("%s / %s", objective, o.Window)),
+		)
+
+		sliContent := styles.MutedStyle.Render("no data")
+		if o.Requests > 0 {
+			sliContent = fmt.Sprintf("%s\n%s",
+				stateStyle.Render(fmt.Sprintf("%.3f%%", o.SLI)),
+				styles.MutedStyle.Render(fmt.Sprintf("%s req", formatNumber(int(o.Requests)))),
+			)
+		}
+
+		remaining := o.ErrorBudgetRemaining
+		fraction := remaining / 100
+		if fraction < 0 {
+			fraction = 0
+		}
+		budgetContent := fmt.Sprintf("%s\n%s",
+			stateStyle.Render(fmt.Sprintf("%.1f%% left", remaining)),
+			renderProgressBar(fraction, budgetWidth-4, remaining < 25),
+		)
+
+		alertContent := renderBurnRates(o)
+
+		row := lipgloss.JoinHorizontal(
+			lipgloss.Top,
+			styles.TableCellStyle.Width(nameWidth).Render(nameContent),
+			styles.TableCellStyle.Width(sliWidth).Render(sliContent),
+			styles.TableCellStyle.Width(budgetWidth).Render(budgetContent),
+			styles.TableCellStyle.Width(alertWidth).Render(alertContent),
+		)
+		b.WriteString(row)
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// renderBurnRates lists the firing alerts of an objective, or the burn rate
+// of its fastest alert when none fire
+func renderBurnRates(o logs.SLOStatus) string {
+	var firing []string
+	for _, a := range o.Alerts {
+		if a.Firing {
+			firing = append(firing, styles.ErrorStyle.Render(
+				fmt.Sprintf("%s %s: %.1fx/%.1fx ≥ %.1fx", a.Name, a.Long, a.LongBurnRate, a.ShortBurnRate, a.BurnRate),
+			))
+		}
+	}
+	if len(firing) > 0 {
+		return strings.Join(firing, "\n")
+	}
+	if len(o.Alerts) == 0 {
+		return styles.MutedStyle.Render("-")
+	}
+	a := o.Alerts[0]
+	return styles.SuccessStyle.Render(fmt.Sprintf("%.1fx over %s", a.LongBurnRate, a.Long)) +
+		"\n" + styles.MutedStyle.Render("no alerts firing")
+}
+
+// sloStateStyle colours an objective state
+func sloStateStyle(state string) lipgloss.Style {
+	switch state {
+	case logs.SLOExhausted:
+		return styles.ErrorStyle
+	case logs.SLOBurning:
+		return styles.WarningStyle
+	case logs.SLONoData:
+		return styles.MutedStyle
+	default:
+		return styles.SuccessStyle
+	}
+}
@@ -6,8 +6,9 @@ import (
 	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/ui/styles"
 )
 
-// RenderStatusCodes renders the status codes metrics card
-func RenderStatusCodes(status2xx, status3xx, status4xx, status5xx int, errorRate float64, width int) string {
+// RenderStatusCodes renders the status codes metrics card; selected is the
+// highlighted class, from 0 for 2xx, or -1
+func RenderStatusCodes(status2xx, status3xx, status4xx, status5xx int, errorRate float64, selected, width int) string {
 	total := status2xx + status3xx + status4xx + status5xx
 
 	var statusLines string
@@ -15,16 +16,16 @@ func RenderStatusCodes(status2xx, status3xx, status4xx, status5xx int, errorRate
 		statusLines = fmt.Sprintf(
 			"%s %s (%.1f%%)\n%s %s (%.1f%%)\n%s %s (%.1f%%)\n%s %s (%.1f%%)",
 			styles.Status2xxStyle.Render("2xx"),
-			formatNumber(status2xx),
+			markSelected(formatNumber(status2xx), 0, selected),
 			float64(status2xx)/float64(total)*100,
 			styles.Status3xxStyle.Render("3xx"),
-			formatNumber(status3xx),
+			markSelected(formatNumber(status3xx), 1, selected),
 			float64(status3xx)/float64(total)*100,
 			styles.Status4xxStyle.Render("4xx"),
-			formatNumber(status4xx),
+			markSelected(formatNumber(status4xx), 2, selected),
 			float64(status4xx)/float64(total)*100,
 			styles.Status5xxStyle.Render("5xx"),
-			formatNumber(status5xx),
+			markSelected(formatNumber(status5xx), 3, selected),
 			float64(status5xx)/float64(total)*100,
 		)
 	} else {
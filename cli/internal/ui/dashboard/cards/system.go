@@ -41,61 +41,23 @@ func RenderSystemStats(stats *SystemStats, width int) string {
 		return ""
 	}
 
-	cardWidth := width
-	contentWidth := cardWidth - 4
-
-	var b strings.Builder
-
-	// Header
-	b.WriteString(styles.CardTitleStyle.Width(cardWidth).Render("💻 System Resources"))
-	b.WriteString("\n")
+	title := "💻 System Resources"
+	contentWidth := width - 6
 
 	if stats == nil {
-		b.WriteString(styles.CardStyle.Width(cardWidth).Render(
-			styles.MutedStyle.Render("System stats unavailable"),
-		))
-		return b.String()
+		return Render(title, styles.MutedStyle.Render("System stats unavailable"), width)
 	}
 
-	// CPU Usage
-	cpuLine := renderResourceLine("CPU", stats.CPU.UsagePercent, contentWidth)
-	cpuDetail := fmt.Sprintf("    %d cores", stats.CPU.Cores)
-	b.WriteString(styles.CardStyle.Width(cardWidth).Render(cpuLine))
-	b.WriteString("\n")
-	b.WriteString(styles.CardStyle.Width(cardWidth).Render(
-		styles.MutedStyle.Render(cpuDetail),
-	))
-	b.WriteString("\n")
-
-	// Memory Usage
-	memLine := renderResourceLine("Memory", stats.Memory.UsedPercent, contentWidth)
-	memDetail := fmt.Sprintf(
-		"    %s / %s",
-		formatBytes(stats.Memory.Used),
-		formatBytes(stats.Memory.Total),
-	)
-	b.WriteString(styles.CardStyle.Width(cardWidth).Render(memLine))
-	b.WriteString("\n")
-	b.WriteString(styles.CardStyle.Width(cardWidth).Render(
-		styles.MutedStyle.Render(memDetail),
-	))
-	b.WriteString("\n")
-
-	// Disk Usage
-	diskLine := renderResourceLine("Disk", stats.Disk.UsedPercent, contentWidth)
-	diskDetail := fmt.Sprintf(
-		"    %s / %s",
-		formatBytes(stats.Disk.Used),
-		formatBytes(stats.Disk.Total),
-	)
-	b.WriteString(styles.CardStyle.Width(cardWidth).Render(diskLine))
-	b.WriteString("\n")
-	b.WriteString(styles.CardStyle.Width(cardWidth).Render(
-		styles.MutedStyle.Render(diskDetail),
-	))
-	b.WriteString("\n")
-
-	return b.String()
+	lines := []string{
+		renderResourceLine("CPU", stats.CPU.UsagePercent, contentWidth),
+		styles.MutedStyle.Render(fmt.Sprintf("    %d cores", stats.CPU.Cores)),
+		renderResourceLine("Memory", stats.Memory.UsedPercent, contentWidth),
+		styles.MutedStyle.Render(fmt.Sprintf("    %s / %s", formatBytes(stats.Memory.Used), formatBytes(stats.Memory.Total))),
+		renderResourceLine("Disk", stats.Disk.UsedPercent, contentWidth),
+		styles.MutedStyle.Render(fmt.Sprintf("    %s / %s", formatBytes(stats.Disk.Used), formatBytes(stats.Disk.Total))),
+	}
+
+	return Render(title, strings.Join(lines, "\n"), width)
 }
 
 // renderResourceLine renders a single resource usage line with progress bar
@@ -156,31 +118,14 @@ func RenderSystemHealth(stats *SystemStats, width int) string {
 		return ""
 	}
 
-	cardWidth := width
-	var b strings.Builder
-
-	// Header
-	b.WriteString(styles.CardTitleStyle.Width(cardWidth).Render("🏥 System Health"))
-	b.WriteString("\n")
+	title := "🏥 System Health"
 
 	if stats == nil {
-		b.WriteString(styles.CardStyle.Width(cardWidth).Render(
-			styles.MutedStyle.Render("Health check unavailable"),
-		))
-		return b.String()
+		return Render(title, styles.MutedStyle.Render("Health check unavailable"), width)
 	}
 
 	// Calculate overall health score
 	healthScore := calculateHealthScore(stats)
-	healthStatus := getHealthStatus(healthScore)
-	healthEmoji := getHealthEmoji(healthScore)
-
-	statusLine := fmt.Sprintf(
-		"%s Overall Health: %s (%.0f%%)",
-		healthEmoji,
-		healthStatus,
-		healthScore,
-	)
 
 	var statusStyle lipgloss.Style
 	if healthScore >= 80 {
@@ -191,46 +136,31 @@ func RenderSystemHealth(stats *SystemStats, width int) string {
 		statusStyle = styles.ErrorStyle
 	}
 
-	b.WriteString(styles.CardStyle.Width(cardWidth).Render(
-		statusStyle.Render(statusLine),
-	))
-	b.WriteString("\n")
-
-	// Individual resource status
-	cpuStatus := getResourceStatus(stats.CPU.UsagePercent)
-	memStatus := getResourceStatus(stats.Memory.UsedPercent)
-	diskStatus := getResourceStatus(stats.Disk.UsedPercent)
-
-	details := fmt.Sprintf(
-		"CPU: %s  |  Memory: %s  |  Disk: %s",
-		cpuStatus,
-		memStatus,
-		diskStatus,
-	)
-
-	b.WriteString(styles.CardStyle.Width(cardWidth).Render(
-		styles.MutedStyle.Render(details),
-	))
-	b.WriteString("\n")
+	lines := []string{
+		statusStyle.Render(fmt.Sprintf(
+			"%s Overall Health: %s (%.0f%%)",
+			getHealthEmoji(healthScore),
+			getHealthStatus(healthScore),
+			healthScore,
+		)),
+		"",
+		// Individual resource status
+		styles.MutedStyle.Render(fmt.Sprintf("CPU:    %s", getResourceStatus(stats.CPU.UsagePercent))),
+		styles.MutedStyle.Render(fmt.Sprintf("Memory: %s", getResourceStatus(stats.Memory.UsedPercent))),
+		styles.MutedStyle.Render(fmt.Sprintf("Disk:   %s", getResourceStatus(stats.Disk.UsedPercent))),
+	}
 
 	// Recommendations if needed
 	if healthScore < 80 {
-		recommendations := getHealthRecommendations(stats)
-		if len(recommendations) > 0 {
-			b.WriteString(styles.CardStyle.Width(cardWidth).Render(
-				styles.WarningStyle.Render("⚠ Recommendations:"),
-			))
-			b.WriteString("\n")
+		if recommendations := getHealthRecommendations(stats); len(recommendations) > 0 {
+			lines = append(lines, "", styles.WarningStyle.Render("⚠ Recommendations:"))
 			for _, rec := range recommendations {
-				b.WriteString(styles.CardStyle.Width(cardWidth).Render(
-					styles.MutedStyle.Render("  • "+rec),
-				))
-				b.WriteString("\n")
+				lines = append(lines, styles.MutedStyle.Render("  • "+rec))
 			}
 		}
 	}
 
-	return b.String()
+	return Render(title, strings.Join(lines, "\n"), width)
 }
 
 // calculateHealthScore calculates an overall health score (0-100)
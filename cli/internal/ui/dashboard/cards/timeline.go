@@ -15,86 +15,57 @@ func RenderTimeline(logEntries []logs.TraefikLog, metrics *logs.Metrics, width i
 		return ""
 	}
 
-	cardWidth := width
-	contentWidth := cardWidth - 4
-
-	var b strings.Builder
-
-	// Header
-	b.WriteString(styles.CardTitleStyle.Width(cardWidth).Render("📈 Request Timeline"))
-	b.WriteString("\n")
+	title := "📈 Request Timeline"
+	contentWidth := width - 6
 
 	if len(logEntries) == 0 {
-		b.WriteString(styles.CardStyle.Width(cardWidth).Render(
-			styles.MutedStyle.Render("No timeline data available"),
-		))
-		return b.String()
+		return Render(title, styles.MutedStyle.Render("No timeline data available"), width)
 	}
 
-	// Group requests by time bucket (5-minute intervals)
-	buckets := groupByTimeBucket(logEntries, 5*time.Minute)
-
+	// Group requests into buckets sized to the time span
+	buckets, interval := GroupByTimeBucket(logEntries)
 	if len(buckets) == 0 {
-		b.WriteString(styles.CardStyle.Width(cardWidth).Render(
-			styles.MutedStyle.Render("Insufficient data for timeline"),
-		))
-		return b.String()
+		return Render(title, styles.MutedStyle.Render("Insufficient data for timeline"), width)
 	}
 
-	// Find min/max for scaling
+	// Find max for scaling
 	var maxCount int
 	for _, bucket := range buckets {
 		if bucket.Count > maxCount {
 			maxCount = bucket.Count
 		}
 	}
-
 	if maxCount == 0 {
 		maxCount = 1
 	}
 
 	// Render sparkline
-	sparkWidth := contentWidth - 15
-	if sparkWidth > 60 {
-		sparkWidth = 60
+	sparkWidth := contentWidth
+	if sparkWidth > 120 {
+		sparkWidth = 120
 	}
 
-	sparkline := renderSparkline(buckets, sparkWidth, maxCount)
-	b.WriteString(styles.CardStyle.Width(cardWidth).Render(sparkline))
-	b.WriteString("\n")
-
-	// Timeline stats
-	// FIX: Changed metrics.RequestsPerSecond to metrics.RequestsPerSec
-	statsLine := fmt.Sprintf(
-		"Peak: %s req/5min  |  Avg: %s req/5min  |  Current: %.1f req/sec",
-		formatNumber(maxCount),
-		formatNumber(calculateAverage(buckets)),
-		metrics.RequestsPerSec,
-	)
-	b.WriteString(styles.CardStyle.Width(cardWidth).Render(
-		styles.MutedStyle.Render(statsLine),
-	))
-	b.WriteString("\n")
-
-	// Time range
-	if len(buckets) > 0 {
-		firstTime := buckets[0].Time
-		lastTime := buckets[len(buckets)-1].Time
-		duration := lastTime.Sub(firstTime)
-
-		timeRange := fmt.Sprintf(
+	per := "req/" + formatInterval(interval)
+	firstTime := buckets[0].Time
+	lastTime := buckets[len(buckets)-1].Time
+	lines := []string{
+		renderSparkline(buckets, sparkWidth, maxCount),
+		"",
+		styles.MutedStyle.Render(fmt.Sprintf(
+			"Peak: %s %s  |  Avg: %s %s",
+			formatNumber(maxCount), per,
+			formatNumber(calculateAverage(buckets)), per,
+		)),
+		styles.MutedStyle.Render(fmt.Sprintf("Current: %.1f req/sec", metrics.RequestsPerSec)),
+		styles.MutedStyle.Render(fmt.Sprintf(
 			"Time Range: %s → %s (%s)",
 			firstTime.Format("15:04"),
 			lastTime.Format("15:04"),
-			formatTimeDuration(duration),
-		)
-		b.WriteString(styles.CardStyle.Width(cardWidth).Render(
-			styles.MutedStyle.Render(timeRange),
-		))
-		b.WriteString("\n")
+			formatTimeDuration(lastTime.Sub(firstTime)),
+		)),
 	}
 
-	return b.String()
+	return Render(title, strings.Join(lines, "\n"), width)
 }
 
 // TimeBucket represents a time bucket with request count
@@ -103,62 +74,76 @@ type TimeBucket struct {
 	Count int
 }
 
-// groupByTimeBucket groups log entries into time buckets
-func groupByTimeBucket(logs []logs.TraefikLog, interval time.Duration) []TimeBucket {
-	if len(logs) == 0 {
-		return nil
-	}
+// bucketIntervals are the bucket sizes GroupByTimeBucket chooses from
+var bucketIntervals = []time.Duration{
+	time.Minute,
+	5 * time.Minute,
+	15 * time.Minute,
+	time.Hour,
+	6 * time.Hour,
+	24 * time.Hour,
+}
 
-	bucketMap := make(map[int64]int)
+// GroupByTimeBucket groups log entries into time buckets, choosing the
+// smallest interval that keeps their time span within 60 buckets
+func GroupByTimeBucket(entries []logs.TraefikLog) ([]TimeBucket, time.Duration) {
+	times := make([]time.Time, 0, len(entries))
 	var minTime, maxTime time.Time
-
-	// Find the time range of the logs
-	for i, log := range logs {
-		// Assuming StartUTC is a string, parse it.
-		// If it's already a time.Time, this check can be simplified.
-		parsedTime, err := time.Parse(time.RFC3339Nano, log.StartUTC)
+	for _, entry := range entries {
+		parsedTime, err := logs.ParseTime(entry.StartUTC)
 		if err != nil {
 			continue // Skip logs with unparseable timestamps
 		}
-
-		if i == 0 {
+		parsedTime = parsedTime.Local()
+		if minTime.IsZero() || parsedTime.Before(minTime) {
 			minTime = parsedTime
+		}
+		if parsedTime.After(maxTime) {
 			maxTime = parsedTime
-		} else {
-			if parsedTime.Before(minTime) {
-				minTime = parsedTime
-			}
-			if parsedTime.After(maxTime) {
-				maxTime = parsedTime
-			}
 		}
+		times = append(times, parsedTime)
+	}
+	if len(times) == 0 {
+		return nil, 0
+	}
 
-		bucketKey := parsedTime.Unix() / int64(interval.Seconds())
-		bucketMap[bucketKey]++
+	interval := bucketIntervals[len(bucketIntervals)-1]
+	for _, candidate := range bucketIntervals {
+		if maxTime.Sub(minTime)/candidate < 60 {
+			interval = candidate
+			break
+		}
 	}
 
-	if minTime.IsZero() {
-		return nil // No valid logs found
+	bucketMap := make(map[int64]int)
+	for _, t := range times {
+		bucketMap[t.Truncate(interval).Unix()]++
 	}
 
 	// Create ordered buckets
 	var buckets []TimeBucket
-	currentTime := minTime.Truncate(interval)
-	endTime := maxTime.Truncate(interval).Add(interval)
-
-	for !currentTime.After(endTime) {
-		bucketKey := currentTime.Unix() / int64(interval.Seconds())
-		count := bucketMap[bucketKey]
+	endTime := maxTime.Truncate(interval)
+	for currentTime := minTime.Truncate(interval); !currentTime.After(endTime); currentTime = currentTime.Add(interval) {
 		buckets = append(buckets, TimeBucket{
 			Time:  currentTime,
-			Count: count,
+			Count: bucketMap[currentTime.Unix()],
 		})
-		currentTime = currentTime.Add(interval)
 	}
 
-	return buckets
+	return buckets, interval
 }
 
+// formatInterval formats a bucket interval such as 5m or 1h
+func formatInterval(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour && d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d >= time.Hour && d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	default:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+}
 
 // renderSparkline renders a sparkline chart
 func renderSparkline(buckets []TimeBucket, width, maxCount int) string {
@@ -246,16 +231,14 @@ func formatTimeDuration(d time.Duration) string {
 
 // RenderRequestDistribution renders request distribution over time
 func RenderRequestDistribution(buckets []TimeBucket, width int) string {
-	if width < 40 || len(buckets) == 0 {
+	if width < 40 {
 		return ""
 	}
 
-	cardWidth := width
-	var b strings.Builder
-
-	// Header
-	b.WriteString(styles.CardTitleStyle.Width(cardWidth).Render("📊 Request Distribution"))
-	b.WriteString("\n")
+	title := "📊 Request Distribution"
+	if len(buckets) == 0 {
+		return Render(title, styles.MutedStyle.Render("No requests in period"), width)
+	}
 
 	// Find max for scaling
 	maxCount := 0
@@ -266,10 +249,7 @@ func RenderRequestDistribution(buckets []TimeBucket, width int) string {
 	}
 
 	if maxCount == 0 {
-		b.WriteString(styles.CardStyle.Width(cardWidth).Render(
-			styles.MutedStyle.Render("No requests in period"),
-		))
-		return b.String()
+		return Render(title, styles.MutedStyle.Render("No requests in period"), width)
 	}
 
 	// Show last 8 buckets as horizontal bars
@@ -278,22 +258,18 @@ func RenderRequestDistribution(buckets []TimeBucket, width int) string {
 		startIdx = 0
 	}
 
-	barWidth := cardWidth - 20
+	barWidth := width - 22
 	if barWidth > 40 {
 		barWidth = 40
 	}
 
+	var lines []string
 	for i := startIdx; i < len(buckets); i++ {
 		bucket := buckets[i]
-		timeLabel := bucket.Time.Format("15:04")
 		percentage := float64(bucket.Count) / float64(maxCount)
-
 		bar := renderProgressBar(percentage, barWidth, false)
-		line := fmt.Sprintf("%s  %4d  %s", timeLabel, bucket.Count, bar)
-
-		b.WriteString(styles.CardStyle.Width(cardWidth).Render(line))
-		b.WriteString("\n")
+		lines = append(lines, fmt.Sprintf("%s  %4d  %s", bucket.Time.Format("15:04"), bucket.Count, bar))
 	}
 
-	return b.String()
-}
\ No newline at end of file
+	return Render(title, strings.Join(lines, "\n"), width)
+}
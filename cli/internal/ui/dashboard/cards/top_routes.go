@@ -8,8 +8,9 @@ import (
 	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/ui/styles"
 )
 
-// RenderTopRoutes renders the top routes card
-func RenderTopRoutes(routes []logs.RouteMetric, width int) string {
+// RenderTopRoutes renders the top routes card; selected is the highlighted
+// route, or -1
+func RenderTopRoutes(routes []logs.RouteMetric, selected, width int) string {
 	if len(routes) == 0 {
 		return Render("🔀 Top Routes", styles.MutedStyle.Render("No routes data"), width)
 	}
@@ -23,7 +24,7 @@ func RenderTopRoutes(routes []logs.RouteMetric, width int) string {
 		}
 
 		// Truncate path if too long
-		path := truncate(route.Path, width-25)
+		path := markSelected(truncate(route.Path, width-25), i, selected)
 		
 		// Create progress bar
 		barWidth := (width - 30)
@@ -6,84 +6,118 @@ import (
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/logs"
-	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/ui/dashboard/cards"
 	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/ui/styles"
 )
 
-// Render renders the dashboard view
-func Render(metrics *logs.Metrics, systemStats *logs.SystemStats, width, height int) string {
-	if metrics == nil {
-		return styles.MutedStyle.Render("No metrics available")
-	}
-
-	var sections []string
+// Options control how the dashboard is drawn
+type Options struct {
+	Width  int
+	Height int
+	// Focus is the index of the focused card in Cards, or -1 when no card
+	// is focused. Expanded shows the focused card alone, across the view.
+	Focus    int
+	Expanded bool
+	// Row is the selected row of the focused card, from Rows, or -1
+	Row int
+}
 
-	// Top metrics row
-	topRow := renderTopMetrics(metrics, width)
-	sections = append(sections, topRow)
+const (
+	// cardGap separates the cards of a row
+	cardGap = 2
+	// minCardWidth is the narrowest a card gets before its row is split
+	minCardWidth = 44
+)
 
-	// Middle section - routes and services
-	middleRow := renderMiddleSection(metrics, width)
-	sections = append(sections, middleRow)
+// Cards lists the cards of the layout that have data, in focus order
+func Cards(layout Layout, data Data) []string {
+	var names []string
+	for _, row := range layout.visible(&data) {
+		for _, cell := range row {
+			names = append(names, cell.Card)
+		}
+	}
+	return names
+}
 
-	// Bottom section - system stats if available
-	if systemStats != nil {
-		bottomRow := renderSystemStats(systemStats, width)
-		sections = append(sections, bottomRow)
+// Render renders the dashboard view. Rows that don't fit are cut off at
+// the bottom, unless a card below them is focused.
+func Render(data Data, layout Layout, opts Options) string {
+	if data.Metrics == nil {
+		return styles.MutedStyle.Render("No metrics available")
 	}
 
-	return lipgloss.JoinVertical(lipgloss.Left, sections...)
-}
+	names := Cards(layout, data)
+	if opts.Expanded && opts.Focus >= 0 && opts.Focus < len(names) {
+		return crop(cardTypes[names[opts.Focus]].render(&data, opts.Width-2, opts.Row), 0, opts.Height)
+	}
 
-// renderTopMetrics renders the top row of metrics
-func renderTopMetrics(metrics *logs.Metrics, width int) string {
-	cardWidth := (width - 12) / 3
-
-	requestsCard := cards.RenderRequests(metrics.TotalRequests, metrics.RequestsPerSec, cardWidth)
-	responseCard := cards.RenderResponseTime(metrics.AvgResponseTime, metrics.P95ResponseTime, metrics.P99ResponseTime, cardWidth)
-	statusCard := cards.RenderStatusCodes(metrics.Status2xx, metrics.Status3xx, metrics.Status4xx, metrics.Status5xx, metrics.ErrorRate, cardWidth)
-
-	return lipgloss.JoinHorizontal(
-		lipgloss.Top,
-		requestsCard,
-		"  ",
-		responseCard,
-		"  ",
-		statusCard,
-	)
-}
+	// A gutter left of each card marks the focused one
+	gutter := 0
+	if opts.Focus >= 0 {
+		gutter = 1
+	}
 
-// renderMiddleSection renders the middle section with routes and services
-func renderMiddleSection(metrics *logs.Metrics, width int) string {
-	halfWidth := (width - 4) / 2
+	rows := layout.visible(&data).collapse(opts.Width, cardGap, minCardWidth)
+	grid := NewGrid(opts.Width, opts.Height, 0, len(rows), cardGap)
+	sections := make([]string, 0, len(rows))
+	index, y := 0, 0
+	focusTop, focusBottom := 0, 0
+	for _, row := range rows {
+		grid.columns = len(row)
+		spans := grid.Spans(row.weights())
+		cells := make([]string, len(row))
+		focused := false
+		for i, cell := range row {
+			row := -1
+			if index == opts.Focus {
+				row = opts.Row
+			}
+			cells[i] = cardTypes[cell.Card].render(&data, spans[i]-2-gutter, row)
+			if gutter > 0 {
+				cells[i] = markCard(cells[i], index == opts.Focus)
+			}
+			focused = focused || index == opts.Focus
+			index++
+		}
+
+		section := RenderRow(cells, cardGap)
+		height := lipgloss.Height(section)
+		if focused {
+			focusTop, focusBottom = y, y+height
+		}
+		y += height
+		sections = append(sections, section)
+	}
 
-	routesCard := cards.RenderTopRoutes(metrics.TopRoutes, halfWidth)
-	servicesCard := cards.RenderBackends(metrics.TopServices, halfWidth)
+	start := 0
+	if focusBottom > opts.Height {
+		start = focusTop
+	}
+	return crop(lipgloss.JoinVertical(lipgloss.Left, sections...), start, opts.Height)
+}
 
-	return lipgloss.JoinHorizontal(
-		lipgloss.Top,
-		routesCard,
-		"  ",
-		servicesCard,
-	)
+// markCard draws the focus gutter left of a card
+func markCard(card string, focused bool) string {
+	mark := " "
+	if focused {
+		mark = lipgloss.NewStyle().Foreground(styles.Primary).Render("▌")
+	}
+	lines := strings.Split(card, "\n")
+	for i := range lines {
+		lines[i] = mark + lines[i]
+	}
+	return strings.Join(lines, "\n")
 }
 
-// renderSystemStats renders system statistics
-func renderSystemStats(stats *logs.SystemStats, width int) string {
-	cardWidth := (width - 12) / 3
-
-	cpuCard := renderCPUCard(stats.CPU, cardWidth)
-	memCard := renderMemoryCard(stats.Memory, cardWidth)
-	diskCard := renderDiskCard(stats.Disk, cardWidth)
-
-	return lipgloss.JoinHorizontal(
-		lipgloss.Top,
-		cpuCard,
-		"  ",
-		memCard,
-		"  ",
-		diskCard,
-	)
+// crop keeps height lines from start
+func crop(s string, start, height int) string {
+	if height <= 0 {
+		return s
+	}
+	lines := strings.Split(s, "\n")
+	start = min(start, len(lines))
+	end := min(start+height, len(lines))
+	return strings.Join(lines[start:end], "\n")
 }
 
 // renderCPUCard renders CPU statistics card
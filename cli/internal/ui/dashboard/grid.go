@@ -36,6 +36,27 @@ func (g *Grid) CellHeight() int {
 	return (g.height - totalGap) / g.rows
 }
 
+// Spans splits the width between the columns by their weights; the
+// remainder goes to the first columns
+func (g *Grid) Spans(weights []int) []int {
+	total := 0
+	for _, w := range weights {
+		total += w
+	}
+	free := g.width - g.gap*(len(weights)-1)
+	spans := make([]int, len(weights))
+	used := 0
+	for i, w := range weights {
+		spans[i] = free * w / total
+		used += spans[i]
+	}
+	for i := 0; used < free; i = (i + 1) % len(spans) {
+		spans[i]++
+		used++
+	}
+	return spans
+}
+
 // RenderRow renders a horizontal row of cells
 func RenderRow(cells []string, gap int) string {
 	if len(cells) == 0 {
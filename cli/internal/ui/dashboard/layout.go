@@ -0,0 +1,418 @@
+package dashboard
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/logs"
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/ui/dashboard/cards"
+)
+
+// Data is what the cards are rendered from
+type Data struct {
+	Metrics     *logs.Metrics
+	Entries     []logs.TraefikLog
+	SystemStats *logs.SystemStats
+}
+
+// Layout lists the rows of the dashboard, top to bottom
+type Layout []Row
+
+// Row is a line of cards sharing the width by their weights
+type Row []Cell
+
+// Cell places a card in a row
+type Cell struct {
+	Card   string
+	Weight int
+}
+
+// Drill is a row of a card, leading to the requests it sums up
+type Drill struct {
+	// Label names the row, such as GET /api/users
+	Label string
+	// Filter selects the requests of the row in the filter language
+	Filter string
+	// Entry is the request of a row showing a single one
+	Entry *logs.TraefikLog
+}
+
+// DefaultLayout shows every card
+var DefaultLayout = []string{
+	"requests, response_time, status_codes",
+	"timeline:2, distribution",
+	"top_routes, backends",
+	"routers, error_summary",
+	"errors",
+	"latency",
+	"slo",
+	"cpu, memory, disk, system_health",
+}
+
+// card renders a card from the data, highlighting the selected row, or
+// none for -1; available reports whether the data it needs is there, and
+// cards without it are left out of their row. Cards with rows list what
+// each leads to.
+type card struct {
+	render    func(d *Data, width, row int) string
+	available func(d *Data) bool
+	rows      func(d *Data) []Drill
+}
+
+var cardTypes = map[string]card{
+	"requests": {render: func(d *Data, width, row int) string {
+		return cards.RenderRequests(d.Metrics.TotalRequests, d.Metrics.RequestsPerSec, width)
+	}},
+	"response_time": {render: func(d *Data, width, row int) string {
+		return cards.RenderResponseTime(d.Metrics.AvgResponseTime, d.Metrics.P95ResponseTime, d.Metrics.P99ResponseTime, width)
+	}},
+	"status_codes": {
+		render: func(d *Data, width, row int) string {
+			m := d.Metrics
+			return cards.RenderStatusCodes(m.Status2xx, m.Status3xx, m.Status4xx, m.Status5xx, m.ErrorRate, row, width)
+		},
+		rows: statusRows,
+	},
+	"timeline": {render: func(d *Data, width, row int) string {
+		return cards.RenderTimeline(d.Entries, d.Metrics, width)
+	}},
+	"distribution": {render: func(d *Data, width, row int) string {
+		buckets, _ := cards.GroupByTimeBucket(d.Entries)
+		return cards.RenderRequestDistribution(buckets, width)
+	}},
+	"top_routes": {
+		render: func(d *Data, width, row int) string {
+			return cards.RenderTopRoutes(d.Metrics.TopRoutes, row, width)
+		},
+		rows: routeRows,
+	},
+	"backends": {
+		render: func(d *Data, width, row int) string {
+			return cards.RenderBackends(d.Metrics.Backends, d.Metrics.TopServices, row, width)
+		},
+		rows: backendRows,
+	},
+	"routers": {
+		render: func(d *Data, width, row int) string {
+			return cards.RenderRouters(d.Metrics.TopRouters, row, width)
+		},
+		rows: routerRows,
+	},
+	"errors": {
+		render: func(d *Data, width, row int) string {
+			return cards.RenderErrors(recentErrors(d.Entries), row, width)
+		},
+		rows: errorRows,
+	},
+	"error_summary": {
+		render: func(d *Data, width, row int) string {
+			return cards.RenderErrorSummary(d.Metrics, row, width)
+		},
+		rows: errorClassRows,
+	},
+	"latency": {
+		render:    func(d *Data, width, row int) string { return cards.RenderLatencyHeatmap(d.Metrics.Latency, width) },
+		available: func(d *Data) bool { return d.Metrics.Latency != nil },
+	},
+	"slo": {
+		render:    func(d *Data, width, row int) string { return cards.RenderSLO(d.Metrics.SLO, width) },
+		available: func(d *Data) bool { return d.Metrics.SLO != nil },
+	},
+	"cpu": {
+		render:    func(d *Data, width, row int) string { return renderCPUCard(d.SystemStats.CPU, width) },
+		available: hasSystemStats,
+	},
+	"memory": {
+		render:    func(d *Data, width, row int) string { return renderMemoryCard(d.SystemStats.Memory, width) },
+		available: hasSystemStats,
+	},
+	"disk": {
+		render:    func(d *Data, width, row int) string { return renderDiskCard(d.SystemStats.Disk, width) },
+		available: hasSystemStats,
+	},
+	"system_health": {
+		render: func(d *Data, width, row int) string {
+			return cards.RenderSystemHealth(systemStats(d.SystemStats), width)
+		},
+		available: hasSystemStats,
+	},
+}
+
+func hasSystemStats(d *Data) bool {
+	return d.SystemStats != nil
+}
+
+// CardNames lists the cards a layout can place
+func CardNames() []string {
+	names := make([]string, 0, len(cardTypes))
+	for name := range cardTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseLayout reads a layout from rows of comma-separated cards, each
+// with an optional relative width such as timeline:2
+func ParseLayout(rows []string) (Layout, error) {
+	var layout Layout
+	for _, text := range rows {
+		var row Row
+		for _, field := range strings.Split(text, ",") {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+			cell := Cell{Card: field, Weight: 1}
+			if name, weight, ok := strings.Cut(field, ":"); ok {
+				n, err := strconv.Atoi(strings.TrimSpace(weight))
+				if err != nil || n < 1 {
+					return nil, fmt.Errorf("invalid width in %q", field)
+				}
+				cell = Cell{Card: strings.TrimSpace(name), Weight: n}
+			}
+			if _, ok := cardTypes[cell.Card]; !ok {
+				return nil, fmt.Errorf("unknown card %q (available: %s)", cell.Card, strings.Join(CardNames(), ", "))
+			}
+			row = append(row, cell)
+		}
+		if len(row) > 0 {
+			layout = append(layout, row)
+		}
+	}
+	if len(layout) == 0 {
+		return nil, fmt.Errorf("layout has no cards")
+	}
+	return layout, nil
+}
+
+// Rows lists the rows of the card at index in Cards that lead to
+// requests; nil for cards without any
+func Rows(layout Layout, data Data, index int) []Drill {
+	names := Cards(layout, data)
+	if index < 0 || index >= len(names) {
+		return nil
+	}
+	rows := cardTypes[names[index]].rows
+	if rows == nil {
+		return nil
+	}
+	return rows(&data)
+}
+
+// CardTitle names a card for display, such as Top Routes for top_routes
+func CardTitle(name string) string {
+	words := strings.Split(name, "_")
+	for i, w := range words {
+		words[i] = strings.ToUpper(w[:1]) + w[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+// visible leaves out the cards whose data is missing, and the rows left
+// empty
+func (l Layout) visible(d *Data) Layout {
+	var out Layout
+	for _, row := range l {
+		var kept Row
+		for _, cell := range row {
+			if available := cardTypes[cell.Card].available; available == nil || available(d) {
+				kept = append(kept, cell)
+			}
+		}
+		if len(kept) > 0 {
+			out = append(out, kept)
+		}
+	}
+	return out
+}
+
+// collapse splits the rows whose cards would be narrower than minWidth, so
+// small terminals stack the cards
+func (l Layout) collapse(width, gap, minWidth int) Layout {
+	var out Layout
+	for _, row := range l {
+		for len(row) > 0 {
+			n := len(row)
+			for n > 1 && narrowest(row[:n], width, gap) < minWidth {
+				n--
+			}
+			out = append(out, row[:n])
+			row = row[n:]
+		}
+	}
+	return out
+}
+
+func narrowest(row Row, width, gap int) int {
+	widths := NewGrid(width, 0, len(row), 1, gap).Spans(row.weights())
+	least := width
+	for _, w := range widths {
+		least = min(least, w)
+	}
+	return least
+}
+
+func (r Row) weights() []int {
+	weights := make([]int, len(r))
+	for i, cell := range r {
+		weights[i] = cell.Weight
+	}
+	return weights
+}
+
+// recentErrors lists the failed requests, newest first
+func recentErrors(entries []logs.TraefikLog) []logs.TraefikLog {
+	// Times are parsed, as their fractional seconds vary in length
+	type failed struct {
+		at    time.Time
+		entry logs.TraefikLog
+	}
+	var errs []failed
+	for _, entry := range entries {
+		if entry.DownstreamStatus >= 400 {
+			at, _ := logs.ParseTime(entry.StartUTC)
+			errs = append(errs, failed{at, entry})
+		}
+	}
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].at.After(errs[j].at)
+	})
+
+	out := make([]logs.TraefikLog, len(errs))
+	for i, e := range errs {
+		out[i] = e.entry
+	}
+	return out
+}
+
+// statusRows leads from the status codes card to each class
+func statusRows(d *Data) []Drill {
+	m := d.Metrics
+	if m.Status2xx+m.Status3xx+m.Status4xx+m.Status5xx == 0 {
+		return nil
+	}
+	return classRows("2xx", "3xx", "4xx", "5xx")
+}
+
+// errorClassRows leads from the error summary to 4xx and 5xx
+func errorClassRows(d *Data) []Drill {
+	if d.Metrics.Status4xx+d.Metrics.Status5xx == 0 {
+		return nil
+	}
+	return classRows("4xx", "5xx")
+}
+
+func classRows(classes ...string) []Drill {
+	rows := make([]Drill, len(classes))
+	for i, class := range classes {
+		rows[i] = Drill{Label: class, Filter: "status:" + class}
+	}
+	return rows
+}
+
+// routeRows leads from the top routes to their method and path
+func routeRows(d *Data) []Drill {
+	routes := d.Metrics.TopRoutes[:min(len(d.Metrics.TopRoutes), 8)]
+	rows := make([]Drill, len(routes))
+	for i, route := range routes {
+		rows[i] = Drill{
+			Label:  strings.TrimSpace(route.Method + " " + route.Path),
+			Filter: terms("method", route.Method, "path", route.Path),
+		}
+	}
+	return rows
+}
+
+// backendRows leads from the backends card to the service of each row,
+// the backend servers when the agent reports them
+func backendRows(d *Data) []Drill {
+	var services []string
+	if report := d.Metrics.Backends; report != nil && len(report.Backends) > 0 {
+		for _, backend := range report.Backends {
+			services = append(services, backend.Service)
+		}
+	} else {
+		for _, svc := range d.Metrics.TopServices {
+			services = append(services, svc.Name)
+		}
+	}
+	return nameRows("service", services[:min(len(services), 8)])
+}
+
+// routerRows leads from the routers card to each router
+func routerRows(d *Data) []Drill {
+	routers := d.Metrics.TopRouters[:min(len(d.Metrics.TopRouters), 8)]
+	names := make([]string, len(routers))
+	for i, router := range routers {
+		names[i] = router.Name
+	}
+	return nameRows("router", names)
+}
+
+func nameRows(field string, names []string) []Drill {
+	rows := make([]Drill, len(names))
+	for i, name := range names {
+		rows[i] = Drill{Label: name, Filter: terms(field, name)}
+	}
+	return rows
+}
+
+// errorRows leads from the recent errors to each request
+func errorRows(d *Data) []Drill {
+	errs := recentErrors(d.Entries)
+	errs = errs[:min(len(errs), 6)]
+	rows := make([]Drill, len(errs))
+	for i := range errs {
+		e := &errs[i]
+		status := strconv.Itoa(e.DownstreamStatus)
+		rows[i] = Drill{
+			Label:  strings.TrimSpace(status + " " + e.RequestMethod + " " + e.RequestPath),
+			Filter: terms("status", status, "method", e.RequestMethod, "path", e.RequestPath),
+			Entry:  e,
+		}
+	}
+	return rows
+}
+
+// terms builds a filter from field and value pairs, leaving out empty
+// values. Text fields are globs, so a * stands in for the spaces, commas
+// and quotes the filter language would take apart.
+func terms(pairs ...string) string {
+	var out []string
+	for i := 0; i+1 < len(pairs); i += 2 {
+		value := strings.Map(func(r rune) rune {
+			if r == ' ' || r == '\t' || r == ',' || r == '"' {
+				return '*'
+			}
+			return r
+		}, pairs[i+1])
+		if value != "" {
+			out = append(out, pairs[i]+":"+value)
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+// systemStats converts the agent's system stats for the health card
+func systemStats(stats *logs.SystemStats) *cards.SystemStats {
+	return &cards.SystemStats{
+		CPU: cards.CPUStats{
+			UsagePercent: stats.CPU.UsagePercent,
+			Cores:        stats.CPU.Cores,
+		},
+		Memory: cards.MemoryStats{
+			Total:       stats.Memory.Total,
+			Used:        stats.Memory.Used,
+			UsedPercent: stats.Memory.UsedPercent,
+		},
+		Disk: cards.DiskStats{
+			Total:       stats.Disk.Total,
+			Used:        stats.Disk.Used,
+			UsedPercent: stats.Disk.UsedPercent,
+		},
+	}
+}
@@ -0,0 +1,127 @@
+package dashboard
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/hhftechnology/traefik-log-dashboard/cli/internal/logs"
+)
+
+// format writes a layout back in the syntax ParseLayout reads
+func format(layout Layout) string {
+	rows := make([]string, len(layout))
+	for i, row := range layout {
+		cells := make([]string, len(row))
+		for j, cell := range row {
+			cells[j] = cell.Card
+			if cell.Weight != 1 {
+				cells[j] += ":" + strconv.Itoa(cell.Weight)
+			}
+		}
+		rows[i] = strings.Join(cells, ",")
+	}
+	return strings.Join(rows, ";")
+}
+
+func TestParseLayout(t *testing.T) {
+	tests := []struct {
+		rows []string
+		want string
+	}{
+		{[]string{"requests"}, "requests"},
+		{[]string{"requests, response_time", "timeline:2, distribution"}, "requests,response_time;timeline:2,distribution"},
+		{[]string{" timeline : 3 ,errors:1"}, "timeline:3,errors"},
+		// Empty cells and rows are skipped
+		{[]string{"requests,,", "", " , ", "errors"}, "requests;errors"},
+		{DefaultLayout, strings.ReplaceAll(strings.Join(DefaultLayout, ";"), " ", "")},
+	}
+	for _, tt := range tests {
+		layout, err := ParseLayout(tt.rows)
+		if err != nil {
+			t.Errorf("%q: %v", tt.rows, err)
+			continue
+		}
+		if got := format(layout); got != tt.want {
+			t.Errorf("%q: got %s, want %s", tt.rows, got, tt.want)
+		}
+	}
+}
+
+func TestParseLayoutErrors(t *testing.T) {
+	tests := []struct {
+		rows []string
+		want string
+	}{
+		{nil, "no cards"},
+		{[]string{"", " , "}, "no cards"},
+		{[]string{"timeline:0"}, `invalid width in "timeline:0"`},
+		{[]string{"timeline:-1"}, `invalid width in "timeline:-1"`},
+		{[]string{"timeline:wide"}, `invalid width in "timeline:wide"`},
+		{[]string{"timeline:"}, `invalid width in "timeline:"`},
+		{[]string{"requests", "bogus"}, `unknown card "bogus"`},
+		{[]string{"Requests"}, `unknown card "Requests"`},
+		{[]string{":2"}, `unknown card ""`},
+	}
+	for _, tt := range tests {
+		_, err := ParseLayout(tt.rows)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: error %v, want %s", tt.rows, err, tt.want)
+		}
+	}
+}
+
+func TestCardsAvailable(t *testing.T) {
+	layout, err := ParseLayout([]string{"requests, slo", "cpu, memory", "latency"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := Data{Metrics: &logs.Metrics{}}
+	// Cards without their data are left out, and rows left empty with them
+	if got := strings.Join(Cards(layout, data), ","); got != "requests" {
+		t.Errorf("cards = %s", got)
+	}
+	data.Metrics.SLO = &logs.SLOReport{}
+	data.SystemStats = &logs.SystemStats{}
+	if got := strings.Join(Cards(layout, data), ","); got != "requests,slo,cpu,memory" {
+		t.Errorf("cards = %s", got)
+	}
+}
+
+func TestCollapse(t *testing.T) {
+	layout, err := ParseLayout([]string{"requests, response_time, status_codes", "timeline:2, distribution"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		width int
+		want  string
+	}{
+		{120, "requests,response_time,status_codes;timeline:2,distribution"},
+		{100, "requests,response_time,status_codes;timeline:2,distribution"},
+		// Weighted cards split once the lightest gets too narrow
+		{70, "requests,response_time;status_codes;timeline:2;distribution"},
+		{30, "requests;response_time;status_codes;timeline:2;distribution"},
+	}
+	for _, tt := range tests {
+		if got := format(layout.collapse(tt.width, 1, 30)); got != tt.want {
+			t.Errorf("width %d: got %s, want %s", tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestRecentErrors(t *testing.T) {
+	entries := []logs.TraefikLog{
+		{RequestPath: "/a", DownstreamStatus: 500, StartUTC: "2024-05-01T10:00:00.5Z"},
+		{RequestPath: "/ok", DownstreamStatus: 200, StartUTC: "2024-05-01T10:00:02Z"},
+		{RequestPath: "/b", DownstreamStatus: 404, StartUTC: "2024-05-01T10:00:01Z"},
+		{RequestPath: "/c", DownstreamStatus: 503, StartUTC: "2024-05-01T10:00:00Z"},
+	}
+	var got []string
+	for _, entry := range recentErrors(entries) {
+		got = append(got, entry.RequestPath)
+	}
+	if strings.Join(got, " ") != "/b /a /c" {
+		t.Errorf("errors = %v, want /b /a /c", got)
+	}
+}